@@ -0,0 +1,71 @@
+package reschedule
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// resetTrackingRequest identifies the tracking resource instance to reset. For cluster scoped tracking resources such as
+// Namespaces, the namespace is ignored.
+type resetTrackingRequest struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+}
+
+type resetTrackingResponse struct {
+	Removed []string `json:"removed"`
+}
+
+// serveResetTracking removes every tracking annotation from a tracking resource instance. This is used to manually reset
+// tracking state, for example after a failed upgrade.
+func serveResetTracking(w http.ResponseWriter, r *http.Request, client Client) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !isAdminAuthorized(r, client.GetConfig().adminToken) {
+		slog.Warn("Unauthorized admin request", "path", r.URL.Path, "remote", r.RemoteAddr)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var request resetTrackingRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.Name == "" {
+		slog.Error("Invalid reset tracking request", "error", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	logger := slog.With("trackingResource", client.GetConfig().trackingResource.GetResourceType(), "name", request.Name, "namespace", request.Namespace)
+
+	removed, err := client.ClearTrackingAnnotations(request.Name, request.Namespace)
+	if errors.Is(err, ErrTrackingResourceNotFound) {
+		logger.Error("Tracking resource not found")
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		logger.Error("Failed to reset tracking annotations", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Reset tracking annotations", "removed", removed)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resetTrackingResponse{Removed: removed}); err != nil {
+		slog.Error("Failed to write reset tracking response", "error", err)
+	}
+}
+
+// isAdminAuthorized checks the request presents the admin token as a bearer token
+func isAdminAuthorized(r *http.Request, token string) bool {
+	presented, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && token != "" && subtle.ConstantTimeCompare([]byte(presented), []byte(token)) == 1
+}
@@ -0,0 +1,118 @@
+package reschedule
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestServeResetTracking(t *testing.T) {
+	testcases := []struct {
+		testname                            string
+		method                              string
+		authorization                       string
+		body                                string
+		trackingResourceNotFound            bool
+		expectedCode                        int
+		expectedRemoved                     []string
+		expectedTrackingResourceAnnotations map[string]string
+	}{
+		{
+			testname:        "Removes tracking annotations",
+			method:          http.MethodPost,
+			authorization:   "Bearer secret",
+			body:            `{"name": "test-cluster", "namespace": "default"}`,
+			expectedCode:    http.StatusOK,
+			expectedRemoved: []string{"reschedule.hook/default.pod1", "reschedule.hook/default.pod2"},
+			expectedTrackingResourceAnnotations: map[string]string{
+				DefaultForceTrackingAnnotation: "true",
+				"other":                        "value",
+			},
+		},
+		{
+			testname:     "Requires token",
+			method:       http.MethodPost,
+			body:         `{"name": "test-cluster", "namespace": "default"}`,
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			testname:      "Rejects incorrect token",
+			method:        http.MethodPost,
+			authorization: "Bearer wrong",
+			body:          `{"name": "test-cluster", "namespace": "default"}`,
+			expectedCode:  http.StatusUnauthorized,
+		},
+		{
+			testname:      "Rejects GET",
+			method:        http.MethodGet,
+			authorization: "Bearer secret",
+			expectedCode:  http.StatusMethodNotAllowed,
+		},
+		{
+			testname:      "Requires tracking resource name",
+			method:        http.MethodPost,
+			authorization: "Bearer secret",
+			body:          `{"namespace": "default"}`,
+			expectedCode:  http.StatusBadRequest,
+		},
+		{
+			testname:                 "Tracking resource not found",
+			method:                   http.MethodPost,
+			authorization:            "Bearer secret",
+			body:                     `{"name": "missing-cluster", "namespace": "default"}`,
+			trackingResourceNotFound: true,
+			expectedCode:             http.StatusNotFound,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testname, func(t *testing.T) {
+			annotations := map[string]string{
+				"reschedule.hook/default.pod1": "true",
+				"reschedule.hook/default.pod2": "true",
+				DefaultForceTrackingAnnotation: "true",
+				"other":                        "value",
+			}
+			client := &mockClient{
+				config:                      NewConfigBuilder().FromEnvironment().WithAdminEndpoints(true, "secret").Build(),
+				trackingResourceAnnotations: annotations,
+				trackingResourceNotFound:    testcase.trackingResourceNotFound,
+			}
+
+			recorder := httptest.NewRecorder()
+			request := httptest.NewRequest(testcase.method, "/admin/reset-tracking", strings.NewReader(testcase.body))
+			if testcase.authorization != "" {
+				request.Header.Set("Authorization", testcase.authorization)
+			}
+
+			serveResetTracking(recorder, request, client)
+
+			if recorder.Code != testcase.expectedCode {
+				t.Fatalf("Expected status code %d, got %d", testcase.expectedCode, recorder.Code)
+			}
+
+			if testcase.expectedCode != http.StatusOK {
+				if len(client.trackingResourceAnnotations) != 4 {
+					t.Fatalf("Expected tracking resource annotations to be unchanged, got %v", client.trackingResourceAnnotations)
+				}
+				return
+			}
+
+			var response resetTrackingResponse
+			if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+
+			if !reflect.DeepEqual(response.Removed, testcase.expectedRemoved) {
+				t.Fatalf("Expected removed annotations to be %v, got %v", testcase.expectedRemoved, response.Removed)
+			}
+
+			if !reflect.DeepEqual(client.trackingResourceAnnotations, testcase.expectedTrackingResourceAnnotations) {
+				t.Fatalf("Expected tracking resource annotations to be %v, got %v", testcase.expectedTrackingResourceAnnotations, client.trackingResourceAnnotations)
+			}
+		})
+	}
+}
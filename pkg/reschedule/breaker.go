@@ -0,0 +1,242 @@
+package reschedule
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+// CircuitBreaker stops eviction requests from being handled while the API server appears to be unavailable, so that handler
+// goroutines are not tied up waiting for requests that will time out. It is safe for concurrent use. A nil CircuitBreaker
+// allows all requests.
+type CircuitBreaker struct {
+	mu           sync.Mutex
+	threshold    int
+	window       time.Duration
+	cooldown     time.Duration
+	state        circuitState
+	failures     int
+	firstFailure time.Time
+	openedAt     time.Time
+	clock        Clock
+}
+
+// NewCircuitBreaker creates a CircuitBreaker that opens after threshold consecutive API server errors within window, and
+// half-opens after cooldown to test whether the API server has recovered. If threshold is not positive, circuit breaking is
+// disabled and nil is returned.
+func NewCircuitBreaker(threshold int, window, cooldown time.Duration, clock Clock) *CircuitBreaker {
+	if threshold <= 0 {
+		return nil
+	}
+
+	return &CircuitBreaker{
+		threshold: threshold,
+		window:    window,
+		cooldown:  cooldown,
+		clock:     clock,
+	}
+}
+
+// Allow reports whether a request can be handled now. Once the cooldown has elapsed an open circuit becomes half-open and
+// requests are allowed again until the next API call either closes or re-opens it.
+func (b *CircuitBreaker) Allow() bool {
+	if b == nil {
+		return true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == circuitOpen && b.clock.Now().Sub(b.openedAt) >= b.cooldown {
+		b.state = circuitHalfOpen
+	}
+
+	return b.state != circuitOpen
+}
+
+// Record updates the circuit with the result of an API call. Only errors indicating the API server is unavailable count as
+// failures, any other result closes a half-open circuit and resets the failure count. Errors returned without contacting the
+// API server are ignored, as are annotation size limits, which are checked by the hook before patching.
+func (b *CircuitBreaker) Record(err error) {
+	if b == nil || errors.Is(err, ErrNoTrackingInstanceName) || errors.Is(err, ErrEvictionNotSupported) || errors.Is(err, ErrAnnotationSizeLimit) {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.clock.Now()
+	if !isAPIServerUnavailable(err) {
+		b.state = circuitClosed
+		b.failures = 0
+		return
+	}
+
+	if b.state == circuitHalfOpen {
+		b.open(now)
+		return
+	}
+
+	if b.failures == 0 || now.Sub(b.firstFailure) > b.window {
+		b.failures = 0
+		b.firstFailure = now
+	}
+
+	b.failures++
+	if b.failures >= b.threshold {
+		b.open(now)
+	}
+}
+
+func (b *CircuitBreaker) open(now time.Time) {
+	b.state = circuitOpen
+	b.openedAt = now
+	b.failures = 0
+}
+
+// Wrap returns a client that records the result of each API call made through it. If the breaker is nil, the client is
+// returned unchanged.
+func (b *CircuitBreaker) Wrap(client Client) Client {
+	if b == nil {
+		return client
+	}
+
+	return &circuitBreakerClient{Client: client, breaker: b}
+}
+
+// isAPIServerUnavailable checks whether an error suggests the API server could not handle a request, as opposed to a
+// response such as NotFound or Conflict. A pod missing the reschedule annotation after it was added is also a response.
+func isAPIServerUnavailable(err error) bool {
+	if err == nil || errors.Is(err, ErrAnnotationNotPersisted) {
+		return false
+	}
+
+	var status k8serrors.APIStatus
+	if errors.As(err, &status) {
+		return status.Status().Code >= 500
+	}
+
+	return true
+}
+
+// circuitBreakerClient records the result of each API call made by the embedded client with the breaker
+type circuitBreakerClient struct {
+	Client
+	breaker *CircuitBreaker
+}
+
+func (c *circuitBreakerClient) WithContext(ctx context.Context) Client {
+	return &circuitBreakerClient{Client: c.Client.WithContext(ctx), breaker: c.breaker}
+}
+
+func (c *circuitBreakerClient) GetPod(name, namespace string) (*corev1.Pod, error) {
+	pod, err := c.Client.GetPod(name, namespace)
+	c.breaker.Record(err)
+	return pod, err
+}
+
+func (c *circuitBreakerClient) GetPodMeta(name, namespace string) (map[string]string, map[string]string, types.UID, corev1.PodPhase, *metav1.Time, error) {
+	labels, annotations, uid, phase, deletionTimestamp, err := c.Client.GetPodMeta(name, namespace)
+	c.breaker.Record(err)
+	return labels, annotations, uid, phase, deletionTimestamp, err
+}
+
+func (c *circuitBreakerClient) GetNode(nodeName string) (*corev1.Node, error) {
+	node, err := c.Client.GetNode(nodeName)
+	c.breaker.Record(err)
+	return node, err
+}
+
+func (c *circuitBreakerClient) GetNodeConditions(nodeName string) ([]corev1.Taint, corev1.ConditionStatus, error) {
+	taints, ready, err := c.Client.GetNodeConditions(nodeName)
+	c.breaker.Record(err)
+	return taints, ready, err
+}
+
+func (c *circuitBreakerClient) ListPodsByTrackingInstance(instance, namespace string) ([]corev1.Pod, error) {
+	pods, err := c.Client.ListPodsByTrackingInstance(instance, namespace)
+	c.breaker.Record(err)
+	return pods, err
+}
+
+func (c *circuitBreakerClient) ReschedulePod(pod *corev1.Pod) error {
+	err := c.Client.ReschedulePod(pod)
+	c.breaker.Record(err)
+	return err
+}
+
+func (c *circuitBreakerClient) RecordRescheduleAttempt(pod *corev1.Pod) error {
+	err := c.Client.RecordRescheduleAttempt(pod)
+	c.breaker.Record(err)
+	return err
+}
+
+func (c *circuitBreakerClient) RemovePodAnnotations(pod *corev1.Pod, annotations ...string) error {
+	err := c.Client.RemovePodAnnotations(pod, annotations...)
+	c.breaker.Record(err)
+	return err
+}
+
+func (c *circuitBreakerClient) GetTrackingResourceInstance(name, namespace string) (*unstructured.Unstructured, error) {
+	instance, err := c.Client.GetTrackingResourceInstance(name, namespace)
+	c.breaker.Record(err)
+	return instance, err
+}
+
+func (c *circuitBreakerClient) TrackingInstanceName(pod *corev1.Pod) (string, error) {
+	name, err := c.Client.TrackingInstanceName(pod)
+	c.breaker.Record(err)
+	return name, err
+}
+
+func (c *circuitBreakerClient) ResolveTrackingInstance(pod *corev1.Pod) (*unstructured.Unstructured, error) {
+	instance, err := c.Client.ResolveTrackingInstance(pod)
+	c.breaker.Record(err)
+	return instance, err
+}
+
+func (c *circuitBreakerClient) BatchRemoveTrackingAnnotations(resourceInstanceName, namespace string, podKeys []string) error {
+	err := c.Client.BatchRemoveTrackingAnnotations(resourceInstanceName, namespace, podKeys)
+	c.breaker.Record(err)
+	return err
+}
+
+func (c *circuitBreakerClient) AddRescheduleHookTrackingAnnotation(pod *corev1.Pod, trackingResourceInstance *unstructured.Unstructured) error {
+	err := c.Client.AddRescheduleHookTrackingAnnotation(pod, trackingResourceInstance)
+	c.breaker.Record(err)
+	return err
+}
+
+func (c *circuitBreakerClient) RemoveRescheduleHookTrackingAnnotation(pod *corev1.Pod, resourceInstanceName string) error {
+	err := c.Client.RemoveRescheduleHookTrackingAnnotation(pod, resourceInstanceName)
+	c.breaker.Record(err)
+	return err
+}
+
+func (c *circuitBreakerClient) GetResource(gvr schema.GroupVersionResource, namespace, name string) (*unstructured.Unstructured, error) {
+	resource, err := c.Client.GetResource(gvr, namespace, name)
+	c.breaker.Record(err)
+	return resource, err
+}
+
+func (c *circuitBreakerClient) PatchResource(gvr schema.GroupVersionResource, namespace, name string, patchType types.PatchType, payload []byte) error {
+	err := c.Client.PatchResource(gvr, namespace, name, patchType, payload)
+	c.breaker.Record(err)
+	return err
+}
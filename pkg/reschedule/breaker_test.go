@@ -0,0 +1,185 @@
+package reschedule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+type breakerEvent struct {
+	elapsed time.Duration
+	err     error
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	unavailable := k8serrors.NewServiceUnavailable("etcd unavailable")
+	notFound := k8serrors.NewNotFound(schema.GroupResource{Resource: "pods"}, "pod1")
+
+	testcases := []struct {
+		testname string
+		events   []breakerEvent
+		expected []bool
+	}{
+		{
+			testname: "Closed while API calls succeed",
+			events: []breakerEvent{
+				{0, nil}, {0, nil}, {0, nil},
+			},
+			expected: []bool{true, true, true},
+		},
+		{
+			testname: "Opens after sustained failures",
+			events: []breakerEvent{
+				{0, unavailable}, {0, unavailable}, {0, unavailable}, {time.Second, nil},
+			},
+			expected: []bool{true, true, true, false},
+		},
+		{
+			testname: "Connection errors count as failures",
+			events: []breakerEvent{
+				{0, errors.New("connection refused")}, {0, errors.New("connection refused")}, {0, errors.New("connection refused")}, {time.Second, nil},
+			},
+			expected: []bool{true, true, true, false},
+		},
+		{
+			testname: "Responses from the API server reset the failure count",
+			events: []breakerEvent{
+				{0, unavailable}, {0, unavailable}, {0, notFound}, {0, unavailable}, {0, nil},
+			},
+			expected: []bool{true, true, true, true, true},
+		},
+		{
+			testname: "Annotations missing after being added do not count as failures",
+			events: []breakerEvent{
+				{0, unavailable}, {0, unavailable}, {0, ErrAnnotationNotPersisted}, {0, unavailable}, {0, nil},
+			},
+			expected: []bool{true, true, true, true, true},
+		},
+		{
+			testname: "Failures outside the window do not open the circuit",
+			events: []breakerEvent{
+				{0, unavailable}, {0, unavailable}, {time.Minute, unavailable}, {0, nil},
+			},
+			expected: []bool{true, true, true, true},
+		},
+		{
+			testname: "Half-opens after the cooldown and closes on recovery",
+			events: []breakerEvent{
+				{0, unavailable}, {0, unavailable}, {0, unavailable}, {10 * time.Second, nil}, {0, nil},
+			},
+			expected: []bool{true, true, true, true, true},
+		},
+		{
+			testname: "Re-opens if the API server is still unavailable after the cooldown",
+			events: []breakerEvent{
+				{0, unavailable}, {0, unavailable}, {0, unavailable}, {10 * time.Second, unavailable}, {time.Second, nil},
+			},
+			expected: []bool{true, true, true, true, false},
+		},
+		{
+			testname: "Errors returned without contacting the API server are ignored",
+			events: []breakerEvent{
+				{0, unavailable}, {0, unavailable}, {0, ErrNoTrackingInstanceName}, {0, unavailable}, {0, nil},
+			},
+			expected: []bool{true, true, true, true, false},
+		},
+		{
+			testname: "Annotation size limits do not count as failures",
+			events: []breakerEvent{
+				{0, fmt.Errorf("%w: annotations too large", ErrAnnotationSizeLimit)}, {0, ErrAnnotationSizeLimit}, {0, ErrAnnotationSizeLimit}, {0, nil},
+			},
+			expected: []bool{true, true, true, true},
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testname, func(t *testing.T) {
+			clock := newFakeClock(time.Now())
+			breaker := NewCircuitBreaker(3, 30*time.Second, 10*time.Second, clock)
+
+			for i, event := range testcase.events {
+				clock.Advance(event.elapsed)
+				if allowed := breaker.Allow(); allowed != testcase.expected[i] {
+					t.Fatalf("Request %d: expected allowed=%t, got %t", i, testcase.expected[i], allowed)
+				}
+
+				if testcase.expected[i] {
+					breaker.Record(event.err)
+				}
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerDisabled(t *testing.T) {
+	breaker := NewCircuitBreaker(0, 30*time.Second, 10*time.Second, RealClock)
+	for range 5 {
+		breaker.Record(k8serrors.NewServiceUnavailable("etcd unavailable"))
+	}
+
+	if !breaker.Allow() {
+		t.Fatalf("Expected disabled circuit breaker to allow requests")
+	}
+
+	client := &mockClient{}
+	if wrapped := breaker.Wrap(client); wrapped != client {
+		t.Fatalf("Expected disabled circuit breaker to return the client unchanged")
+	}
+}
+
+// unavailableClient fails every call to get a pod as if the API server were unavailable
+type unavailableClient struct {
+	*mockClient
+}
+
+func (c *unavailableClient) WithContext(ctx context.Context) Client {
+	return c
+}
+
+func (c *unavailableClient) GetPod(name, namespace string) (*corev1.Pod, error) {
+	return nil, k8serrors.NewServiceUnavailable("etcd unavailable")
+}
+
+func (c *unavailableClient) GetPodMeta(name, namespace string) (map[string]string, map[string]string, types.UID, corev1.PodPhase, *metav1.Time, error) {
+	return nil, nil, "", "", nil, k8serrors.NewServiceUnavailable("etcd unavailable")
+}
+
+func TestCircuitBreakerClient(t *testing.T) {
+	breaker := NewCircuitBreaker(2, 30*time.Second, 10*time.Second, RealClock)
+	config := NewConfigBuilder().FromEnvironment().Build()
+	client := breaker.Wrap(&unavailableClient{mockClient: &mockClient{config: config}})
+
+	eviction := policyv1.Eviction{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "pod1",
+			Namespace: "default",
+		},
+	}
+	expected := denyEviction(http.StatusInternalServerError, metav1.StatusReasonInternalError, FailedToGetPodMsg)
+
+	for i := range 2 {
+		if !breaker.Allow() {
+			t.Fatalf("Request %d: expected circuit to be closed", i)
+		}
+
+		result := handleEviction(context.Background(), eviction, client, config, CreateLogger(eviction.Name, eviction.Namespace, false))
+		if !reflect.DeepEqual(result, expected) {
+			t.Fatalf("Request %d: expected response to be %v, got %v", i, expected, result)
+		}
+	}
+
+	if breaker.Allow() {
+		t.Fatalf("Expected circuit to be open after sustained API server errors")
+	}
+}
@@ -0,0 +1,153 @@
+package reschedule
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"time"
+)
+
+// caCheckDialTimeout is how long the CA check waits to connect to the server's own TLS listener
+const caCheckDialTimeout = 5 * time.Second
+
+// loadCABundle reads a PEM encoded CA bundle into a certificate pool
+func loadCABundle(caBundleFile string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(caBundleFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseCABundle(data, caBundleFile)
+}
+
+// parseCABundle reads PEM encoded CA certificates into a certificate pool. The source is only used in errors.
+func parseCABundle(data []byte, source string) (*x509.CertPool, error) {
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificates found in %s", source)
+	}
+
+	return roots, nil
+}
+
+// verifyServingCertificate connects to the TLS listener at addr and verifies the certificate chain it presents against roots.
+// Only the chain is verified, as the listener is reached through loopback rather than the service name in the certificate.
+func verifyServingCertificate(addr string, roots *x509.CertPool) error {
+	dialer := &net.Dialer{Timeout: caCheckDialTimeout}
+	conn, err := tls.DialWithDialer(dialer, "tcp", addr, &tls.Config{
+		// The presented chain is verified below without checking the host name
+		InsecureSkipVerify: true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", addr, err)
+	}
+	defer conn.Close()
+
+	certs := conn.ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return errors.New("no serving certificate presented")
+	}
+
+	return verifyCertificateChain(certs, roots)
+}
+
+// verifyCertificateChain verifies that the leaf, the first certificate, chains to roots through the remaining certificates
+func verifyCertificateChain(certs []*x509.Certificate, roots *x509.CertPool) error {
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	_, err := certs[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+	})
+	return err
+}
+
+// verifyWebhookCABundle verifies the serving certificate against the CA bundle of the ValidatingWebhookConfiguration, which is
+// what the API server uses to verify the webhook
+func verifyWebhookCABundle(client Client, configName string, cert *tls.Certificate) error {
+	caBundle, err := client.GetWebhookCABundle(configName)
+	if err != nil {
+		return err
+	}
+
+	roots, err := parseCABundle(caBundle, "ValidatingWebhookConfiguration "+configName)
+	if err != nil {
+		return err
+	}
+
+	certs := make([]*x509.Certificate, 0, len(cert.Certificate))
+	for _, der := range cert.Certificate {
+		parsed, err := x509.ParseCertificate(der)
+		if err != nil {
+			return fmt.Errorf("failed to parse serving certificate: %w", err)
+		}
+		certs = append(certs, parsed)
+	}
+
+	if len(certs) == 0 {
+		return errors.New("no serving certificate loaded")
+	}
+
+	return verifyCertificateChain(certs, roots)
+}
+
+// checkWebhookCABundle runs verifyWebhookCABundle at startup. A mismatch means the API server will fail to call the webhook, so
+// it is logged as an error, but the server is still started so that the configuration can be fixed without a restart.
+func checkWebhookCABundle(config *Config, reloader *certificateReloader) {
+	client, err := NewClient(config, false)
+	if err != nil {
+		slog.Error("Failed to create Kubernetes client for the webhook CA bundle check", "error", err)
+		return
+	}
+
+	if err := verifyWebhookCABundle(client, config.webhookConfigName, reloader.cert.Load()); err != nil {
+		slog.Error("Serving certificate does not chain to the CA bundle in the webhook configuration, the API server will be unable to call the webhook", "webhookConfig", config.webhookConfigName, "error", err)
+		return
+	}
+
+	slog.Info("Serving certificate chains to the CA bundle in the webhook configuration", "webhookConfig", config.webhookConfigName)
+}
+
+// runCACheck verifies the serving certificate against the CA bundle at startup and then every interval until the context is
+// cancelled. The CA bundle is re-read on each check so that a rotated bundle is picked up. Mismatches are logged as warnings.
+func runCACheck(ctx context.Context, addr, caBundleFile string, interval time.Duration) {
+	check := func() {
+		roots, err := loadCABundle(caBundleFile)
+		if err != nil {
+			slog.Warn("Unable to load CA bundle for serving certificate check", "file", caBundleFile, "error", err)
+			return
+		}
+
+		if err := verifyServingCertificate(addr, roots); err != nil {
+			slog.Warn("Serving certificate does not chain to the expected CA bundle, the webhook configuration may be out of date", "file", caBundleFile, "error", err)
+			return
+		}
+
+		slog.Debug("Serving certificate chains to the expected CA bundle", "file", caBundleFile)
+	}
+
+	check()
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			check()
+		}
+	}
+}
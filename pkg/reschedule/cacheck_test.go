@@ -0,0 +1,217 @@
+package reschedule
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+)
+
+func TestVerifyServingCertificate(t *testing.T) {
+	servingCA, servingCAKey := caStub(t, "serving-ca")
+	otherCA, _ := caStub(t, "other-ca")
+
+	server := httptest.NewUnstartedServer(http.NotFoundHandler())
+	server.TLS = &tls.Config{Certificates: []tls.Certificate{leafStub(t, servingCA, servingCAKey)}}
+	server.StartTLS()
+	defer server.Close()
+
+	addr := strings.TrimPrefix(server.URL, "https://")
+
+	testcases := []struct {
+		testname    string
+		ca          *x509.Certificate
+		expectError bool
+	}{
+		{
+			testname: "Matching CA",
+			ca:       servingCA,
+		},
+		{
+			testname:    "Mismatched CA",
+			ca:          otherCA,
+			expectError: true,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testname, func(t *testing.T) {
+			caBundleFile := filepath.Join(t.TempDir(), "ca.crt")
+			if err := os.WriteFile(caBundleFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: testcase.ca.Raw}), 0o600); err != nil {
+				t.Fatalf("Failed to write CA bundle: %v", err)
+			}
+
+			roots, err := loadCABundle(caBundleFile)
+			if err != nil {
+				t.Fatalf("Failed to load CA bundle: %v", err)
+			}
+
+			err = verifyServingCertificate(addr, roots)
+			if testcase.expectError && err == nil {
+				t.Fatalf("Expected serving certificate verification to fail")
+			}
+
+			if !testcase.expectError && err != nil {
+				t.Fatalf("Expected serving certificate verification to succeed, got %v", err)
+			}
+		})
+	}
+}
+
+func TestVerifyWebhookCABundle(t *testing.T) {
+	servingCA, servingCAKey := caStub(t, "serving-ca")
+	otherCA, _ := caStub(t, "other-ca")
+	cert := leafStub(t, servingCA, servingCAKey)
+
+	testcases := []struct {
+		testname    string
+		caBundles   []*x509.Certificate
+		expectError bool
+	}{
+		{
+			testname:  "Matching CA bundle",
+			caBundles: []*x509.Certificate{servingCA},
+		},
+		{
+			testname:    "Mismatched CA bundle",
+			caBundles:   []*x509.Certificate{otherCA},
+			expectError: true,
+		},
+		{
+			testname:  "Matching CA bundle in a later webhook",
+			caBundles: []*x509.Certificate{otherCA, servingCA},
+		},
+		{
+			testname:    "No CA bundle",
+			caBundles:   []*x509.Certificate{nil},
+			expectError: true,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testname, func(t *testing.T) {
+			webhooks := []interface{}{}
+			for i, ca := range testcase.caBundles {
+				clientConfig := map[string]interface{}{}
+				if ca != nil {
+					// Byte slices are base64 encoded in unstructured objects, as they are in JSON
+					clientConfig["caBundle"] = base64.StdEncoding.EncodeToString(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.Raw}))
+				}
+
+				webhooks = append(webhooks, map[string]interface{}{
+					"name":         fmt.Sprintf("webhook%d.reschedule.hook", i),
+					"clientConfig": clientConfig,
+				})
+			}
+
+			webhookConfig := &unstructured.Unstructured{Object: map[string]interface{}{
+				"metadata": map[string]interface{}{"name": "reschedule-webhook-config"},
+				"webhooks": webhooks,
+			}}
+			webhookConfig.SetAPIVersion("admissionregistration.k8s.io/v1")
+			webhookConfig.SetKind("ValidatingWebhookConfiguration")
+
+			client := &ClientImpl{dynamicClient: fake.NewSimpleDynamicClient(runtime.NewScheme(), webhookConfig)}
+
+			err := verifyWebhookCABundle(client, "reschedule-webhook-config", &cert)
+			if testcase.expectError && err == nil {
+				t.Fatalf("Expected webhook CA bundle verification to fail")
+			}
+
+			if !testcase.expectError && err != nil {
+				t.Fatalf("Expected webhook CA bundle verification to succeed, got %v", err)
+			}
+		})
+	}
+
+	// A missing webhook configuration is reported rather than treated as a match
+	client := &ClientImpl{dynamicClient: fake.NewSimpleDynamicClient(runtime.NewScheme())}
+	if err := verifyWebhookCABundle(client, "reschedule-webhook-config", &cert); err == nil {
+		t.Fatalf("Expected verification against a missing webhook configuration to fail")
+	}
+}
+
+func TestLoadCABundleInvalid(t *testing.T) {
+	caBundleFile := filepath.Join(t.TempDir(), "ca.crt")
+	if err := os.WriteFile(caBundleFile, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatalf("Failed to write CA bundle: %v", err)
+	}
+
+	if _, err := loadCABundle(caBundleFile); err == nil {
+		t.Fatalf("Expected loading an invalid CA bundle to fail")
+	}
+}
+
+func caStub(t *testing.T, commonName string) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate CA key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create CA certificate: %v", err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("Failed to parse CA certificate: %v", err)
+	}
+
+	return cert, key
+}
+
+func leafStub(t *testing.T, ca *x509.Certificate, caKey *ecdsa.PrivateKey) tls.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate serving key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "reschedule-hook-server.default.svc"},
+		DNSNames:     []string{"reschedule-hook-server.default.svc"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("Failed to create serving certificate: %v", err)
+	}
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
@@ -0,0 +1,87 @@
+package reschedule
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// certificateReloader serves the TLS certificate through GetCertificate, so that it can be replaced without restarting the
+// listener. It is safe for concurrent use.
+type certificateReloader struct {
+	certFile string
+	keyFile  string
+	clock    Clock
+	cert     atomic.Pointer[tls.Certificate]
+}
+
+// newCertificateReloader creates a certificateReloader serving the certificate loaded from certFile and keyFile
+func newCertificateReloader(certFile, keyFile string, clock Clock) (*certificateReloader, error) {
+	reloader := &certificateReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+		clock:    clock,
+	}
+
+	if err := reloader.Reload(); err != nil {
+		return nil, err
+	}
+
+	return reloader, nil
+}
+
+// Reload loads and validates the certificate from the configured files. If this fails, the current certificate continues to
+// be served.
+func (r *certificateReloader) Reload() error {
+	cert, err := loadCertificate(r.certFile, r.keyFile, r.clock.Now())
+	if err != nil {
+		return err
+	}
+
+	r.cert.Store(cert)
+	return nil
+}
+
+// GetCertificate returns the current certificate, for use as tls.Config.GetCertificate
+func (r *certificateReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return r.cert.Load(), nil
+}
+
+// current returns the leaf of the certificate currently being served
+func (r *certificateReloader) current() *x509.Certificate {
+	return r.cert.Load().Leaf
+}
+
+// loadCertificate loads a key pair and checks the certificate is valid at now and has subject alternative names, as the API
+// server will not call a webhook presenting a certificate without them
+func loadCertificate(certFile, keyFile string, now time.Time) (*tls.Certificate, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	leaf := cert.Leaf
+	if leaf == nil {
+		if leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+			return nil, fmt.Errorf("failed to parse certificate: %w", err)
+		}
+		cert.Leaf = leaf
+	}
+
+	if now.Before(leaf.NotBefore) {
+		return nil, fmt.Errorf("certificate is not valid until %s", leaf.NotBefore.Format(time.RFC3339))
+	}
+
+	if now.After(leaf.NotAfter) {
+		return nil, fmt.Errorf("certificate expired at %s", leaf.NotAfter.Format(time.RFC3339))
+	}
+
+	if len(leaf.DNSNames) == 0 && len(leaf.IPAddresses) == 0 {
+		return nil, errors.New("certificate has no subject alternative names")
+	}
+
+	return &cert, nil
+}
@@ -0,0 +1,129 @@
+package reschedule
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCertificateReloader(t *testing.T) {
+	ca, caKey := caStub(t, "serving-ca")
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "tls.crt")
+	keyFile := filepath.Join(dir, "tls.key")
+
+	now := time.Now()
+	writeCertificateStub(t, certFile, keyFile, ca, caKey, 1, now.Add(time.Hour), []string{"reschedule-hook-server.default.svc"})
+
+	reloader, err := newCertificateReloader(certFile, keyFile, newFakeClock(now))
+	if err != nil {
+		t.Fatalf("Failed to load certificate: %v", err)
+	}
+
+	assertServing := func(serial int64) {
+		t.Helper()
+
+		cert, err := reloader.GetCertificate(&tls.ClientHelloInfo{})
+		if err != nil {
+			t.Fatalf("Failed to get certificate: %v", err)
+		}
+
+		if cert.Leaf.SerialNumber.Int64() != serial {
+			t.Fatalf("Expected certificate %d to be served, got %d", serial, cert.Leaf.SerialNumber.Int64())
+		}
+	}
+
+	assertServing(1)
+
+	writeCertificateStub(t, certFile, keyFile, ca, caKey, 2, now.Add(time.Hour), []string{"reschedule-hook-server.default.svc"})
+	if err := reloader.Reload(); err != nil {
+		t.Fatalf("Failed to reload certificate: %v", err)
+	}
+
+	assertServing(2)
+
+	testcases := []struct {
+		testname string
+		write    func()
+	}{
+		{
+			testname: "Invalid key pair",
+			write: func() {
+				if err := os.WriteFile(certFile, []byte("not a certificate"), 0o600); err != nil {
+					t.Fatalf("Failed to write certificate: %v", err)
+				}
+			},
+		},
+		{
+			testname: "Expired certificate",
+			write: func() {
+				writeCertificateStub(t, certFile, keyFile, ca, caKey, 3, now.Add(-time.Minute), []string{"reschedule-hook-server.default.svc"})
+			},
+		},
+		{
+			testname: "Certificate without subject alternative names",
+			write: func() {
+				writeCertificateStub(t, certFile, keyFile, ca, caKey, 4, now.Add(time.Hour), nil)
+			},
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testname, func(t *testing.T) {
+			testcase.write()
+
+			if err := reloader.Reload(); err == nil {
+				t.Fatalf("Expected reloading the certificate to fail")
+			}
+
+			assertServing(2)
+		})
+	}
+}
+
+// writeCertificateStub writes a serving certificate signed by ca and its key to certFile and keyFile
+func writeCertificateStub(t *testing.T, certFile, keyFile string, ca *x509.Certificate, caKey *ecdsa.PrivateKey, serial int64, notAfter time.Time, dnsNames []string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate serving key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "reschedule-hook-server.default.svc"},
+		DNSNames:     dnsNames,
+		NotBefore:    notAfter.Add(-2 * time.Hour),
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("Failed to create serving certificate: %v", err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("Failed to marshal serving key: %v", err)
+	}
+
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatalf("Failed to write certificate: %v", err)
+	}
+
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatalf("Failed to write key: %v", err)
+	}
+}
@@ -2,73 +2,349 @@ package reschedule
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
 	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 )
 
 var podResource = schema.GroupVersionResource{Group: "", Version: "v1", Resource: "pods"}
+var nodeResource = schema.GroupVersionResource{Group: "", Version: "v1", Resource: "nodes"}
+var validatingWebhookConfigurationResource = schema.GroupVersionResource{Group: "admissionregistration.k8s.io", Version: "v1", Resource: "validatingwebhookconfigurations"}
 
 const (
 	RescheduledPodsTrackingKeyPrefix = "reschedule.hook/"
+	EvictionGroup                    = "policy"
+	EvictionKind                     = "Eviction"
+	EvictionSubresource              = "eviction"
+	EvictionVersionV1                = "v1"
+	EvictionVersionV1beta1           = "v1beta1"
+)
+
+const (
+	// maxAnnotationsSize is the Kubernetes limit on the total size of the annotation keys and values of a resource
+	maxAnnotationsSize = 256 * 1024
+	// annotationsSizeWarningPercent is the percentage of maxAnnotationsSize above which adding a tracking annotation logs a
+	// warning, or fails if DENY_NEAR_ANNOTATION_LIMIT is enabled
+	annotationsSizeWarningPercent = 90
+)
+
+var (
+	// ErrTrackingResourceNotFound is returned when the tracking resource instance for a pod does not exist
+	ErrTrackingResourceNotFound = errors.New("tracking resource not found")
+	// ErrNoTrackingInstanceName is returned when the name of the tracking resource instance cannot be derived from a pod
+	ErrNoTrackingInstanceName = errors.New("unable to derive tracking resource instance name")
+	// ErrEvictionNotSupported is returned when the API server does not serve any supported version of the Eviction API
+	ErrEvictionNotSupported = errors.New("eviction API not served")
+	// ErrUnauthorized is returned when the API server still rejects requests as unauthorized after the service account token has
+	// been re-read
+	ErrUnauthorized = errors.New("authentication to the Kubernetes API server failed")
+	// ErrAnnotationNotPersisted is returned when the reschedule annotations are missing from a pod after being added to it
+	ErrAnnotationNotPersisted = errors.New("reschedule annotation not persisted")
+	// ErrAnnotationSizeLimit is returned when adding a tracking annotation would take the annotations of the tracking resource
+	// instance over, or close to, the Kubernetes size limit
+	ErrAnnotationSizeLimit = errors.New("annotation size limit reached")
 )
 
 type Client interface {
 	GetPod(name, namespace string) (*corev1.Pod, error)
+	GetPodMeta(name, namespace string) (labels, annotations map[string]string, uid types.UID, phase corev1.PodPhase, deletionTimestamp *metav1.Time, err error)
+	GetNode(nodeName string) (*corev1.Node, error)
+	GetNodeConditions(nodeName string) (taints []corev1.Taint, ready corev1.ConditionStatus, err error)
+	ListPodsByTrackingInstance(instance, namespace string) ([]corev1.Pod, error)
 	ReschedulePod(pod *corev1.Pod) error
+	RecordRescheduleAttempt(pod *corev1.Pod) error
+	RemovePodAnnotations(pod *corev1.Pod, annotations ...string) error
 	GetTrackingResourceInstance(name, namespace string) (*unstructured.Unstructured, error)
-	AddRescheduleHookTrackingAnnotation(podName, podNamespace, resourceInstanceName string) error
-	RemoveRescheduleHookTrackingAnnotation(podName, podNamespace, resourceInstanceName string) error
+	ResolveTrackingInstance(pod *corev1.Pod) (*unstructured.Unstructured, error)
+	TrackingInstanceName(pod *corev1.Pod) (string, error)
+	AddRescheduleHookTrackingAnnotation(pod *corev1.Pod, trackingResourceInstance *unstructured.Unstructured) error
+	RemoveRescheduleHookTrackingAnnotation(pod *corev1.Pod, resourceInstanceName string) error
+	ClearTrackingAnnotations(resourceInstanceName, namespace string) ([]string, error)
+	BatchRemoveTrackingAnnotations(resourceInstanceName, namespace string, podKeys []string) error
 	ShouldTrackRescheduledPods() bool
-	ShouldAddTrackingAnnotation(trackingResourceInstance *unstructured.Unstructured) bool
+	ShouldAddTrackingAnnotation(pod *corev1.Pod, trackingResourceInstance *unstructured.Unstructured) bool
+	GetEvictionSubresourceSupport() ([]string, error)
+	IsTrackingResourceServed() (bool, error)
+	DryRunPatchTrackingResource(name, namespace string) error
+	GetWebhookCABundle(configName string) ([]byte, error)
+	GetResource(gvr schema.GroupVersionResource, namespace, name string) (*unstructured.Unstructured, error)
+	PatchResource(gvr schema.GroupVersionResource, namespace, name string, patchType types.PatchType, payload []byte) error
 	GetConfig() *Config
+	// WithContext returns a copy of the client whose API requests are made with ctx, so that they stop once it is done
+	WithContext(ctx context.Context) Client
+	// Close releases the client's idle connections to the API server. The client must not be used once it has been closed.
+	Close()
 }
 
 type ClientImpl struct {
-	config        *Config
-	dynamicClient dynamic.Interface
+	config          *Config
+	httpClient      *http.Client
+	dynamicClient   dynamic.Interface
+	discoveryClient discovery.DiscoveryInterface
+	// clientset is used to read and patch pods if USE_TYPED_POD_CLIENT is enabled. If it is nil, pods are read and patched with
+	// the dynamic client.
+	clientset kubernetes.Interface
+	// refreshDynamicClient creates a dynamic client with the service account token re-read, for retrying unauthorized requests.
+	// If it is nil, unauthorized requests are retried with the same client.
+	refreshDynamicClient func() (dynamic.Interface, error)
+	// refreshClientset is the equivalent of refreshDynamicClient for the clientset
+	refreshClientset func() (kubernetes.Interface, error)
+	// ctx is the context API requests are made with. If it is nil, requests are not cancelled.
+	ctx context.Context
 }
 
 func NewClient(config *Config, dryRun bool) (Client, error) {
-	kubeConfig, err := rest.InClusterConfig()
+	kubeConfig, err := inClusterRestConfig(config)
 	if err != nil {
 		return nil, err
 	}
 
-	dynamicClient, err := dynamic.NewForConfig(kubeConfig)
+	client, err := newClientForConfig(kubeConfig, config)
 	if err != nil {
 		return nil, err
 	}
 
+	client.refreshDynamicClient = func() (dynamic.Interface, error) {
+		return newInClusterDynamicClient(config)
+	}
+	client.refreshClientset = func() (kubernetes.Interface, error) {
+		return newInClusterClientset(config)
+	}
+
 	if dryRun {
-		return &DryRunClientImpl{
-			ClientImpl: &ClientImpl{
-				dynamicClient: dynamicClient,
-				config:        config,
-			},
-		}, nil
+		return &DryRunClientImpl{ClientImpl: client}, nil
+	}
+
+	return client, nil
+}
+
+// newClientForConfig creates a client for the API server in the rest config. The dynamic, discovery and typed clients share an
+// HTTP client, so that its connections can be released by Close.
+func newClientForConfig(kubeConfig *rest.Config, config *Config) (*ClientImpl, error) {
+	httpClient, err := rest.HTTPClientFor(kubeConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	dynamicClient, err := dynamic.NewForConfigAndClient(kubeConfig, httpClient)
+	if err != nil {
+		return nil, err
+	}
+
+	discoveryClient, err := discovery.NewDiscoveryClientForConfigAndClient(kubeConfig, httpClient)
+	if err != nil {
+		return nil, err
+	}
+
+	clientset, err := kubernetes.NewForConfigAndClient(kubeConfig, httpClient)
+	if err != nil {
+		return nil, err
 	}
 
 	return &ClientImpl{
-		dynamicClient: dynamicClient,
-		config:        config,
+		httpClient:      httpClient,
+		dynamicClient:   dynamicClient,
+		discoveryClient: discoveryClient,
+		clientset:       clientset,
+		config:          config,
 	}, nil
 }
 
+// Close releases the idle connections of the client's HTTP client. Clients created from the same rest config share a transport, so
+// this is only done for clients that live as long as the server, during shutdown.
+func (c *ClientImpl) Close() {
+	if c.httpClient != nil {
+		c.httpClient.CloseIdleConnections()
+	}
+}
+
+// inClusterRestConfig creates the in cluster config, which reads the current service account token, configured for the hook
+func inClusterRestConfig(config *Config) (*rest.Config, error) {
+	kubeConfig, err := rest.InClusterConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	return configureRestConfig(kubeConfig, config), nil
+}
+
+// configureRestConfig applies the client side rate limit from the config to the rest config
+func configureRestConfig(kubeConfig *rest.Config, config *Config) *rest.Config {
+	kubeConfig.QPS = float32(config.clientQPS)
+	kubeConfig.Burst = config.clientBurst
+	return kubeConfig
+}
+
+// newInClusterDynamicClient creates a dynamic client from the in cluster config, which reads the current service account token
+func newInClusterDynamicClient(config *Config) (dynamic.Interface, error) {
+	kubeConfig, err := inClusterRestConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return dynamic.NewForConfig(kubeConfig)
+}
+
+// newInClusterClientset creates a clientset from the in cluster config, which reads the current service account token
+func newInClusterClientset(config *Config) (kubernetes.Interface, error) {
+	kubeConfig, err := inClusterRestConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return kubernetes.NewForConfig(kubeConfig)
+}
+
+// retryUnauthorized makes a request with the dynamic client, retrying it if unauthorized as described by retryUnauthorizedWith
+func (c *ClientImpl) retryUnauthorized(request func(client dynamic.Interface) error) error {
+	return retryUnauthorizedWith(c.dynamicClient, c.refreshDynamicClient, request)
+}
+
+// retryUnauthorizedClientset makes a request with the clientset, retrying it if unauthorized as described by
+// retryUnauthorizedWith
+func (c *ClientImpl) retryUnauthorizedClientset(request func(client kubernetes.Interface) error) error {
+	return retryUnauthorizedWith(c.clientset, c.refreshClientset, request)
+}
+
+// retryUnauthorizedWith makes a request with a client. The API server can briefly reject requests as unauthorized while the
+// service account token is rotated, so an unauthorized request is retried once with a client refreshed to re-read the token. If
+// refresh is nil, the request is retried with the same client. If it is still rejected, the error wraps ErrUnauthorized.
+func retryUnauthorizedWith[T any](client T, refresh func() (T, error), request func(client T) error) error {
+	err := request(client)
+	if !k8serrors.IsUnauthorized(err) {
+		return err
+	}
+
+	if refresh != nil {
+		if refreshed, refreshErr := refresh(); refreshErr == nil {
+			client = refreshed
+		} else {
+			slog.Warn("Failed to re-read service account token, retrying with the current token", "error", refreshErr)
+		}
+	}
+
+	if err = request(client); k8serrors.IsUnauthorized(err) {
+		return fmt.Errorf("%w: %w", ErrUnauthorized, err)
+	}
+
+	return err
+}
+
 func (c *ClientImpl) GetConfig() *Config {
 	return c.config
 }
 
+// WithContext returns a copy of the client whose API requests are made with ctx, and whose retries stop once it is done. The
+// copy shares the underlying clients, so closing either closes both.
+func (c *ClientImpl) WithContext(ctx context.Context) Client {
+	return c.withContext(ctx)
+}
+
+func (c *ClientImpl) withContext(ctx context.Context) *ClientImpl {
+	client := *c
+	client.ctx = ctx
+	return &client
+}
+
+// requestContext returns the context API requests are made with
+func (c *ClientImpl) requestContext() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+
+	return c.ctx
+}
+
+// GetResource gets a resource of any type. If namespace is empty, the resource is fetched as a cluster scoped resource.
+func (c *ClientImpl) GetResource(gvr schema.GroupVersionResource, namespace, name string) (*unstructured.Unstructured, error) {
+	var obj *unstructured.Unstructured
+	err := c.retryUnauthorized(func(client dynamic.Interface) error {
+		var err error
+		obj, err = namespacedResource(client, gvr, namespace).Get(c.requestContext(), name, metav1.GetOptions{})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return obj, nil
+}
+
+// PatchResource applies a patch of the given type to a resource of any type. If namespace is empty, the resource is patched as a
+// cluster scoped resource.
+func (c *ClientImpl) PatchResource(gvr schema.GroupVersionResource, namespace, name string, patchType types.PatchType, payload []byte) error {
+	return c.retryUnauthorized(func(client dynamic.Interface) error {
+		_, err := namespacedResource(client, gvr, namespace).Patch(c.requestContext(), name, patchType, payload, metav1.PatchOptions{})
+		return err
+	})
+}
+
+// namespacedResource returns the interface for a resource in the namespace, or for a cluster scoped resource if namespace is empty
+func namespacedResource(client dynamic.Interface, gvr schema.GroupVersionResource, namespace string) dynamic.ResourceInterface {
+	if namespace == "" {
+		return client.Resource(gvr)
+	}
+
+	return client.Resource(gvr).Namespace(namespace)
+}
+
+// trackingResourceNamespace returns the namespace of the tracking resource instance for pods in the given namespace, which is
+// empty for cluster scoped tracking resources
+func (c *ClientImpl) trackingResourceNamespace(namespace string) string {
+	if !c.config.trackingResource.IsNamespaced() {
+		return ""
+	}
+
+	return namespace
+}
+
+// usesTypedPodClient reports whether pods are read and patched with the clientset, which requires USE_TYPED_POD_CLIENT to be
+// enabled and the client to have a clientset
+func (c *ClientImpl) usesTypedPodClient() bool {
+	return c.clientset != nil && c.config != nil && c.config.useTypedPodClient
+}
+
+// GetPod gets a pod, with the clientset if USE_TYPED_POD_CLIENT is enabled, or otherwise with the dynamic client, converting the
+// unstructured pod to a corev1.Pod
 func (c *ClientImpl) GetPod(name, namespace string) (*corev1.Pod, error) {
-	podUnstructured, err := c.dynamicClient.Resource(podResource).Namespace(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if c.usesTypedPodClient() {
+		var pod *corev1.Pod
+		err := c.retryUnauthorizedClientset(func(client kubernetes.Interface) error {
+			var err error
+			pod, err = client.CoreV1().Pods(namespace).Get(c.requestContext(), name, metav1.GetOptions{})
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return pod, nil
+	}
+
+	podUnstructured, err := c.GetResource(podResource, namespace, name)
 	if err != nil {
 		return nil, err
 	}
@@ -82,89 +358,897 @@ func (c *ClientImpl) GetPod(name, namespace string) (*corev1.Pod, error) {
 	return pod, nil
 }
 
+// GetPodMeta gets the labels, annotations, UID, phase and deletion timestamp of a pod directly from the unstructured object, with
+// the dynamic client whether or not USE_TYPED_POD_CLIENT is enabled.
+// Unlike GetPod, the pod is not converted to a corev1.Pod, so fields elsewhere in the pod that do not match the corev1
+// schema cannot cause it to fail.
+func (c *ClientImpl) GetPodMeta(name, namespace string) (map[string]string, map[string]string, types.UID, corev1.PodPhase, *metav1.Time, error) {
+	podUnstructured, err := c.GetResource(podResource, namespace, name)
+	if err != nil {
+		return nil, nil, "", "", nil, err
+	}
+
+	phase, _, err := unstructured.NestedString(podUnstructured.Object, "status", "phase")
+	if err != nil {
+		return nil, nil, "", "", nil, fmt.Errorf("failed to read pod phase: %w", err)
+	}
+
+	return podUnstructured.GetLabels(), podUnstructured.GetAnnotations(), podUnstructured.GetUID(), corev1.PodPhase(phase), podUnstructured.GetDeletionTimestamp(), nil
+}
+
+func (c *ClientImpl) GetNode(nodeName string) (*corev1.Node, error) {
+	nodeUnstructured, err := c.GetResource(nodeResource, "", nodeName)
+	if err != nil {
+		return nil, err
+	}
+
+	node := &corev1.Node{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(nodeUnstructured.Object, node); err != nil {
+		return nil, fmt.Errorf("failed to convert unstructured to Node: %w", err)
+	}
+
+	return node, nil
+}
+
+// GetNodeConditions gets the taints of a node and the status of its Ready condition. If the node has no Ready condition, the
+// status is ConditionUnknown.
+func (c *ClientImpl) GetNodeConditions(nodeName string) ([]corev1.Taint, corev1.ConditionStatus, error) {
+	node, err := c.GetNode(nodeName)
+	if err != nil {
+		return nil, "", err
+	}
+
+	ready := corev1.ConditionUnknown
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			ready = condition.Status
+		}
+	}
+
+	return node.Spec.Taints, ready, nil
+}
+
+// ListPodsByTrackingInstance lists the pods in the namespace that belong to the tracking resource instance with the given name,
+// such as the pods labelled with couchbase_cluster=<instance> for CouchbaseClusters, or every pod in the namespace for
+// Namespaces. If INSTANCE_NAME_FROM is owner, pods without the label are listed if their controller has it. If no instance name is
+// given, the returned error will wrap ErrNoTrackingInstanceName.
+func (c *ClientImpl) ListPodsByTrackingInstance(instance, namespace string) ([]corev1.Pod, error) {
+	if instance == "" {
+		return nil, fmt.Errorf("%w: %s in namespace %s", ErrNoTrackingInstanceName, c.config.trackingResource.GetResourceType(), namespace)
+	}
+
+	// Pods that are only matched by their controller's labels cannot be selected by label, so every pod in the namespace is listed
+	fromOwner := c.config.instanceNameFrom == InstanceNameFromOwner
+	options := metav1.ListOptions{}
+	if !fromOwner {
+		options.LabelSelector = labels.SelectorFromSet(c.config.trackingResource.GetInstanceLabels(instance)).String()
+	}
+
+	var podsUnstructured *unstructured.UnstructuredList
+	err := c.retryUnauthorized(func(client dynamic.Interface) error {
+		var err error
+		podsUnstructured, err = namespacedResource(client, podResource, namespace).List(c.requestContext(), options)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	pods := make([]corev1.Pod, len(podsUnstructured.Items))
+	for i, podUnstructured := range podsUnstructured.Items {
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(podUnstructured.Object, &pods[i]); err != nil {
+			return nil, fmt.Errorf("failed to convert unstructured to Pod: %w", err)
+		}
+	}
+
+	if fromOwner {
+		return c.podsOfTrackingInstance(pods, instance), nil
+	}
+
+	return pods, nil
+}
+
+// podsOfTrackingInstance returns the pods that belong to the tracking resource instance, deriving the instance name from the
+// controller of pods that do not have it. Each controller is only fetched once, as the pods of an instance usually share one.
+// Pods whose controller cannot be fetched, such as those of other apps that the hook cannot read, are skipped.
+func (c *ClientImpl) podsOfTrackingInstance(pods []corev1.Pod, instance string) []corev1.Pod {
+	controllers := make(map[string]string)
+	var matched []corev1.Pod
+	for i := range pods {
+		pod := &pods[i]
+		name := c.config.trackingResource.GetInstanceName(pod)
+		if owner := metav1.GetControllerOf(pod); name == "" && owner != nil {
+			var cached bool
+			if name, cached = controllers[owner.Kind+"/"+owner.Name]; !cached {
+				var err error
+				if name, err = c.TrackingInstanceName(pod); err != nil {
+					slog.Debug("Failed to derive tracking resource instance of pod, skipping it", "pod", pod.Name, "namespace", pod.Namespace, "error", err)
+				}
+				controllers[owner.Kind+"/"+owner.Name] = name
+			}
+		}
+
+		if name == instance {
+			matched = append(matched, *pod)
+		}
+	}
+
+	return matched
+}
+
+// GetTrackingResourceInstance gets the tracking resource instance with the given name. An instance that is not found is retried
+// with a backoff, as it may only be missing briefly while it is recreated. If it still does not exist, the returned error will
+// wrap ErrTrackingResourceNotFound.
 func (c *ClientImpl) GetTrackingResourceInstance(name, namespace string) (*unstructured.Unstructured, error) {
-	return c.config.trackingResource.GetResourceInterface(c.dynamicClient, namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	gvr := c.config.trackingResource.GetGroupVersionResource()
+	trackingResourceInstance, err := c.GetResource(gvr, c.trackingResourceNamespace(namespace), name)
+
+	interval := c.config.trackingNotFoundInterval
+	for retry := 0; retry < c.config.trackingNotFoundRetries && k8serrors.IsNotFound(err); retry++ {
+		slog.Debug("Tracking resource not found, retrying", "trackingResource", name, "retry", retry+1, "interval", interval)
+		if err := sleepContext(c.requestContext(), interval); err != nil {
+			return nil, fmt.Errorf("stopped retrying %s %q: %w", c.config.trackingResource.GetResourceType(), name, err)
+		}
+		interval *= 2
+
+		trackingResourceInstance, err = c.GetResource(gvr, c.trackingResourceNamespace(namespace), name)
+	}
+
+	if k8serrors.IsNotFound(err) {
+		return nil, fmt.Errorf("%w: %s %q: %w", ErrTrackingResourceNotFound, c.config.trackingResource.GetResourceType(), name, err)
+	}
+
+	return trackingResourceInstance, err
+}
+
+// sleepContext sleeps for the given duration, returning the context's error if it is done first
+func sleepContext(ctx context.Context, duration time.Duration) error {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// ResolveTrackingInstance derives the name of the tracking resource instance the pod belongs to and gets it. If the name cannot
+// be derived, the returned error will wrap ErrNoTrackingInstanceName. If the instance does not exist, the returned error will
+// wrap ErrTrackingResourceNotFound.
+func (c *ClientImpl) ResolveTrackingInstance(pod *corev1.Pod) (*unstructured.Unstructured, error) {
+	name, err := c.TrackingInstanceName(pod)
+	if err != nil {
+		return nil, err
+	}
+
+	if name == "" {
+		return nil, fmt.Errorf("%w: %s for pod %s/%s", ErrNoTrackingInstanceName, c.config.trackingResource.GetResourceType(), pod.Namespace, pod.Name)
+	}
+
+	return c.GetTrackingResourceInstance(name, pod.Namespace)
+}
+
+// TrackingInstanceName derives the name of the tracking resource instance the pod belongs to, which is empty if it cannot be
+// derived. If INSTANCE_NAME_FROM is owner and the name cannot be derived from the pod, the pod's controller is fetched and the
+// name is derived from its labels instead.
+func (c *ClientImpl) TrackingInstanceName(pod *corev1.Pod) (string, error) {
+	name := c.config.trackingResource.GetInstanceName(pod)
+	if name != "" || c.config.instanceNameFrom != InstanceNameFromOwner {
+		return name, nil
+	}
+
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil {
+		return "", nil
+	}
+
+	gvr, err := c.ownerResource(owner)
+	if err != nil {
+		return "", err
+	}
+
+	ownerInstance, err := c.GetResource(gvr, pod.Namespace, owner.Name)
+	if err != nil {
+		return "", fmt.Errorf("failed to get owner %s %q of pod %s/%s: %w", owner.Kind, owner.Name, pod.Namespace, pod.Name, err)
+	}
+
+	// Tracking resources derive instance names from pods, so the owner's labels are read as if they were on the pod
+	ownerPod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: pod.Name, Namespace: pod.Namespace, Labels: ownerInstance.GetLabels()}}
+	return c.config.trackingResource.GetInstanceName(ownerPod), nil
+}
+
+// ownerResource returns the resource served by the API server for the kind of the owner
+func (c *ClientImpl) ownerResource(owner *metav1.OwnerReference) (schema.GroupVersionResource, error) {
+	groupVersion, err := schema.ParseGroupVersion(owner.APIVersion)
+	if err != nil {
+		return schema.GroupVersionResource{}, err
+	}
+
+	resources, err := c.discoveryClient.ServerResourcesForGroupVersion(owner.APIVersion)
+	if err != nil {
+		return schema.GroupVersionResource{}, err
+	}
+
+	for _, resource := range resources.APIResources {
+		// Subresources, such as statefulsets/status, are served for the same kind
+		if resource.Kind == owner.Kind && !strings.Contains(resource.Name, "/") {
+			return groupVersion.WithResource(resource.Name), nil
+		}
+	}
+
+	return schema.GroupVersionResource{}, fmt.Errorf("no resource served for kind %s in %s", owner.Kind, owner.APIVersion)
 }
 
 // AddRescheduleHookTrackingAnnotation adds an annotation to the tracking resource, marking that a pod has had the reschedule annotation added to it.
-func (c *ClientImpl) AddRescheduleHookTrackingAnnotation(podName, podNamespace, trackingResourceName string) error {
-	return c.addResourceAnnotation(trackingResourceName, TrackingResourceAnnotation(podName, podNamespace), "true", c.config.trackingResource.GetResourceInterface(c.dynamicClient, podNamespace))
+// If this would take the annotations of the given tracking resource instance over the Kubernetes size limit, the returned error
+// will wrap ErrAnnotationSizeLimit.
+func (c *ClientImpl) AddRescheduleHookTrackingAnnotation(pod *corev1.Pod, trackingResourceInstance *unstructured.Unstructured) error {
+	annotations := map[string]string{trackingAnnotationKey(pod, c.config): trackingAnnotationValue(pod, c.config)}
+
+	resource := c.config.trackingResource.GetResourceType() + " " + trackingResourceInstance.GetName()
+	if err := checkAnnotationsSize(resource, trackingResourceInstance.GetAnnotations(), annotations, c.config.denyNearAnnotationLimit); err != nil {
+		return err
+	}
+
+	return c.addResourceAnnotations(c.config.trackingResource.GetGroupVersionResource(), c.trackingResourceNamespace(pod.Namespace), trackingResourceInstance.GetName(), annotations, "")
 }
 
 // RemoveRescheduleHookTrackingAnnotation removes the tracking annotation from the tracking resource if it is present
-func (c *ClientImpl) RemoveRescheduleHookTrackingAnnotation(podName, podNamespace, trackingResourceName string) error {
-	return c.removeResourceAnnotation(trackingResourceName, TrackingResourceAnnotation(podName, podNamespace), c.config.trackingResource.GetResourceInterface(c.dynamicClient, podNamespace))
+func (c *ClientImpl) RemoveRescheduleHookTrackingAnnotation(pod *corev1.Pod, trackingResourceName string) error {
+	return c.removeTrackingResourceAnnotations(trackingResourceName, pod.Namespace, trackingAnnotationKey(pod, c.config))
 }
 
+// ClearTrackingAnnotations removes every tracking annotation from the tracking resource instance, returning the removed keys.
+// The force tracking annotation is left in place as it is set by users rather than the hook.
+func (c *ClientImpl) ClearTrackingAnnotations(resourceInstanceName, namespace string) ([]string, error) {
+	keys, err := c.trackingAnnotationKeys(resourceInstanceName, namespace)
+	if err != nil || len(keys) == 0 {
+		return nil, err
+	}
+
+	if err := c.removeTrackingResourceAnnotations(resourceInstanceName, namespace, keys...); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// BatchRemoveTrackingAnnotations removes the tracking annotations for several pods from the tracking resource instance in a
+// single patch. Pods are identified by keys of the form <namespace>/<name>, or <namespace>/<scope>/<name> for pods whose tracking
+// annotations are scoped, such as by NAMESPACE_TRACKING_SCOPE_LABEL. Annotations for other pods are left in place.
+func (c *ClientImpl) BatchRemoveTrackingAnnotations(resourceInstanceName, namespace string, podKeys []string) error {
+	annotations, err := podKeyTrackingAnnotations(podKeys)
+	if err != nil || len(annotations) == 0 {
+		return err
+	}
+
+	return c.removeTrackingResourceAnnotations(resourceInstanceName, namespace, annotations...)
+}
+
+// podKeyTrackingAnnotations returns the tracking annotation keys for pods identified by keys of the form <namespace>/<name> or
+// <namespace>/<scope>/<name>
+func podKeyTrackingAnnotations(podKeys []string) ([]string, error) {
+	annotations := make([]string, 0, len(podKeys))
+	for _, podKey := range podKeys {
+		parts := strings.Split(podKey, "/")
+		if (len(parts) != 2 && len(parts) != 3) || slices.Contains(parts, "") {
+			return nil, fmt.Errorf("invalid pod key %q, expected <namespace>/<name> or <namespace>/<scope>/<name>", podKey)
+		}
+
+		var scope string
+		if len(parts) == 3 {
+			scope = parts[1]
+		}
+
+		annotations = append(annotations, ScopedTrackingResourceAnnotation(parts[len(parts)-1], parts[0], scope))
+	}
+
+	return annotations, nil
+}
+
+// trackingAnnotationKeys returns the sorted tracking annotation keys on the tracking resource instance, excluding the force
+// tracking annotation
+func (c *ClientImpl) trackingAnnotationKeys(resourceInstanceName, namespace string) ([]string, error) {
+	trackingResourceInstance, err := c.GetTrackingResourceInstance(resourceInstanceName, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	for key := range trackingResourceInstance.GetAnnotations() {
+		if strings.HasPrefix(key, RescheduledPodsTrackingKeyPrefix) && !slices.Contains(c.config.trackingControlAnnotations(), key) {
+			keys = append(keys, key)
+		}
+	}
+
+	slices.Sort(keys)
+	return keys, nil
+}
+
+// ReschedulePod adds the reschedule annotations to the pod in a single patch, as labels if RESCHEDULE_MARKER_TYPE is label. The
+// pod's resourceVersion is used as a precondition, so the patch will fail with a Conflict error if the pod has changed since it
+// was fetched, or a NotFound error if it has since been deleted. If enabled, the pod is fetched again afterwards and the returned
+// error will wrap ErrAnnotationNotPersisted if any of the annotations are missing.
 func (c *ClientImpl) ReschedulePod(pod *corev1.Pod) error {
-	return c.addResourceAnnotation(pod.Name, c.config.rescheduleAnnotationKey, c.config.rescheduleAnnotationValue, c.dynamicClient.Resource(podResource).Namespace(pod.Namespace))
+	labels, annotations := rescheduleMarkers(pod, c.config)
+
+	// Concurrent drains can reach here for a pod that is already marked, in which case the patch would change nothing
+	if containsAll(pod.Labels, labels) && containsAll(pod.Annotations, annotations) {
+		return nil
+	}
+
+	payload, err := addMetadataPatch(labels, annotations, pod.ResourceVersion)
+	if err != nil {
+		return err
+	}
+
+	if err := c.patchPod(pod.Namespace, pod.Name, payload); err != nil {
+		return err
+	}
+
+	if !c.config.verifyAnnotation {
+		return nil
+	}
+
+	currentLabels, currentAnnotations, _, _, _, err := c.GetPodMeta(pod.Name, pod.Namespace)
+	if err != nil {
+		return fmt.Errorf("failed to verify reschedule annotation: %w", err)
+	}
+
+	markers := currentAnnotations
+	if c.config.rescheduleMarkerType == RescheduleMarkerLabel {
+		markers = currentLabels
+	}
+
+	for key, value := range c.config.rescheduleAnnotationSet() {
+		if markers[key] != value {
+			return fmt.Errorf("%w: pod %s/%s has %s=%q", ErrAnnotationNotPersisted, pod.Namespace, pod.Name, key, markers[key])
+		}
+	}
+
+	return nil
+}
+
+// GetEvictionSubresourceSupport returns the versions of the Eviction API served by the API server, in order of preference. If
+// neither policy/v1 nor policy/v1beta1 are served, the returned error will wrap ErrEvictionNotSupported.
+func (c *ClientImpl) GetEvictionSubresourceSupport() ([]string, error) {
+	groups, err := c.discoveryClient.ServerGroups()
+	if err != nil {
+		return nil, err
+	}
+
+	var served []string
+	for _, group := range groups.Groups {
+		if group.Name != EvictionGroup {
+			continue
+		}
+
+		for _, version := range group.Versions {
+			served = append(served, version.Version)
+		}
+	}
+
+	var versions []string
+	for _, version := range []string{EvictionVersionV1, EvictionVersionV1beta1} {
+		if slices.Contains(served, version) {
+			versions = append(versions, version)
+		}
+	}
+
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("%w: %s group versions %v", ErrEvictionNotSupported, EvictionGroup, served)
+	}
+
+	return versions, nil
+}
+
+// IsTrackingResourceServed checks whether the API server serves the tracking resource, e.g. that the CouchbaseCluster CRD is installed
+func (c *ClientImpl) IsTrackingResourceServed() (bool, error) {
+	gvr := c.config.trackingResource.GetGroupVersionResource()
+	resources, err := c.discoveryClient.ServerResourcesForGroupVersion(gvr.GroupVersion().String())
+	if k8serrors.IsNotFound(err) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	for _, resource := range resources.APIResources {
+		if resource.Name == gvr.Resource {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// DryRunPatchTrackingResource checks that the tracking resource instance can be fetched and patched. The patch is a server side
+// dry run, so it is authorized and admitted as a real patch would be but the instance is never modified.
+func (c *ClientImpl) DryRunPatchTrackingResource(name, namespace string) error {
+	if _, err := c.GetTrackingResourceInstance(name, namespace); err != nil {
+		return err
+	}
+
+	gvr := c.config.trackingResource.GetGroupVersionResource()
+	return c.retryUnauthorized(func(client dynamic.Interface) error {
+		_, err := namespacedResource(client, gvr, c.trackingResourceNamespace(namespace)).Patch(c.requestContext(), name, types.MergePatchType, []byte("{}"), metav1.PatchOptions{DryRun: []string{metav1.DryRunAll}})
+		return err
+	})
+}
+
+// GetWebhookCABundle gets the CA bundles of the webhooks in the ValidatingWebhookConfiguration, concatenated in the order the
+// webhooks are listed. Webhooks without a CA bundle are skipped.
+func (c *ClientImpl) GetWebhookCABundle(configName string) ([]byte, error) {
+	obj, err := c.GetResource(validatingWebhookConfigurationResource, "", configName)
+	if err != nil {
+		return nil, err
+	}
+
+	var webhookConfig admissionregistrationv1.ValidatingWebhookConfiguration
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &webhookConfig); err != nil {
+		return nil, fmt.Errorf("failed to decode ValidatingWebhookConfiguration %s: %w", configName, err)
+	}
+
+	var caBundle []byte
+	for _, webhook := range webhookConfig.Webhooks {
+		if len(webhook.ClientConfig.CABundle) > 0 {
+			caBundle = append(caBundle, webhook.ClientConfig.CABundle...)
+			caBundle = append(caBundle, '\n')
+		}
+	}
+
+	if len(caBundle) == 0 {
+		return nil, fmt.Errorf("no CA bundle set in ValidatingWebhookConfiguration %s", configName)
+	}
+
+	return caBundle, nil
 }
 
 func (c *ClientImpl) ShouldTrackRescheduledPods() bool {
 	return c.config.trackRescheduledPods
 }
 
-func (c *ClientImpl) ShouldAddTrackingAnnotation(trackingResourceInstance *unstructured.Unstructured) bool {
+// ShouldAddTrackingAnnotation checks whether a tracking annotation should be added for the pod. This is false if the disable
+// tracking annotation is set on the tracking resource, even if tracking is forced. Otherwise it is true if the force tracking
+// annotation is set on either the pod or the tracking resource, and is determined by the tracking resource conditional if not.
+func (c *ClientImpl) ShouldAddTrackingAnnotation(pod *corev1.Pod, trackingResourceInstance *unstructured.Unstructured) bool {
+	if hasTrueAnnotation(trackingResourceInstance.GetAnnotations(), c.config.disableTrackingAnnotation) {
+		return false
+	}
+
+	if hasTrueAnnotation(pod.GetAnnotations(), c.config.forceTrackingAnnotation) || hasTrueAnnotation(trackingResourceInstance.GetAnnotations(), c.config.forceTrackingAnnotation) {
+		return true
+	}
+
 	return c.config.trackingResource.ShouldTrack(trackingResourceInstance)
 }
 
-func (c *ClientImpl) addResourceAnnotation(name, annotation string, value string, resourceInterface dynamic.ResourceInterface) error {
-	patch := map[string]interface{}{
-		"metadata": map[string]interface{}{
-			"annotations": map[string]interface{}{
-				annotation: value,
-			},
-		},
+func hasTrueAnnotation(annotations map[string]string, key string) bool {
+	if key == "" {
+		return false
 	}
 
-	payload, err := json.Marshal(patch)
+	force, err := strconv.ParseBool(annotations[key])
+	return err == nil && force
+}
+
+// RecordRescheduleAttempt increments the attempts annotation on the pod. A missing or invalid value is treated as zero.
+func (c *ClientImpl) RecordRescheduleAttempt(pod *corev1.Pod) error {
+	attempts, _ := strconv.Atoi(pod.GetAnnotations()[DefaultAttemptsAnnotation])
+	payload, err := addAnnotationsPatch(map[string]string{DefaultAttemptsAnnotation: strconv.Itoa(attempts + 1)}, "")
 	if err != nil {
 		return err
 	}
 
-	_, err = resourceInterface.Patch(context.TODO(), name, types.MergePatchType, payload, metav1.PatchOptions{})
-	return err
+	return c.patchPod(pod.Namespace, pod.Name, payload)
 }
 
-func (c *ClientImpl) removeResourceAnnotation(name, annotation string, resourceInterface dynamic.ResourceInterface) error {
-	patch := map[string]interface{}{
-		"metadata": map[string]interface{}{
-			"annotations": map[string]interface{}{
-				annotation: nil,
-			},
-		},
+// patchPod applies a merge patch to a pod, with the clientset if USE_TYPED_POD_CLIENT is enabled, or otherwise with the dynamic
+// client
+func (c *ClientImpl) patchPod(namespace, name string, payload []byte) error {
+	if !c.usesTypedPodClient() {
+		return c.PatchResource(podResource, namespace, name, types.MergePatchType, payload)
 	}
 
-	payload, err := json.Marshal(patch)
+	return c.retryUnauthorizedClientset(func(client kubernetes.Interface) error {
+		_, err := client.CoreV1().Pods(namespace).Patch(c.requestContext(), name, types.MergePatchType, payload, metav1.PatchOptions{})
+		return err
+	})
+}
+
+// addResourceAnnotations adds annotations to a resource. If resourceVersion is set, it will be included in the patch so that
+// the API server rejects it if the resource has since changed.
+func (c *ClientImpl) addResourceAnnotations(gvr schema.GroupVersionResource, namespace, name string, annotations map[string]string, resourceVersion string) error {
+	payload, err := addAnnotationsPatch(annotations, resourceVersion)
 	if err != nil {
 		return err
 	}
 
-	_, err = resourceInterface.Patch(context.TODO(), name, types.MergePatchType, payload, metav1.PatchOptions{})
-	return err
+	return c.PatchResource(gvr, namespace, name, types.MergePatchType, payload)
+}
+
+// RemovePodAnnotations removes the given annotations from the pod in a single patch
+func (c *ClientImpl) RemovePodAnnotations(pod *corev1.Pod, annotations ...string) error {
+	payload, err := removeAnnotationsPatch(annotations...)
+	if err != nil {
+		return err
+	}
+
+	return c.patchPod(pod.Namespace, pod.Name, payload)
+}
+
+// removeTrackingResourceAnnotations removes annotations from the tracking resource instance for pods in the given namespace
+func (c *ClientImpl) removeTrackingResourceAnnotations(resourceInstanceName, namespace string, annotations ...string) error {
+	return c.removeResourceAnnotations(c.config.trackingResource.GetGroupVersionResource(), c.trackingResourceNamespace(namespace), resourceInstanceName, annotations...)
+}
+
+func (c *ClientImpl) removeResourceAnnotations(gvr schema.GroupVersionResource, namespace, name string, annotations ...string) error {
+	payload, err := removeAnnotationsPatch(annotations...)
+	if err != nil {
+		return err
+	}
+
+	return c.PatchResource(gvr, namespace, name, types.MergePatchType, payload)
+}
+
+// addAnnotationsPatch returns the merge patch adding annotations to a resource, with resourceVersion as a precondition if set
+func addAnnotationsPatch(annotations map[string]string, resourceVersion string) ([]byte, error) {
+	return addMetadataPatch(nil, annotations, resourceVersion)
+}
+
+// addMetadataPatch returns the merge patch adding labels and annotations to a resource, with resourceVersion as a precondition
+// if set. Labels are left out of the patch if there are none, as are annotations if they are nil.
+func addMetadataPatch(labels, annotations map[string]string, resourceVersion string) ([]byte, error) {
+	metadata := map[string]interface{}{}
+	if len(labels) > 0 {
+		metadata["labels"] = labels
+	}
+
+	if annotations != nil {
+		metadata["annotations"] = annotations
+	}
+
+	if resourceVersion != "" {
+		metadata["resourceVersion"] = resourceVersion
+	}
+
+	return json.Marshal(map[string]interface{}{
+		"metadata": metadata,
+	})
+}
+
+// annotationsSize returns the size of annotations as counted by the Kubernetes limit, which is the total length of the keys
+// and values
+func annotationsSize(annotations map[string]string) int {
+	size := 0
+	for key, value := range annotations {
+		size += len(key) + len(value)
+	}
+
+	return size
+}
+
+// checkAnnotationsSize checks the size the annotations of resource would be after adding annotations to existing. It returns
+// an error if this is over the Kubernetes limit, or over annotationsSizeWarningPercent of it when deny is set, logging a warning
+// in the latter case either way.
+func checkAnnotationsSize(resource string, existing, added map[string]string, deny bool) error {
+	merged := make(map[string]string, len(existing)+len(added))
+	maps.Copy(merged, existing)
+	maps.Copy(merged, added)
+
+	size := annotationsSize(merged)
+	if size > maxAnnotationsSize {
+		return fmt.Errorf("%w: annotations of %s would be %d bytes, over the limit of %d bytes", ErrAnnotationSizeLimit, resource, size, maxAnnotationsSize)
+	}
+
+	if size*100 < maxAnnotationsSize*annotationsSizeWarningPercent {
+		return nil
+	}
+
+	slog.Warn("Annotations are close to the Kubernetes size limit, tracking annotations may soon fail to be added", "resource", resource, "size", size, "limit", maxAnnotationsSize)
+	if deny {
+		return fmt.Errorf("%w: annotations of %s would be %d bytes, over %d%% of the limit of %d bytes", ErrAnnotationSizeLimit, resource, size, annotationsSizeWarningPercent, maxAnnotationsSize)
+	}
+
+	return nil
 }
 
+// removeAnnotationsPatch returns the merge patch removing annotations from a resource
+func removeAnnotationsPatch(annotations ...string) ([]byte, error) {
+	removed := make(map[string]interface{}, len(annotations))
+	for _, annotation := range annotations {
+		removed[annotation] = nil
+	}
+
+	return json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": removed,
+		},
+	})
+}
+
+// TrackingResourceAnnotation returns the tracking annotation key for a pod. Kubernetes limits the name segment of an annotation key
+// to 63 characters, so if the namespace and pod name are too long they will be truncated and suffixed with a hash of the full name.
+// The full name is then stored in the annotation value.
 func TrackingResourceAnnotation(podName, podNamespace string) string {
-	return RescheduledPodsTrackingKeyPrefix + podNamespace + "." + podName
+	return ScopedTrackingResourceAnnotation(podName, podNamespace, "")
+}
+
+// ScopedTrackingResourceAnnotation returns the tracking annotation key for a pod, including the scope if it is not empty, so that
+// pods of different apps that share a tracking resource instance have different keys
+func ScopedTrackingResourceAnnotation(podName, podNamespace, scope string) string {
+	name := trackingAnnotationName(podName, podNamespace, scope)
+	if !isTrackingAnnotationNameHashed(podName, podNamespace, scope) {
+		return RescheduledPodsTrackingKeyPrefix + name
+	}
+
+	hash := sha256.Sum256([]byte(name))
+	suffix := "-" + hex.EncodeToString(hash[:])[:trackingAnnotationHashLength]
+	return RescheduledPodsTrackingKeyPrefix + name[:trackingAnnotationNameMaxLength-len(suffix)] + suffix
+}
+
+const (
+	// trackingAnnotationNameMaxLength is the maximum length of the name segment of an annotation key
+	trackingAnnotationNameMaxLength = 63
+	// trackingAnnotationHashLength is the number of hex characters of the name hash used in truncated tracking annotation keys
+	trackingAnnotationHashLength = 16
+)
+
+// trackingAnnotationName returns the name segment of a tracking annotation key before any truncation
+func trackingAnnotationName(podName, podNamespace, scope string) string {
+	if scope == "" {
+		return podNamespace + "." + podName
+	}
+
+	return podNamespace + "." + scope + "." + podName
+}
+
+func isTrackingAnnotationNameHashed(podName, podNamespace, scope string) bool {
+	return len(trackingAnnotationName(podName, podNamespace, scope)) > trackingAnnotationNameMaxLength
+}
+
+// trackingAnnotationKey returns the key of the pod's tracking annotation, scoped as the tracking resource requires
+func trackingAnnotationKey(pod *corev1.Pod, config *Config) string {
+	return ScopedTrackingResourceAnnotation(pod.Name, pod.Namespace, trackingAnnotationScope(pod, config))
+}
+
+// trackingAnnotationScope returns the scope of the pod's tracking annotation key, which is empty unless the tracking resource
+// scopes its annotations
+func trackingAnnotationScope(pod *corev1.Pod, config *Config) string {
+	if config == nil || config.trackingResource == nil {
+		return ""
+	}
+
+	return config.trackingResource.GetAnnotationScope(pod)
+}
+
+// countTrackingAnnotations returns the number of tracking annotations in the given annotations, ignoring any of the excluded keys
+// that share the tracking annotation prefix
+func countTrackingAnnotations(annotations map[string]string, excludedKeys ...string) int {
+	count := 0
+	for key := range annotations {
+		if strings.HasPrefix(key, RescheduledPodsTrackingKeyPrefix) && !slices.Contains(excludedKeys, key) {
+			count++
+		}
+	}
+
+	return count
+}
+
+// TrackedPod is stored as JSON in the tracking annotation value when the pod's node is being tracked, the annotation key has been
+// truncated or tracking annotations have a maximum age. The node and UID allow a pod that has been recreated with the same name to
+// be distinguished from the original pod that was marked for rescheduling, and the namespace and name identify the pod when they
+// cannot be read from the key.
+type TrackedPod struct {
+	NodeName  string    `json:"nodeName,omitempty"`
+	UID       types.UID `json:"uid,omitempty"`
+	Namespace string    `json:"namespace,omitempty"`
+	Name      string    `json:"name,omitempty"`
+	// TrackedAt is when the pod was tracked, recorded only when tracking annotations have a maximum age
+	TrackedAt time.Time `json:"trackedAt,omitzero"`
+}
+
+// TrackingResourceAnnotationValue returns the value of the tracking annotation for a pod. If there is nothing to record, or the value
+// cannot be encoded, the value will be "true".
+func TrackingResourceAnnotationValue(pod *corev1.Pod, trackPodNode bool) string {
+	return encodeTrackedPod(newTrackedPod(pod, "", trackPodNode))
+}
+
+// rescheduleMarkers returns the labels and annotations added to a pod to mark it for rescheduling. The reschedule annotations
+// are returned as labels if RESCHEDULE_MARKER_TYPE is label. When enabled, the name of the pod's node is recorded in an annotation
+// alongside them, unless the pod has not been scheduled to a node.
+func rescheduleMarkers(pod *corev1.Pod, config *Config) (labels, annotations map[string]string) {
+	if config.rescheduleMarkerType == RescheduleMarkerLabel {
+		labels = maps.Clone(config.rescheduleAnnotationSet())
+	} else {
+		annotations = maps.Clone(config.rescheduleAnnotationSet())
+	}
+
+	if config.recordFromNode && pod.Spec.NodeName != "" {
+		if annotations == nil {
+			annotations = make(map[string]string, 1)
+		}
+
+		annotations[DefaultFromNodeAnnotation] = pod.Spec.NodeName
+	}
+
+	return labels, annotations
+}
+
+// containsAll checks whether current has every key in expected, with the same value
+func containsAll(current, expected map[string]string) bool {
+	for key, value := range expected {
+		if existing, exists := current[key]; !exists || existing != value {
+			return false
+		}
+	}
+
+	return true
+}
+
+// trackingAnnotationValue returns the value of the tracking annotation for a pod tracked now, recording the time it was tracked if
+// tracking annotations have a maximum age
+func trackingAnnotationValue(pod *corev1.Pod, config *Config) string {
+	trackedPod := newTrackedPod(pod, trackingAnnotationScope(pod, config), config.trackPodNode)
+	if config.trackingAnnotationMaxAge > 0 {
+		trackedPod.TrackedAt = config.clock.Now().UTC().Truncate(time.Second)
+	}
+
+	return encodeTrackedPod(trackedPod)
+}
+
+// newTrackedPod records the fields of a pod needed in its tracking annotation, whose key has the given scope
+func newTrackedPod(pod *corev1.Pod, scope string, trackPodNode bool) TrackedPod {
+	trackedPod := TrackedPod{}
+	if trackPodNode {
+		trackedPod.NodeName = pod.Spec.NodeName
+		trackedPod.UID = pod.UID
+	}
+
+	if isTrackingAnnotationNameHashed(pod.Name, pod.Namespace, scope) {
+		trackedPod.Namespace = pod.Namespace
+		trackedPod.Name = pod.Name
+	}
+
+	return trackedPod
+}
+
+// encodeTrackedPod encodes a tracked pod as the value of a tracking annotation
+func encodeTrackedPod(trackedPod TrackedPod) string {
+	if trackedPod == (TrackedPod{}) {
+		return "true"
+	}
+
+	value, err := json.Marshal(trackedPod)
+	if err != nil {
+		return "true"
+	}
+
+	return string(value)
+}
+
+// IsTrackedPodRescheduled checks whether a pod has been rescheduled using the value of its tracking annotation. A value of "true"
+// means the pod has been rescheduled. If the value records the original node and UID, a pod on a different node or with a different
+// UID has been rescheduled, whereas a pod on the same node with the same UID is still the original. The second return value will
+// be false if the annotation value is not recognised or records a different pod.
+func IsTrackedPodRescheduled(value string, pod *corev1.Pod) (bool, bool) {
+	if value == "true" {
+		return true, true
+	}
+
+	var trackedPod TrackedPod
+	if err := json.Unmarshal([]byte(value), &trackedPod); err != nil {
+		return false, false
+	}
+
+	if trackedPod.Name != "" && (trackedPod.Name != pod.Name || trackedPod.Namespace != pod.Namespace) {
+		return false, false
+	}
+
+	if trackedPod.NodeName == "" && trackedPod.UID == "" {
+		return true, true
+	}
+
+	return trackedPod.NodeName != pod.Spec.NodeName || trackedPod.UID != pod.UID, true
+}
+
+// isTrackingAnnotationExpired checks whether the value of a tracking annotation records that the pod was tracked longer than
+// maxAge before now. Values that do not record when the pod was tracked never expire.
+func isTrackingAnnotationExpired(value string, now time.Time, maxAge time.Duration) bool {
+	if maxAge <= 0 {
+		return false
+	}
+
+	var trackedPod TrackedPod
+	if err := json.Unmarshal([]byte(value), &trackedPod); err != nil || trackedPod.TrackedAt.IsZero() {
+		return false
+	}
+
+	return now.Sub(trackedPod.TrackedAt) > maxAge
+}
+
+// isTrackedPodOnDifferentNode checks whether the value of a tracking annotation records a node other than the one the pod is
+// now on. Values that do not record a node, such as "true", are never on a different node.
+func isTrackedPodOnDifferentNode(value string, pod *corev1.Pod) bool {
+	var trackedPod TrackedPod
+	if err := json.Unmarshal([]byte(value), &trackedPod); err != nil {
+		return false
+	}
+
+	return trackedPod.NodeName != "" && trackedPod.NodeName != pod.Spec.NodeName
 }
 
 // DryRunClientImpl embeds ClientImpl to inherit all read-only methods
-// and overrides only the mutating methods to be no-ops
+// and overrides only the mutating methods to log the patch they would have applied
 type DryRunClientImpl struct {
 	*ClientImpl
 }
 
+func (c *DryRunClientImpl) WithContext(ctx context.Context) Client {
+	return &DryRunClientImpl{ClientImpl: c.ClientImpl.withContext(ctx)}
+}
+
+func (c *DryRunClientImpl) PatchResource(gvr schema.GroupVersionResource, namespace, name string, patchType types.PatchType, payload []byte) error {
+	return logDryRunPatch(gvr.Resource, name, namespace, payload, nil)
+}
+
 func (c *DryRunClientImpl) ReschedulePod(pod *corev1.Pod) error {
-	// No-op for dry run
-	return nil
+	labels, annotations := rescheduleMarkers(pod, c.config)
+	payload, err := addMetadataPatch(labels, annotations, pod.ResourceVersion)
+	return logDryRunPatch("pod", pod.Name, pod.Namespace, payload, err)
 }
 
-func (c *DryRunClientImpl) AddRescheduleHookTrackingAnnotation(podName, podNamespace, resourceInstanceName string) error {
-	// No-op for dry run
-	return nil
+func (c *DryRunClientImpl) RecordRescheduleAttempt(pod *corev1.Pod) error {
+	attempts, _ := strconv.Atoi(pod.GetAnnotations()[DefaultAttemptsAnnotation])
+	payload, err := addAnnotationsPatch(map[string]string{DefaultAttemptsAnnotation: strconv.Itoa(attempts + 1)}, "")
+	return logDryRunPatch("pod", pod.Name, pod.Namespace, payload, err)
+}
+
+func (c *DryRunClientImpl) RemovePodAnnotations(pod *corev1.Pod, annotations ...string) error {
+	payload, err := removeAnnotationsPatch(annotations...)
+	return logDryRunPatch("pod", pod.Name, pod.Namespace, payload, err)
+}
+
+func (c *DryRunClientImpl) ClearTrackingAnnotations(resourceInstanceName, namespace string) ([]string, error) {
+	keys, err := c.trackingAnnotationKeys(resourceInstanceName, namespace)
+	if err != nil || len(keys) == 0 {
+		return nil, err
+	}
+
+	payload, err := removeAnnotationsPatch(keys...)
+	return keys, logDryRunPatch(c.config.trackingResource.GetResourceType(), resourceInstanceName, namespace, payload, err)
 }
 
-func (c *DryRunClientImpl) RemoveRescheduleHookTrackingAnnotation(podName, podNamespace, resourceInstanceName string) error {
-	// No-op for dry run
+func (c *DryRunClientImpl) BatchRemoveTrackingAnnotations(resourceInstanceName, namespace string, podKeys []string) error {
+	annotations, err := podKeyTrackingAnnotations(podKeys)
+	if err != nil || len(annotations) == 0 {
+		return err
+	}
+
+	payload, err := removeAnnotationsPatch(annotations...)
+	return logDryRunPatch(c.config.trackingResource.GetResourceType(), resourceInstanceName, namespace, payload, err)
+}
+
+func (c *DryRunClientImpl) AddRescheduleHookTrackingAnnotation(pod *corev1.Pod, trackingResourceInstance *unstructured.Unstructured) error {
+	annotations := map[string]string{trackingAnnotationKey(pod, c.config): trackingAnnotationValue(pod, c.config)}
+	payload, err := addAnnotationsPatch(annotations, "")
+	return logDryRunPatch(c.config.trackingResource.GetResourceType(), trackingResourceInstance.GetName(), pod.Namespace, payload, err)
+}
+
+func (c *DryRunClientImpl) RemoveRescheduleHookTrackingAnnotation(pod *corev1.Pod, resourceInstanceName string) error {
+	payload, err := removeAnnotationsPatch(trackingAnnotationKey(pod, c.config))
+	return logDryRunPatch(c.config.trackingResource.GetResourceType(), resourceInstanceName, pod.Namespace, payload, err)
+}
+
+// logDryRunPatch logs the merge patch that would have been applied to a resource outside of dry run mode
+func logDryRunPatch(resource, name, namespace string, payload []byte, err error) error {
+	if err != nil {
+		return err
+	}
+
+	slog.Info("Dry run, patch not applied", "resource", resource, "name", name, "namespace", namespace, "patch", string(payload))
 	return nil
 }
@@ -1,14 +1,39 @@
 package reschedule
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"maps"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/couchbaselabs/eviction-reschedule-hook/pkg/reschedule/tracking"
 	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes"
+	fakekubernetes "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+	k8stesting "k8s.io/client-go/testing"
 )
 
 func TestGetPod(t *testing.T) {
@@ -42,6 +67,295 @@ func TestGetPod(t *testing.T) {
 	}
 }
 
+func TestGetPodMeta(t *testing.T) {
+	deletionTimestamp := metav1.NewTime(time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC))
+
+	testcases := []struct {
+		testname string
+		stub     *corev1.Pod
+	}{
+		{
+			testname: "Pod without metadata",
+			stub: &corev1.Pod{
+				TypeMeta: metav1.TypeMeta{Kind: "Pod", APIVersion: "v1"},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-pod",
+					Namespace: "default-namespace",
+				},
+			},
+		},
+		{
+			testname: "Pod with metadata",
+			stub: &corev1.Pod{
+				TypeMeta: metav1.TypeMeta{Kind: "Pod", APIVersion: "v1"},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "test-pod",
+					Namespace:         "default-namespace",
+					UID:               "test-uid",
+					Labels:            map[string]string{"app": "couchbase"},
+					Annotations:       map[string]string{"cao.couchbase.com/reschedule": "true"},
+					DeletionTimestamp: &deletionTimestamp,
+				},
+				Spec: corev1.PodSpec{
+					NodeName: "node1",
+				},
+				Status: corev1.PodStatus{
+					Phase: corev1.PodRunning,
+				},
+			},
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testname, func(t *testing.T) {
+			unstructuredStub, err := runtime.DefaultUnstructuredConverter.ToUnstructured(testcase.stub)
+			if err != nil {
+				t.Fatalf("Failed to convert pod to unstructured: %v", err)
+			}
+
+			client := &ClientImpl{
+				dynamicClient: fake.NewSimpleDynamicClient(runtime.NewScheme(), &unstructured.Unstructured{Object: unstructuredStub}),
+			}
+
+			pod, err := client.GetPod("test-pod", "default-namespace")
+			if err != nil {
+				t.Fatalf("Failed to get pod: %v", err)
+			}
+
+			labels, annotations, uid, phase, deletionTimestamp, err := client.GetPodMeta("test-pod", "default-namespace")
+			if err != nil {
+				t.Fatalf("Failed to get pod metadata: %v", err)
+			}
+
+			if !reflect.DeepEqual(labels, pod.Labels) {
+				t.Errorf("Expected labels to be %v, got %v", pod.Labels, labels)
+			}
+
+			if !reflect.DeepEqual(annotations, pod.Annotations) {
+				t.Errorf("Expected annotations to be %v, got %v", pod.Annotations, annotations)
+			}
+
+			if uid != pod.UID {
+				t.Errorf("Expected UID to be %q, got %q", pod.UID, uid)
+			}
+
+			if phase != pod.Status.Phase {
+				t.Errorf("Expected phase to be %q, got %q", pod.Status.Phase, phase)
+			}
+
+			if (deletionTimestamp == nil) != (pod.DeletionTimestamp == nil) || (deletionTimestamp != nil && !deletionTimestamp.Equal(pod.DeletionTimestamp)) {
+				t.Errorf("Expected deletion timestamp to be %v, got %v", pod.DeletionTimestamp, deletionTimestamp)
+			}
+		})
+	}
+}
+
+func TestGetPodMetaSchemaDrift(t *testing.T) {
+	// A spec field with an unexpected type cannot be converted to a corev1.Pod, but does not affect the metadata
+	stub := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata": map[string]interface{}{
+			"name":      "test-pod",
+			"namespace": "default-namespace",
+			"labels":    map[string]interface{}{"app": "couchbase"},
+		},
+		"spec": map[string]interface{}{
+			"containers": "not-a-list",
+		},
+	}}
+
+	client := &ClientImpl{
+		dynamicClient: fake.NewSimpleDynamicClient(runtime.NewScheme(), stub),
+	}
+
+	if _, err := client.GetPod("test-pod", "default-namespace"); err == nil {
+		t.Fatalf("Expected converting the pod to fail")
+	}
+
+	labels, _, _, _, _, err := client.GetPodMeta("test-pod", "default-namespace")
+	if err != nil {
+		t.Fatalf("Failed to get pod metadata: %v", err)
+	}
+
+	if labels["app"] != "couchbase" {
+		t.Fatalf("Expected labels to be read from the pod, got %v", labels)
+	}
+}
+
+func TestGetNodeConditions(t *testing.T) {
+	noReadyCondition := nodeStub("node-no-ready", corev1.ConditionTrue)
+	noReadyCondition.Status.Conditions = []corev1.NodeCondition{{Type: corev1.NodeMemoryPressure, Status: corev1.ConditionFalse}}
+
+	nodes := []*corev1.Node{
+		nodeStub("node-ready", corev1.ConditionTrue),
+		nodeStub("node-cordoned", corev1.ConditionTrue, unschedulableTaint),
+		nodeStub("node-not-ready", corev1.ConditionFalse, corev1.Taint{Key: corev1.TaintNodeNotReady, Effect: corev1.TaintEffectNoExecute}),
+		noReadyCondition,
+	}
+
+	objects := []runtime.Object{}
+	for _, node := range nodes {
+		object, err := runtime.DefaultUnstructuredConverter.ToUnstructured(node)
+		if err != nil {
+			t.Fatalf("Failed to convert node: %v", err)
+		}
+		objects = append(objects, &unstructured.Unstructured{Object: object})
+	}
+
+	client := &ClientImpl{dynamicClient: fake.NewSimpleDynamicClient(runtime.NewScheme(), objects...)}
+
+	testcases := []struct {
+		node           string
+		expectedReady  corev1.ConditionStatus
+		expectedTaints []string
+		expectNotFound bool
+	}{
+		{node: "node-ready", expectedReady: corev1.ConditionTrue},
+		{node: "node-cordoned", expectedReady: corev1.ConditionTrue, expectedTaints: []string{corev1.TaintNodeUnschedulable}},
+		{node: "node-not-ready", expectedReady: corev1.ConditionFalse, expectedTaints: []string{corev1.TaintNodeNotReady}},
+		{node: "node-no-ready", expectedReady: corev1.ConditionUnknown},
+		{node: "node-missing", expectNotFound: true},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.node, func(t *testing.T) {
+			taints, ready, err := client.GetNodeConditions(testcase.node)
+			if testcase.expectNotFound {
+				if !k8serrors.IsNotFound(err) {
+					t.Fatalf("Expected a NotFound error, got %v", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Failed to get node conditions: %v", err)
+			}
+
+			if ready != testcase.expectedReady {
+				t.Fatalf("Expected Ready condition %s, got %s", testcase.expectedReady, ready)
+			}
+
+			keys := []string{}
+			for _, taint := range taints {
+				keys = append(keys, taint.Key)
+			}
+
+			if len(keys) != len(testcase.expectedTaints) || (len(keys) > 0 && !reflect.DeepEqual(keys, testcase.expectedTaints)) {
+				t.Fatalf("Expected taints %v, got %v", testcase.expectedTaints, keys)
+			}
+		})
+	}
+}
+
+func TestGetResource(t *testing.T) {
+	testcases := []struct {
+		testname     string
+		gvr          schema.GroupVersionResource
+		namespace    string
+		name         string
+		expectedKind string
+	}{
+		{
+			testname:     "Namespaced resource",
+			gvr:          tracking.NewCouchbaseClusterTrackingResource("").GetGroupVersionResource(),
+			namespace:    "default-namespace",
+			name:         "test-cluster",
+			expectedKind: "CouchbaseCluster",
+		},
+		{
+			testname:     "Cluster scoped resource",
+			gvr:          (&tracking.NamespaceTrackingResource{}).GetGroupVersionResource(),
+			name:         "default-namespace",
+			expectedKind: "Namespace",
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testname, func(t *testing.T) {
+			client := &ClientImpl{
+				dynamicClient: fake.NewSimpleDynamicClient(runtime.NewScheme(),
+					couchbaseClusterStub("test-cluster", "default-namespace", true, nil),
+					namespaceStub("default-namespace", nil),
+				),
+			}
+
+			resource, err := client.GetResource(testcase.gvr, testcase.namespace, testcase.name)
+			if err != nil {
+				t.Fatalf("Failed to get resource: %v", err)
+			}
+
+			if resource.GetKind() != testcase.expectedKind || resource.GetName() != testcase.name || resource.GetNamespace() != testcase.namespace {
+				t.Fatalf("Expected %s %s/%s, got %s %s/%s", testcase.expectedKind, testcase.namespace, testcase.name, resource.GetKind(), resource.GetNamespace(), resource.GetName())
+			}
+		})
+	}
+}
+
+func TestGetResourceNotFound(t *testing.T) {
+	client := &ClientImpl{
+		dynamicClient: fake.NewSimpleDynamicClient(runtime.NewScheme()),
+	}
+
+	_, err := client.GetResource(podResource, "default-namespace", "test-pod")
+	if !k8serrors.IsNotFound(err) {
+		t.Fatalf("Expected a NotFound error, got %v", err)
+	}
+}
+
+func TestPatchResource(t *testing.T) {
+	testcases := []struct {
+		testname            string
+		patchType           types.PatchType
+		payload             string
+		expectedAnnotations map[string]string
+	}{
+		{
+			testname:  "Merge patch",
+			patchType: types.MergePatchType,
+			payload:   `{"metadata":{"annotations":{"added":"true","removed":null}}}`,
+			expectedAnnotations: map[string]string{
+				"added": "true",
+				"kept":  "true",
+			},
+		},
+		{
+			testname:  "JSON patch",
+			patchType: types.JSONPatchType,
+			payload:   `[{"op":"add","path":"/metadata/annotations/added","value":"true"},{"op":"remove","path":"/metadata/annotations/removed"}]`,
+			expectedAnnotations: map[string]string{
+				"added": "true",
+				"kept":  "true",
+			},
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testname, func(t *testing.T) {
+			gvr := (&tracking.NamespaceTrackingResource{}).GetGroupVersionResource()
+			client := &ClientImpl{
+				dynamicClient: fake.NewSimpleDynamicClient(runtime.NewScheme(), namespaceStub("default-namespace", map[string]interface{}{
+					"kept":    "true",
+					"removed": "true",
+				})),
+			}
+
+			if err := client.PatchResource(gvr, "", "default-namespace", testcase.patchType, []byte(testcase.payload)); err != nil {
+				t.Fatalf("Failed to patch resource: %v", err)
+			}
+
+			resource, err := client.GetResource(gvr, "", "default-namespace")
+			if err != nil {
+				t.Fatalf("Failed to get patched resource: %v", err)
+			}
+
+			if !reflect.DeepEqual(resource.GetAnnotations(), testcase.expectedAnnotations) {
+				t.Fatalf("Expected annotations to be %v, got %v", testcase.expectedAnnotations, resource.GetAnnotations())
+			}
+		})
+	}
+}
+
 func TestGetTrackingResourceInstance(t *testing.T) {
 	testcases := []struct {
 		testname             string
@@ -73,74 +387,1803 @@ func TestGetTrackingResourceInstance(t *testing.T) {
 				config:        NewConfigBuilder().FromEnvironment().WithTrackingResource(testcase.trackingResourceType).Build(),
 			}
 
-			trackingResourceInstance, err := client.GetTrackingResourceInstance(testcase.resourceStub.GetName(), "default-namespace")
-			if err != nil {
-				t.Fatalf("Failed to get tracking resource: %v", err)
+			trackingResourceInstance, err := client.GetTrackingResourceInstance(testcase.resourceStub.GetName(), "default-namespace")
+			if err != nil {
+				t.Fatalf("Failed to get tracking resource: %v", err)
+			}
+
+			if !reflect.DeepEqual(trackingResourceInstance, testcase.resourceStub) {
+				t.Fatalf("Expected tracking resource to be %v, got %v", testcase.resourceStub, trackingResourceInstance)
+			}
+		})
+	}
+}
+
+func TestGetTrackingResourceInstanceNotFound(t *testing.T) {
+	testcases := []struct {
+		testname             string
+		trackingResourceType string
+	}{
+		{
+			testname:             "CouchbaseCluster",
+			trackingResourceType: "couchbasecluster",
+		},
+		{
+			testname:             "Namespace",
+			trackingResourceType: "namespace",
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testname, func(t *testing.T) {
+			client := &ClientImpl{
+				dynamicClient: fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+					{Group: "couchbase.com", Version: "v2", Resource: "couchbaseclusters"}: "CouchbaseClusterList",
+					{Version: "v1", Resource: "namespaces"}:                                "NamespaceList",
+				}),
+				config: NewConfigBuilder().FromEnvironment().WithTrackingResource(testcase.trackingResourceType).Build(),
+			}
+
+			trackingResourceInstance, err := client.GetTrackingResourceInstance("missing", "default-namespace")
+			if !errors.Is(err, ErrTrackingResourceNotFound) {
+				t.Fatalf("Expected error to be %v, got %v", ErrTrackingResourceNotFound, err)
+			}
+
+			if !k8serrors.IsNotFound(err) {
+				t.Fatalf("Expected error to wrap a NotFound API error, got %v", err)
+			}
+
+			if trackingResourceInstance != nil {
+				t.Fatalf("Expected tracking resource to be nil, got %v", trackingResourceInstance)
+			}
+		})
+	}
+}
+
+func TestGetTrackingResourceInstanceNotFoundRetry(t *testing.T) {
+	testcases := []struct {
+		testname       string
+		notFoundGets   int
+		retries        int
+		expectNotFound bool
+		expectedGets   int
+	}{
+		{
+			testname:     "Found without retrying",
+			retries:      2,
+			expectedGets: 1,
+		},
+		{
+			testname:     "Found after retrying",
+			notFoundGets: 2,
+			retries:      2,
+			expectedGets: 3,
+		},
+		{
+			testname:       "Not found once retries are exhausted",
+			notFoundGets:   3,
+			retries:        2,
+			expectNotFound: true,
+			expectedGets:   3,
+		},
+		{
+			testname:       "Not found without retries",
+			notFoundGets:   1,
+			expectNotFound: true,
+			expectedGets:   1,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testname, func(t *testing.T) {
+			unstructuredStub, err := runtime.DefaultUnstructuredConverter.ToUnstructured(couchbaseClusterStub("test-cluster", "default-namespace", true, nil))
+			if err != nil {
+				t.Fatalf("Failed to convert resource to unstructured: %v", err)
+			}
+
+			// The cluster is missing for the first gets, as it would be while the operator recreates it
+			gets := 0
+			dynamicClient := fake.NewSimpleDynamicClient(runtime.NewScheme(), &unstructured.Unstructured{Object: unstructuredStub})
+			dynamicClient.PrependReactor("get", "couchbaseclusters", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				gets++
+				if gets <= testcase.notFoundGets {
+					return true, nil, k8serrors.NewNotFound(schema.GroupResource{Group: "couchbase.com", Resource: "couchbaseclusters"}, "test-cluster")
+				}
+				return false, nil, nil
+			})
+
+			client := &ClientImpl{
+				dynamicClient: dynamicClient,
+				config:        NewConfigBuilder().FromEnvironment().WithTrackingNotFoundRetry(testcase.retries, time.Millisecond).Build(),
+			}
+
+			trackingResourceInstance, err := client.GetTrackingResourceInstance("test-cluster", "default-namespace")
+			if testcase.expectNotFound != errors.Is(err, ErrTrackingResourceNotFound) {
+				t.Fatalf("Expected not found=%t, got error %v", testcase.expectNotFound, err)
+			}
+
+			if !testcase.expectNotFound && (err != nil || trackingResourceInstance.GetName() != "test-cluster") {
+				t.Fatalf("Expected to get test-cluster, got %v with error %v", trackingResourceInstance, err)
+			}
+
+			if gets != testcase.expectedGets {
+				t.Fatalf("Expected %d gets, got %d", testcase.expectedGets, gets)
+			}
+		})
+	}
+}
+func TestResolveTrackingInstance(t *testing.T) {
+	testcases := []struct {
+		testname             string
+		trackingResourceType string
+		podLabels            map[string]string
+		getError             error
+		expectedName         string
+		expectedError        error
+	}{
+		{
+			testname:             "CouchbaseCluster",
+			trackingResourceType: "couchbasecluster",
+			podLabels:            map[string]string{"couchbase_cluster": "test-cluster"},
+			expectedName:         "test-cluster",
+		},
+		{
+			testname:             "Namespace",
+			trackingResourceType: "namespace",
+			expectedName:         "default-namespace",
+		},
+		{
+			testname:             "Missing cluster label",
+			trackingResourceType: "couchbasecluster",
+			expectedError:        ErrNoTrackingInstanceName,
+		},
+		{
+			testname:             "CouchbaseCluster not found",
+			trackingResourceType: "couchbasecluster",
+			podLabels:            map[string]string{"couchbase_cluster": "missing-cluster"},
+			expectedError:        ErrTrackingResourceNotFound,
+		},
+		{
+			testname:             "Other error",
+			trackingResourceType: "couchbasecluster",
+			podLabels:            map[string]string{"couchbase_cluster": "test-cluster"},
+			getError:             k8serrors.NewForbidden(schema.GroupResource{Group: "couchbase.com", Resource: "couchbaseclusters"}, "test-cluster", errors.New("forbidden")),
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testname, func(t *testing.T) {
+			dynamicClient := fake.NewSimpleDynamicClient(runtime.NewScheme(),
+				couchbaseClusterStub("test-cluster", "default-namespace", true, nil),
+				namespaceStub("default-namespace", nil),
+			)
+
+			if testcase.getError != nil {
+				dynamicClient.PrependReactor("get", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+					return true, nil, testcase.getError
+				})
+			}
+
+			client := &ClientImpl{
+				dynamicClient: dynamicClient,
+				config:        NewConfigBuilder().FromEnvironment().WithTrackingResource(testcase.trackingResourceType).Build(),
+			}
+
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test-pod", Namespace: "default-namespace", Labels: testcase.podLabels}}
+
+			trackingResourceInstance, err := client.ResolveTrackingInstance(pod)
+			if testcase.getError != nil {
+				if errors.Is(err, ErrNoTrackingInstanceName) || errors.Is(err, ErrTrackingResourceNotFound) || !k8serrors.IsForbidden(err) {
+					t.Fatalf("Expected error to be %v, got %v", testcase.getError, err)
+				}
+				return
+			}
+
+			if testcase.expectedError != nil {
+				if !errors.Is(err, testcase.expectedError) {
+					t.Fatalf("Expected error to be %v, got %v", testcase.expectedError, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Failed to resolve tracking resource: %v", err)
+			}
+
+			if trackingResourceInstance.GetName() != testcase.expectedName {
+				t.Fatalf("Expected tracking resource to be %s, got %s", testcase.expectedName, trackingResourceInstance.GetName())
+			}
+		})
+	}
+}
+
+func TestResolveTrackingInstanceFromOwner(t *testing.T) {
+	statefulSet := &unstructured.Unstructured{}
+	statefulSet.SetAPIVersion("apps/v1")
+	statefulSet.SetKind("StatefulSet")
+	statefulSet.SetName("test-statefulset")
+	statefulSet.SetNamespace("default-namespace")
+	statefulSet.SetLabels(map[string]string{"couchbase_cluster": "test-cluster"})
+
+	controller := true
+	ownerReferences := []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "StatefulSet", Name: "test-statefulset", Controller: &controller}}
+
+	testcases := []struct {
+		testname        string
+		from            InstanceNameFrom
+		podLabels       map[string]string
+		ownerReferences []metav1.OwnerReference
+		expectedError   error
+		expectOwnerGet  bool
+	}{
+		{
+			testname:        "Pod label",
+			from:            InstanceNameFromPod,
+			podLabels:       map[string]string{"couchbase_cluster": "test-cluster"},
+			ownerReferences: ownerReferences,
+		},
+		{
+			testname:        "Pod label only on owner",
+			from:            InstanceNameFromPod,
+			ownerReferences: ownerReferences,
+			expectedError:   ErrNoTrackingInstanceName,
+		},
+		{
+			testname:        "Pod label preferred to owner label",
+			from:            InstanceNameFromOwner,
+			podLabels:       map[string]string{"couchbase_cluster": "test-cluster"},
+			ownerReferences: ownerReferences,
+		},
+		{
+			testname:        "Owner label",
+			from:            InstanceNameFromOwner,
+			ownerReferences: ownerReferences,
+			expectOwnerGet:  true,
+		},
+		{
+			testname:      "Owner label without owner",
+			from:          InstanceNameFromOwner,
+			expectedError: ErrNoTrackingInstanceName,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testname, func(t *testing.T) {
+			dynamicClient := fake.NewSimpleDynamicClient(runtime.NewScheme(),
+				couchbaseClusterStub("test-cluster", "default-namespace", true, nil),
+				statefulSet,
+			)
+
+			resources := []*metav1.APIResourceList{{
+				GroupVersion: "apps/v1",
+				APIResources: []metav1.APIResource{
+					{Name: "statefulsets/status", Kind: "StatefulSet"},
+					{Name: "statefulsets", Kind: "StatefulSet"},
+				},
+			}}
+
+			client := &ClientImpl{
+				dynamicClient:   dynamicClient,
+				discoveryClient: &fakediscovery.FakeDiscovery{Fake: &k8stesting.Fake{Resources: resources}},
+				config:          NewConfigBuilder().FromEnvironment().WithInstanceNameFrom(testcase.from).Build(),
+			}
+
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+				Name:            "test-pod",
+				Namespace:       "default-namespace",
+				Labels:          testcase.podLabels,
+				OwnerReferences: testcase.ownerReferences,
+			}}
+
+			trackingResourceInstance, err := client.ResolveTrackingInstance(pod)
+			if testcase.expectedError != nil {
+				if !errors.Is(err, testcase.expectedError) {
+					t.Fatalf("Expected error to be %v, got %v", testcase.expectedError, err)
+				}
+			} else if err != nil {
+				t.Fatalf("Failed to resolve tracking resource: %v", err)
+			} else if trackingResourceInstance.GetName() != "test-cluster" {
+				t.Fatalf("Expected tracking resource to be test-cluster, got %s", trackingResourceInstance.GetName())
+			}
+
+			// The owner is only fetched when its labels are needed
+			ownerGet := slices.ContainsFunc(dynamicClient.Actions(), func(action k8stesting.Action) bool {
+				return action.GetVerb() == "get" && action.GetResource().Resource == "statefulsets"
+			})
+			if ownerGet != testcase.expectOwnerGet {
+				t.Fatalf("Expected owner to be fetched %t, got %t", testcase.expectOwnerGet, ownerGet)
+			}
+		})
+	}
+}
+
+func TestListPodsByTrackingInstance(t *testing.T) {
+	podObject := func(name, namespace, clusterName string) runtime.Object {
+		pod := clusterPodStub(name, clusterName)
+		pod.Namespace = namespace
+		pod.TypeMeta = metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"}
+		object, err := runtime.DefaultUnstructuredConverter.ToUnstructured(pod)
+		if err != nil {
+			t.Fatalf("Failed to convert pod: %v", err)
+		}
+		return &unstructured.Unstructured{Object: object}
+	}
+
+	testcases := []struct {
+		testname             string
+		trackingResourceType string
+		instance             string
+		expectedPods         []string
+		expectedError        error
+	}{
+		{
+			testname:             "CouchbaseCluster",
+			trackingResourceType: "couchbasecluster",
+			instance:             "cluster1",
+			expectedPods:         []string{"pod1", "pod2"},
+		},
+		{
+			testname:             "Namespace",
+			trackingResourceType: "namespace",
+			instance:             "default-namespace",
+			expectedPods:         []string{"pod1", "pod2", "pod3"},
+		},
+		{
+			testname:             "Missing instance name",
+			trackingResourceType: "couchbasecluster",
+			expectedError:        ErrNoTrackingInstanceName,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testname, func(t *testing.T) {
+			client := &ClientImpl{
+				dynamicClient: fake.NewSimpleDynamicClient(runtime.NewScheme(),
+					podObject("pod1", "default-namespace", "cluster1"),
+					podObject("pod2", "default-namespace", "cluster1"),
+					podObject("pod3", "default-namespace", "cluster2"),
+					podObject("pod4", "other-namespace", "cluster1"),
+				),
+				config: NewConfigBuilder().FromEnvironment().WithTrackingResource(testcase.trackingResourceType).Build(),
+			}
+
+			pods, err := client.ListPodsByTrackingInstance(testcase.instance, "default-namespace")
+			if testcase.expectedError != nil {
+				if !errors.Is(err, testcase.expectedError) {
+					t.Fatalf("Expected error to be %v, got %v", testcase.expectedError, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Failed to list pods: %v", err)
+			}
+
+			names := make([]string, 0, len(pods))
+			for _, pod := range pods {
+				names = append(names, pod.Name)
+			}
+			slices.Sort(names)
+
+			if !slices.Equal(names, testcase.expectedPods) {
+				t.Fatalf("Expected pods %v, got %v", testcase.expectedPods, names)
+			}
+		})
+	}
+}
+
+func TestListPodsByTrackingInstanceFromOwner(t *testing.T) {
+	statefulSet := &unstructured.Unstructured{}
+	statefulSet.SetAPIVersion("apps/v1")
+	statefulSet.SetKind("StatefulSet")
+	statefulSet.SetName("test-statefulset")
+	statefulSet.SetNamespace("default-namespace")
+	statefulSet.SetLabels(map[string]string{"couchbase_cluster": "cluster1"})
+
+	controller := true
+	podObject := func(name, clusterName, owner string) (*corev1.Pod, runtime.Object) {
+		pod := trackedPodStub(name, "node1", types.UID(name))
+		pod.Namespace = "default-namespace"
+		pod.TypeMeta = metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"}
+		if clusterName != "" {
+			pod.Labels["couchbase_cluster"] = clusterName
+		}
+		if owner != "" {
+			pod.OwnerReferences = []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "StatefulSet", Name: owner, Controller: &controller}}
+		}
+
+		object, err := runtime.DefaultUnstructuredConverter.ToUnstructured(pod)
+		if err != nil {
+			t.Fatalf("Failed to convert pod: %v", err)
+		}
+		return pod, &unstructured.Unstructured{Object: object}
+	}
+
+	// pod1 and pod2 only belong to cluster1 through their controller, and the controller of pod5 does not exist
+	pod1, pod1Object := podObject("pod1", "", "test-statefulset")
+	_, pod2Object := podObject("pod2", "", "test-statefulset")
+	_, pod3Object := podObject("pod3", "cluster1", "")
+	_, pod4Object := podObject("pod4", "cluster2", "")
+	_, pod5Object := podObject("pod5", "", "missing-statefulset")
+
+	dynamicClient := fake.NewSimpleDynamicClient(runtime.NewScheme(), statefulSet, pod1Object, pod2Object, pod3Object, pod4Object, pod5Object)
+	resources := []*metav1.APIResourceList{{
+		GroupVersion: "apps/v1",
+		APIResources: []metav1.APIResource{{Name: "statefulsets", Kind: "StatefulSet"}},
+	}}
+
+	config := NewConfigBuilder().FromEnvironment().WithInstanceNameFrom(InstanceNameFromOwner).Build()
+	client := &ClientImpl{
+		dynamicClient:   dynamicClient,
+		discoveryClient: &fakediscovery.FakeDiscovery{Fake: &k8stesting.Fake{Resources: resources}},
+		config:          config,
+	}
+
+	// The peers of a pod without the label are found through its controller
+	peers, err := trackingInstancePeers(client, config, pod1)
+	if err != nil {
+		t.Fatalf("Failed to list peers: %v", err)
+	}
+
+	names := make([]string, 0, len(peers))
+	for _, peer := range peers {
+		names = append(names, peer.Name)
+	}
+	slices.Sort(names)
+
+	if expected := []string{"pod1", "pod2", "pod3"}; !slices.Equal(names, expected) {
+		t.Fatalf("Expected peers %v, got %v", expected, names)
+	}
+
+	// The pod's controller is fetched to derive its instance, then each controller is only fetched once while matching pods
+	var ownerGets int
+	for _, action := range dynamicClient.Actions() {
+		if action.GetVerb() == "get" && action.GetResource().Resource == "statefulsets" {
+			ownerGets++
+		}
+	}
+	if ownerGets != 3 {
+		t.Fatalf("Expected 3 gets of controllers, got %d", ownerGets)
+	}
+}
+
+func TestGetEvictionSubresourceSupport(t *testing.T) {
+	testcases := []struct {
+		testname         string
+		groupVersions    []string
+		expectedVersions []string
+		expectedError    error
+	}{
+		{
+			testname:         "v1",
+			groupVersions:    []string{"v1", "policy/v1"},
+			expectedVersions: []string{EvictionVersionV1},
+		},
+		{
+			testname:         "v1beta1",
+			groupVersions:    []string{"v1", "policy/v1beta1"},
+			expectedVersions: []string{EvictionVersionV1beta1},
+		},
+		{
+			testname:         "v1 and v1beta1",
+			groupVersions:    []string{"v1", "policy/v1beta1", "policy/v1"},
+			expectedVersions: []string{EvictionVersionV1, EvictionVersionV1beta1},
+		},
+		{
+			testname:      "Not served",
+			groupVersions: []string{"v1"},
+			expectedError: ErrEvictionNotSupported,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testname, func(t *testing.T) {
+			var resources []*metav1.APIResourceList
+			for _, groupVersion := range testcase.groupVersions {
+				resources = append(resources, &metav1.APIResourceList{GroupVersion: groupVersion})
+			}
+
+			client := &ClientImpl{
+				discoveryClient: &fakediscovery.FakeDiscovery{Fake: &k8stesting.Fake{Resources: resources}},
+				config:          NewConfigBuilder().FromEnvironment().Build(),
+			}
+
+			versions, err := client.GetEvictionSubresourceSupport()
+			if testcase.expectedError != nil {
+				if !errors.Is(err, testcase.expectedError) {
+					t.Fatalf("Expected error to be %v, got %v", testcase.expectedError, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Failed to get eviction subresource support: %v", err)
+			}
+
+			if !reflect.DeepEqual(versions, testcase.expectedVersions) {
+				t.Fatalf("Expected versions to be %v, got %v", testcase.expectedVersions, versions)
+			}
+		})
+	}
+}
+
+func TestIsTrackingResourceServed(t *testing.T) {
+	testcases := []struct {
+		testname             string
+		trackingResourceType string
+		couchbaseAPIVersion  string
+		resources            []*metav1.APIResourceList
+		expected             bool
+	}{
+		{
+			testname:             "CouchbaseCluster CRD installed",
+			trackingResourceType: "couchbasecluster",
+			resources: []*metav1.APIResourceList{
+				{GroupVersion: "couchbase.com/v2", APIResources: []metav1.APIResource{{Name: "couchbasebuckets"}, {Name: "couchbaseclusters"}}},
+			},
+			expected: true,
+		},
+		{
+			testname:             "CouchbaseCluster CRD not installed",
+			trackingResourceType: "couchbasecluster",
+			resources: []*metav1.APIResourceList{
+				{GroupVersion: "v1", APIResources: []metav1.APIResource{{Name: "namespaces"}}},
+			},
+			expected: false,
+		},
+		{
+			testname:             "CouchbaseCluster CRD installed without cluster resource",
+			trackingResourceType: "couchbasecluster",
+			resources: []*metav1.APIResourceList{
+				{GroupVersion: "couchbase.com/v2", APIResources: []metav1.APIResource{{Name: "couchbasebuckets"}}},
+			},
+			expected: false,
+		},
+		{
+			testname:             "CouchbaseCluster CRD installed with a different version",
+			trackingResourceType: "couchbasecluster",
+			couchbaseAPIVersion:  "v3",
+			resources: []*metav1.APIResourceList{
+				{GroupVersion: "couchbase.com/v2", APIResources: []metav1.APIResource{{Name: "couchbaseclusters"}}},
+			},
+			expected: false,
+		},
+		{
+			testname:             "Namespace",
+			trackingResourceType: "namespace",
+			resources: []*metav1.APIResourceList{
+				{GroupVersion: "v1", APIResources: []metav1.APIResource{{Name: "namespaces"}}},
+			},
+			expected: true,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testname, func(t *testing.T) {
+			builder := NewConfigBuilder().FromEnvironment().WithTrackingResource(testcase.trackingResourceType)
+			if testcase.couchbaseAPIVersion != "" {
+				builder = builder.WithCouchbaseAPIVersion(testcase.couchbaseAPIVersion)
+			}
+
+			client := &ClientImpl{
+				discoveryClient: &fakediscovery.FakeDiscovery{Fake: &k8stesting.Fake{Resources: testcase.resources}},
+				config:          builder.Build(),
+			}
+
+			served, err := client.IsTrackingResourceServed()
+			if err != nil {
+				t.Fatalf("Failed to check tracking resource is served: %v", err)
+			}
+
+			if served != testcase.expected {
+				t.Fatalf("Expected tracking resource served=%t, got %t", testcase.expected, served)
+			}
+		})
+	}
+}
+
+func TestReschedulePod(t *testing.T) {
+	stub := &corev1.Pod{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Pod",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-pod",
+			Namespace: "default-namespace",
+		},
+	}
+
+	unstructuredStub, err := runtime.DefaultUnstructuredConverter.ToUnstructured(stub)
+	if err != nil {
+		t.Fatalf("Failed to convert pod to unstructured: %v", err)
+	}
+
+	client := &ClientImpl{
+		dynamicClient: fake.NewSimpleDynamicClient(runtime.NewScheme(), &unstructured.Unstructured{Object: unstructuredStub}),
+		config:        NewConfigBuilder().FromEnvironment().Build(),
+	}
+
+	err = client.ReschedulePod(stub)
+	if err != nil {
+		t.Fatalf("Failed to reschedule pod: %v", err)
+	}
+
+	updatedPod, err := client.GetPod("test-pod", "default-namespace")
+	if err != nil {
+		t.Fatalf("Failed to get pod: %v", err)
+	}
+
+	// Check that the updated pod has the reschedule annotation
+	if updatedPod.Annotations[client.GetConfig().rescheduleAnnotationKey] != client.GetConfig().rescheduleAnnotationValue {
+		t.Fatalf("Expected pod to have reschedule annotation, got %v", updatedPod.Annotations)
+	}
+}
+
+func TestReschedulePodDeletedAfterGet(t *testing.T) {
+	stub := trackedPodStub("test-pod", "node1", "uid1")
+	stub.TypeMeta = metav1.TypeMeta{Kind: "Pod", APIVersion: "v1"}
+
+	unstructuredStub, err := runtime.DefaultUnstructuredConverter.ToUnstructured(stub)
+	if err != nil {
+		t.Fatalf("Failed to convert pod to unstructured: %v", err)
+	}
+
+	// The pod can still be fetched, but is deleted before the reschedule annotation is patched onto it
+	dynamicClient := fake.NewSimpleDynamicClient(runtime.NewScheme(), &unstructured.Unstructured{Object: unstructuredStub})
+	dynamicClient.PrependReactor("patch", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, k8serrors.NewNotFound(schema.GroupResource{Resource: "pods"}, "test-pod")
+	})
+
+	client := &ClientImpl{
+		dynamicClient: dynamicClient,
+		config:        NewConfigBuilder().FromEnvironment().WithTrackRescheduledPods(false).Build(),
+	}
+
+	if err := client.ReschedulePod(stub); !k8serrors.IsNotFound(err) {
+		t.Fatalf("Expected a NotFound error, got %v", err)
+	}
+
+	eviction := policyv1.Eviction{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-pod",
+			Namespace: "default",
+		},
+	}
+
+	expected := denyEviction(http.StatusNotFound, metav1.StatusReasonNotFound, PodNoLongerExistsMsg)
+	if result := handleEviction(context.Background(), eviction, client, client.config, CreateLogger(eviction.Name, eviction.Namespace, false)); !reflect.DeepEqual(result, expected) {
+		t.Fatalf("Expected response to be %v, got %v", expected, result)
+	}
+}
+
+func TestReschedulePodVerifyAnnotation(t *testing.T) {
+	testcases := []struct {
+		testname    string
+		dropPatch   bool
+		expectError bool
+	}{
+		{
+			testname: "Annotation persisted",
+		},
+		{
+			testname:    "Patch accepted but not persisted",
+			dropPatch:   true,
+			expectError: true,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testname, func(t *testing.T) {
+			stub := &corev1.Pod{
+				TypeMeta: metav1.TypeMeta{
+					Kind:       "Pod",
+					APIVersion: "v1",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-pod",
+					Namespace: "default-namespace",
+				},
+			}
+
+			unstructuredStub, err := runtime.DefaultUnstructuredConverter.ToUnstructured(stub)
+			if err != nil {
+				t.Fatalf("Failed to convert pod to unstructured: %v", err)
+			}
+
+			dynamicClient := fake.NewSimpleDynamicClient(runtime.NewScheme(), &unstructured.Unstructured{Object: unstructuredStub})
+			if testcase.dropPatch {
+				// Simulate a patch that succeeds without changing the stored pod
+				dynamicClient.PrependReactor("patch", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+					return true, &unstructured.Unstructured{Object: unstructuredStub}, nil
+				})
+			}
+
+			client := &ClientImpl{
+				dynamicClient: dynamicClient,
+				config:        NewConfigBuilder().FromEnvironment().WithVerifyAnnotation(true).Build(),
+			}
+
+			err = client.ReschedulePod(stub)
+			if testcase.expectError && !errors.Is(err, ErrAnnotationNotPersisted) {
+				t.Fatalf("Expected error to wrap ErrAnnotationNotPersisted, got %v", err)
+			}
+
+			if !testcase.expectError && err != nil {
+				t.Fatalf("Failed to reschedule pod: %v", err)
+			}
+
+			var gets int
+			for _, action := range dynamicClient.Actions() {
+				if action.GetVerb() == "get" {
+					gets++
+				}
+			}
+
+			if gets != 1 {
+				t.Fatalf("Expected the pod to be fetched once to verify the annotation, got %d", gets)
+			}
+		})
+	}
+}
+
+func TestReschedulePodMultipleAnnotations(t *testing.T) {
+	stub := &corev1.Pod{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Pod",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-pod",
+			Namespace: "default-namespace",
+		},
+	}
+
+	unstructuredStub, err := runtime.DefaultUnstructuredConverter.ToUnstructured(stub)
+	if err != nil {
+		t.Fatalf("Failed to convert pod to unstructured: %v", err)
+	}
+
+	annotations := map[string]string{
+		"cao.couchbase.com/reschedule":        "true",
+		"cao.couchbase.com/reschedule-reason": "eviction",
+	}
+
+	client := &ClientImpl{
+		dynamicClient: fake.NewSimpleDynamicClient(runtime.NewScheme(), &unstructured.Unstructured{Object: unstructuredStub}),
+		config:        NewConfigBuilder().FromEnvironment().WithRescheduleAnnotations(annotations).Build(),
+	}
+
+	if err := client.ReschedulePod(stub); err != nil {
+		t.Fatalf("Failed to reschedule pod: %v", err)
+	}
+
+	updatedPod, err := client.GetPod("test-pod", "default-namespace")
+	if err != nil {
+		t.Fatalf("Failed to get pod: %v", err)
+	}
+
+	if !reflect.DeepEqual(updatedPod.Annotations, annotations) {
+		t.Fatalf("Expected pod annotations to be %v, got %v", annotations, updatedPod.Annotations)
+	}
+}
+
+func TestReschedulePodRecordFromNode(t *testing.T) {
+	testcases := []struct {
+		testname            string
+		nodeName            string
+		recordFromNode      bool
+		expectedAnnotations map[string]string
+	}{
+		{
+			testname:            "Node recorded",
+			nodeName:            "node1",
+			recordFromNode:      true,
+			expectedAnnotations: map[string]string{DefaultRescheduleAnnotationKey: "true", DefaultFromNodeAnnotation: "node1"},
+		},
+		{
+			testname:            "Unscheduled pod",
+			recordFromNode:      true,
+			expectedAnnotations: map[string]string{DefaultRescheduleAnnotationKey: "true"},
+		},
+		{
+			testname:            "Disabled",
+			nodeName:            "node1",
+			expectedAnnotations: map[string]string{DefaultRescheduleAnnotationKey: "true"},
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testname, func(t *testing.T) {
+			stub := trackedPodStub("test-pod", testcase.nodeName, "uid1")
+			stub.TypeMeta = metav1.TypeMeta{Kind: "Pod", APIVersion: "v1"}
+
+			unstructuredStub, err := runtime.DefaultUnstructuredConverter.ToUnstructured(stub)
+			if err != nil {
+				t.Fatalf("Failed to convert pod to unstructured: %v", err)
+			}
+
+			client := &ClientImpl{
+				dynamicClient: fake.NewSimpleDynamicClient(runtime.NewScheme(), &unstructured.Unstructured{Object: unstructuredStub}),
+				config:        NewConfigBuilder().WithRecordFromNode(testcase.recordFromNode).Build(),
+			}
+
+			if err := client.ReschedulePod(stub); err != nil {
+				t.Fatalf("Failed to reschedule pod: %v", err)
+			}
+
+			updatedPod, err := client.GetPod("test-pod", "default")
+			if err != nil {
+				t.Fatalf("Failed to get pod: %v", err)
+			}
+
+			if !reflect.DeepEqual(updatedPod.Annotations, testcase.expectedAnnotations) {
+				t.Fatalf("Expected pod annotations to be %v, got %v", testcase.expectedAnnotations, updatedPod.Annotations)
+			}
+		})
+	}
+}
+
+func TestReschedulePodMarkerType(t *testing.T) {
+	testcases := []struct {
+		testname            string
+		markerType          RescheduleMarkerType
+		expectedLabels      map[string]string
+		expectedAnnotations map[string]string
+	}{
+		{
+			testname:            "Annotation",
+			markerType:          RescheduleMarkerAnnotation,
+			expectedLabels:      map[string]string{DefaultPodLabelSelectorKey: DefaultPodLabelSelectorValue},
+			expectedAnnotations: map[string]string{DefaultRescheduleAnnotationKey: "true"},
+		},
+		{
+			testname:       "Label",
+			markerType:     RescheduleMarkerLabel,
+			expectedLabels: map[string]string{DefaultPodLabelSelectorKey: DefaultPodLabelSelectorValue, DefaultRescheduleAnnotationKey: "true"},
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testname, func(t *testing.T) {
+			stub := trackedPodStub("test-pod", "node1", "uid1")
+			stub.TypeMeta = metav1.TypeMeta{Kind: "Pod", APIVersion: "v1"}
+
+			unstructuredStub, err := runtime.DefaultUnstructuredConverter.ToUnstructured(stub)
+			if err != nil {
+				t.Fatalf("Failed to convert pod to unstructured: %v", err)
+			}
+
+			client := &ClientImpl{
+				dynamicClient: fake.NewSimpleDynamicClient(runtime.NewScheme(), &unstructured.Unstructured{Object: unstructuredStub}),
+				config:        NewConfigBuilder().WithRescheduleMarker(testcase.markerType).WithVerifyAnnotation(true).Build(),
+			}
+
+			if err := client.ReschedulePod(stub); err != nil {
+				t.Fatalf("Failed to reschedule pod: %v", err)
+			}
+
+			updatedPod, err := client.GetPod("test-pod", "default")
+			if err != nil {
+				t.Fatalf("Failed to get pod: %v", err)
+			}
+
+			if !maps.Equal(updatedPod.Labels, testcase.expectedLabels) {
+				t.Fatalf("Expected pod labels to be %v, got %v", testcase.expectedLabels, updatedPod.Labels)
+			}
+
+			if !maps.Equal(updatedPod.Annotations, testcase.expectedAnnotations) {
+				t.Fatalf("Expected pod annotations to be %v, got %v", testcase.expectedAnnotations, updatedPod.Annotations)
+			}
+		})
+	}
+}
+
+func TestReschedulePodAlreadyMarked(t *testing.T) {
+	testcases := []struct {
+		testname        string
+		annotations     map[string]string
+		expectedPatches int
+	}{
+		{
+			testname:        "Already marked",
+			annotations:     map[string]string{DefaultRescheduleAnnotationKey: "true"},
+			expectedPatches: 0,
+		},
+		{
+			testname:        "Marked with a different value",
+			annotations:     map[string]string{DefaultRescheduleAnnotationKey: "false"},
+			expectedPatches: 1,
+		},
+		{
+			testname:        "Not marked",
+			expectedPatches: 1,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testname, func(t *testing.T) {
+			stub := trackedPodStub("test-pod", "node1", "uid1")
+			stub.TypeMeta = metav1.TypeMeta{Kind: "Pod", APIVersion: "v1"}
+			stub.Annotations = testcase.annotations
+
+			unstructuredStub, err := runtime.DefaultUnstructuredConverter.ToUnstructured(stub)
+			if err != nil {
+				t.Fatalf("Failed to convert pod to unstructured: %v", err)
+			}
+
+			dynamicClient := fake.NewSimpleDynamicClient(runtime.NewScheme(), &unstructured.Unstructured{Object: unstructuredStub})
+			client := &ClientImpl{
+				dynamicClient: dynamicClient,
+				config:        NewConfigBuilder().Build(),
+			}
+
+			if err := client.ReschedulePod(stub); err != nil {
+				t.Fatalf("Failed to reschedule pod: %v", err)
+			}
+
+			patches := 0
+			for _, action := range dynamicClient.Actions() {
+				if action.GetVerb() == "patch" {
+					patches++
+				}
+			}
+
+			if patches != testcase.expectedPatches {
+				t.Fatalf("Expected %d patches, got %d", testcase.expectedPatches, patches)
+			}
+		})
+	}
+}
+
+func TestConfigureRestConfig(t *testing.T) {
+	testcases := []struct {
+		testname      string
+		config        *Config
+		expectedQPS   float32
+		expectedBurst int
+	}{
+		{
+			testname:      "Defaults",
+			config:        NewConfigBuilder().Build(),
+			expectedQPS:   DefaultClientQPS,
+			expectedBurst: DefaultClientBurst,
+		},
+		{
+			testname:      "Configured rate limit",
+			config:        NewConfigBuilder().WithClientRateLimit(50, 100).Build(),
+			expectedQPS:   50,
+			expectedBurst: 100,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testname, func(t *testing.T) {
+			kubeConfig := configureRestConfig(&rest.Config{Host: "https://kubernetes.default.svc"}, testcase.config)
+
+			if kubeConfig.QPS != testcase.expectedQPS || kubeConfig.Burst != testcase.expectedBurst {
+				t.Fatalf("Expected QPS %v and burst %d, got QPS %v and burst %d", testcase.expectedQPS, testcase.expectedBurst, kubeConfig.QPS, kubeConfig.Burst)
+			}
+
+			if kubeConfig.Host != "https://kubernetes.default.svc" {
+				t.Fatalf("Expected the rest of the config to be left as it is, got host %q", kubeConfig.Host)
+			}
+		})
+	}
+}
+
+func TestClientClose(t *testing.T) {
+	var mu sync.Mutex
+	open := 0
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"apiVersion":"v1","kind":"Pod","metadata":{"name":"test-pod","namespace":"default"}}`))
+	}))
+	server.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch state {
+		case http.StateNew:
+			open++
+		case http.StateClosed, http.StateHijacked:
+			open--
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	openConnections := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return open
+	}
+
+	client, err := newClientForConfig(&rest.Config{Host: server.URL}, NewConfigBuilder().Build())
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	if _, err := client.GetPod("test-pod", "default"); err != nil {
+		t.Fatalf("Failed to get pod: %v", err)
+	}
+
+	// The connection is kept alive for reuse until the client is closed
+	if openConnections() != 1 {
+		t.Fatalf("Expected 1 open connection before closing the client, got %d", openConnections())
+	}
+
+	client.Close()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for openConnections() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the connection to be closed, %d still open", openConnections())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// A client without an HTTP client, such as one using a fake dynamic client, can also be closed
+	(&ClientImpl{}).Close()
+}
+
+func TestClientWithContext(t *testing.T) {
+	// The API server only responds once the test has finished
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test-pod", Namespace: "default"}}
+	testcases := []struct {
+		testname          string
+		useTypedPodClient bool
+		request           func(client Client) error
+	}{
+		{
+			testname: "Get pod with the dynamic client",
+			request: func(client Client) error {
+				_, err := client.GetPod(pod.Name, pod.Namespace)
+				return err
+			},
+		},
+		{
+			testname:          "Get pod with the clientset",
+			useTypedPodClient: true,
+			request: func(client Client) error {
+				_, err := client.GetPod(pod.Name, pod.Namespace)
+				return err
+			},
+		},
+		{
+			testname: "Patch pod with the dynamic client",
+			request: func(client Client) error {
+				return client.ReschedulePod(pod)
+			},
+		},
+		{
+			testname:          "Patch pod with the clientset",
+			useTypedPodClient: true,
+			request: func(client Client) error {
+				return client.ReschedulePod(pod)
+			},
+		},
+		{
+			testname: "Patch tracking resource instance",
+			request: func(client Client) error {
+				return client.RemoveRescheduleHookTrackingAnnotation(pod, "test-cluster")
+			},
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testname, func(t *testing.T) {
+			config := NewConfigBuilder().WithTypedPodClient(testcase.useTypedPodClient).Build()
+			client, err := newClientForConfig(&rest.Config{Host: server.URL}, config)
+			if err != nil {
+				t.Fatalf("Failed to create client: %v", err)
+			}
+			defer client.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+
+			start := time.Now()
+			err = testcase.request(client.WithContext(ctx))
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf("Expected the request to stop at the deadline, got %v", err)
+			}
+
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Fatalf("Expected the request to stop at the deadline, took %s", elapsed)
+			}
+		})
+	}
+}
+
+func TestGetTrackingResourceInstanceNotFoundContext(t *testing.T) {
+	client := &ClientImpl{
+		dynamicClient: fake.NewSimpleDynamicClient(runtime.NewScheme()),
+		config:        NewConfigBuilder().WithTrackingResource("couchbasecluster").WithTrackingNotFoundRetry(3, time.Minute).Build(),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	// The retries would wait for minutes, but stop once the context is done
+	start := time.Now()
+	_, err := client.WithContext(ctx).GetTrackingResourceInstance("test-cluster", "default")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected retrying to stop at the deadline, got %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("Expected retrying to stop at the deadline, took %s", elapsed)
+	}
+}
+
+func TestRetryUnauthorized(t *testing.T) {
+	testcases := []struct {
+		testname          string
+		useTypedPodClient bool
+		unauthorized      int
+		expectUnauthorize bool
+		expectedRequests  int
+	}{
+		{
+			testname:         "Authorized",
+			expectedRequests: 1,
+		},
+		{
+			testname:         "Unauthorized then authorized after the token is re-read",
+			unauthorized:     1,
+			expectedRequests: 2,
+		},
+		{
+			testname:          "Still unauthorized after the token is re-read",
+			unauthorized:      2,
+			expectUnauthorize: true,
+			expectedRequests:  2,
+		},
+		{
+			testname:          "Typed pod client authorized",
+			useTypedPodClient: true,
+			expectedRequests:  1,
+		},
+		{
+			testname:          "Typed pod client unauthorized then authorized after the token is re-read",
+			useTypedPodClient: true,
+			unauthorized:      1,
+			expectedRequests:  2,
+		},
+		{
+			testname:          "Typed pod client still unauthorized after the token is re-read",
+			useTypedPodClient: true,
+			unauthorized:      2,
+			expectUnauthorize: true,
+			expectedRequests:  2,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testname, func(t *testing.T) {
+			stub := trackedPodStub("test-pod", "node1", "uid1")
+			stub.TypeMeta = metav1.TypeMeta{Kind: "Pod", APIVersion: "v1"}
+
+			unstructuredStub, err := runtime.DefaultUnstructuredConverter.ToUnstructured(stub)
+			if err != nil {
+				t.Fatalf("Failed to convert pod to unstructured: %v", err)
+			}
+
+			// Requests are rejected until the token has been re-read enough times, as they would be while it is rotated
+			requests := map[string]int{}
+			reactor := func(action k8stesting.Action) (bool, runtime.Object, error) {
+				requests[action.GetVerb()]++
+				if requests[action.GetVerb()] <= testcase.unauthorized {
+					return true, nil, k8serrors.NewUnauthorized("token expired")
+				}
+				return false, nil, nil
+			}
+
+			dynamicClient := fake.NewSimpleDynamicClient(runtime.NewScheme(), &unstructured.Unstructured{Object: unstructuredStub})
+			clientset := fakekubernetes.NewClientset(stub.DeepCopy())
+			if testcase.useTypedPodClient {
+				clientset.PrependReactor("*", "pods", reactor)
+			} else {
+				dynamicClient.PrependReactor("*", "pods", reactor)
+			}
+
+			refreshes := 0
+			client := &ClientImpl{
+				dynamicClient: dynamicClient,
+				clientset:     clientset,
+				config:        NewConfigBuilder().WithTypedPodClient(testcase.useTypedPodClient).Build(),
+				refreshDynamicClient: func() (dynamic.Interface, error) {
+					refreshes++
+					return dynamicClient, nil
+				},
+				refreshClientset: func() (kubernetes.Interface, error) {
+					refreshes++
+					return clientset, nil
+				},
+			}
+
+			_, getErr := client.GetPod("test-pod", "default")
+			patchErr := client.ReschedulePod(stub)
+
+			for verb, err := range map[string]error{"get": getErr, "patch": patchErr} {
+				if testcase.expectUnauthorize != errors.Is(err, ErrUnauthorized) {
+					t.Fatalf("Expected %s unauthorized=%t, got error %v", verb, testcase.expectUnauthorize, err)
+				}
+
+				if !testcase.expectUnauthorize && err != nil {
+					t.Fatalf("Expected %s to succeed, got %v", verb, err)
+				}
+
+				if requests[verb] != testcase.expectedRequests {
+					t.Fatalf("Expected %d %s requests, got %d", testcase.expectedRequests, verb, requests[verb])
+				}
+			}
+
+			if expectedRefreshes := 2 * (testcase.expectedRequests - 1); refreshes != expectedRefreshes {
+				t.Fatalf("Expected the token to be re-read %d times, got %d", expectedRefreshes, refreshes)
+			}
+		})
+	}
+}
+
+func TestRetryUnauthorizedTrackingInstanceRequests(t *testing.T) {
+	pod := clusterPodStub("pod1", "cluster1")
+	pod.Namespace = "default"
+	pod.TypeMeta = metav1.TypeMeta{Kind: "Pod", APIVersion: "v1"}
+
+	unstructuredPod, err := runtime.DefaultUnstructuredConverter.ToUnstructured(pod)
+	if err != nil {
+		t.Fatalf("Failed to convert pod to unstructured: %v", err)
+	}
+
+	// The first list and dry run patch are rejected, as they would be while the token is rotated
+	requests := map[string]int{}
+	reactor := func(action k8stesting.Action) (bool, runtime.Object, error) {
+		requests[action.GetVerb()]++
+		if requests[action.GetVerb()] == 1 {
+			return true, nil, k8serrors.NewUnauthorized("token expired")
+		}
+		return false, nil, nil
+	}
+
+	dynamicClient := fake.NewSimpleDynamicClient(runtime.NewScheme(),
+		&unstructured.Unstructured{Object: unstructuredPod},
+		couchbaseClusterStub("cluster1", "default", false, nil),
+	)
+	dynamicClient.PrependReactor("list", "pods", reactor)
+	dynamicClient.PrependReactor("patch", "couchbaseclusters", reactor)
+
+	refreshes := 0
+	client := &ClientImpl{
+		dynamicClient: dynamicClient,
+		config:        NewConfigBuilder().Build(),
+		refreshDynamicClient: func() (dynamic.Interface, error) {
+			refreshes++
+			return dynamicClient, nil
+		},
+	}
+
+	pods, err := client.ListPodsByTrackingInstance("cluster1", "default")
+	if err != nil {
+		t.Fatalf("Expected list to succeed after the token is re-read, got %v", err)
+	}
+
+	if len(pods) != 1 || pods[0].Name != "pod1" {
+		t.Fatalf("Expected pod1 to be listed, got %v", pods)
+	}
+
+	if err := client.DryRunPatchTrackingResource("cluster1", "default"); err != nil {
+		t.Fatalf("Expected dry run patch to succeed after the token is re-read, got %v", err)
+	}
+
+	for _, verb := range []string{"list", "patch"} {
+		if requests[verb] != 2 {
+			t.Fatalf("Expected 2 %s requests, got %d", verb, requests[verb])
+		}
+	}
+
+	if refreshes != 2 {
+		t.Fatalf("Expected the token to be re-read 2 times, got %d", refreshes)
+	}
+}
+
+func TestTypedPodClient(t *testing.T) {
+	testcases := []struct {
+		testname          string
+		useTypedPodClient bool
+	}{
+		{
+			testname:          "Typed pod client",
+			useTypedPodClient: true,
+		},
+		{
+			testname: "Dynamic pod client",
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testname, func(t *testing.T) {
+			stub := trackedPodStub("test-pod", "node1", "uid1")
+			stub.TypeMeta = metav1.TypeMeta{Kind: "Pod", APIVersion: "v1"}
+
+			unstructuredStub, err := runtime.DefaultUnstructuredConverter.ToUnstructured(stub)
+			if err != nil {
+				t.Fatalf("Failed to convert pod to unstructured: %v", err)
+			}
+
+			// Both clients hold the pod, so only the client the pod was read and patched with has it modified
+			dynamicClient := fake.NewSimpleDynamicClient(runtime.NewScheme(), &unstructured.Unstructured{Object: unstructuredStub})
+			clientset := fakekubernetes.NewClientset(stub.DeepCopy())
+			config := NewConfigBuilder().WithTypedPodClient(testcase.useTypedPodClient).WithTrackAttempts(true).Build()
+			client := &ClientImpl{
+				dynamicClient: dynamicClient,
+				clientset:     clientset,
+				config:        config,
+			}
+
+			if err := client.ReschedulePod(stub); err != nil {
+				t.Fatalf("Failed to reschedule pod: %v", err)
+			}
+
+			if err := client.RecordRescheduleAttempt(stub); err != nil {
+				t.Fatalf("Failed to record reschedule attempt: %v", err)
+			}
+
+			pod, err := client.GetPod("test-pod", "default")
+			if err != nil {
+				t.Fatalf("Failed to get pod: %v", err)
+			}
+
+			for key, value := range config.rescheduleAnnotationSet() {
+				if pod.Annotations[key] != value {
+					t.Fatalf("Expected annotation %s=%s, got %v", key, value, pod.Annotations)
+				}
+			}
+
+			if pod.Annotations[DefaultAttemptsAnnotation] != "1" {
+				t.Fatalf("Expected attempts annotation to be 1, got %v", pod.Annotations)
+			}
+
+			if err := client.RemovePodAnnotations(pod, DefaultAttemptsAnnotation); err != nil {
+				t.Fatalf("Failed to remove pod annotations: %v", err)
+			}
+
+			if pod, err = client.GetPod("test-pod", "default"); err != nil {
+				t.Fatalf("Failed to get pod: %v", err)
+			}
+
+			if _, ok := pod.Annotations[DefaultAttemptsAnnotation]; ok {
+				t.Fatalf("Expected attempts annotation to be removed, got %v", pod.Annotations)
+			}
+
+			used, unused := len(clientset.Actions()), len(dynamicClient.Actions())
+			if !testcase.useTypedPodClient {
+				used, unused = unused, used
+			}
+
+			if used == 0 || unused != 0 {
+				t.Fatalf("Expected every pod request to use the configured client, got %d requests with it and %d with the other", used, unused)
+			}
+		})
+	}
+}
+
+func TestRecordRescheduleAttempt(t *testing.T) {
+	stub := &corev1.Pod{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Pod",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-pod",
+			Namespace: "default-namespace",
+		},
+	}
+
+	unstructuredStub, err := runtime.DefaultUnstructuredConverter.ToUnstructured(stub)
+	if err != nil {
+		t.Fatalf("Failed to convert pod to unstructured: %v", err)
+	}
+
+	client := &ClientImpl{
+		dynamicClient: fake.NewSimpleDynamicClient(runtime.NewScheme(), &unstructured.Unstructured{Object: unstructuredStub}),
+		config:        NewConfigBuilder().FromEnvironment().WithTrackAttempts(true).Build(),
+	}
+
+	pod := stub
+	for _, expected := range []string{"1", "2", "3"} {
+		if err := client.RecordRescheduleAttempt(pod); err != nil {
+			t.Fatalf("Failed to record reschedule attempt: %v", err)
+		}
+
+		pod, err = client.GetPod("test-pod", "default-namespace")
+		if err != nil {
+			t.Fatalf("Failed to get pod: %v", err)
+		}
+
+		if pod.Annotations[DefaultAttemptsAnnotation] != expected {
+			t.Fatalf("Expected attempts annotation to be %s, got %v", expected, pod.Annotations)
+		}
+	}
+}
+
+func TestRemovePodAnnotations(t *testing.T) {
+	stub := &corev1.Pod{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Pod",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-pod",
+			Namespace: "default-namespace",
+			Annotations: map[string]string{
+				"cao.couchbase.com/reschedule": "true",
+				DefaultAttemptsAnnotation:      "2",
+				"other":                        "value",
+			},
+		},
+	}
+
+	unstructuredStub, err := runtime.DefaultUnstructuredConverter.ToUnstructured(stub)
+	if err != nil {
+		t.Fatalf("Failed to convert pod to unstructured: %v", err)
+	}
+
+	client := &ClientImpl{
+		dynamicClient: fake.NewSimpleDynamicClient(runtime.NewScheme(), &unstructured.Unstructured{Object: unstructuredStub}),
+		config:        NewConfigBuilder().FromEnvironment().Build(),
+	}
+
+	if err := client.RemovePodAnnotations(stub, "cao.couchbase.com/reschedule", DefaultAttemptsAnnotation); err != nil {
+		t.Fatalf("Failed to remove pod annotations: %v", err)
+	}
+
+	updatedPod, err := client.GetPod("test-pod", "default-namespace")
+	if err != nil {
+		t.Fatalf("Failed to get pod: %v", err)
+	}
+
+	expected := map[string]string{"other": "value"}
+	if !reflect.DeepEqual(updatedPod.Annotations, expected) {
+		t.Fatalf("Expected pod annotations to be %v, got %v", expected, updatedPod.Annotations)
+	}
+}
+
+func TestDryRunClientLogsPatch(t *testing.T) {
+	var buf bytes.Buffer
+	defaultLogger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, nil)))
+	t.Cleanup(func() {
+		slog.SetDefault(defaultLogger)
+	})
+
+	stub := &corev1.Pod{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Pod",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "test-pod",
+			Namespace:       "default-namespace",
+			ResourceVersion: "42",
+		},
+	}
+
+	unstructuredStub, err := runtime.DefaultUnstructuredConverter.ToUnstructured(stub)
+	if err != nil {
+		t.Fatalf("Failed to convert pod to unstructured: %v", err)
+	}
+
+	dynamicClient := fake.NewSimpleDynamicClient(runtime.NewScheme(), &unstructured.Unstructured{Object: unstructuredStub})
+	client := &DryRunClientImpl{
+		ClientImpl: &ClientImpl{
+			dynamicClient: dynamicClient,
+			config:        NewConfigBuilder().FromEnvironment().Build(),
+		},
+	}
+
+	if err := client.ReschedulePod(stub); err != nil {
+		t.Fatalf("Failed to reschedule pod: %v", err)
+	}
+
+	var entry struct {
+		Msg      string `json:"msg"`
+		Resource string `json:"resource"`
+		Name     string `json:"name"`
+		Patch    string `json:"patch"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Failed to decode log entry %q: %v", buf.String(), err)
+	}
+
+	expectedPatch := `{"metadata":{"annotations":{"cao.couchbase.com/reschedule":"true"},"resourceVersion":"42"}}`
+	if entry.Msg != "Dry run, patch not applied" || entry.Resource != "pod" || entry.Name != "test-pod" || entry.Patch != expectedPatch {
+		t.Fatalf("Expected dry run patch %s to be logged for pod test-pod, got %q", expectedPatch, buf.String())
+	}
+
+	for _, action := range dynamicClient.Actions() {
+		if action.GetVerb() == "patch" {
+			t.Fatalf("Expected no patch to be applied in dry run, got %v", action)
+		}
+	}
+}
+
+func TestReschedulePodResourceVersion(t *testing.T) {
+	testcases := []struct {
+		testname        string
+		resourceVersion string
+		expectConflict  bool
+	}{
+		{
+			testname:        "Matching resourceVersion",
+			resourceVersion: "1",
+		},
+		{
+			testname:        "Stale resourceVersion",
+			resourceVersion: "0",
+			expectConflict:  true,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testname, func(t *testing.T) {
+			stub := &corev1.Pod{
+				TypeMeta: metav1.TypeMeta{
+					Kind:       "Pod",
+					APIVersion: "v1",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            "test-pod",
+					Namespace:       "default-namespace",
+					ResourceVersion: "1",
+				},
+			}
+
+			unstructuredStub, err := runtime.DefaultUnstructuredConverter.ToUnstructured(stub)
+			if err != nil {
+				t.Fatalf("Failed to convert pod to unstructured: %v", err)
+			}
+
+			dynamicClient := fake.NewSimpleDynamicClient(runtime.NewScheme(), &unstructured.Unstructured{Object: unstructuredStub})
+
+			// The fake client does not check preconditions, so simulate the API server rejecting patches with a stale resourceVersion
+			dynamicClient.PrependReactor("patch", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				var patch struct {
+					Metadata struct {
+						ResourceVersion string `json:"resourceVersion"`
+					} `json:"metadata"`
+				}
+				if err := json.Unmarshal(action.(k8stesting.PatchAction).GetPatch(), &patch); err != nil {
+					return true, nil, err
+				}
+
+				if patch.Metadata.ResourceVersion != stub.ResourceVersion {
+					return true, nil, k8serrors.NewConflict(schema.GroupResource{Resource: "pods"}, stub.Name, errors.New("the object has been modified"))
+				}
+
+				return false, nil, nil
+			})
+
+			client := &ClientImpl{
+				dynamicClient: dynamicClient,
+				config:        NewConfigBuilder().FromEnvironment().Build(),
+			}
+
+			pod := stub.DeepCopy()
+			pod.ResourceVersion = testcase.resourceVersion
+
+			err = client.ReschedulePod(pod)
+			if testcase.expectConflict != k8serrors.IsConflict(err) {
+				t.Fatalf("Expected conflict to be %t, got %v", testcase.expectConflict, err)
+			}
+
+			if !testcase.expectConflict && err != nil {
+				t.Fatalf("Failed to reschedule pod: %v", err)
+			}
+		})
+	}
+}
+
+func TestAddRescheduleHookTrackingAnnotation(t *testing.T) {
+	testcases := []struct {
+		testname             string
+		trackingResourceType string
+		namespace            string
+		resourceStub         *unstructured.Unstructured
+	}{
+		{
+			testname:             "CouchbaseCluster",
+			trackingResourceType: "couchbasecluster",
+			namespace:            "test-namespace",
+			resourceStub:         couchbaseClusterStub("test-cluster", "test-namespace", true, nil),
+		},
+		{
+			testname:             "Namespace",
+			trackingResourceType: "namespace",
+			namespace:            "test-namespace",
+			resourceStub:         namespaceStub("test-namespace", nil),
+		},
+		{
+			testname:             "CouchbaseCluster with existing annotations",
+			trackingResourceType: "couchbasecluster",
+			namespace:            "test-namespace",
+			resourceStub: couchbaseClusterStub("test-cluster", "test-namespace", true, map[string]interface{}{
+				TrackingResourceAnnotation("other-pod", "test-namespace"): "true",
+			}),
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testname, func(t *testing.T) {
+			unstructuredStub, err := runtime.DefaultUnstructuredConverter.ToUnstructured(testcase.resourceStub)
+			if err != nil {
+				t.Fatalf("Failed to convert resource to unstructured: %v", err)
+			}
+
+			existingAnnotations := testcase.resourceStub.GetAnnotations()
+
+			dynamicClient := fake.NewSimpleDynamicClient(runtime.NewScheme(), &unstructured.Unstructured{Object: unstructuredStub})
+			client := &ClientImpl{
+				dynamicClient: dynamicClient,
+				config:        NewConfigBuilder().FromEnvironment().WithTrackingResource(testcase.trackingResourceType).Build(),
+			}
+
+			podName := "test-pod"
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: podName, Namespace: testcase.namespace}}
+
+			err = client.AddRescheduleHookTrackingAnnotation(pod, testcase.resourceStub)
+			if err != nil {
+				t.Fatalf("Failed to add reschedule hook tracking annotation: %v", err)
+			}
+
+			// The size of the annotations is checked against the instance that was passed in, so it is only patched
+			for _, action := range dynamicClient.Actions() {
+				if action.GetVerb() != "patch" {
+					t.Fatalf("Expected the tracking resource instance to only be patched, got %s", action.GetVerb())
+				}
+			}
+
+			updatedResource, err := client.GetTrackingResourceInstance(testcase.resourceStub.GetName(), testcase.namespace)
+			if err != nil {
+				t.Fatalf("Failed to get updated resource: %v", err)
+			}
+
+			if updatedResource.GetAnnotations()[TrackingResourceAnnotation(podName, testcase.namespace)] != "true" {
+				t.Fatalf("Expected resource to have reschedule hook tracking annotation, got %v", updatedResource.GetAnnotations())
+			}
+
+			// Resources without an annotations map should only have the new annotation, and existing annotations should be kept
+			if len(updatedResource.GetAnnotations()) != len(existingAnnotations)+1 {
+				t.Fatalf("Expected resource to have %d annotations, got %v", len(existingAnnotations)+1, updatedResource.GetAnnotations())
+			}
+		})
+	}
+}
+
+func TestCheckAnnotationsSize(t *testing.T) {
+	added := map[string]string{TrackingResourceAnnotation("test-pod", "test-namespace"): "true"}
+	addedSize := annotationsSize(added)
+	warningSize := maxAnnotationsSize * annotationsSizeWarningPercent / 100
+
+	// existingOfSize returns existing annotations that take the total size to size once added is included
+	existingOfSize := func(size int) map[string]string {
+		return map[string]string{"large": strings.Repeat("x", size-addedSize-len("large"))}
+	}
+
+	testcases := []struct {
+		testname      string
+		existing      map[string]string
+		deny          bool
+		expectedError bool
+	}{
+		{
+			testname: "No existing annotations",
+		},
+		{
+			testname: "Below warning threshold",
+			existing: existingOfSize(warningSize - 1),
+			deny:     true,
+		},
+		{
+			testname: "Near limit without deny",
+			existing: existingOfSize(warningSize + 1),
+		},
+		{
+			testname:      "Near limit with deny",
+			existing:      existingOfSize(warningSize + 1),
+			deny:          true,
+			expectedError: true,
+		},
+		{
+			testname: "At limit without deny",
+			existing: existingOfSize(maxAnnotationsSize),
+		},
+		{
+			testname:      "Over limit without deny",
+			existing:      existingOfSize(maxAnnotationsSize + 1),
+			expectedError: true,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testname, func(t *testing.T) {
+			err := checkAnnotationsSize("couchbasecluster test-cluster", testcase.existing, added, testcase.deny)
+			if testcase.expectedError != (err != nil) {
+				t.Fatalf("Expected error %v, got %v", testcase.expectedError, err)
 			}
 
-			if !reflect.DeepEqual(trackingResourceInstance, testcase.resourceStub) {
-				t.Fatalf("Expected tracking resource to be %v, got %v", testcase.resourceStub, trackingResourceInstance)
+			if err != nil && !errors.Is(err, ErrAnnotationSizeLimit) {
+				t.Fatalf("Expected error to wrap ErrAnnotationSizeLimit, got %v", err)
 			}
 		})
 	}
 }
 
-func TestReschedulePod(t *testing.T) {
-	stub := &corev1.Pod{
-		TypeMeta: metav1.TypeMeta{
-			Kind:       "Pod",
-			APIVersion: "v1",
-		},
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      "test-pod",
-			Namespace: "default-namespace",
-		},
-	}
+func TestAddRescheduleHookTrackingAnnotationSizeLimit(t *testing.T) {
+	resourceStub := couchbaseClusterStub("test-cluster", "test-namespace", true, map[string]interface{}{
+		"large": strings.Repeat("x", maxAnnotationsSize*annotationsSizeWarningPercent/100),
+	})
 
-	unstructuredStub, err := runtime.DefaultUnstructuredConverter.ToUnstructured(stub)
+	unstructuredStub, err := runtime.DefaultUnstructuredConverter.ToUnstructured(resourceStub)
 	if err != nil {
-		t.Fatalf("Failed to convert pod to unstructured: %v", err)
+		t.Fatalf("Failed to convert resource to unstructured: %v", err)
 	}
 
 	client := &ClientImpl{
 		dynamicClient: fake.NewSimpleDynamicClient(runtime.NewScheme(), &unstructured.Unstructured{Object: unstructuredStub}),
-		config:        NewConfigBuilder().FromEnvironment().Build(),
+		config:        NewConfigBuilder().WithTrackingResource("couchbasecluster").WithDenyNearAnnotationLimit(true).Build(),
 	}
 
-	err = client.ReschedulePod(stub)
-	if err != nil {
-		t.Fatalf("Failed to reschedule pod: %v", err)
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test-pod", Namespace: "test-namespace"}}
+	if err := client.AddRescheduleHookTrackingAnnotation(pod, resourceStub); !errors.Is(err, ErrAnnotationSizeLimit) {
+		t.Fatalf("Expected error to wrap ErrAnnotationSizeLimit, got %v", err)
 	}
 
-	updatedPod, err := client.GetPod("test-pod", "default-namespace")
+	updatedResource, err := client.GetTrackingResourceInstance("test-cluster", "test-namespace")
 	if err != nil {
-		t.Fatalf("Failed to get pod: %v", err)
+		t.Fatalf("Failed to get updated resource: %v", err)
 	}
 
-	// Check that the updated pod has the reschedule annotation
-	if updatedPod.Annotations[client.GetConfig().rescheduleAnnotationKey] != client.GetConfig().rescheduleAnnotationValue {
-		t.Fatalf("Expected pod to have reschedule annotation, got %v", updatedPod.Annotations)
+	if _, exists := updatedResource.GetAnnotations()[TrackingResourceAnnotation("test-pod", "test-namespace")]; exists {
+		t.Fatalf("Expected tracking annotation not to be added, got %v", updatedResource.GetAnnotations())
 	}
 }
 
-func TestAddRescheduleHookTrackingAnnotation(t *testing.T) {
+func TestRemoveRescheduleHookTrackingAnnotation(t *testing.T) {
 	testcases := []struct {
 		testname             string
 		trackingResourceType string
-		namespace            string
 		resourceStub         *unstructured.Unstructured
 	}{
 		{
 			testname:             "CouchbaseCluster",
 			trackingResourceType: "couchbasecluster",
-			namespace:            "test-namespace",
-			resourceStub:         couchbaseClusterStub("test-cluster", "test-namespace", true, nil),
+			resourceStub: couchbaseClusterStub("test-cluster", "default-namespace", true, map[string]interface{}{
+				TrackingResourceAnnotation("test-pod", "default-namespace"): "true",
+			}),
 		},
 		{
 			testname:             "Namespace",
 			trackingResourceType: "namespace",
-			namespace:            "test-namespace",
-			resourceStub:         namespaceStub("test-namespace", nil),
+			resourceStub: namespaceStub("test-namespace", map[string]interface{}{
+				TrackingResourceAnnotation("test-pod", "default-namespace"): "true",
+			}),
+		},
+		{
+			testname:             "No annotations exist on cluster",
+			trackingResourceType: "couchbasecluster",
+			resourceStub:         couchbaseClusterStub("test-cluster", "default-namespace", true, nil),
 		},
 	}
 
@@ -148,7 +2191,7 @@ func TestAddRescheduleHookTrackingAnnotation(t *testing.T) {
 		t.Run(testcase.testname, func(t *testing.T) {
 			unstructuredStub, err := runtime.DefaultUnstructuredConverter.ToUnstructured(testcase.resourceStub)
 			if err != nil {
-				t.Fatalf("Failed to convert resource to unstructured: %v", err)
+				t.Fatalf("Failed to convert tracking resource to unstructured: %v", err)
 			}
 
 			client := &ClientImpl{
@@ -157,25 +2200,25 @@ func TestAddRescheduleHookTrackingAnnotation(t *testing.T) {
 			}
 
 			podName := "test-pod"
-
-			err = client.AddRescheduleHookTrackingAnnotation(podName, testcase.namespace, testcase.resourceStub.GetName())
+			podNamespace := "default-namespace"
+			err = client.RemoveRescheduleHookTrackingAnnotation(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: podName, Namespace: podNamespace}}, testcase.resourceStub.GetName())
 			if err != nil {
-				t.Fatalf("Failed to add reschedule hook tracking annotation: %v", err)
+				t.Fatalf("Failed to remove reschedule hook tracking annotation: %v", err)
 			}
 
-			updatedResource, err := client.GetTrackingResourceInstance(testcase.resourceStub.GetName(), testcase.namespace)
+			updatedResource, err := client.GetTrackingResourceInstance(testcase.resourceStub.GetName(), "default-namespace")
 			if err != nil {
-				t.Fatalf("Failed to get updated resource: %v", err)
+				t.Fatalf("Failed to get updated tracking resource: %v", err)
 			}
 
-			if updatedResource.GetAnnotations()[TrackingResourceAnnotation(podName, testcase.namespace)] != "true" {
-				t.Fatalf("Expected resource to have reschedule hook tracking annotation, got %v", updatedResource.GetAnnotations())
+			if updatedResource.GetAnnotations()[TrackingResourceAnnotation(podName, podNamespace)] != "" {
+				t.Fatalf("Expected tracking resource to not have reschedule hook tracking annotation, got %v", updatedResource.GetAnnotations())
 			}
 		})
 	}
 }
 
-func TestRemoveRescheduleHookTrackingAnnotation(t *testing.T) {
+func TestClearTrackingAnnotations(t *testing.T) {
 	testcases := []struct {
 		testname             string
 		trackingResourceType string
@@ -185,40 +2228,43 @@ func TestRemoveRescheduleHookTrackingAnnotation(t *testing.T) {
 			testname:             "CouchbaseCluster",
 			trackingResourceType: "couchbasecluster",
 			resourceStub: couchbaseClusterStub("test-cluster", "default-namespace", true, map[string]interface{}{
-				TrackingResourceAnnotation("test-pod", "default-namespace"): "true",
+				TrackingResourceAnnotation("pod1", "default-namespace"): "true",
+				TrackingResourceAnnotation("pod2", "default-namespace"): "true",
+				DefaultForceTrackingAnnotation:                          "true",
+				DefaultDisableTrackingAnnotation:                        "true",
+				"reschedule.hook/track":                                 "true",
+				"other":                                                 "value",
 			}),
 		},
 		{
 			testname:             "Namespace",
 			trackingResourceType: "namespace",
-			resourceStub: namespaceStub("test-namespace", map[string]interface{}{
-				TrackingResourceAnnotation("test-pod", "default-namespace"): "true",
+			resourceStub: namespaceStub("default-namespace", map[string]interface{}{
+				TrackingResourceAnnotation("pod1", "default-namespace"): "true",
+				TrackingResourceAnnotation("pod2", "default-namespace"): "true",
+				DefaultForceTrackingAnnotation:                          "true",
+				DefaultDisableTrackingAnnotation:                        "true",
+				"reschedule.hook/track":                                 "true",
+				"other":                                                 "value",
 			}),
 		},
-		{
-			testname:             "No annotations exist on cluster",
-			trackingResourceType: "couchbasecluster",
-			resourceStub:         couchbaseClusterStub("test-cluster", "default-namespace", true, nil),
-		},
 	}
 
 	for _, testcase := range testcases {
 		t.Run(testcase.testname, func(t *testing.T) {
-			unstructuredStub, err := runtime.DefaultUnstructuredConverter.ToUnstructured(testcase.resourceStub)
-			if err != nil {
-				t.Fatalf("Failed to convert tracking resource to unstructured: %v", err)
-			}
-
 			client := &ClientImpl{
-				dynamicClient: fake.NewSimpleDynamicClient(runtime.NewScheme(), &unstructured.Unstructured{Object: unstructuredStub}),
-				config:        NewConfigBuilder().FromEnvironment().WithTrackingResource(testcase.trackingResourceType).Build(),
+				dynamicClient: fake.NewSimpleDynamicClient(runtime.NewScheme(), testcase.resourceStub),
+				config:        NewConfigBuilder().FromEnvironment().WithTrackingResource(testcase.trackingResourceType).WithNamespaceTracking("reschedule.hook/track", "").Build(),
 			}
 
-			podName := "test-pod"
-			podNamespace := "default-namespace"
-			err = client.RemoveRescheduleHookTrackingAnnotation(podName, podNamespace, testcase.resourceStub.GetName())
+			removed, err := client.ClearTrackingAnnotations(testcase.resourceStub.GetName(), "default-namespace")
 			if err != nil {
-				t.Fatalf("Failed to remove reschedule hook tracking annotation: %v", err)
+				t.Fatalf("Failed to clear tracking annotations: %v", err)
+			}
+
+			expectedRemoved := []string{TrackingResourceAnnotation("pod1", "default-namespace"), TrackingResourceAnnotation("pod2", "default-namespace")}
+			if !reflect.DeepEqual(removed, expectedRemoved) {
+				t.Fatalf("Expected removed annotations to be %v, got %v", expectedRemoved, removed)
 			}
 
 			updatedResource, err := client.GetTrackingResourceInstance(testcase.resourceStub.GetName(), "default-namespace")
@@ -226,8 +2272,346 @@ func TestRemoveRescheduleHookTrackingAnnotation(t *testing.T) {
 				t.Fatalf("Failed to get updated tracking resource: %v", err)
 			}
 
-			if updatedResource.GetAnnotations()[TrackingResourceAnnotation(podName, podNamespace)] != "" {
-				t.Fatalf("Expected tracking resource to not have reschedule hook tracking annotation, got %v", updatedResource.GetAnnotations())
+			expectedAnnotations := map[string]string{
+				DefaultForceTrackingAnnotation:   "true",
+				DefaultDisableTrackingAnnotation: "true",
+				"reschedule.hook/track":          "true",
+				"other":                          "value",
+			}
+			if !reflect.DeepEqual(updatedResource.GetAnnotations(), expectedAnnotations) {
+				t.Fatalf("Expected tracking resource annotations to be %v, got %v", expectedAnnotations, updatedResource.GetAnnotations())
+			}
+		})
+	}
+}
+
+func TestCountTrackingAnnotations(t *testing.T) {
+	config := NewConfigBuilder().WithTrackingResource("namespace").WithNamespaceTracking("reschedule.hook/track", "").Build()
+	annotations := map[string]string{
+		TrackingResourceAnnotation("pod1", "default-namespace"): "true",
+		TrackingResourceAnnotation("pod2", "default-namespace"): "true",
+		DefaultForceTrackingAnnotation:                          "true",
+		DefaultDisableTrackingAnnotation:                        "false",
+		"reschedule.hook/track":                                 "true",
+		"other":                                                 "value",
+	}
+
+	if count := countTrackingAnnotations(annotations, config.trackingControlAnnotations()...); count != 2 {
+		t.Fatalf("Expected 2 tracking annotations, got %d: %v", count, annotations)
+	}
+}
+
+func TestBatchRemoveTrackingAnnotations(t *testing.T) {
+	resourceStub := couchbaseClusterStub("test-cluster", "default-namespace", true, map[string]interface{}{
+		TrackingResourceAnnotation("pod1", "default-namespace"): "true",
+		TrackingResourceAnnotation("pod2", "default-namespace"): "true",
+		TrackingResourceAnnotation("pod3", "default-namespace"): "true",
+		DefaultForceTrackingAnnotation:                          "true",
+		"other":                                                 "value",
+	})
+
+	dynamicClient := fake.NewSimpleDynamicClient(runtime.NewScheme(), resourceStub)
+	client := &ClientImpl{
+		dynamicClient: dynamicClient,
+		config:        NewConfigBuilder().FromEnvironment().WithTrackingResource("couchbasecluster").Build(),
+	}
+
+	err := client.BatchRemoveTrackingAnnotations("test-cluster", "default-namespace", []string{"default-namespace/pod1", "default-namespace/pod3"})
+	if err != nil {
+		t.Fatalf("Failed to remove tracking annotations: %v", err)
+	}
+
+	var patches int
+	for _, action := range dynamicClient.Actions() {
+		if action.GetVerb() == "patch" {
+			patches++
+		}
+	}
+
+	if patches != 1 {
+		t.Fatalf("Expected tracking annotations to be removed in a single patch, got %d", patches)
+	}
+
+	updatedResource, err := client.GetTrackingResourceInstance("test-cluster", "default-namespace")
+	if err != nil {
+		t.Fatalf("Failed to get updated tracking resource: %v", err)
+	}
+
+	expectedAnnotations := map[string]string{
+		TrackingResourceAnnotation("pod2", "default-namespace"): "true",
+		DefaultForceTrackingAnnotation:                          "true",
+		"other":                                                 "value",
+	}
+	if !reflect.DeepEqual(updatedResource.GetAnnotations(), expectedAnnotations) {
+		t.Fatalf("Expected tracking resource annotations to be %v, got %v", expectedAnnotations, updatedResource.GetAnnotations())
+	}
+}
+
+func TestBatchRemoveScopedTrackingAnnotations(t *testing.T) {
+	resourceStub := namespaceStub("default-namespace", map[string]interface{}{
+		ScopedTrackingResourceAnnotation("pod1", "default-namespace", "app1"): "true",
+		ScopedTrackingResourceAnnotation("pod2", "default-namespace", "app1"): "true",
+		ScopedTrackingResourceAnnotation("pod3", "default-namespace", "app2"): "true",
+		"other": "value",
+	})
+
+	client := &ClientImpl{
+		dynamicClient: fake.NewSimpleDynamicClient(runtime.NewScheme(), resourceStub),
+		config:        NewConfigBuilder().FromEnvironment().WithTrackingResource("namespace").WithNamespaceTracking("", "app.kubernetes.io/name").Build(),
+	}
+
+	err := client.BatchRemoveTrackingAnnotations("default-namespace", "default-namespace", []string{"default-namespace/app1/pod1", "default-namespace/app2/pod3"})
+	if err != nil {
+		t.Fatalf("Failed to remove tracking annotations: %v", err)
+	}
+
+	updatedResource, err := client.GetTrackingResourceInstance("default-namespace", "default-namespace")
+	if err != nil {
+		t.Fatalf("Failed to get updated tracking resource: %v", err)
+	}
+
+	expectedAnnotations := map[string]string{
+		ScopedTrackingResourceAnnotation("pod2", "default-namespace", "app1"): "true",
+		"other": "value",
+	}
+	if !reflect.DeepEqual(updatedResource.GetAnnotations(), expectedAnnotations) {
+		t.Fatalf("Expected tracking resource annotations to be %v, got %v", expectedAnnotations, updatedResource.GetAnnotations())
+	}
+}
+
+func TestBatchRemoveTrackingAnnotationsInvalidPodKey(t *testing.T) {
+	dynamicClient := fake.NewSimpleDynamicClient(runtime.NewScheme(), couchbaseClusterStub("test-cluster", "default-namespace", true, nil))
+	client := &ClientImpl{
+		dynamicClient: dynamicClient,
+		config:        NewConfigBuilder().FromEnvironment().WithTrackingResource("couchbasecluster").Build(),
+	}
+
+	for _, podKey := range []string{"pod2", "default-namespace//pod2", "default-namespace/app1/pod2/extra"} {
+		if err := client.BatchRemoveTrackingAnnotations("test-cluster", "default-namespace", []string{"default-namespace/pod1", podKey}); err == nil {
+			t.Fatalf("Expected invalid pod key %q to fail", podKey)
+		}
+	}
+
+	if actions := dynamicClient.Actions(); len(actions) != 0 {
+		t.Fatalf("Expected no API calls for an invalid pod key, got %v", actions)
+	}
+}
+
+func TestShouldAddTrackingAnnotation(t *testing.T) {
+	testcases := []struct {
+		testname       string
+		podAnnotations map[string]string
+		resourceStub   *unstructured.Unstructured
+		expected       bool
+	}{
+		{
+			testname:     "InPlaceUpgrade cluster",
+			resourceStub: couchbaseClusterStub("test-cluster", "default-namespace", true, nil),
+			expected:     true,
+		},
+		{
+			testname:     "SwapRebalance cluster",
+			resourceStub: couchbaseClusterStub("test-cluster", "default-namespace", false, nil),
+			expected:     false,
+		},
+		{
+			testname: "SwapRebalance cluster with force tracking annotation",
+			resourceStub: couchbaseClusterStub("test-cluster", "default-namespace", false, map[string]interface{}{
+				DefaultForceTrackingAnnotation: "true",
+			}),
+			expected: true,
+		},
+		{
+			testname: "SwapRebalance cluster with force tracking annotation disabled",
+			resourceStub: couchbaseClusterStub("test-cluster", "default-namespace", false, map[string]interface{}{
+				DefaultForceTrackingAnnotation: "false",
+			}),
+			expected: false,
+		},
+		{
+			testname:       "SwapRebalance cluster with force tracking annotation on pod",
+			podAnnotations: map[string]string{DefaultForceTrackingAnnotation: "true"},
+			resourceStub:   couchbaseClusterStub("test-cluster", "default-namespace", false, nil),
+			expected:       true,
+		},
+		{
+			testname: "InPlaceUpgrade cluster with disable tracking annotation",
+			resourceStub: couchbaseClusterStub("test-cluster", "default-namespace", true, map[string]interface{}{
+				DefaultDisableTrackingAnnotation: "true",
+			}),
+			expected: false,
+		},
+		{
+			testname: "InPlaceUpgrade cluster with disable tracking annotation unset",
+			resourceStub: couchbaseClusterStub("test-cluster", "default-namespace", true, map[string]interface{}{
+				DefaultDisableTrackingAnnotation: "false",
+			}),
+			expected: true,
+		},
+		{
+			testname:       "SwapRebalance cluster with disable tracking annotation and force tracking annotation on pod",
+			podAnnotations: map[string]string{DefaultForceTrackingAnnotation: "true"},
+			resourceStub: couchbaseClusterStub("test-cluster", "default-namespace", false, map[string]interface{}{
+				DefaultDisableTrackingAnnotation: "true",
+			}),
+			expected: false,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testname, func(t *testing.T) {
+			client := &ClientImpl{
+				config: NewConfigBuilder().FromEnvironment().WithTrackingResource("couchbasecluster").Build(),
+			}
+
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test-pod", Namespace: "default-namespace", Annotations: testcase.podAnnotations}}
+
+			if shouldTrack := client.ShouldAddTrackingAnnotation(pod, testcase.resourceStub); shouldTrack != testcase.expected {
+				t.Fatalf("Expected ShouldAddTrackingAnnotation to be %t, got %t", testcase.expected, shouldTrack)
+			}
+		})
+	}
+}
+
+func TestTrackingResourceAnnotation(t *testing.T) {
+	longPodName := strings.Repeat("a", 100)
+
+	testcases := []struct {
+		testname      string
+		podName       string
+		podNamespace  string
+		expectedKey   string
+		expectedValue string
+	}{
+		{
+			testname:      "Short name",
+			podName:       "test-pod",
+			podNamespace:  "default-namespace",
+			expectedKey:   RescheduledPodsTrackingKeyPrefix + "default-namespace.test-pod",
+			expectedValue: "true",
+		},
+		{
+			testname:      "Dotted name",
+			podName:       "test.pod.0",
+			podNamespace:  "default-namespace",
+			expectedKey:   RescheduledPodsTrackingKeyPrefix + "default-namespace.test.pod.0",
+			expectedValue: "true",
+		},
+		{
+			testname:      "Long name",
+			podName:       longPodName,
+			podNamespace:  "default-namespace",
+			expectedValue: `{"namespace":"default-namespace","name":"` + longPodName + `"}`,
+		},
+		{
+			testname:      "Long dotted name",
+			podName:       strings.Repeat("a.", 50) + "a",
+			podNamespace:  "default-namespace",
+			expectedValue: `{"namespace":"default-namespace","name":"` + strings.Repeat("a.", 50) + `a"}`,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testname, func(t *testing.T) {
+			key := TrackingResourceAnnotation(testcase.podName, testcase.podNamespace)
+			if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+				t.Fatalf("Expected key %q to be a valid annotation key, got %v", key, errs)
+			}
+
+			if key != TrackingResourceAnnotation(testcase.podName, testcase.podNamespace) {
+				t.Fatalf("Expected key %q to be deterministic", key)
+			}
+
+			if testcase.expectedKey != "" && key != testcase.expectedKey {
+				t.Fatalf("Expected key to be %q, got %q", testcase.expectedKey, key)
+			}
+
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: testcase.podName, Namespace: testcase.podNamespace}}
+			value := TrackingResourceAnnotationValue(pod, false)
+			if value != testcase.expectedValue {
+				t.Fatalf("Expected value to be %q, got %q", testcase.expectedValue, value)
+			}
+
+			if rescheduled, recognised := IsTrackedPodRescheduled(value, pod); !rescheduled || !recognised {
+				t.Fatalf("Expected pod to be rescheduled and recognised, got rescheduled=%t recognised=%t", rescheduled, recognised)
+			}
+		})
+	}
+
+	// Long names which only differ after the truncation point should not produce the same key
+	if TrackingResourceAnnotation(longPodName+"-0", "default-namespace") == TrackingResourceAnnotation(longPodName+"-1", "default-namespace") {
+		t.Fatalf("Expected long pod names to produce different keys")
+	}
+}
+
+func TestIsTrackedPodRescheduled(t *testing.T) {
+	originalPod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-pod", Namespace: "default-namespace", UID: "uid-1"},
+		Spec:       corev1.PodSpec{NodeName: "node-1"},
+	}
+
+	testcases := []struct {
+		testname            string
+		value               string
+		pod                 *corev1.Pod
+		expectedRescheduled bool
+		expectedRecognised  bool
+	}{
+		{
+			testname:            "Value without node is rescheduled",
+			value:               "true",
+			pod:                 originalPod,
+			expectedRescheduled: true,
+			expectedRecognised:  true,
+		},
+		{
+			testname:            "Same node and same UID is not rescheduled",
+			value:               TrackingResourceAnnotationValue(originalPod, true),
+			pod:                 originalPod,
+			expectedRescheduled: false,
+			expectedRecognised:  true,
+		},
+		{
+			testname: "Different node is rescheduled",
+			value:    TrackingResourceAnnotationValue(originalPod, true),
+			pod: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-pod", Namespace: "default-namespace", UID: "uid-1"},
+				Spec:       corev1.PodSpec{NodeName: "node-2"},
+			},
+			expectedRescheduled: true,
+			expectedRecognised:  true,
+		},
+		{
+			testname: "Same node and different UID is rescheduled",
+			value:    TrackingResourceAnnotationValue(originalPod, true),
+			pod: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-pod", Namespace: "default-namespace", UID: "uid-2"},
+				Spec:       corev1.PodSpec{NodeName: "node-1"},
+			},
+			expectedRescheduled: true,
+			expectedRecognised:  true,
+		},
+		{
+			testname:            "Value for a different pod",
+			value:               `{"namespace":"default-namespace","name":"other-pod"}`,
+			pod:                 originalPod,
+			expectedRescheduled: false,
+			expectedRecognised:  false,
+		},
+		{
+			testname:            "Unrecognised value",
+			value:               "false",
+			pod:                 originalPod,
+			expectedRescheduled: false,
+			expectedRecognised:  false,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testname, func(t *testing.T) {
+			rescheduled, recognised := IsTrackedPodRescheduled(testcase.value, testcase.pod)
+			if rescheduled != testcase.expectedRescheduled || recognised != testcase.expectedRecognised {
+				t.Fatalf("Expected rescheduled=%t recognised=%t, got rescheduled=%t recognised=%t", testcase.expectedRescheduled, testcase.expectedRecognised, rescheduled, recognised)
 			}
 		})
 	}
@@ -281,3 +2665,63 @@ func namespaceStub(name string, annotations map[string]interface{}) *unstructure
 	obj.SetAPIVersion("v1")
 	return obj
 }
+
+func TestReadinessCheckDeep(t *testing.T) {
+	testcases := []struct {
+		testname       string
+		patchForbidden bool
+		expectReady    bool
+	}{
+		{
+			testname:    "Canary can be patched",
+			expectReady: true,
+		},
+		{
+			testname:       "Canary can be read but not patched",
+			patchForbidden: true,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testname, func(t *testing.T) {
+			unstructuredStub, err := runtime.DefaultUnstructuredConverter.ToUnstructured(couchbaseClusterStub("readiness-canary", "default", false, nil))
+			if err != nil {
+				t.Fatalf("Failed to convert resource to unstructured: %v", err)
+			}
+
+			dynamicClient := fake.NewSimpleDynamicClient(runtime.NewScheme(), &unstructured.Unstructured{Object: unstructuredStub})
+			dynamicClient.PrependReactor("patch", "couchbaseclusters", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				if testcase.patchForbidden {
+					return true, nil, k8serrors.NewForbidden(schema.GroupResource{Group: "couchbase.com", Resource: "couchbaseclusters"}, "readiness-canary", errors.New("patch not allowed"))
+				}
+				return false, nil, nil
+			})
+
+			config := NewConfigBuilder().WithDeepReadiness(true, "default/readiness-canary").Build()
+			readiness := &readinessCheck{config: config, client: &ClientImpl{dynamicClient: dynamicClient, config: config}}
+
+			// A passing check is not repeated, so later probes make no further requests
+			for probe := 0; probe < 2; probe++ {
+				if ready := readiness.ready(); ready != testcase.expectReady {
+					t.Fatalf("Probe %d: expected ready=%t, got %t", probe, testcase.expectReady, ready)
+				}
+			}
+
+			patches := 0
+			for _, action := range dynamicClient.Actions() {
+				if action.GetVerb() == "patch" {
+					patches++
+				}
+			}
+
+			expectedPatches := 2
+			if testcase.expectReady {
+				expectedPatches = 1
+			}
+
+			if patches != expectedPatches {
+				t.Fatalf("Expected %d patches, got %d", expectedPatches, patches)
+			}
+		})
+	}
+}
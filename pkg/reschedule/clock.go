@@ -0,0 +1,17 @@
+package reschedule
+
+import "time"
+
+// Clock provides the current time to time-dependent behaviour, so that it can be controlled in tests
+type Clock interface {
+	Now() time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// RealClock is the Clock used by default, which returns the current system time
+var RealClock Clock = realClock{}
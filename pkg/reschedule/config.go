@@ -1,11 +1,23 @@
 package reschedule
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"log/slog"
+	"maps"
+	"net"
+	"net/url"
 	"os"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/couchbaselabs/eviction-reschedule-hook/pkg/reschedule/tracking"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
 const (
@@ -16,42 +28,909 @@ const (
 	DefaultCertFile                  = "/etc/webhook/certs/tls.crt"
 	DefaultKeyFile                   = "/etc/webhook/certs/tls.key"
 	DefaultTrackRescheduledPods      = "true"
+	DefaultTrackPodNode              = "false"
 	DefaultTrackingResourceType      = tracking.ResourceTypeCouchbaseCluster
+	DefaultLogLevel                  = slog.LevelInfo
+	DefaultForceTrackingAnnotation   = "reschedule.hook/force-tracking"
+	DefaultDisableTrackingAnnotation = "reschedule.hook/disable-tracking"
+	DefaultApprovalAnnotation        = "reschedule.hook/approved"
+	DefaultReadTimeout               = 10 * time.Second
+	DefaultWriteTimeout              = 10 * time.Second
+	DefaultIdleTimeout               = 30 * time.Second
+	DefaultShutdownTimeout           = 10 * time.Second
+	DefaultRateLimit                 = 0
+	DefaultRateLimitBurst            = 5
+	DefaultAttemptsAnnotation        = "reschedule.hook/attempts"
+	DefaultFromNodeAnnotation        = "reschedule.hook/from-node"
+	DefaultCACheckInterval           = 5 * time.Minute
+	DefaultCircuitBreakerThreshold   = 0
+	DefaultCircuitBreakerWindow      = 30 * time.Second
+	DefaultCircuitBreakerCooldown    = 10 * time.Second
+	DefaultTrackingFailurePolicy     = TrackingFailurePolicyFail
+	DefaultRescheduleMarkerType      = RescheduleMarkerAnnotation
+	DefaultInstanceNameFrom          = InstanceNameFromPod
+	DefaultTrackingNotFoundRetries   = 2
+	DefaultTrackingNotFoundInterval  = 100 * time.Millisecond
+	DefaultRegistrySaveInterval      = 10 * time.Second
+	DefaultNotifyTimeout             = 5 * time.Second
+	DefaultTelemetryTimeout          = 100 * time.Millisecond
+	DefaultDecisionLog               = DecisionLogOff
+	DefaultMetricsInstanceLimit      = 100
+	DefaultClientQPS                 = 5
+	DefaultClientBurst               = 10
+	DefaultRolloutPercent            = 100
 )
 
+// TrackingFailurePolicy determines how an eviction is handled when the tracking resource instance for a pod does not exist
+type TrackingFailurePolicy string
+
+const (
+	// TrackingFailurePolicyFail denies the eviction with an InternalError
+	TrackingFailurePolicyFail TrackingFailurePolicy = "Fail"
+	// TrackingFailurePolicyIgnore marks the pod for rescheduling without tracking it
+	TrackingFailurePolicyIgnore TrackingFailurePolicy = "Ignore"
+)
+
+// parseTrackingFailurePolicy parses a tracking failure policy, ignoring case
+func parseTrackingFailurePolicy(value string) (TrackingFailurePolicy, error) {
+	for _, policy := range []TrackingFailurePolicy{TrackingFailurePolicyFail, TrackingFailurePolicyIgnore} {
+		if strings.EqualFold(value, string(policy)) {
+			return policy, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown tracking failure policy %q, must be %s or %s", value, TrackingFailurePolicyFail, TrackingFailurePolicyIgnore)
+}
+
+// RescheduleMarkerType determines whether pods are marked for rescheduling with annotations or labels
+type RescheduleMarkerType string
+
+const (
+	// RescheduleMarkerAnnotation marks pods for rescheduling with annotations
+	RescheduleMarkerAnnotation RescheduleMarkerType = "annotation"
+	// RescheduleMarkerLabel marks pods for rescheduling with labels, for operators that trigger rescheduling off a label
+	RescheduleMarkerLabel RescheduleMarkerType = "label"
+)
+
+// parseRescheduleMarkerType parses a reschedule marker type, ignoring case
+func parseRescheduleMarkerType(value string) (RescheduleMarkerType, error) {
+	for _, markerType := range []RescheduleMarkerType{RescheduleMarkerAnnotation, RescheduleMarkerLabel} {
+		if strings.EqualFold(value, string(markerType)) {
+			return markerType, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown reschedule marker type %q, must be %s or %s", value, RescheduleMarkerAnnotation, RescheduleMarkerLabel)
+}
+
+// InstanceNameFrom determines where the name of the tracking resource instance a pod belongs to is read from
+type InstanceNameFrom string
+
+const (
+	// InstanceNameFromPod reads the instance name from the pod's labels
+	InstanceNameFromPod InstanceNameFrom = "pod"
+	// InstanceNameFromOwner reads the instance name from the labels of the pod's controller when the pod's labels do not have it
+	InstanceNameFromOwner InstanceNameFrom = "owner"
+)
+
+// parseInstanceNameFrom parses where instance names are read from, ignoring case
+func parseInstanceNameFrom(value string) (InstanceNameFrom, error) {
+	for _, from := range []InstanceNameFrom{InstanceNameFromPod, InstanceNameFromOwner} {
+		if strings.EqualFold(value, string(from)) {
+			return from, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown instance name source %q, must be %s or %s", value, InstanceNameFromPod, InstanceNameFromOwner)
+}
+
+// DecisionLog determines where eviction decisions are written as NDJSON, separately from the logs
+type DecisionLog string
+
+const (
+	// DecisionLogStdout writes one JSON line per decision to stdout
+	DecisionLogStdout DecisionLog = "stdout"
+	// DecisionLogOff does not write decisions
+	DecisionLogOff DecisionLog = "off"
+)
+
+// parseDecisionLog parses a decision log target, ignoring case
+func parseDecisionLog(value string) (DecisionLog, error) {
+	for _, target := range []DecisionLog{DecisionLogStdout, DecisionLogOff} {
+		if strings.EqualFold(value, string(target)) {
+			return target, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown decision log %q, must be %s or %s", value, DecisionLogStdout, DecisionLogOff)
+}
+
+// JSONCase determines how the keys of the decision log and notification payloads are named
+type JSONCase string
+
+const (
+	// JSONCaseSnake names keys in snake_case, e.g. reason_code
+	JSONCaseSnake JSONCase = "snake"
+	// JSONCaseCamel names keys in camelCase, e.g. reasonCode
+	JSONCaseCamel JSONCase = "camel"
+)
+
+// parseJSONCase parses a JSON key case, ignoring case
+func parseJSONCase(value string) (JSONCase, error) {
+	for _, jsonCase := range []JSONCase{JSONCaseSnake, JSONCaseCamel} {
+		if strings.EqualFold(value, string(jsonCase)) {
+			return jsonCase, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown JSON case %q, must be %s or %s", value, JSONCaseSnake, JSONCaseCamel)
+}
+
 // Config holds the configuration for the reschedule hook
 type Config struct {
 	rescheduleAnnotationValue string
 	rescheduleAnnotationKey   string
 	trackRescheduledPods      bool
+	trackPodNode              bool
 	podLabelSelectorKey       string
 	podLabelSelectorValue     string
+	excludeSelector           labels.Selector
+	excludeSelectorErr        error
+	operatorPodSelector       labels.Selector
+	operatorPodSelectorErr    error
 	certFile                  string
 	keyFile                   string
 	trackingResource          tracking.TrackingResource
+	logLevel                  slog.Level
+	forceTrackingAnnotation   string
+	disableTrackingAnnotation string
+	approvalAnnotation        string
+	rescheduleDoneAnnotation  string
+	readTimeout               time.Duration
+	writeTimeout              time.Duration
+	idleTimeout               time.Duration
+	timeoutErr                error
+	shutdownTimeout           time.Duration
+	ignoreOwnerKinds          []string
+	statefulOwnerKinds        []string
+	rateLimit                 float64
+	rateLimitBurst            int
+	rootOK                    bool
+	blockEviction             bool
+	reportPendingPeers        bool
+	trackAttempts             bool
+	recordFromNode            bool
+	caBundleFile              string
+	caCheckInterval           time.Duration
+	validateCABundle          bool
+	webhookConfigName         string
+	cleanupPodAnnotations     bool
+	verifyAnnotation          bool
+	rescheduleAnnotations     map[string]string
+	rescheduleMarkerType      RescheduleMarkerType
+	trustWebhookSelector      bool
+	protectOnlyStateful       bool
+	protectPendingInstance    bool
+	onlyDrainingNodes         bool
+	nodeDrainAnnotation       string
+	nodeDrainAnnotationValue  string
+	minClusterSize            int
+	priorityAnnotation        string
+	rolloutPercent            int
+	circuitBreakerThreshold   int
+	circuitBreakerWindow      time.Duration
+	circuitBreakerCooldown    time.Duration
+	protectOrdinals           *ordinalRange
+	adminEndpoints            bool
+	healthAddr                string
+	adminToken                string
+	couchbaseAPIVersion       string
+	instanceNameFrom          InstanceNameFrom
+	trackingPredicate         *tracking.FieldPredicate
+	namespaceTrackAnnotation  string
+	namespaceScopeLabel       string
+	readyRequireTrackingCRD   bool
+	deepReadiness             bool
+	readinessCanary           string
+	denyNearAnnotationLimit   bool
+	allowTerminatingInstance  bool
+	trackingStatusPath        string
+	trackingNotReadyStatuses  []string
+	activeWindows             *activeWindows
+	activeWindowsErr          error
+	annotateOutsideWindows    bool
+	clock                     Clock
+	trackingFailurePolicy     TrackingFailurePolicy
+	trackingNotFoundRetries   int
+	trackingNotFoundInterval  time.Duration
+	trackingAnnotationMaxAge  time.Duration
+	firstAnnotationDelay      time.Duration
+	postRescheduleWait        time.Duration
+	requestTimeout            time.Duration
+	firstSeen                 *firstSeenRegistry
+	registryConfigMap         string
+	registrySaveInterval      time.Duration
+	notifyURL                 string
+	notifyTimeout             time.Duration
+	telemetryTimeout          time.Duration
+	decisionLog               DecisionLog
+	decisionJSONCase          JSONCase
+	metricsInstanceLimit      int
+	clientQPS                 float64
+	clientBurst               int
+	useTypedPodClient         bool
+	shutdownDelay             time.Duration
+	annotationKeyPrefix       string
+}
+
+// ordinalRange is an inclusive range of pod ordinals
+type ordinalRange struct {
+	min int
+	max int
+}
+
+// parseOrdinalRange parses a range of the form "min-max", or a single ordinal
+func parseOrdinalRange(value string) (*ordinalRange, error) {
+	minValue, maxValue, isRange := strings.Cut(strings.TrimSpace(value), "-")
+	if !isRange {
+		maxValue = minValue
+	}
+
+	minOrdinal, err := strconv.Atoi(strings.TrimSpace(minValue))
+	if err != nil || minOrdinal < 0 {
+		return nil, fmt.Errorf("invalid ordinal range %q: minimum must be a non-negative integer", value)
+	}
+
+	maxOrdinal, err := strconv.Atoi(strings.TrimSpace(maxValue))
+	if err != nil || maxOrdinal < minOrdinal {
+		return nil, fmt.Errorf("invalid ordinal range %q: maximum must be an integer no less than the minimum", value)
+	}
+
+	return &ordinalRange{min: minOrdinal, max: maxOrdinal}, nil
+}
+
+func (r *ordinalRange) String() string {
+	if r == nil {
+		return ""
+	}
+
+	return fmt.Sprintf("%d-%d", r.min, r.max)
+}
+
+// Contains checks whether the ordinal is within the range. A nil range contains every ordinal.
+func (r *ordinalRange) Contains(ordinal int) bool {
+	return r == nil || (ordinal >= r.min && ordinal <= r.max)
 }
 
 func (c *Config) ToEnvironment() map[string]string {
 	env := map[string]string{}
 	env["POD_LABEL_SELECTOR_KEY"] = c.podLabelSelectorKey
 	env["POD_LABEL_SELECTOR_VALUE"] = c.podLabelSelectorValue
+	if c.excludeSelector != nil {
+		env["POD_LABEL_EXCLUDE_SELECTOR"] = c.excludeSelector.String()
+	}
+	if c.operatorPodSelector != nil {
+		env["OPERATOR_POD_SELECTOR"] = c.operatorPodSelector.String()
+	}
 	env["TLS_CERT_FILE"] = c.certFile
 	env["TLS_KEY_FILE"] = c.keyFile
 	env["RESCHEDULE_ANNOTATION_KEY"] = c.rescheduleAnnotationKey
 	env["RESCHEDULE_ANNOTATION_VALUE"] = c.rescheduleAnnotationValue
+	env["RESCHEDULE_MARKER_TYPE"] = string(c.rescheduleMarkerType)
+	env["ANNOTATION_KEY_PREFIX"] = c.annotationKeyPrefix
 	env["TRACK_RESCHEULED_PODS"] = strconv.FormatBool(c.trackRescheduledPods)
+	env["TRACK_POD_NODE"] = strconv.FormatBool(c.trackPodNode)
 	env["TRACKING_RESOURCE_TYPE"] = c.trackingResource.GetResourceType()
+	env["COUCHBASE_API_VERSION"] = c.couchbaseAPIVersion
+	env["INSTANCE_NAME_FROM"] = string(c.instanceNameFrom)
+	env["TRACKING_FAILURE_POLICY"] = string(c.trackingFailurePolicy)
+	env["TRACKING_NOT_FOUND_RETRIES"] = strconv.Itoa(c.trackingNotFoundRetries)
+	env["TRACKING_NOT_FOUND_RETRY_INTERVAL"] = c.trackingNotFoundInterval.String()
+	env["TRACKING_ANNOTATION_MAX_AGE"] = c.trackingAnnotationMaxAge.String()
+	env["READY_REQUIRE_TRACKING_CRD"] = strconv.FormatBool(c.readyRequireTrackingCRD)
+	env["DEEP_READINESS"] = strconv.FormatBool(c.deepReadiness)
+	env["READINESS_CANARY"] = c.readinessCanary
+	env["DENY_NEAR_ANNOTATION_LIMIT"] = strconv.FormatBool(c.denyNearAnnotationLimit)
+	env["ALLOW_TERMINATING_INSTANCE"] = strconv.FormatBool(c.allowTerminatingInstance)
+	env["TRACKING_RESOURCE_STATUS_PATH"] = c.trackingStatusPath
+	env["TRACKING_RESOURCE_NOT_READY_STATUSES"] = strings.Join(c.trackingNotReadyStatuses, ",")
+	if c.trackingPredicate != nil {
+		env["TRACKING_PREDICATE"] = c.trackingPredicate.String()
+	}
+	env["NAMESPACE_TRACK_ANNOTATION"] = c.namespaceTrackAnnotation
+	env["NAMESPACE_TRACKING_SCOPE_LABEL"] = c.namespaceScopeLabel
+	env["LOG_LEVEL"] = c.logLevel.String()
+	env["FORCE_TRACKING_ANNOTATION"] = c.forceTrackingAnnotation
+	env["DISABLE_TRACKING_ANNOTATION"] = c.disableTrackingAnnotation
+	env["APPROVAL_ANNOTATION"] = c.approvalAnnotation
+	env["RESCHEDULE_DONE_ANNOTATION"] = c.rescheduleDoneAnnotation
+	env["IGNORE_OWNER_KINDS"] = strings.Join(c.ignoreOwnerKinds, ",")
+	env["STATEFUL_OWNER_KINDS"] = strings.Join(c.statefulOwnerKinds, ",")
+	env["RATE_LIMIT"] = strconv.FormatFloat(c.rateLimit, 'f', -1, 64)
+	env["RATE_LIMIT_BURST"] = strconv.Itoa(c.rateLimitBurst)
+	env["ROOT_OK"] = strconv.FormatBool(c.rootOK)
+	env["BLOCK_EVICTION"] = strconv.FormatBool(c.blockEviction)
+	env["REPORT_PENDING_PEERS"] = strconv.FormatBool(c.reportPendingPeers)
+	env["TRACK_ATTEMPTS"] = strconv.FormatBool(c.trackAttempts)
+	env["RECORD_FROM_NODE"] = strconv.FormatBool(c.recordFromNode)
+	env["CA_BUNDLE_FILE"] = c.caBundleFile
+	env["CA_CHECK_INTERVAL"] = c.caCheckInterval.String()
+	env["VALIDATE_CA_BUNDLE"] = strconv.FormatBool(c.validateCABundle)
+	env["WEBHOOK_CONFIG_NAME"] = c.webhookConfigName
+	env["CLEANUP_POD_ANNOTATIONS"] = strconv.FormatBool(c.cleanupPodAnnotations)
+	env["VERIFY_ANNOTATION"] = strconv.FormatBool(c.verifyAnnotation)
+	env["TRUST_WEBHOOK_SELECTOR"] = strconv.FormatBool(c.trustWebhookSelector)
+	env["PROTECT_ONLY_STATEFUL"] = strconv.FormatBool(c.protectOnlyStateful)
+	env["PROTECT_PENDING_INSTANCE_PODS"] = strconv.FormatBool(c.protectPendingInstance)
+	env["ONLY_DRAINING_NODES"] = strconv.FormatBool(c.onlyDrainingNodes)
+	env["REQUIRE_NODE_DRAIN_ANNOTATION"] = c.nodeDrainAnnotation
+	env["NODE_DRAIN_ANNOTATION_VALUE"] = c.nodeDrainAnnotationValue
+	env["MIN_CLUSTER_SIZE"] = strconv.Itoa(c.minClusterSize)
+	env["PRIORITY_ANNOTATION"] = c.priorityAnnotation
+	env["ROLLOUT_PERCENT"] = strconv.Itoa(c.rolloutPercent)
+	env["CIRCUIT_BREAKER_THRESHOLD"] = strconv.Itoa(c.circuitBreakerThreshold)
+	env["CIRCUIT_BREAKER_WINDOW"] = c.circuitBreakerWindow.String()
+	env["CIRCUIT_BREAKER_COOLDOWN"] = c.circuitBreakerCooldown.String()
+	env["ADMIN_ENDPOINTS"] = strconv.FormatBool(c.adminEndpoints)
+	env["HEALTH_ADDR"] = c.healthAddr
+	env["ADMIN_TOKEN"] = c.adminToken
+	if c.protectOrdinals != nil {
+		env["PROTECT_ORDINAL_RANGE"] = c.protectOrdinals.String()
+	}
+	if c.activeWindows != nil {
+		env["ACTIVE_WINDOWS"] = c.activeWindows.String()
+		env["ACTIVE_WINDOWS_TIMEZONE"] = c.activeWindows.Timezone()
+	}
+	env["HTTP_READ_TIMEOUT"] = c.readTimeout.String()
+	env["HTTP_WRITE_TIMEOUT"] = c.writeTimeout.String()
+	env["HTTP_IDLE_TIMEOUT"] = c.idleTimeout.String()
+	env["SHUTDOWN_DELAY"] = c.shutdownDelay.String()
+	env["FIRST_ANNOTATION_DELAY"] = c.firstAnnotationDelay.String()
+	env["POST_RESCHEDULE_WAIT"] = c.postRescheduleWait.String()
+	env["REQUEST_TIMEOUT"] = c.requestTimeout.String()
+	env["REGISTRY_CONFIGMAP"] = c.registryConfigMap
+	env["REGISTRY_SAVE_INTERVAL"] = c.registrySaveInterval.String()
+	env["NOTIFY_URL"] = c.notifyURL
+	env["NOTIFY_TIMEOUT"] = c.notifyTimeout.String()
+	env["TELEMETRY_TIMEOUT"] = c.telemetryTimeout.String()
+	env["DECISION_LOG"] = string(c.decisionLog)
+	env["DECISION_JSON_CASE"] = string(c.decisionJSONCase)
+	env["METRICS_INSTANCE_LIMIT"] = strconv.Itoa(c.metricsInstanceLimit)
+	env["CLIENT_QPS"] = strconv.FormatFloat(c.clientQPS, 'f', -1, 64)
+	env["CLIENT_BURST"] = strconv.Itoa(c.clientBurst)
+	env["USE_TYPED_POD_CLIENT"] = strconv.FormatBool(c.useTypedPodClient)
+	env["ANNOTATE_OUTSIDE_ACTIVE_WINDOWS"] = strconv.FormatBool(c.annotateOutsideWindows)
+	if len(c.rescheduleAnnotations) > 0 {
+		env["RESCHEDULE_ANNOTATIONS"] = encodeAnnotations(c.rescheduleAnnotations)
+	}
 	return env
 }
 
+// Print logs the effective config at startup. The contents of the TLS certificate and key and the admin token are never logged.
 func (c *Config) Print() {
-	slog.Info("Config loaded",
-		"rescheduleAnnotationKey", c.rescheduleAnnotationKey,
-		"rescheduleAnnotationValue", c.rescheduleAnnotationValue,
-		"podLabelSelectorKey", c.podLabelSelectorKey,
-		"podLabelSelectorValue", c.podLabelSelectorValue,
-		"trackRescheduledPods", c.trackRescheduledPods,
-		"trackingResource", c.trackingResource.GetResourceType())
+	slog.LogAttrs(context.Background(), slog.LevelInfo, "Config loaded", c.attrs()...)
+}
+
+// String returns a representation of the config suitable for logging and diffing. The TLS certificate and key file paths and the admin token are omitted.
+func (c *Config) String() string {
+	attrs := c.attrs()
+	fields := make([]string, 0, len(attrs))
+	for _, attr := range attrs {
+		fields = append(fields, attr.String())
+	}
+
+	return strings.Join(fields, " ")
+}
+
+// attrs returns the structured fields of the config used by Print and String
+func (c *Config) attrs() []slog.Attr {
+	return []slog.Attr{
+		slog.String("podLabelSelectorKey", c.podLabelSelectorKey),
+		slog.String("podLabelSelectorValue", c.podLabelSelectorValue),
+		slog.String("podLabelExcludeSelector", selectorString(c.excludeSelector)),
+		slog.String("operatorPodSelector", selectorString(c.operatorPodSelector)),
+		slog.String("rescheduleAnnotationKey", c.rescheduleAnnotationKey),
+		slog.String("rescheduleAnnotationValue", c.rescheduleAnnotationValue),
+		slog.String("rescheduleAnnotations", encodeAnnotations(c.rescheduleAnnotations)),
+		slog.String("rescheduleMarkerType", string(c.rescheduleMarkerType)),
+		slog.String("annotationKeyPrefix", c.annotationKeyPrefix),
+		slog.Bool("trackRescheduledPods", c.trackRescheduledPods),
+		slog.Bool("trackPodNode", c.trackPodNode),
+		slog.String("trackingResource", trackingResourceType(c.trackingResource)),
+		slog.String("couchbaseAPIVersion", c.couchbaseAPIVersion),
+		slog.String("instanceNameFrom", string(c.instanceNameFrom)),
+		slog.String("trackingPredicate", c.trackingPredicate.String()),
+		slog.String("namespaceTrackAnnotation", c.namespaceTrackAnnotation),
+		slog.String("namespaceScopeLabel", c.namespaceScopeLabel),
+		slog.String("trackingFailurePolicy", string(c.trackingFailurePolicy)),
+		slog.Int("trackingNotFoundRetries", c.trackingNotFoundRetries),
+		slog.Duration("trackingNotFoundInterval", c.trackingNotFoundInterval),
+		slog.Duration("trackingAnnotationMaxAge", c.trackingAnnotationMaxAge),
+		slog.Bool("readyRequireTrackingCRD", c.readyRequireTrackingCRD),
+		slog.Bool("deepReadiness", c.deepReadiness),
+		slog.String("readinessCanary", c.readinessCanary),
+		slog.Bool("denyNearAnnotationLimit", c.denyNearAnnotationLimit),
+		slog.Bool("allowTerminatingInstance", c.allowTerminatingInstance),
+		slog.String("trackingStatusPath", c.trackingStatusPath),
+		slog.String("trackingNotReadyStatuses", strings.Join(c.trackingNotReadyStatuses, ",")),
+		slog.String("forceTrackingAnnotation", c.forceTrackingAnnotation),
+		slog.String("disableTrackingAnnotation", c.disableTrackingAnnotation),
+		slog.String("approvalAnnotation", c.approvalAnnotation),
+		slog.String("rescheduleDoneAnnotation", c.rescheduleDoneAnnotation),
+		slog.String("logLevel", c.logLevel.String()),
+		slog.String("ignoreOwnerKinds", strings.Join(c.ignoreOwnerKinds, ",")),
+		slog.String("statefulOwnerKinds", strings.Join(c.statefulOwnerKinds, ",")),
+		slog.Float64("rateLimit", c.rateLimit),
+		slog.Int("rateLimitBurst", c.rateLimitBurst),
+		slog.Bool("rootOK", c.rootOK),
+		slog.Bool("blockEviction", c.blockEviction),
+		slog.Bool("reportPendingPeers", c.reportPendingPeers),
+		slog.Bool("trackAttempts", c.trackAttempts),
+		slog.Bool("recordFromNode", c.recordFromNode),
+		slog.String("caBundleFile", c.caBundleFile),
+		slog.Duration("caCheckInterval", c.caCheckInterval),
+		slog.Bool("validateCABundle", c.validateCABundle),
+		slog.String("webhookConfigName", c.webhookConfigName),
+		slog.Bool("cleanupPodAnnotations", c.cleanupPodAnnotations),
+		slog.Bool("verifyAnnotation", c.verifyAnnotation),
+		slog.Bool("trustWebhookSelector", c.trustWebhookSelector),
+		slog.Bool("protectOnlyStateful", c.protectOnlyStateful),
+		slog.Bool("protectPendingInstance", c.protectPendingInstance),
+		slog.Bool("onlyDrainingNodes", c.onlyDrainingNodes),
+		slog.String("nodeDrainAnnotation", c.nodeDrainAnnotation),
+		slog.String("nodeDrainAnnotationValue", c.nodeDrainAnnotationValue),
+		slog.Int("minClusterSize", c.minClusterSize),
+		slog.String("priorityAnnotation", c.priorityAnnotation),
+		slog.Int("rolloutPercent", c.rolloutPercent),
+		slog.Int("circuitBreakerThreshold", c.circuitBreakerThreshold),
+		slog.Duration("circuitBreakerWindow", c.circuitBreakerWindow),
+		slog.Duration("circuitBreakerCooldown", c.circuitBreakerCooldown),
+		slog.String("protectOrdinalRange", c.protectOrdinals.String()),
+		slog.Bool("adminEndpoints", c.adminEndpoints),
+		slog.String("healthAddr", c.healthAddr),
+		slog.Duration("firstAnnotationDelay", c.firstAnnotationDelay),
+		slog.Duration("postRescheduleWait", c.postRescheduleWait),
+		slog.Duration("requestTimeout", c.requestTimeout),
+		slog.String("registryConfigMap", c.registryConfigMap),
+		slog.Duration("registrySaveInterval", c.registrySaveInterval),
+		slog.String("notifyURL", c.notifyURL),
+		slog.Duration("notifyTimeout", c.notifyTimeout),
+		slog.Duration("telemetryTimeout", c.telemetryTimeout),
+		slog.String("decisionLog", string(c.decisionLog)),
+		slog.String("decisionJSONCase", string(c.decisionJSONCase)),
+		slog.Int("metricsInstanceLimit", c.metricsInstanceLimit),
+		slog.Float64("clientQPS", c.clientQPS),
+		slog.Int("clientBurst", c.clientBurst),
+		slog.Bool("useTypedPodClient", c.useTypedPodClient),
+		slog.String("activeWindows", c.activeWindows.String()),
+		slog.String("activeWindowsTimezone", c.activeWindows.Timezone()),
+		slog.Bool("annotateOutsideActiveWindows", c.annotateOutsideWindows),
+		slog.Duration("readTimeout", c.readTimeout),
+		slog.Duration("writeTimeout", c.writeTimeout),
+		slog.Duration("idleTimeout", c.idleTimeout),
+		slog.Duration("shutdownTimeout", c.shutdownTimeout),
+		slog.Duration("shutdownDelay", c.shutdownDelay),
+	}
+}
+
+// Validate checks that the config can be used by the server. Annotation keys must be valid Kubernetes qualified names,
+// otherwise any patch using them will be rejected by the API server.
+func (c *Config) Validate() error {
+	if err := validateAnnotationKey("RESCHEDULE_ANNOTATION_KEY", c.rescheduleAnnotationKey); err != nil {
+		return err
+	}
+
+	keys := slices.Sorted(maps.Keys(c.rescheduleAnnotations))
+	for _, key := range keys {
+		if err := validateAnnotationKey("RESCHEDULE_ANNOTATIONS", key); err != nil {
+			return err
+		}
+	}
+
+	switch c.rescheduleMarkerType {
+	case RescheduleMarkerAnnotation:
+	case RescheduleMarkerLabel:
+		// Label values are restricted to short qualified names, unlike annotation values
+		set := c.rescheduleAnnotationSet()
+		for _, key := range slices.Sorted(maps.Keys(set)) {
+			if errs := validation.IsValidLabelValue(set[key]); len(errs) > 0 {
+				return fmt.Errorf("invalid label value %q for reschedule marker %s: %s", set[key], key, strings.Join(errs, ", "))
+			}
+		}
+	default:
+		return fmt.Errorf("invalid RESCHEDULE_MARKER_TYPE %q, must be %s or %s", c.rescheduleMarkerType, RescheduleMarkerAnnotation, RescheduleMarkerLabel)
+	}
+
+	if c.excludeSelectorErr != nil {
+		return c.excludeSelectorErr
+	}
+
+	if c.operatorPodSelectorErr != nil {
+		return c.operatorPodSelectorErr
+	}
+
+	if c.activeWindowsErr != nil {
+		return c.activeWindowsErr
+	}
+
+	if c.adminEndpoints && c.adminToken == "" {
+		return errors.New("ADMIN_TOKEN must be set when ADMIN_ENDPOINTS is enabled")
+	}
+
+	if c.forceTrackingAnnotation != "" {
+		if err := validateAnnotationKey("FORCE_TRACKING_ANNOTATION", c.forceTrackingAnnotation); err != nil {
+			return err
+		}
+	}
+
+	if c.disableTrackingAnnotation != "" {
+		if err := validateAnnotationKey("DISABLE_TRACKING_ANNOTATION", c.disableTrackingAnnotation); err != nil {
+			return err
+		}
+	}
+
+	if c.approvalAnnotation != "" {
+		if err := validateAnnotationKey("APPROVAL_ANNOTATION", c.approvalAnnotation); err != nil {
+			return err
+		}
+	}
+
+	if c.namespaceTrackAnnotation != "" {
+		if err := validateAnnotationKey("NAMESPACE_TRACK_ANNOTATION", c.namespaceTrackAnnotation); err != nil {
+			return err
+		}
+	}
+
+	if c.namespaceScopeLabel != "" {
+		if err := validateAnnotationKey("NAMESPACE_TRACKING_SCOPE_LABEL", c.namespaceScopeLabel); err != nil {
+			return err
+		}
+	}
+
+	if c.rescheduleDoneAnnotation != "" {
+		if err := validateAnnotationKey("RESCHEDULE_DONE_ANNOTATION", c.rescheduleDoneAnnotation); err != nil {
+			return err
+		}
+	}
+
+	if c.nodeDrainAnnotation != "" {
+		if err := validateAnnotationKey("REQUIRE_NODE_DRAIN_ANNOTATION", c.nodeDrainAnnotation); err != nil {
+			return err
+		}
+	} else if c.nodeDrainAnnotationValue != "" {
+		return fmt.Errorf("NODE_DRAIN_ANNOTATION_VALUE requires REQUIRE_NODE_DRAIN_ANNOTATION to be set")
+	}
+
+	if c.trackingStatusPath != "" {
+		if slices.Contains(strings.Split(c.trackingStatusPath, "."), "") {
+			return fmt.Errorf("invalid TRACKING_RESOURCE_STATUS_PATH %q, must be a dot separated field path such as status.phase", c.trackingStatusPath)
+		}
+
+		if len(c.trackingNotReadyStatuses) == 0 {
+			return fmt.Errorf("TRACKING_RESOURCE_STATUS_PATH requires TRACKING_RESOURCE_NOT_READY_STATUSES to be set")
+		}
+	} else if len(c.trackingNotReadyStatuses) > 0 {
+		return fmt.Errorf("TRACKING_RESOURCE_NOT_READY_STATUSES requires TRACKING_RESOURCE_STATUS_PATH to be set")
+	}
+
+	if c.priorityAnnotation != "" {
+		if err := validateAnnotationKey("PRIORITY_ANNOTATION", c.priorityAnnotation); err != nil {
+			return err
+		}
+	}
+
+	timeouts := []struct {
+		name    string
+		timeout time.Duration
+	}{
+		{"HTTP_READ_TIMEOUT", c.readTimeout},
+		{"HTTP_WRITE_TIMEOUT", c.writeTimeout},
+		{"HTTP_IDLE_TIMEOUT", c.idleTimeout},
+	}
+	if c.timeoutErr != nil {
+		return c.timeoutErr
+	}
+
+	for _, timeout := range timeouts {
+		if timeout.timeout <= 0 {
+			return fmt.Errorf("%s must be a positive duration, got %s", timeout.name, timeout.timeout)
+		}
+	}
+
+	// The wait is part of handling the request, so the response could not be written if it took up the whole write timeout
+	if c.postRescheduleWait < 0 || c.postRescheduleWait >= c.writeTimeout {
+		return fmt.Errorf("POST_RESCHEDULE_WAIT must not be negative and must be shorter than HTTP_WRITE_TIMEOUT, got %s", c.postRescheduleWait)
+	}
+
+	if c.requestTimeout < 0 {
+		return fmt.Errorf("REQUEST_TIMEOUT must not be negative, got %s", c.requestTimeout)
+	}
+
+	if c.healthAddr != "" {
+		if _, _, err := net.SplitHostPort(c.healthAddr); err != nil {
+			return fmt.Errorf("invalid HEALTH_ADDR: %w", err)
+		}
+	}
+
+	if c.deepReadiness {
+		if namespace, name := c.readinessCanaryName(); name == "" || (namespace == "") == c.trackingResource.IsNamespaced() {
+			return fmt.Errorf("invalid READINESS_CANARY %q, expected <namespace>/<name> for namespaced tracking resources or <name> otherwise", c.readinessCanary)
+		}
+	}
+
+	if c.validateCABundle && c.webhookConfigName == "" {
+		return errors.New("WEBHOOK_CONFIG_NAME must be set when VALIDATE_CA_BUNDLE is enabled")
+	}
+
+	if c.trackingNotFoundRetries < 0 {
+		return fmt.Errorf("TRACKING_NOT_FOUND_RETRIES must not be negative, got %d", c.trackingNotFoundRetries)
+	}
+
+	if c.minClusterSize < 0 {
+		return fmt.Errorf("MIN_CLUSTER_SIZE must not be negative, got %d", c.minClusterSize)
+	}
+
+	if c.rolloutPercent < 0 || c.rolloutPercent > 100 {
+		return fmt.Errorf("ROLLOUT_PERCENT must be between 0 and 100, got %d", c.rolloutPercent)
+	}
+
+	if c.registryConfigMap != "" {
+		if _, _, err := splitNamespacedName(c.registryConfigMap); err != nil {
+			return fmt.Errorf("invalid REGISTRY_CONFIGMAP: %w", err)
+		}
+	}
+
+	if c.registrySaveInterval <= 0 {
+		return fmt.Errorf("REGISTRY_SAVE_INTERVAL must be positive, got %s", c.registrySaveInterval)
+	}
+
+	if c.notifyURL != "" {
+		if parsed, err := url.Parse(c.notifyURL); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("NOTIFY_URL must be an absolute http or https URL, got %q", c.notifyURL)
+		}
+	}
+
+	if c.metricsInstanceLimit < 0 {
+		return fmt.Errorf("METRICS_INSTANCE_LIMIT must not be negative, got %d", c.metricsInstanceLimit)
+	}
+
+	if c.telemetryTimeout <= 0 {
+		return fmt.Errorf("TELEMETRY_TIMEOUT must be positive, got %s", c.telemetryTimeout)
+	}
+
+	if c.clientQPS <= 0 {
+		return fmt.Errorf("CLIENT_QPS must be positive, got %v", c.clientQPS)
+	}
+
+	if c.clientBurst <= 0 {
+		return fmt.Errorf("CLIENT_BURST must be positive, got %d", c.clientBurst)
+	}
+
+	if c.decisionJSONCase != "" && c.decisionJSONCase != JSONCaseSnake && c.decisionJSONCase != JSONCaseCamel {
+		return fmt.Errorf("invalid DECISION_JSON_CASE %q, must be %s or %s", c.decisionJSONCase, JSONCaseSnake, JSONCaseCamel)
+	}
+
+	if c.decisionLog != DecisionLogStdout && c.decisionLog != DecisionLogOff {
+		return fmt.Errorf("invalid DECISION_LOG %q, must be %s or %s", c.decisionLog, DecisionLogStdout, DecisionLogOff)
+	}
+
+	return nil
+}
+
+// isExcluded checks whether pod labels match the exclude selector
+func (c *Config) isExcluded(podLabels map[string]string) bool {
+	return c.excludeSelector != nil && !c.excludeSelector.Empty() && c.excludeSelector.Matches(labels.Set(podLabels))
+}
+
+// isOperatorPod checks whether pod labels match the operator pod selector
+func (c *Config) isOperatorPod(podLabels map[string]string) bool {
+	return c.operatorPodSelector != nil && !c.operatorPodSelector.Empty() && c.operatorPodSelector.Matches(labels.Set(podLabels))
+}
+
+// isSelected checks whether pods with the given labels are selected by the pod label. When pod selection is left to the
+// webhook's objectSelector, every pod is treated as selected.
+func (c *Config) isSelected(podLabels map[string]string) bool {
+	return c.trustWebhookSelector || podLabels[c.podLabelSelectorKey] == c.podLabelSelectorValue
+}
+
+// trackingControlAnnotations returns the annotation keys on a tracking resource instance that share the tracking annotation prefix
+// but configure tracking rather than track a pod
+func (c *Config) trackingControlAnnotations() []string {
+	return []string{c.forceTrackingAnnotation, c.disableTrackingAnnotation, c.namespaceTrackAnnotation}
+}
+
+// selectorString returns the string form of a selector, or an empty string if it is not set
+func selectorString(selector labels.Selector) string {
+	if selector == nil {
+		return ""
+	}
+
+	return selector.String()
+}
+
+// readinessCanaryName returns the namespace and name of the tracking resource instance checked by the deep readiness check. The
+// namespace is empty if the canary is given without one.
+func (c *Config) readinessCanaryName() (string, string) {
+	if namespace, name, ok := strings.Cut(c.readinessCanary, "/"); ok {
+		return namespace, name
+	}
+
+	return "", c.readinessCanary
+}
+
+// rescheduleAnnotationSet returns the annotations used to mark a pod for rescheduling. If RESCHEDULE_ANNOTATIONS is set it
+// supersedes the single RESCHEDULE_ANNOTATION_KEY and RESCHEDULE_ANNOTATION_VALUE.
+func (c *Config) rescheduleAnnotationSet() map[string]string {
+	if len(c.rescheduleAnnotations) > 0 {
+		return c.rescheduleAnnotations
+	}
+
+	return map[string]string{c.rescheduleAnnotationKey: c.rescheduleAnnotationValue}
+}
+
+// encodeAnnotations returns the JSON representation of a set of annotations, or an empty string if there are none
+func encodeAnnotations(annotations map[string]string) string {
+	if len(annotations) == 0 {
+		return ""
+	}
+
+	data, err := json.Marshal(annotations)
+	if err != nil {
+		return ""
+	}
+
+	return string(data)
+}
+
+// normalizeAnnotationKey adds prefix to an annotation key without one. If there is no prefix to add, a warning is logged, as
+// keys without a prefix are easily confused with those of other tools. A warning is also logged if the key uses a prefix
+// reserved for Kubernetes components.
+func normalizeAnnotationKey(name, key, prefix string) string {
+	if key == "" {
+		return key
+	}
+
+	keyPrefix, _, hasPrefix := strings.Cut(key, "/")
+	if !hasPrefix {
+		if prefix == "" {
+			slog.Warn("Annotation key has no prefix, set ANNOTATION_KEY_PREFIX to add one", "option", name, "key", key)
+			return key
+		}
+
+		normalized := prefix + "/" + key
+		slog.Info("Adding prefix to annotation key", "option", name, "key", key, "normalized", normalized)
+		return normalized
+	}
+
+	if isReservedAnnotationPrefix(keyPrefix) {
+		slog.Warn("Annotation key uses a prefix reserved for Kubernetes components", "option", name, "key", key)
+	}
+
+	return key
+}
+
+// isReservedAnnotationPrefix checks whether an annotation prefix is kubernetes.io, k8s.io or one of their subdomains
+func isReservedAnnotationPrefix(prefix string) bool {
+	for _, reserved := range []string{"kubernetes.io", "k8s.io"} {
+		if prefix == reserved || strings.HasSuffix(prefix, "."+reserved) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func validateAnnotationKey(name, key string) error {
+	if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+		return fmt.Errorf("invalid %s %q: %s", name, key, strings.Join(errs, ", "))
+	}
+
+	return nil
+}
+
+// Equal checks whether two configs have the same values. Tracking resources are compared by their resource type and clocks
+// are not compared.
+func (c *Config) Equal(other *Config) bool {
+	if c == nil || other == nil {
+		return c == other
+	}
+
+	return c.rescheduleAnnotationKey == other.rescheduleAnnotationKey &&
+		c.rescheduleAnnotationValue == other.rescheduleAnnotationValue &&
+		maps.Equal(c.rescheduleAnnotations, other.rescheduleAnnotations) &&
+		c.rescheduleMarkerType == other.rescheduleMarkerType &&
+		c.annotationKeyPrefix == other.annotationKeyPrefix &&
+		c.trackRescheduledPods == other.trackRescheduledPods &&
+		c.trackPodNode == other.trackPodNode &&
+		c.podLabelSelectorKey == other.podLabelSelectorKey &&
+		c.podLabelSelectorValue == other.podLabelSelectorValue &&
+		selectorString(c.excludeSelector) == selectorString(other.excludeSelector) &&
+		selectorString(c.operatorPodSelector) == selectorString(other.operatorPodSelector) &&
+		c.certFile == other.certFile &&
+		c.keyFile == other.keyFile &&
+		trackingResourceType(c.trackingResource) == trackingResourceType(other.trackingResource) &&
+		c.couchbaseAPIVersion == other.couchbaseAPIVersion &&
+		c.instanceNameFrom == other.instanceNameFrom &&
+		c.trackingPredicate.String() == other.trackingPredicate.String() &&
+		c.namespaceTrackAnnotation == other.namespaceTrackAnnotation &&
+		c.namespaceScopeLabel == other.namespaceScopeLabel &&
+		c.trackingFailurePolicy == other.trackingFailurePolicy &&
+		c.trackingNotFoundRetries == other.trackingNotFoundRetries &&
+		c.trackingNotFoundInterval == other.trackingNotFoundInterval &&
+		c.trackingAnnotationMaxAge == other.trackingAnnotationMaxAge &&
+		c.readyRequireTrackingCRD == other.readyRequireTrackingCRD &&
+		c.deepReadiness == other.deepReadiness &&
+		c.readinessCanary == other.readinessCanary &&
+		c.denyNearAnnotationLimit == other.denyNearAnnotationLimit &&
+		c.allowTerminatingInstance == other.allowTerminatingInstance &&
+		c.trackingStatusPath == other.trackingStatusPath &&
+		slices.Equal(c.trackingNotReadyStatuses, other.trackingNotReadyStatuses) &&
+		c.logLevel == other.logLevel &&
+		c.forceTrackingAnnotation == other.forceTrackingAnnotation &&
+		c.disableTrackingAnnotation == other.disableTrackingAnnotation &&
+		c.approvalAnnotation == other.approvalAnnotation &&
+		c.rescheduleDoneAnnotation == other.rescheduleDoneAnnotation &&
+		c.readTimeout == other.readTimeout &&
+		c.writeTimeout == other.writeTimeout &&
+		c.idleTimeout == other.idleTimeout &&
+		c.shutdownTimeout == other.shutdownTimeout &&
+		c.shutdownDelay == other.shutdownDelay &&
+		slices.Equal(c.ignoreOwnerKinds, other.ignoreOwnerKinds) &&
+		slices.Equal(c.statefulOwnerKinds, other.statefulOwnerKinds) &&
+		c.rateLimit == other.rateLimit &&
+		c.rateLimitBurst == other.rateLimitBurst &&
+		c.rootOK == other.rootOK &&
+		c.blockEviction == other.blockEviction &&
+		c.reportPendingPeers == other.reportPendingPeers &&
+		c.trackAttempts == other.trackAttempts &&
+		c.recordFromNode == other.recordFromNode &&
+		c.caBundleFile == other.caBundleFile &&
+		c.caCheckInterval == other.caCheckInterval &&
+		c.validateCABundle == other.validateCABundle &&
+		c.webhookConfigName == other.webhookConfigName &&
+		c.cleanupPodAnnotations == other.cleanupPodAnnotations &&
+		c.verifyAnnotation == other.verifyAnnotation &&
+		c.trustWebhookSelector == other.trustWebhookSelector &&
+		c.protectOnlyStateful == other.protectOnlyStateful &&
+		c.protectPendingInstance == other.protectPendingInstance &&
+		c.onlyDrainingNodes == other.onlyDrainingNodes &&
+		c.nodeDrainAnnotation == other.nodeDrainAnnotation &&
+		c.nodeDrainAnnotationValue == other.nodeDrainAnnotationValue &&
+		c.minClusterSize == other.minClusterSize &&
+		c.priorityAnnotation == other.priorityAnnotation &&
+		c.rolloutPercent == other.rolloutPercent &&
+		c.circuitBreakerThreshold == other.circuitBreakerThreshold &&
+		c.circuitBreakerWindow == other.circuitBreakerWindow &&
+		c.circuitBreakerCooldown == other.circuitBreakerCooldown &&
+		c.protectOrdinals.String() == other.protectOrdinals.String() &&
+		c.adminEndpoints == other.adminEndpoints &&
+		c.healthAddr == other.healthAddr &&
+		c.adminToken == other.adminToken &&
+		c.firstAnnotationDelay == other.firstAnnotationDelay &&
+		c.postRescheduleWait == other.postRescheduleWait &&
+		c.requestTimeout == other.requestTimeout &&
+		c.registryConfigMap == other.registryConfigMap &&
+		c.registrySaveInterval == other.registrySaveInterval &&
+		c.notifyURL == other.notifyURL &&
+		c.notifyTimeout == other.notifyTimeout &&
+		c.telemetryTimeout == other.telemetryTimeout &&
+		c.decisionLog == other.decisionLog &&
+		c.decisionJSONCase == other.decisionJSONCase &&
+		c.metricsInstanceLimit == other.metricsInstanceLimit &&
+		c.clientQPS == other.clientQPS &&
+		c.clientBurst == other.clientBurst &&
+		c.useTypedPodClient == other.useTypedPodClient &&
+		c.activeWindows.String() == other.activeWindows.String() &&
+		c.activeWindows.Timezone() == other.activeWindows.Timezone() &&
+		c.annotateOutsideWindows == other.annotateOutsideWindows
+}
+
+func trackingResourceType(trackingResource tracking.TrackingResource) string {
+	if trackingResource == nil {
+		return ""
+	}
+
+	return trackingResource.GetResourceType()
 }
 
 // ConfigBuilder helps construct a Config with validation
@@ -65,12 +944,43 @@ func NewConfigBuilder() *ConfigBuilder {
 		config: Config{
 			rescheduleAnnotationKey:   DefaultRescheduleAnnotationKey,
 			rescheduleAnnotationValue: DefaultRescheduleAnnotationValue,
+			rescheduleMarkerType:      DefaultRescheduleMarkerType,
 			podLabelSelectorKey:       DefaultPodLabelSelectorKey,
 			podLabelSelectorValue:     DefaultPodLabelSelectorValue,
 			certFile:                  DefaultCertFile,
 			keyFile:                   DefaultKeyFile,
 			trackRescheduledPods:      true,
 			trackingResource:          tracking.GetTrackingResource(DefaultTrackingResourceType),
+			couchbaseAPIVersion:       tracking.DefaultCouchbaseAPIVersion,
+			instanceNameFrom:          DefaultInstanceNameFrom,
+			logLevel:                  DefaultLogLevel,
+			forceTrackingAnnotation:   DefaultForceTrackingAnnotation,
+			disableTrackingAnnotation: DefaultDisableTrackingAnnotation,
+			approvalAnnotation:        DefaultApprovalAnnotation,
+			readTimeout:               DefaultReadTimeout,
+			writeTimeout:              DefaultWriteTimeout,
+			idleTimeout:               DefaultIdleTimeout,
+			shutdownTimeout:           DefaultShutdownTimeout,
+			rateLimit:                 DefaultRateLimit,
+			rateLimitBurst:            DefaultRateLimitBurst,
+			blockEviction:             true,
+			caCheckInterval:           DefaultCACheckInterval,
+			circuitBreakerThreshold:   DefaultCircuitBreakerThreshold,
+			circuitBreakerWindow:      DefaultCircuitBreakerWindow,
+			circuitBreakerCooldown:    DefaultCircuitBreakerCooldown,
+			clock:                     RealClock,
+			trackingFailurePolicy:     DefaultTrackingFailurePolicy,
+			trackingNotFoundRetries:   DefaultTrackingNotFoundRetries,
+			trackingNotFoundInterval:  DefaultTrackingNotFoundInterval,
+			registrySaveInterval:      DefaultRegistrySaveInterval,
+			notifyTimeout:             DefaultNotifyTimeout,
+			telemetryTimeout:          DefaultTelemetryTimeout,
+			decisionLog:               DefaultDecisionLog,
+			metricsInstanceLimit:      DefaultMetricsInstanceLimit,
+			clientQPS:                 DefaultClientQPS,
+			clientBurst:               DefaultClientBurst,
+			useTypedPodClient:         true,
+			rolloutPercent:            DefaultRolloutPercent,
 		},
 	}
 }
@@ -83,6 +993,12 @@ func (b *ConfigBuilder) FromEnvironment() *ConfigBuilder {
 	if val := os.Getenv("POD_LABEL_SELECTOR_VALUE"); val != "" {
 		b.config.podLabelSelectorValue = val
 	}
+	if val := os.Getenv("POD_LABEL_EXCLUDE_SELECTOR"); val != "" {
+		b.WithExcludeSelector(val)
+	}
+	if val := os.Getenv("OPERATOR_POD_SELECTOR"); val != "" {
+		b.WithOperatorPodSelector(val)
+	}
 	if val := os.Getenv("TLS_CERT_FILE"); val != "" {
 		b.config.certFile = val
 	}
@@ -95,12 +1011,360 @@ func (b *ConfigBuilder) FromEnvironment() *ConfigBuilder {
 	if val := os.Getenv("RESCHEDULE_ANNOTATION_VALUE"); val != "" {
 		b.config.rescheduleAnnotationValue = val
 	}
+	if val := os.Getenv("ANNOTATION_KEY_PREFIX"); val != "" {
+		b.config.annotationKeyPrefix = strings.TrimSuffix(val, "/")
+	}
+	if val := os.Getenv("RESCHEDULE_ANNOTATIONS"); val != "" {
+		var annotations map[string]string
+		if err := json.Unmarshal([]byte(val), &annotations); err == nil {
+			b.config.rescheduleAnnotations = annotations
+		} else {
+			slog.Warn("Invalid reschedule annotations, using RESCHEDULE_ANNOTATION_KEY and RESCHEDULE_ANNOTATION_VALUE", "annotations", val, "error", err)
+		}
+	}
+	if val := os.Getenv("RESCHEDULE_MARKER_TYPE"); val != "" {
+		if markerType, err := parseRescheduleMarkerType(val); err == nil {
+			b.config.rescheduleMarkerType = markerType
+		} else {
+			slog.Warn("Invalid reschedule marker type, defaulting to annotation", "error", err)
+		}
+	}
 	if val := os.Getenv("TRACK_RESCHEULED_PODS"); val != "" {
 		b.config.trackRescheduledPods, _ = strconv.ParseBool(val)
 	}
+	if val := os.Getenv("TRACK_POD_NODE"); val != "" {
+		b.config.trackPodNode, _ = strconv.ParseBool(val)
+	}
 	if val := os.Getenv("TRACKING_RESOURCE_TYPE"); val != "" {
 		b.config.trackingResource = tracking.GetTrackingResource(val)
 	}
+	if val := os.Getenv("FORCE_TRACKING_ANNOTATION"); val != "" {
+		b.config.forceTrackingAnnotation = val
+	}
+	if val := os.Getenv("DISABLE_TRACKING_ANNOTATION"); val != "" {
+		b.config.disableTrackingAnnotation = val
+	}
+	if val := os.Getenv("APPROVAL_ANNOTATION"); val != "" {
+		b.config.approvalAnnotation = val
+	}
+	if val := os.Getenv("RESCHEDULE_DONE_ANNOTATION"); val != "" {
+		b.config.rescheduleDoneAnnotation = val
+	}
+	if val := os.Getenv("IGNORE_OWNER_KINDS"); val != "" {
+		b.config.ignoreOwnerKinds = splitList(val)
+	}
+	if val := os.Getenv("STATEFUL_OWNER_KINDS"); val != "" {
+		b.config.statefulOwnerKinds = splitList(val)
+	}
+	if val := os.Getenv("RATE_LIMIT"); val != "" {
+		b.config.rateLimit, _ = strconv.ParseFloat(val, 64)
+	}
+	if val := os.Getenv("RATE_LIMIT_BURST"); val != "" {
+		if burst, err := strconv.Atoi(val); err == nil {
+			b.config.rateLimitBurst = burst
+		}
+	}
+	if val := os.Getenv("ROOT_OK"); val != "" {
+		b.config.rootOK, _ = strconv.ParseBool(val)
+	}
+	if val := os.Getenv("BLOCK_EVICTION"); val != "" {
+		b.config.blockEviction, _ = strconv.ParseBool(val)
+	}
+	if val := os.Getenv("REPORT_PENDING_PEERS"); val != "" {
+		b.config.reportPendingPeers, _ = strconv.ParseBool(val)
+	}
+	if val := os.Getenv("TRACK_ATTEMPTS"); val != "" {
+		b.config.trackAttempts, _ = strconv.ParseBool(val)
+	}
+	if val := os.Getenv("RECORD_FROM_NODE"); val != "" {
+		b.config.recordFromNode, _ = strconv.ParseBool(val)
+	}
+	if val := os.Getenv("CA_BUNDLE_FILE"); val != "" {
+		b.config.caBundleFile = val
+	}
+	if val := os.Getenv("CA_CHECK_INTERVAL"); val != "" {
+		if interval, err := time.ParseDuration(val); err == nil {
+			b.config.caCheckInterval = interval
+		} else {
+			slog.Warn("Invalid CA check interval, using default", "interval", val)
+		}
+	}
+	if val := os.Getenv("VALIDATE_CA_BUNDLE"); val != "" {
+		b.config.validateCABundle, _ = strconv.ParseBool(val)
+	}
+	if val := os.Getenv("WEBHOOK_CONFIG_NAME"); val != "" {
+		b.config.webhookConfigName = val
+	}
+	if val := os.Getenv("CLEANUP_POD_ANNOTATIONS"); val != "" {
+		b.config.cleanupPodAnnotations, _ = strconv.ParseBool(val)
+	}
+	if val := os.Getenv("VERIFY_ANNOTATION"); val != "" {
+		b.config.verifyAnnotation, _ = strconv.ParseBool(val)
+	}
+	if val := os.Getenv("TRUST_WEBHOOK_SELECTOR"); val != "" {
+		b.config.trustWebhookSelector, _ = strconv.ParseBool(val)
+	}
+	if val := os.Getenv("PROTECT_ONLY_STATEFUL"); val != "" {
+		b.config.protectOnlyStateful, _ = strconv.ParseBool(val)
+	}
+	if val := os.Getenv("PROTECT_PENDING_INSTANCE_PODS"); val != "" {
+		b.config.protectPendingInstance, _ = strconv.ParseBool(val)
+	}
+	if val := os.Getenv("ONLY_DRAINING_NODES"); val != "" {
+		b.config.onlyDrainingNodes, _ = strconv.ParseBool(val)
+	}
+	if val := os.Getenv("REQUIRE_NODE_DRAIN_ANNOTATION"); val != "" {
+		b.config.nodeDrainAnnotation = val
+	}
+	if val := os.Getenv("NODE_DRAIN_ANNOTATION_VALUE"); val != "" {
+		b.config.nodeDrainAnnotationValue = val
+	}
+	if val := os.Getenv("MIN_CLUSTER_SIZE"); val != "" {
+		if size, err := strconv.Atoi(val); err == nil {
+			b.config.minClusterSize = size
+		} else {
+			slog.Warn("Invalid minimum cluster size, using default", "size", val)
+		}
+	}
+	if val := os.Getenv("PRIORITY_ANNOTATION"); val != "" {
+		b.config.priorityAnnotation = val
+	}
+	if val := os.Getenv("ROLLOUT_PERCENT"); val != "" {
+		if percent, err := strconv.Atoi(val); err == nil {
+			b.config.rolloutPercent = percent
+		} else {
+			slog.Warn("Invalid rollout percent, using default", "percent", val, "default", DefaultRolloutPercent)
+		}
+	}
+	if val := os.Getenv("CIRCUIT_BREAKER_THRESHOLD"); val != "" {
+		if threshold, err := strconv.Atoi(val); err == nil {
+			b.config.circuitBreakerThreshold = threshold
+		}
+	}
+	if val := os.Getenv("CIRCUIT_BREAKER_WINDOW"); val != "" {
+		if window, err := time.ParseDuration(val); err == nil {
+			b.config.circuitBreakerWindow = window
+		} else {
+			slog.Warn("Invalid circuit breaker window, using default", "window", val)
+		}
+	}
+	if val := os.Getenv("CIRCUIT_BREAKER_COOLDOWN"); val != "" {
+		if cooldown, err := time.ParseDuration(val); err == nil {
+			b.config.circuitBreakerCooldown = cooldown
+		} else {
+			slog.Warn("Invalid circuit breaker cooldown, using default", "cooldown", val)
+		}
+	}
+	if val := os.Getenv("PROTECT_ORDINAL_RANGE"); val != "" {
+		if protectOrdinals, err := parseOrdinalRange(val); err == nil {
+			b.config.protectOrdinals = protectOrdinals
+		} else {
+			slog.Warn("Invalid protected ordinal range, protecting all pods", "error", err)
+		}
+	}
+	if val := os.Getenv("ADMIN_ENDPOINTS"); val != "" {
+		b.config.adminEndpoints, _ = strconv.ParseBool(val)
+	}
+	if val := os.Getenv("HEALTH_ADDR"); val != "" {
+		b.config.healthAddr = val
+	}
+	if val := os.Getenv("ADMIN_TOKEN"); val != "" {
+		b.config.adminToken = val
+	}
+	if val := os.Getenv("COUCHBASE_API_VERSION"); val != "" {
+		b.config.couchbaseAPIVersion = val
+	}
+	if val := os.Getenv("INSTANCE_NAME_FROM"); val != "" {
+		if from, err := parseInstanceNameFrom(val); err == nil {
+			b.config.instanceNameFrom = from
+		} else {
+			slog.Warn("Invalid instance name source, defaulting to pod", "error", err)
+		}
+	}
+	if val := os.Getenv("TRACKING_PREDICATE"); val != "" {
+		if predicate, err := tracking.ParseFieldPredicate(val); err == nil {
+			b.config.trackingPredicate = predicate
+		} else {
+			slog.Warn("Invalid tracking predicate, using the tracking resource default", "error", err)
+		}
+	}
+	if val := os.Getenv("NAMESPACE_TRACK_ANNOTATION"); val != "" {
+		b.config.namespaceTrackAnnotation = val
+	}
+	if val := os.Getenv("NAMESPACE_TRACKING_SCOPE_LABEL"); val != "" {
+		b.config.namespaceScopeLabel = val
+	}
+	if val := os.Getenv("TRACKING_FAILURE_POLICY"); val != "" {
+		if policy, err := parseTrackingFailurePolicy(val); err == nil {
+			b.config.trackingFailurePolicy = policy
+		} else {
+			slog.Warn("Invalid tracking failure policy, defaulting to Fail", "error", err)
+		}
+	}
+	if val := os.Getenv("TRACKING_NOT_FOUND_RETRIES"); val != "" {
+		if retries, err := strconv.Atoi(val); err == nil && retries >= 0 {
+			b.config.trackingNotFoundRetries = retries
+		} else {
+			slog.Warn("Invalid tracking not found retries, using default", "retries", val, "default", DefaultTrackingNotFoundRetries)
+		}
+	}
+	if val := os.Getenv("TRACKING_NOT_FOUND_RETRY_INTERVAL"); val != "" {
+		if interval, err := time.ParseDuration(val); err == nil && interval >= 0 {
+			b.config.trackingNotFoundInterval = interval
+		} else {
+			slog.Warn("Invalid tracking not found retry interval, using default", "interval", val, "default", DefaultTrackingNotFoundInterval)
+		}
+	}
+	if val := os.Getenv("TRACKING_ANNOTATION_MAX_AGE"); val != "" {
+		if maxAge, err := time.ParseDuration(val); err == nil && maxAge >= 0 {
+			b.config.trackingAnnotationMaxAge = maxAge
+		} else {
+			slog.Warn("Invalid tracking annotation max age, tracking annotations will not expire", "maxAge", val)
+		}
+	}
+	if val := os.Getenv("READY_REQUIRE_TRACKING_CRD"); val != "" {
+		b.config.readyRequireTrackingCRD, _ = strconv.ParseBool(val)
+	}
+	if val := os.Getenv("DEEP_READINESS"); val != "" {
+		b.config.deepReadiness, _ = strconv.ParseBool(val)
+	}
+	if val := os.Getenv("READINESS_CANARY"); val != "" {
+		b.config.readinessCanary = val
+	}
+	if val := os.Getenv("DENY_NEAR_ANNOTATION_LIMIT"); val != "" {
+		b.config.denyNearAnnotationLimit, _ = strconv.ParseBool(val)
+	}
+	if val := os.Getenv("ALLOW_TERMINATING_INSTANCE"); val != "" {
+		b.config.allowTerminatingInstance, _ = strconv.ParseBool(val)
+	}
+	if val := os.Getenv("TRACKING_RESOURCE_STATUS_PATH"); val != "" {
+		b.config.trackingStatusPath = val
+	}
+	if val := os.Getenv("TRACKING_RESOURCE_NOT_READY_STATUSES"); val != "" {
+		b.config.trackingNotReadyStatuses = splitList(val)
+	}
+	if val := os.Getenv("HTTP_READ_TIMEOUT"); val != "" {
+		b.parseTimeout("HTTP_READ_TIMEOUT", val, &b.config.readTimeout)
+	}
+	if val := os.Getenv("HTTP_WRITE_TIMEOUT"); val != "" {
+		b.parseTimeout("HTTP_WRITE_TIMEOUT", val, &b.config.writeTimeout)
+	}
+	if val := os.Getenv("HTTP_IDLE_TIMEOUT"); val != "" {
+		b.parseTimeout("HTTP_IDLE_TIMEOUT", val, &b.config.idleTimeout)
+	}
+	if val := os.Getenv("SHUTDOWN_DELAY"); val != "" {
+		if delay, err := time.ParseDuration(val); err == nil && delay >= 0 {
+			b.config.shutdownDelay = delay
+		} else {
+			slog.Warn("Invalid shutdown delay, shutting down immediately", "delay", val)
+		}
+	}
+	if val := os.Getenv("FIRST_ANNOTATION_DELAY"); val != "" {
+		if delay, err := time.ParseDuration(val); err == nil && delay >= 0 {
+			b.config.firstAnnotationDelay = delay
+		} else {
+			slog.Warn("Invalid first annotation delay, pods will be marked for rescheduling immediately", "delay", val)
+		}
+	}
+	if val := os.Getenv("POST_RESCHEDULE_WAIT"); val != "" {
+		if wait, err := time.ParseDuration(val); err == nil && wait >= 0 {
+			b.config.postRescheduleWait = wait
+		} else {
+			slog.Warn("Invalid post reschedule wait, responses will not wait after marking pods", "wait", val)
+		}
+	}
+	if val := os.Getenv("REQUEST_TIMEOUT"); val != "" {
+		if timeout, err := time.ParseDuration(val); err == nil && timeout >= 0 {
+			b.config.requestTimeout = timeout
+		} else {
+			slog.Warn("Invalid request timeout, only the API server's timeout will bound requests", "timeout", val)
+		}
+	}
+	if val := os.Getenv("REGISTRY_CONFIGMAP"); val != "" {
+		b.config.registryConfigMap = val
+	}
+	if val := os.Getenv("REGISTRY_SAVE_INTERVAL"); val != "" {
+		if interval, err := time.ParseDuration(val); err == nil && interval > 0 {
+			b.config.registrySaveInterval = interval
+		} else {
+			slog.Warn("Invalid registry save interval, using default", "interval", val, "default", DefaultRegistrySaveInterval)
+		}
+	}
+	if val := os.Getenv("NOTIFY_URL"); val != "" {
+		b.config.notifyURL = val
+	}
+	if val := os.Getenv("NOTIFY_TIMEOUT"); val != "" {
+		if timeout, err := time.ParseDuration(val); err == nil && timeout > 0 {
+			b.config.notifyTimeout = timeout
+		} else {
+			slog.Warn("Invalid notify timeout, using default", "timeout", val, "default", DefaultNotifyTimeout)
+		}
+	}
+	if val := os.Getenv("TELEMETRY_TIMEOUT"); val != "" {
+		if timeout, err := time.ParseDuration(val); err == nil && timeout > 0 {
+			b.config.telemetryTimeout = timeout
+		} else {
+			slog.Warn("Invalid telemetry timeout, using default", "timeout", val, "default", DefaultTelemetryTimeout)
+		}
+	}
+	if val := os.Getenv("DECISION_LOG"); val != "" {
+		if target, err := parseDecisionLog(val); err == nil {
+			b.config.decisionLog = target
+		} else {
+			slog.Warn("Invalid decision log, defaulting to off", "error", err)
+		}
+	}
+	if val := os.Getenv("DECISION_JSON_CASE"); val != "" {
+		if jsonCase, err := parseJSONCase(val); err == nil {
+			b.config.decisionJSONCase = jsonCase
+		} else {
+			slog.Warn("Invalid decision JSON case, keys will not be renamed", "error", err)
+		}
+	}
+	if val := os.Getenv("METRICS_INSTANCE_LIMIT"); val != "" {
+		if limit, err := strconv.Atoi(val); err == nil && limit >= 0 {
+			b.config.metricsInstanceLimit = limit
+		} else {
+			slog.Warn("Invalid metrics instance limit, using default", "limit", val, "default", DefaultMetricsInstanceLimit)
+		}
+	}
+	if val := os.Getenv("CLIENT_QPS"); val != "" {
+		if qps, err := strconv.ParseFloat(val, 64); err == nil {
+			b.config.clientQPS = qps
+		} else {
+			slog.Warn("Invalid client QPS, using default", "qps", val, "default", DefaultClientQPS)
+		}
+	}
+	if val := os.Getenv("CLIENT_BURST"); val != "" {
+		if burst, err := strconv.Atoi(val); err == nil {
+			b.config.clientBurst = burst
+		} else {
+			slog.Warn("Invalid client burst, using default", "burst", val, "default", DefaultClientBurst)
+		}
+	}
+	if val := os.Getenv("USE_TYPED_POD_CLIENT"); val != "" {
+		b.config.useTypedPodClient, _ = strconv.ParseBool(val)
+	}
+	if val := os.Getenv("ACTIVE_WINDOWS"); val != "" {
+		location := time.UTC
+		if timezone := os.Getenv("ACTIVE_WINDOWS_TIMEZONE"); timezone != "" {
+			if loaded, err := time.LoadLocation(timezone); err == nil {
+				location = loaded
+			} else {
+				slog.Warn("Unknown active windows time zone, defaulting to UTC", "timezone", timezone, "error", err)
+			}
+		}
+		b.WithActiveWindows(val, location)
+	}
+	if val := os.Getenv("ANNOTATE_OUTSIDE_ACTIVE_WINDOWS"); val != "" {
+		b.config.annotateOutsideWindows, _ = strconv.ParseBool(val)
+	}
+	if val := os.Getenv("LOG_LEVEL"); val != "" {
+		if err := b.config.logLevel.UnmarshalText([]byte(val)); err != nil {
+			slog.Warn("Unknown log level, defaulting to info", "level", val)
+			b.config.logLevel = DefaultLogLevel
+		}
+	}
 	return b
 }
 
@@ -110,22 +1374,559 @@ func (b *ConfigBuilder) WithPodLabelSelector(key, value string) *ConfigBuilder {
 	return b
 }
 
+// WithExcludeSelector sets a label selector, such as role=backup, for pods that should not be rescheduled even though they
+// have the pod label. Evictions of these pods are allowed immediately. An invalid selector fails validation.
+func (b *ConfigBuilder) WithExcludeSelector(selector string) *ConfigBuilder {
+	parsed, err := labels.Parse(selector)
+	if err != nil {
+		parsed = nil
+		err = fmt.Errorf("invalid POD_LABEL_EXCLUDE_SELECTOR %q: %w", selector, err)
+	}
+
+	b.config.excludeSelector = parsed
+	b.config.excludeSelectorErr = err
+	return b
+}
+
+// WithOperatorPodSelector sets a label selector, such as app=couchbase-operator, identifying operator pods. Their evictions are
+// always allowed, even if they have the pod label, as blocking them could stop the operator from being upgraded. An invalid
+// selector fails validation.
+func (b *ConfigBuilder) WithOperatorPodSelector(selector string) *ConfigBuilder {
+	parsed, err := labels.Parse(selector)
+	if err != nil {
+		parsed = nil
+		err = fmt.Errorf("invalid OPERATOR_POD_SELECTOR %q: %w", selector, err)
+	}
+
+	b.config.operatorPodSelector = parsed
+	b.config.operatorPodSelectorErr = err
+	return b
+}
+
 func (b *ConfigBuilder) WithRescheduleAnnotation(key, value string) *ConfigBuilder {
 	b.config.rescheduleAnnotationKey = key
 	b.config.rescheduleAnnotationValue = value
 	return b
 }
 
+// WithAnnotationKeyPrefix sets the prefix added to reschedule and force tracking annotation keys configured without one, such
+// as example.com for a key of reschedule
+func (b *ConfigBuilder) WithAnnotationKeyPrefix(prefix string) *ConfigBuilder {
+	b.config.annotationKeyPrefix = strings.TrimSuffix(prefix, "/")
+	return b
+}
+
+// WithRescheduleAnnotations sets multiple annotations to be added to pods together to mark them for rescheduling. When set, these
+// supersede the single annotation set by WithRescheduleAnnotation.
+func (b *ConfigBuilder) WithRescheduleAnnotations(annotations map[string]string) *ConfigBuilder {
+	b.config.rescheduleAnnotations = maps.Clone(annotations)
+	return b
+}
+
+// WithRescheduleMarker sets whether the reschedule annotations are added to pods as annotations or as labels. Label values are
+// validated by Validate, as they are more restricted than annotation values.
+func (b *ConfigBuilder) WithRescheduleMarker(markerType RescheduleMarkerType) *ConfigBuilder {
+	b.config.rescheduleMarkerType = markerType
+	return b
+}
+
 func (b *ConfigBuilder) WithTrackRescheduledPods(track bool) *ConfigBuilder {
 	b.config.trackRescheduledPods = track
 	return b
 }
 
+// WithTrackPodNode sets whether the tracking annotation should record the node and UID of the pod being rescheduled
+func (b *ConfigBuilder) WithTrackPodNode(track bool) *ConfigBuilder {
+	b.config.trackPodNode = track
+	return b
+}
+
 func (b *ConfigBuilder) WithTrackingResource(resourceType string) *ConfigBuilder {
 	b.config.trackingResource = tracking.GetTrackingResource(resourceType)
 	return b
 }
 
+// WithForceTrackingAnnotation sets the annotation key that, when set to true on a pod or its tracking resource, forces
+// tracking of the rescheduled pod regardless of the tracking resource conditional
+func (b *ConfigBuilder) WithForceTrackingAnnotation(key string) *ConfigBuilder {
+	b.config.forceTrackingAnnotation = key
+	return b
+}
+
+// WithDisableTrackingAnnotation sets the annotation key that, when set to true on a tracking resource instance, stops pods of
+// that instance being tracked, while they are still marked for rescheduling. An empty key disables the opt out.
+func (b *ConfigBuilder) WithDisableTrackingAnnotation(key string) *ConfigBuilder {
+	b.config.disableTrackingAnnotation = key
+	return b
+}
+
+// WithApprovalAnnotation sets the annotation key that, when set to true on a pod by the operator, approves its eviction
+// without the pod first being marked for rescheduling. An empty key disables approvals.
+func (b *ConfigBuilder) WithApprovalAnnotation(key string) *ConfigBuilder {
+	b.config.approvalAnnotation = key
+	return b
+}
+
+// WithRescheduleDoneAnnotation sets the annotation key that the operator sets on a pod once its replacement is complete. The
+// eviction of a pod with the annotation is allowed and the hook's annotations for it are removed. An empty key disables it.
+func (b *ConfigBuilder) WithRescheduleDoneAnnotation(key string) *ConfigBuilder {
+	b.config.rescheduleDoneAnnotation = key
+	return b
+}
+
+// WithIgnoreOwnerKinds sets the owner kinds for which pod evictions will always be allowed
+func (b *ConfigBuilder) WithIgnoreOwnerKinds(kinds ...string) *ConfigBuilder {
+	b.config.ignoreOwnerKinds = kinds
+	return b
+}
+
+// WithStatefulOwnerKinds sets the controller kinds whose pods are marked for rescheduling. Evictions of selected pods controlled
+// by any other kind, such as a ReplicaSet, are allowed immediately. If no kinds are given, pods are not checked.
+func (b *ConfigBuilder) WithStatefulOwnerKinds(kinds ...string) *ConfigBuilder {
+	b.config.statefulOwnerKinds = kinds
+	return b
+}
+
+// WithRateLimit sets the number of eviction requests per second, and the burst, that will be handled for each namespace.
+// A rate of 0 disables rate limiting.
+func (b *ConfigBuilder) WithRateLimit(requestsPerSecond float64, burst int) *ConfigBuilder {
+	b.config.rateLimit = requestsPerSecond
+	b.config.rateLimitBurst = burst
+	return b
+}
+
+// WithRootOK sets whether GET requests to / should return 200, which allows naive load balancer health checks to use the root path
+func (b *ConfigBuilder) WithRootOK(rootOK bool) *ConfigBuilder {
+	b.config.rootOK = rootOK
+	return b
+}
+
+// WithBlockEviction sets whether evictions should be denied after marking pods for rescheduling. When false, pods are still
+// marked for rescheduling but their evictions are allowed, so the drain command will not wait for the operator to reschedule them.
+func (b *ConfigBuilder) WithBlockEviction(block bool) *ConfigBuilder {
+	b.config.blockEviction = block
+	return b
+}
+
+// WithReportPendingPeers sets whether the message denying the eviction of a pod waiting to be rescheduled includes how many
+// other pods in its tracking resource instance are also waiting. This lists the instance's pods on each such eviction.
+func (b *ConfigBuilder) WithReportPendingPeers(report bool) *ConfigBuilder {
+	b.config.reportPendingPeers = report
+	return b
+}
+
+// WithTrackAttempts sets whether the number of denied evictions should be recorded in the reschedule.hook/attempts annotation
+// on the pod. This adds an extra API write to each denial.
+func (b *ConfigBuilder) WithTrackAttempts(trackAttempts bool) *ConfigBuilder {
+	b.config.trackAttempts = trackAttempts
+	return b
+}
+
+// WithRecordFromNode sets whether the name of the node a pod was on when it was marked for rescheduling is recorded in its
+// reschedule.hook/from-node annotation, to trace which drain caused the reschedule
+func (b *ConfigBuilder) WithRecordFromNode(recordFromNode bool) *ConfigBuilder {
+	b.config.recordFromNode = recordFromNode
+	return b
+}
+
+// WithCACheck enables a check that the serving certificate chains to the CA bundle at caBundleFile. The check runs at startup
+// and then every interval. If interval is not positive, the check only runs at startup.
+func (b *ConfigBuilder) WithCACheck(caBundleFile string, interval time.Duration) *ConfigBuilder {
+	b.config.caBundleFile = caBundleFile
+	b.config.caCheckInterval = interval
+	return b
+}
+
+// WithValidateCABundle sets whether the serving certificate is checked at startup against the CA bundle of the
+// ValidatingWebhookConfiguration named configName
+func (b *ConfigBuilder) WithValidateCABundle(validate bool, configName string) *ConfigBuilder {
+	b.config.validateCABundle = validate
+	b.config.webhookConfigName = configName
+	return b
+}
+
+// WithCleanupPodAnnotations sets whether stale reschedule annotations should be removed from pods whose evictions are allowed
+func (b *ConfigBuilder) WithCleanupPodAnnotations(cleanup bool) *ConfigBuilder {
+	b.config.cleanupPodAnnotations = cleanup
+	return b
+}
+
+// WithVerifyAnnotation sets whether the pod is fetched again after adding the reschedule annotations, to confirm they were
+// persisted
+func (b *ConfigBuilder) WithVerifyAnnotation(verify bool) *ConfigBuilder {
+	b.config.verifyAnnotation = verify
+	return b
+}
+
+// WithTrustWebhookSelector sets whether pod selection is left to the webhook's objectSelector. When true, the pod label selector
+// is not checked and every pod received by the webhook is marked for rescheduling.
+func (b *ConfigBuilder) WithTrustWebhookSelector(trust bool) *ConfigBuilder {
+	b.config.trustWebhookSelector = trust
+	return b
+}
+
+// WithProtectOnlyStateful sets whether only pods with a PersistentVolumeClaim volume are marked for rescheduling. Evictions of
+// other selected pods are allowed immediately.
+func (b *ConfigBuilder) WithProtectOnlyStateful(protect bool) *ConfigBuilder {
+	b.config.protectOnlyStateful = protect
+	return b
+}
+
+// WithProtectPendingInstancePods sets whether Pending pods without the pod label are still treated as selected when they belong
+// to an existing namespaced tracking resource instance, as the label may not have been set on them yet
+func (b *ConfigBuilder) WithProtectPendingInstancePods(protect bool) *ConfigBuilder {
+	b.config.protectPendingInstance = protect
+	return b
+}
+
+// WithOnlyDrainingNodes sets whether pods are only marked for rescheduling when their node is being drained or is unhealthy,
+// that is when it has the node.kubernetes.io/unschedulable taint or is not Ready. Other evictions are allowed immediately.
+func (b *ConfigBuilder) WithOnlyDrainingNodes(onlyDraining bool) *ConfigBuilder {
+	b.config.onlyDrainingNodes = onlyDraining
+	return b
+}
+
+// WithRequiredNodeDrainAnnotation sets the annotation a pod's node must have, such as one set by a drain tool when a drain starts,
+// for the pod to be marked for rescheduling. If value is empty, the annotation can have any value. Evictions from nodes without
+// it are allowed immediately. An empty key disables the check.
+func (b *ConfigBuilder) WithRequiredNodeDrainAnnotation(key, value string) *ConfigBuilder {
+	b.config.nodeDrainAnnotation = key
+	b.config.nodeDrainAnnotationValue = value
+	return b
+}
+
+// WithMinClusterSize sets the number of pods a tracking resource instance must have for its pods to be marked for rescheduling.
+// Evictions from instances with this many pods or fewer are allowed immediately, so that the last pod of a single pod cluster
+// can be drained. A size of 0 disables the check.
+func (b *ConfigBuilder) WithMinClusterSize(size int) *ConfigBuilder {
+	b.config.minClusterSize = size
+	return b
+}
+
+// WithRolloutPercent sets the percentage of selected pods that are marked for rescheduling. Evictions of the other pods are
+// allowed immediately. Pods are bucketed by their namespace and name, so a pod is consistently in or out of the rollout, even
+// when it is recreated with the same name.
+func (b *ConfigBuilder) WithRolloutPercent(percent int) *ConfigBuilder {
+	b.config.rolloutPercent = percent
+	return b
+}
+
+// WithPriorityAnnotation sets the pod annotation holding the integer priority used to order rescheduling within a tracking
+// resource instance. A pod is not marked for rescheduling while a peer on the same node has a higher priority. An empty key
+// disables ordering.
+func (b *ConfigBuilder) WithPriorityAnnotation(key string) *ConfigBuilder {
+	b.config.priorityAnnotation = key
+	return b
+}
+
+// WithCircuitBreaker sets the number of consecutive API server errors within window after which eviction requests are denied
+// without contacting the API server, and how long to wait before testing whether it has recovered. A threshold of 0 disables
+// circuit breaking.
+func (b *ConfigBuilder) WithCircuitBreaker(threshold int, window, cooldown time.Duration) *ConfigBuilder {
+	b.config.circuitBreakerThreshold = threshold
+	b.config.circuitBreakerWindow = window
+	b.config.circuitBreakerCooldown = cooldown
+	return b
+}
+
+// WithProtectOrdinalRange limits rescheduling to pods with an ordinal between min and max inclusive. Evictions of other pods
+// are allowed.
+func (b *ConfigBuilder) WithProtectOrdinalRange(min, max int) *ConfigBuilder {
+	b.config.protectOrdinals = &ordinalRange{min: min, max: max}
+	return b
+}
+
+// WithAdminEndpoints enables the admin endpoints, which require requests to present token as a bearer token
+func (b *ConfigBuilder) WithAdminEndpoints(enabled bool, token string) *ConfigBuilder {
+	b.config.adminEndpoints = enabled
+	b.config.adminToken = token
+	return b
+}
+
+// WithHealthAddr sets the address, such as :8080, of a plain HTTP server serving the health, readiness and metrics endpoints
+// separately from the TLS webhook server
+func (b *ConfigBuilder) WithHealthAddr(addr string) *ConfigBuilder {
+	b.config.healthAddr = addr
+	return b
+}
+
+// WithCouchbaseAPIVersion sets the version of the couchbase.com API used to get CouchbaseCluster tracking resources
+func (b *ConfigBuilder) WithCouchbaseAPIVersion(version string) *ConfigBuilder {
+	b.config.couchbaseAPIVersion = version
+	return b
+}
+
+// WithInstanceNameFrom sets where the name of the tracking resource instance a pod belongs to is read from. Reading it from the
+// pod's owner takes extra API calls, and is only done when the pod's own labels do not have it.
+func (b *ConfigBuilder) WithInstanceNameFrom(from InstanceNameFrom) *ConfigBuilder {
+	b.config.instanceNameFrom = from
+	return b
+}
+
+// WithTrackingPredicate sets a predicate that tracking resource instances must match for rescheduled pods to be tracked on them,
+// replacing the default conditional of the tracking resource
+func (b *ConfigBuilder) WithTrackingPredicate(predicate *tracking.FieldPredicate) *ConfigBuilder {
+	b.config.trackingPredicate = predicate
+	return b
+}
+
+// WithNamespaceTracking sets how namespaces are shared when they are the tracking resource. If trackAnnotation is set, pods are only
+// tracked in namespaces where it is true. If scopeLabel is set, tracking annotation keys include the value of the label, or the
+// name of the pod's controller, so that unrelated apps in a namespace are tracked separately.
+func (b *ConfigBuilder) WithNamespaceTracking(trackAnnotation, scopeLabel string) *ConfigBuilder {
+	b.config.namespaceTrackAnnotation = trackAnnotation
+	b.config.namespaceScopeLabel = scopeLabel
+	return b
+}
+
+// WithTrackingFailurePolicy sets how evictions are handled when the tracking resource instance for a pod does not exist, such
+// as when the pod's label refers to a CouchbaseCluster that has been deleted
+func (b *ConfigBuilder) WithTrackingFailurePolicy(policy TrackingFailurePolicy) *ConfigBuilder {
+	b.config.trackingFailurePolicy = policy
+	return b
+}
+
+// WithTrackingNotFoundRetry sets how many times getting a tracking resource instance is retried when it is not found, such as
+// while a CouchbaseCluster is being recreated. The wait starts at interval and doubles after each retry. Only once the retries
+// are exhausted is the instance treated as missing and the tracking failure policy applied.
+func (b *ConfigBuilder) WithTrackingNotFoundRetry(retries int, interval time.Duration) *ConfigBuilder {
+	b.config.trackingNotFoundRetries = retries
+	b.config.trackingNotFoundInterval = interval
+	return b
+}
+
+// WithTrackingAnnotationMaxAge sets how old a tracking annotation can be before it is treated as stale, such as one left by a
+// drain that never completed. Stale annotations are removed and the pod is marked for rescheduling again rather than being
+// treated as already rescheduled. Tracking annotations only record when they were added if this is set.
+func (b *ConfigBuilder) WithTrackingAnnotationMaxAge(maxAge time.Duration) *ConfigBuilder {
+	b.config.trackingAnnotationMaxAge = maxAge
+	return b
+}
+
+// WithReadyRequireTrackingCRD sets whether the server should only report ready once the tracking resource is served by the API server
+func (b *ConfigBuilder) WithReadyRequireTrackingCRD(require bool) *ConfigBuilder {
+	b.config.readyRequireTrackingCRD = require
+	return b
+}
+
+// WithDeepReadiness sets whether the server should only report ready once the tracking resource instance canary, given as
+// <namespace>/<name>, or just <name> for cluster scoped tracking resources, can be fetched and patched
+func (b *ConfigBuilder) WithDeepReadiness(deep bool, canary string) *ConfigBuilder {
+	b.config.deepReadiness = deep
+	b.config.readinessCanary = canary
+	return b
+}
+
+// WithDenyNearAnnotationLimit sets whether adding a tracking annotation should fail when the annotations of the tracking
+// resource instance are close to the Kubernetes size limit, rather than only logging a warning
+func (b *ConfigBuilder) WithDenyNearAnnotationLimit(deny bool) *ConfigBuilder {
+	b.config.denyNearAnnotationLimit = deny
+	return b
+}
+
+// WithAllowTerminatingInstance sets whether evictions of pods whose tracking resource instance is being deleted should be
+// allowed immediately, so that blocking them does not hold up the teardown of the instance
+func (b *ConfigBuilder) WithAllowTerminatingInstance(allow bool) *ConfigBuilder {
+	b.config.allowTerminatingInstance = allow
+	return b
+}
+
+// WithTrackingResourceNotReadyStatuses sets the field of the tracking resource instance, as a dot separated path such as
+// status.phase, and the values of it for which the instance cannot currently accept its pods being rescheduled. Evictions of
+// pods whose instance has one of these values are denied without marking the pod. An empty path disables the check.
+func (b *ConfigBuilder) WithTrackingResourceNotReadyStatuses(path string, statuses ...string) *ConfigBuilder {
+	b.config.trackingStatusPath = path
+	b.config.trackingNotReadyStatuses = statuses
+	return b
+}
+
+// WithHTTPTimeouts sets the read, write and idle timeouts of the HTTP server. The write timeout bounds how long an eviction
+// request can be handled for, including any API calls.
+func (b *ConfigBuilder) WithHTTPTimeouts(read, write, idle time.Duration) *ConfigBuilder {
+	b.config.readTimeout = read
+	b.config.writeTimeout = write
+	b.config.idleTimeout = idle
+	return b
+}
+
+// WithShutdownDelay sets how long the server keeps answering requests after being asked to shut down. During this time the
+// server reports that it is not ready and denies evictions, so that they are retried against another replica.
+func (b *ConfigBuilder) WithShutdownDelay(delay time.Duration) *ConfigBuilder {
+	b.config.shutdownDelay = delay
+	return b
+}
+
+// WithFirstAnnotationDelay sets how long after an eviction is first requested for a pod the pod is marked for rescheduling.
+// Until then, evictions of the pod are denied without marking it, to give the operator a chance to respond to the eviction.
+func (b *ConfigBuilder) WithFirstAnnotationDelay(delay time.Duration) *ConfigBuilder {
+	b.config.firstAnnotationDelay = delay
+	return b
+}
+
+// WithPostRescheduleWait sets how long the response is held after a pod is marked for rescheduling, to give the operator a head
+// start before the drain command retries the eviction
+func (b *ConfigBuilder) WithPostRescheduleWait(wait time.Duration) *ConfigBuilder {
+	b.config.postRescheduleWait = wait
+	return b
+}
+
+// WithRequestTimeout bounds how long an eviction request is handled for. The timeout sent by the API server further bounds it.
+// If timeout is 0, only the API server's timeout applies.
+func (b *ConfigBuilder) WithRequestTimeout(timeout time.Duration) *ConfigBuilder {
+	b.config.requestTimeout = timeout
+	return b
+}
+
+// WithRegistryConfigMap sets the ConfigMap, given as <namespace>/<name>, that the times evictions were first requested for
+// pods are saved to, so that FIRST_ANNOTATION_DELAY continues from where it was if the server restarts
+func (b *ConfigBuilder) WithRegistryConfigMap(configMap string) *ConfigBuilder {
+	b.config.registryConfigMap = configMap
+	return b
+}
+
+// WithRegistrySaveInterval sets how often changes to the registry are saved to the registry ConfigMap
+func (b *ConfigBuilder) WithRegistrySaveInterval(interval time.Duration) *ConfigBuilder {
+	b.config.registrySaveInterval = interval
+	return b
+}
+
+// WithNotifyURL sets the URL that each eviction decision is posted to as JSON. Notifications are sent in the background, each
+// limited to timeout, and failures are only logged.
+func (b *ConfigBuilder) WithNotifyURL(notifyURL string, timeout time.Duration) *ConfigBuilder {
+	b.config.notifyURL = notifyURL
+	b.config.notifyTimeout = timeout
+	return b
+}
+
+// WithTelemetryTimeout sets how long the handling of an eviction waits for each telemetry call, such as queueing a notification or
+// writing the decision log, before responding without it
+func (b *ConfigBuilder) WithTelemetryTimeout(timeout time.Duration) *ConfigBuilder {
+	b.config.telemetryTimeout = timeout
+	return b
+}
+
+// WithMetricsInstanceLimit sets how many tracking resource instances are given their own instance label value in metrics.
+// Further instances are reported together under __other__.
+func (b *ConfigBuilder) WithMetricsInstanceLimit(limit int) *ConfigBuilder {
+	b.config.metricsInstanceLimit = limit
+	return b
+}
+
+// WithClientRateLimit sets the queries per second and burst that the Kubernetes client is limited to on the client side
+func (b *ConfigBuilder) WithClientRateLimit(qps float64, burst int) *ConfigBuilder {
+	b.config.clientQPS = qps
+	b.config.clientBurst = burst
+	return b
+}
+
+// WithTypedPodClient sets whether pods are read and patched with the typed clientset rather than the dynamic client
+func (b *ConfigBuilder) WithTypedPodClient(use bool) *ConfigBuilder {
+	b.config.useTypedPodClient = use
+	return b
+}
+
+// WithDecisionJSONCase sets how the keys of the decision log and NOTIFY_URL payloads are named. If jsonCase is empty, the decision
+// log uses snake_case and notifications use camelCase.
+func (b *ConfigBuilder) WithDecisionJSONCase(jsonCase JSONCase) *ConfigBuilder {
+	b.config.decisionJSONCase = jsonCase
+	return b
+}
+
+// WithDecisionLog sets where each eviction decision is written as a JSON line, separately from the logs
+func (b *ConfigBuilder) WithDecisionLog(target DecisionLog) *ConfigBuilder {
+	b.config.decisionLog = target
+	return b
+}
+
+// WithActiveWindows limits the reschedule behaviour to the given windows, evaluated in location. Outside of the windows,
+// evictions are allowed immediately. See parseActiveWindows for the format of spec. An invalid spec fails validation.
+func (b *ConfigBuilder) WithActiveWindows(spec string, location *time.Location) *ConfigBuilder {
+	windows, err := parseActiveWindows(spec, location)
+	if err != nil {
+		err = fmt.Errorf("invalid ACTIVE_WINDOWS: %w", err)
+	}
+
+	b.config.activeWindows = windows
+	b.config.activeWindowsErr = err
+	return b
+}
+
+// WithAnnotateOutsideActiveWindows sets whether pods are still marked for rescheduling when their eviction is allowed outside
+// of the active windows
+func (b *ConfigBuilder) WithAnnotateOutsideActiveWindows(annotate bool) *ConfigBuilder {
+	b.config.annotateOutsideWindows = annotate
+	return b
+}
+
+// WithClock sets the clock used by time-dependent behaviour such as rate limiting, circuit breaking and active windows
+func (b *ConfigBuilder) WithClock(clock Clock) *ConfigBuilder {
+	b.config.clock = clock
+	return b
+}
+
+// WithLogLevel sets the minimum level of logs written by the server
+func (b *ConfigBuilder) WithLogLevel(level slog.Level) *ConfigBuilder {
+	b.config.logLevel = level
+	return b
+}
+
 func (b *ConfigBuilder) Build() *Config {
+	// Tracking resources are resolved here so their options apply regardless of the order they are set
+	switch b.config.trackingResource.(type) {
+	case *tracking.CouchbaseClusterTrackingResource:
+		trackingResource := tracking.NewCouchbaseClusterTrackingResource(b.config.couchbaseAPIVersion)
+		trackingResource.Predicate = b.config.trackingPredicate
+		b.config.trackingResource = trackingResource
+	case *tracking.NamespaceTrackingResource:
+		b.config.trackingResource = &tracking.NamespaceTrackingResource{
+			Predicate:       b.config.trackingPredicate,
+			TrackAnnotation: b.config.namespaceTrackAnnotation,
+			ScopeLabel:      b.config.namespaceScopeLabel,
+		}
+	}
+
+	// Annotation keys are normalized here so the prefix applies regardless of the order options are set
+	prefix := b.config.annotationKeyPrefix
+	b.config.rescheduleAnnotationKey = normalizeAnnotationKey("RESCHEDULE_ANNOTATION_KEY", b.config.rescheduleAnnotationKey, prefix)
+	b.config.forceTrackingAnnotation = normalizeAnnotationKey("FORCE_TRACKING_ANNOTATION", b.config.forceTrackingAnnotation, prefix)
+	b.config.disableTrackingAnnotation = normalizeAnnotationKey("DISABLE_TRACKING_ANNOTATION", b.config.disableTrackingAnnotation, prefix)
+	b.config.approvalAnnotation = normalizeAnnotationKey("APPROVAL_ANNOTATION", b.config.approvalAnnotation, prefix)
+	b.config.rescheduleDoneAnnotation = normalizeAnnotationKey("RESCHEDULE_DONE_ANNOTATION", b.config.rescheduleDoneAnnotation, prefix)
+	if len(b.config.rescheduleAnnotations) > 0 {
+		annotations := make(map[string]string, len(b.config.rescheduleAnnotations))
+		for key, value := range b.config.rescheduleAnnotations {
+			annotations[normalizeAnnotationKey("RESCHEDULE_ANNOTATIONS", key, prefix)] = value
+		}
+		b.config.rescheduleAnnotations = annotations
+	}
+
+	if b.config.firstAnnotationDelay > 0 && b.config.firstSeen == nil {
+		b.config.firstSeen = newFirstSeenRegistry()
+	}
+
 	return &b.config
 }
+
+// parseTimeout parses the named timeout into timeout. A duration that cannot be parsed is recorded so that validation fails,
+// rather than the default silently being used, and non-positive durations are kept for validation to reject.
+func (b *ConfigBuilder) parseTimeout(name, val string, timeout *time.Duration) {
+	parsed, err := time.ParseDuration(val)
+	if err != nil {
+		b.config.timeoutErr = errors.Join(b.config.timeoutErr, fmt.Errorf("invalid %s %q: %w", name, val, err))
+		return
+	}
+
+	*timeout = parsed
+}
+
+// splitList splits a comma-separated list, trimming whitespace and dropping empty values
+func splitList(val string) []string {
+	var list []string
+	for _, item := range strings.Split(val, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+
+	return list
+}
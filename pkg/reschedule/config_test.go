@@ -0,0 +1,646 @@
+package reschedule
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/couchbaselabs/eviction-reschedule-hook/pkg/reschedule/tracking"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestConfigEqual(t *testing.T) {
+	testcases := []struct {
+		testname string
+		env      map[string]string
+		config   *Config
+		expected bool
+	}{
+		{
+			testname: "Default config",
+			config:   NewConfigBuilder().Build(),
+			expected: true,
+		},
+		{
+			testname: "Builder and environment match",
+			env: map[string]string{
+				"POD_LABEL_SELECTOR_KEY":      "appLabel",
+				"POD_LABEL_SELECTOR_VALUE":    "another_application",
+				"RESCHEDULE_ANNOTATION_KEY":   "rescheduleMe",
+				"RESCHEDULE_ANNOTATION_VALUE": "yes",
+				"TRACK_RESCHEULED_PODS":       "false",
+				"TRACKING_RESOURCE_TYPE":      tracking.ResourceTypeNamespace,
+				"LOG_LEVEL":                   "debug",
+				"IGNORE_OWNER_KINDS":          "Job, DaemonSet,",
+			},
+			config: NewConfigBuilder().
+				WithPodLabelSelector("appLabel", "another_application").
+				WithRescheduleAnnotation("rescheduleMe", "yes").
+				WithTrackRescheduledPods(false).
+				WithTrackingResource(tracking.ResourceTypeNamespace).
+				WithLogLevel(slog.LevelDebug).
+				WithIgnoreOwnerKinds("Job", "DaemonSet").
+				Build(),
+			expected: true,
+		},
+		{
+			testname: "Reschedule annotations from environment",
+			env: map[string]string{
+				"RESCHEDULE_ANNOTATIONS": `{"cao.couchbase.com/reschedule":"true","cao.couchbase.com/reschedule-reason":"eviction"}`,
+			},
+			config: NewConfigBuilder().
+				WithRescheduleAnnotations(map[string]string{
+					"cao.couchbase.com/reschedule":        "true",
+					"cao.couchbase.com/reschedule-reason": "eviction",
+				}).
+				Build(),
+			expected: true,
+		},
+		{
+			testname: "Protected ordinal range from environment",
+			env: map[string]string{
+				"PROTECT_ORDINAL_RANGE": "0-2",
+			},
+			config:   NewConfigBuilder().WithProtectOrdinalRange(0, 2).Build(),
+			expected: true,
+		},
+		{
+			testname: "Exclude selector from environment",
+			env: map[string]string{
+				"POD_LABEL_EXCLUDE_SELECTOR": "role=backup",
+			},
+			config:   NewConfigBuilder().WithExcludeSelector("role=backup").Build(),
+			expected: true,
+		},
+		{
+			testname: "Couchbase API version from environment",
+			env: map[string]string{
+				"COUCHBASE_API_VERSION": "v3",
+			},
+			config:   NewConfigBuilder().WithCouchbaseAPIVersion("v3").Build(),
+			expected: true,
+		},
+		{
+			testname: "Tracking predicate from environment",
+			env: map[string]string{
+				"TRACKING_PREDICATE": "spec.upgradeProcess=DeltaRecovery",
+			},
+			config:   NewConfigBuilder().WithTrackingPredicate(&tracking.FieldPredicate{Path: []string{"spec", "upgradeProcess"}, Value: "DeltaRecovery"}).Build(),
+			expected: true,
+		},
+		{
+			testname: "HTTP timeouts from environment",
+			env: map[string]string{
+				"HTTP_READ_TIMEOUT":  "5s",
+				"HTTP_WRITE_TIMEOUT": "1m",
+				"HTTP_IDLE_TIMEOUT":  "2m",
+			},
+			config:   NewConfigBuilder().WithHTTPTimeouts(5*time.Second, time.Minute, 2*time.Minute).Build(),
+			expected: true,
+		},
+		{
+			testname: "Tracking resource differs",
+			env: map[string]string{
+				"TRACKING_RESOURCE_TYPE": tracking.ResourceTypeNamespace,
+			},
+			config:   NewConfigBuilder().Build(),
+			expected: false,
+		},
+		{
+			testname: "Label selector differs",
+			env: map[string]string{
+				"POD_LABEL_SELECTOR_VALUE": "another_application",
+			},
+			config:   NewConfigBuilder().Build(),
+			expected: false,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testname, func(t *testing.T) {
+			for k, v := range testcase.env {
+				t.Setenv(k, v)
+			}
+
+			envConfig := NewConfigBuilder().FromEnvironment().Build()
+			if equal := envConfig.Equal(testcase.config); equal != testcase.expected {
+				t.Fatalf("Expected configs to be equal=%t, got environment config %s and builder config %s", testcase.expected, envConfig, testcase.config)
+			}
+
+			if equal := envConfig.String() == testcase.config.String(); equal != testcase.expected {
+				t.Fatalf("Expected config strings to be equal=%t, got %q and %q", testcase.expected, envConfig, testcase.config)
+			}
+		})
+	}
+}
+
+func TestConfigString(t *testing.T) {
+	config := NewConfigBuilder().WithTrackingResource(tracking.ResourceTypeNamespace).Build()
+
+	str := config.String()
+	if !strings.Contains(str, "trackingResource="+tracking.ResourceTypeNamespace) {
+		t.Fatalf("Expected config string to contain tracking resource type, got %q", str)
+	}
+
+	if strings.Contains(str, DefaultCertFile) || strings.Contains(str, DefaultKeyFile) {
+		t.Fatalf("Expected config string to omit TLS files, got %q", str)
+	}
+}
+
+func TestConfigPrint(t *testing.T) {
+	var buf bytes.Buffer
+	defaultLogger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, nil)))
+	t.Cleanup(func() {
+		slog.SetDefault(defaultLogger)
+	})
+
+	NewConfigBuilder().Build().Print()
+
+	output := buf.String()
+	expectedFields := []string{
+		"podLabelSelectorKey=" + DefaultPodLabelSelectorKey,
+		"podLabelSelectorValue=" + DefaultPodLabelSelectorValue,
+		"rescheduleAnnotationKey=" + DefaultRescheduleAnnotationKey,
+		"trackRescheduledPods=true",
+		"trackingResource=" + DefaultTrackingResourceType,
+		"readTimeout=" + DefaultReadTimeout.String(),
+		"writeTimeout=" + DefaultWriteTimeout.String(),
+		"idleTimeout=" + DefaultIdleTimeout.String(),
+	}
+	for _, field := range expectedFields {
+		if !strings.Contains(output, field) {
+			t.Errorf("Expected config log to contain %q, got %q", field, output)
+		}
+	}
+
+	sensitiveFields := []string{"certFile", "keyFile", DefaultCertFile, DefaultKeyFile}
+	for _, field := range sensitiveFields {
+		if strings.Contains(output, field) {
+			t.Errorf("Expected config log not to contain %q, got %q", field, output)
+		}
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	testcases := []struct {
+		testname    string
+		config      *Config
+		expectError bool
+	}{
+		{
+			testname: "Default config",
+			config:   NewConfigBuilder().Build(),
+		},
+		{
+			testname: "Annotation key without prefix",
+			config:   NewConfigBuilder().WithRescheduleAnnotation("rescheduleMe", "yes").Build(),
+		},
+		{
+			testname: "Annotation key with prefix",
+			config:   NewConfigBuilder().WithRescheduleAnnotation("example.com/reschedule-me", "yes").Build(),
+		},
+		{
+			testname:    "Empty annotation key",
+			config:      NewConfigBuilder().WithRescheduleAnnotation("", "yes").Build(),
+			expectError: true,
+		},
+		{
+			testname:    "Annotation key too long",
+			config:      NewConfigBuilder().WithRescheduleAnnotation("example.com/"+strings.Repeat("a", 64), "yes").Build(),
+			expectError: true,
+		},
+		{
+			testname:    "Annotation key with invalid characters",
+			config:      NewConfigBuilder().WithRescheduleAnnotation("example.com/reschedule me!", "yes").Build(),
+			expectError: true,
+		},
+		{
+			testname:    "Annotation key with invalid prefix",
+			config:      NewConfigBuilder().WithRescheduleAnnotation("Example_com/reschedule", "yes").Build(),
+			expectError: true,
+		},
+		{
+			testname: "Label reschedule marker",
+			config:   NewConfigBuilder().WithRescheduleMarker(RescheduleMarkerLabel).Build(),
+		},
+		{
+			testname:    "Label reschedule marker with invalid label value",
+			config:      NewConfigBuilder().WithRescheduleMarker(RescheduleMarkerLabel).WithRescheduleAnnotation("example.com/reschedule", "evicted by drain").Build(),
+			expectError: true,
+		},
+		{
+			testname: "Annotation reschedule marker with value invalid as a label",
+			config:   NewConfigBuilder().WithRescheduleMarker(RescheduleMarkerAnnotation).WithRescheduleAnnotation("example.com/reschedule", "evicted by drain").Build(),
+		},
+		{
+			testname:    "Label reschedule markers with invalid label value",
+			config:      NewConfigBuilder().WithRescheduleMarker(RescheduleMarkerLabel).WithRescheduleAnnotations(map[string]string{"example.com/reschedule": "true", "example.com/reason": strings.Repeat("x", 64)}).Build(),
+			expectError: true,
+		},
+		{
+			testname:    "Unknown reschedule marker type",
+			config:      NewConfigBuilder().WithRescheduleMarker("taint").Build(),
+			expectError: true,
+		},
+		{
+			testname: "Notify URL",
+			config:   NewConfigBuilder().WithNotifyURL("https://audit.example.com/evictions", time.Second).Build(),
+		},
+		{
+			testname:    "Notify URL without scheme",
+			config:      NewConfigBuilder().WithNotifyURL("audit.example.com/evictions", time.Second).Build(),
+			expectError: true,
+		},
+		{
+			testname:    "Rollout percent above 100",
+			config:      NewConfigBuilder().WithRolloutPercent(101).Build(),
+			expectError: true,
+		},
+		{
+			testname:    "Negative minimum cluster size",
+			config:      NewConfigBuilder().WithMinClusterSize(-1).Build(),
+			expectError: true,
+		},
+		{
+			testname:    "Admin endpoints without token",
+			config:      NewConfigBuilder().WithAdminEndpoints(true, "").Build(),
+			expectError: true,
+		},
+		{
+			testname: "Admin endpoints with token",
+			config:   NewConfigBuilder().WithAdminEndpoints(true, "secret").Build(),
+		},
+		{
+			testname:    "Invalid force tracking annotation key",
+			config:      NewConfigBuilder().WithForceTrackingAnnotation("force tracking").Build(),
+			expectError: true,
+		},
+		{
+			testname: "Force tracking annotation disabled",
+			config:   NewConfigBuilder().WithForceTrackingAnnotation("").Build(),
+		},
+		{
+			testname:    "Invalid reschedule done annotation key",
+			config:      NewConfigBuilder().WithRescheduleDoneAnnotation("reschedule done").Build(),
+			expectError: true,
+		},
+		{
+			testname: "Post reschedule wait",
+			config:   NewConfigBuilder().WithPostRescheduleWait(2 * time.Second).Build(),
+		},
+		{
+			testname:    "Post reschedule wait as long as HTTP write timeout",
+			config:      NewConfigBuilder().WithPostRescheduleWait(DefaultWriteTimeout).Build(),
+			expectError: true,
+		},
+		{
+			testname: "Deep readiness with a namespaced canary",
+			config:   NewConfigBuilder().WithDeepReadiness(true, "default/readiness-canary").Build(),
+		},
+		{
+			testname:    "Deep readiness without a canary",
+			config:      NewConfigBuilder().WithDeepReadiness(true, "").Build(),
+			expectError: true,
+		},
+		{
+			testname:    "Deep readiness canary without a namespace",
+			config:      NewConfigBuilder().WithDeepReadiness(true, "readiness-canary").Build(),
+			expectError: true,
+		},
+		{
+			testname: "Decision log to stdout",
+			config:   NewConfigBuilder().WithDecisionLog(DecisionLogStdout).Build(),
+		},
+		{
+			testname:    "Unknown decision log",
+			config:      NewConfigBuilder().WithDecisionLog("stderr").Build(),
+			expectError: true,
+		},
+		{
+			testname: "Validate CA bundle",
+			config:   NewConfigBuilder().WithValidateCABundle(true, "reschedule-webhook-config").Build(),
+		},
+		{
+			testname:    "Validate CA bundle without a webhook config name",
+			config:      NewConfigBuilder().WithValidateCABundle(true, "").Build(),
+			expectError: true,
+		},
+		{
+			testname:    "Negative request timeout",
+			config:      NewConfigBuilder().WithRequestTimeout(-time.Second).Build(),
+			expectError: true,
+		},
+		{
+			testname:    "Negative metrics instance limit",
+			config:      NewConfigBuilder().WithMetricsInstanceLimit(-1).Build(),
+			expectError: true,
+		},
+		{
+			testname: "Decision JSON in camel case",
+			config:   NewConfigBuilder().WithDecisionJSONCase(JSONCaseCamel).Build(),
+		},
+		{
+			testname:    "Unknown decision JSON case",
+			config:      NewConfigBuilder().WithDecisionJSONCase("kebab").Build(),
+			expectError: true,
+		},
+		{
+			testname: "Client rate limit",
+			config:   NewConfigBuilder().WithClientRateLimit(50, 100).Build(),
+		},
+		{
+			testname:    "Zero client QPS",
+			config:      NewConfigBuilder().WithClientRateLimit(0, 100).Build(),
+			expectError: true,
+		},
+		{
+			testname:    "Negative client burst",
+			config:      NewConfigBuilder().WithClientRateLimit(50, -1).Build(),
+			expectError: true,
+		},
+		{
+			testname:    "Zero telemetry timeout",
+			config:      NewConfigBuilder().WithTelemetryTimeout(0).Build(),
+			expectError: true,
+		},
+		{
+			testname:    "Invalid node drain annotation",
+			config:      NewConfigBuilder().WithRequiredNodeDrainAnnotation("node.kubernetes.io/drain=true", "").Build(),
+			expectError: true,
+		},
+		{
+			testname:    "Node drain annotation value without a key",
+			config:      NewConfigBuilder().WithRequiredNodeDrainAnnotation("", "true").Build(),
+			expectError: true,
+		},
+		{
+			testname:    "Invalid tracking resource status path",
+			config:      NewConfigBuilder().WithTrackingResourceNotReadyStatuses("status..phase", "Rebalancing").Build(),
+			expectError: true,
+		},
+		{
+			testname:    "Tracking resource status path without not ready statuses",
+			config:      NewConfigBuilder().WithTrackingResourceNotReadyStatuses("status.phase").Build(),
+			expectError: true,
+		},
+		{
+			testname:    "Tracking resource not ready statuses without a status path",
+			config:      NewConfigBuilder().WithTrackingResourceNotReadyStatuses("", "Rebalancing").Build(),
+			expectError: true,
+		},
+		{
+			testname:    "Invalid disable tracking annotation",
+			config:      NewConfigBuilder().WithDisableTrackingAnnotation("reschedule.hook/disable tracking").Build(),
+			expectError: true,
+		},
+		{
+			testname:    "Registry save interval not positive",
+			config:      NewConfigBuilder().WithRegistryConfigMap("default/registry").WithRegistrySaveInterval(0).Build(),
+			expectError: true,
+		},
+		{
+			testname: "Exclude selector",
+			config:   NewConfigBuilder().WithExcludeSelector("role in (backup,restore)").Build(),
+		},
+		{
+			testname:    "Invalid exclude selector",
+			config:      NewConfigBuilder().WithExcludeSelector("role in backup").Build(),
+			expectError: true,
+		},
+		{
+			testname: "Operator pod selector",
+			config:   NewConfigBuilder().WithOperatorPodSelector("app=couchbase-operator").Build(),
+		},
+		{
+			testname:    "Invalid operator pod selector",
+			config:      NewConfigBuilder().WithOperatorPodSelector("app in couchbase-operator").Build(),
+			expectError: true,
+		},
+		{
+			testname: "Active windows",
+			config:   NewConfigBuilder().WithActiveWindows("Mon-Fri 22:00-06:00,Sat-Sun 00:00-24:00", time.UTC).Build(),
+		},
+		{
+			testname:    "Invalid active windows",
+			config:      NewConfigBuilder().WithActiveWindows("Mon-Fri 22:00", time.UTC).Build(),
+			expectError: true,
+		},
+		{
+			testname: "HTTP timeouts",
+			config:   NewConfigBuilder().WithHTTPTimeouts(time.Second, time.Minute, time.Hour).Build(),
+		},
+		{
+			testname:    "Zero HTTP write timeout",
+			config:      NewConfigBuilder().WithHTTPTimeouts(time.Second, 0, time.Hour).Build(),
+			expectError: true,
+		},
+		{
+			testname:    "Negative HTTP idle timeout",
+			config:      NewConfigBuilder().WithHTTPTimeouts(time.Second, time.Minute, -time.Hour).Build(),
+			expectError: true,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testname, func(t *testing.T) {
+			err := testcase.config.Validate()
+			if testcase.expectError && err == nil {
+				t.Fatalf("Expected config to be invalid")
+			}
+
+			if !testcase.expectError && err != nil {
+				t.Fatalf("Expected config to be valid, got %v", err)
+			}
+		})
+	}
+}
+
+func TestConfigValidateTimeoutsFromEnvironment(t *testing.T) {
+	testcases := []struct {
+		testname    string
+		env         map[string]string
+		expectError bool
+	}{
+		{
+			testname: "Valid HTTP timeouts",
+			env: map[string]string{
+				"HTTP_READ_TIMEOUT":  "5s",
+				"HTTP_WRITE_TIMEOUT": "1m",
+				"HTTP_IDLE_TIMEOUT":  "2m",
+			},
+		},
+		{
+			testname: "Negative HTTP write timeout",
+			env: map[string]string{
+				"HTTP_WRITE_TIMEOUT": "-1s",
+			},
+			expectError: true,
+		},
+		{
+			testname: "Zero HTTP read timeout",
+			env: map[string]string{
+				"HTTP_READ_TIMEOUT": "0s",
+			},
+			expectError: true,
+		},
+		{
+			testname: "Unparsable HTTP write timeout",
+			env: map[string]string{
+				"HTTP_WRITE_TIMEOUT": "garbage",
+			},
+			expectError: true,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testname, func(t *testing.T) {
+			for k, v := range testcase.env {
+				t.Setenv(k, v)
+			}
+
+			err := NewConfigBuilder().FromEnvironment().Build().Validate()
+			if testcase.expectError && err == nil {
+				t.Fatalf("Expected config to be invalid")
+			}
+
+			if !testcase.expectError && err != nil {
+				t.Fatalf("Expected config to be valid, got %v", err)
+			}
+		})
+	}
+}
+
+func TestConfigAnnotationKeyPrefix(t *testing.T) {
+	testcases := []struct {
+		testname        string
+		key             string
+		prefix          string
+		expectedKey     string
+		expectedWarning string
+	}{
+		{
+			testname:    "Prefixed key",
+			key:         "example.com/reschedule",
+			prefix:      "other.example.com",
+			expectedKey: "example.com/reschedule",
+		},
+		{
+			testname:        "Unprefixed key without a prefix to add",
+			key:             "reschedule",
+			expectedKey:     "reschedule",
+			expectedWarning: "Annotation key has no prefix",
+		},
+		{
+			testname:    "Unprefixed key",
+			key:         "reschedule",
+			prefix:      "example.com",
+			expectedKey: "example.com/reschedule",
+		},
+		{
+			testname:    "Unprefixed key with a trailing slash on the prefix",
+			key:         "reschedule",
+			prefix:      "example.com/",
+			expectedKey: "example.com/reschedule",
+		},
+		{
+			testname:        "Reserved prefix",
+			key:             "kubernetes.io/reschedule",
+			prefix:          "example.com",
+			expectedKey:     "kubernetes.io/reschedule",
+			expectedWarning: "reserved for Kubernetes",
+		},
+		{
+			testname:        "Reserved subdomain prefix",
+			key:             "node.k8s.io/reschedule",
+			expectedKey:     "node.k8s.io/reschedule",
+			expectedWarning: "reserved for Kubernetes",
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testname, func(t *testing.T) {
+			var buf bytes.Buffer
+			defaultLogger := slog.Default()
+			slog.SetDefault(slog.New(slog.NewTextHandler(&buf, nil)))
+			t.Cleanup(func() {
+				slog.SetDefault(defaultLogger)
+			})
+
+			config := NewConfigBuilder().
+				WithRescheduleAnnotation(testcase.key, "true").
+				WithRescheduleAnnotations(map[string]string{testcase.key: "true"}).
+				WithAnnotationKeyPrefix(testcase.prefix).
+				Build()
+
+			if config.rescheduleAnnotationKey != testcase.expectedKey {
+				t.Errorf("Expected reschedule annotation key to be %q, got %q", testcase.expectedKey, config.rescheduleAnnotationKey)
+			}
+
+			if _, ok := config.rescheduleAnnotations[testcase.expectedKey]; !ok {
+				t.Errorf("Expected reschedule annotations to contain %q, got %v", testcase.expectedKey, config.rescheduleAnnotations)
+			}
+
+			if err := config.Validate(); err != nil {
+				t.Errorf("Expected config to be valid, got %v", err)
+			}
+
+			output := buf.String()
+			if testcase.expectedWarning != "" && !strings.Contains(output, testcase.expectedWarning) {
+				t.Errorf("Expected a warning containing %q, got %q", testcase.expectedWarning, output)
+			}
+
+			if testcase.expectedWarning == "" && strings.Contains(output, "level=WARN") {
+				t.Errorf("Expected no warnings, got %q", output)
+			}
+		})
+	}
+}
+
+func TestParseOrdinalRange(t *testing.T) {
+	testcases := []struct {
+		value       string
+		expected    string
+		expectError bool
+	}{
+		{value: "0-2", expected: "0-2"},
+		{value: " 1 - 3 ", expected: "1-3"},
+		{value: "4", expected: "4-4"},
+		{value: "2-1", expectError: true},
+		{value: "a-2", expectError: true},
+		{value: "0-b", expectError: true},
+		{value: "-1", expectError: true},
+		{value: "", expectError: true},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.value, func(t *testing.T) {
+			protected, err := parseOrdinalRange(testcase.value)
+			if testcase.expectError {
+				if err == nil {
+					t.Fatalf("Expected parsing %q to fail, got %s", testcase.value, protected)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Failed to parse %q: %v", testcase.value, err)
+			}
+
+			if protected.String() != testcase.expected {
+				t.Fatalf("Expected range %s, got %s", testcase.expected, protected)
+			}
+		})
+	}
+}
+
+func TestConfigCouchbaseAPIVersion(t *testing.T) {
+	config := NewConfigBuilder().WithCouchbaseAPIVersion("v3").WithTrackingResource(tracking.ResourceTypeCouchbaseCluster).Build()
+
+	expected := schema.GroupVersionResource{Group: "couchbase.com", Version: "v3", Resource: "couchbaseclusters"}
+	if gvr := config.trackingResource.GetGroupVersionResource(); gvr != expected {
+		t.Fatalf("Expected tracking resource GroupVersionResource to be %v, got %v", expected, gvr)
+	}
+}
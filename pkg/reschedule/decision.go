@@ -0,0 +1,58 @@
+package reschedule
+
+import (
+	admissionv1 "k8s.io/api/admission/v1"
+)
+
+// ReasonCode is a stable, machine readable code identifying why an eviction request was allowed or denied. Unlike the
+// response message, it does not change between releases, so it can be used to build alerts from the logs.
+type ReasonCode string
+
+const (
+	ReasonInvalidEviction        ReasonCode = "INVALID_EVICTION"
+	ReasonUnsupportedOperation   ReasonCode = "UNSUPPORTED_OPERATION"
+	ReasonUnsupportedSubresource ReasonCode = "UNSUPPORTED_SUBRESOURCE"
+	ReasonShuttingDown           ReasonCode = "SHUTTING_DOWN"
+	ReasonRateLimited            ReasonCode = "RATE_LIMITED"
+	ReasonAPIServerUnavailable   ReasonCode = "API_SERVER_UNAVAILABLE"
+	ReasonPodNotFound            ReasonCode = "POD_NOT_FOUND"
+	ReasonPodLookupError         ReasonCode = "POD_LOOKUP_ERROR"
+	ReasonAuthenticationFailed   ReasonCode = "AUTHENTICATION_FAILED"
+	ReasonApproved               ReasonCode = "APPROVED"
+	ReasonRescheduleDone         ReasonCode = "RESCHEDULE_DONE"
+	ReasonOperatorPod            ReasonCode = "OPERATOR_POD"
+	ReasonIgnoredOwnerKind       ReasonCode = "IGNORED_OWNER_KIND"
+	ReasonLabelMismatch          ReasonCode = "LABEL_MISMATCH"
+	ReasonExcluded               ReasonCode = "EXCLUDED"
+	ReasonOrdinalNotProtected    ReasonCode = "ORDINAL_NOT_PROTECTED"
+	ReasonStateless              ReasonCode = "STATELESS"
+	ReasonStatelessOwner         ReasonCode = "STATELESS_OWNER"
+	ReasonNodeNotDraining        ReasonCode = "NODE_NOT_DRAINING"
+	ReasonNodeNotAnnotated       ReasonCode = "NODE_NOT_ANNOTATED"
+	ReasonNodeLookupError        ReasonCode = "NODE_LOOKUP_ERROR"
+	ReasonClusterTooSmall        ReasonCode = "CLUSTER_TOO_SMALL"
+	ReasonPodCountError          ReasonCode = "POD_COUNT_ERROR"
+	ReasonOutsideRollout         ReasonCode = "OUTSIDE_ROLLOUT"
+	ReasonOutsideActiveWindows   ReasonCode = "OUTSIDE_ACTIVE_WINDOWS"
+	ReasonAlreadyMarked          ReasonCode = "ALREADY_MARKED"
+	ReasonWaitingForPriority     ReasonCode = "WAITING_FOR_PRIORITY"
+	ReasonPeerLookupError        ReasonCode = "PEER_LOOKUP_ERROR"
+	ReasonAnnotationDelayed      ReasonCode = "ANNOTATION_DELAYED"
+	ReasonSameNameRescheduled    ReasonCode = "SAME_NAME_RESCHEDULED"
+	ReasonTrackingError          ReasonCode = "TRACKING_ERROR"
+	ReasonInstanceTerminating    ReasonCode = "INSTANCE_TERMINATING"
+	ReasonInstanceNotReady       ReasonCode = "INSTANCE_NOT_READY"
+	ReasonPodChanged             ReasonCode = "POD_CHANGED"
+	ReasonAnnotationError        ReasonCode = "ANNOTATION_ERROR"
+	ReasonAnnotationAdded        ReasonCode = "ANNOTATION_ADDED"
+)
+
+// Decision is the outcome of handling an eviction request, together with the reason code for the path that produced it
+type Decision struct {
+	Response *admissionv1.AdmissionResponse
+	Reason   ReasonCode
+}
+
+func newDecision(reason ReasonCode, response *admissionv1.AdmissionResponse) Decision {
+	return Decision{Response: response, Reason: reason}
+}
@@ -0,0 +1,80 @@
+package reschedule
+
+import (
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	policyv1 "k8s.io/api/policy/v1"
+)
+
+const (
+	decisionAllowed = "allowed"
+	decisionDenied  = "denied"
+)
+
+// decisionRecord is a single line written by DecisionLogWriter. The field names are stable so that log-based metrics can be
+// built on them.
+type decisionRecord struct {
+	Timestamp  time.Time  `json:"timestamp"`
+	Pod        string     `json:"pod"`
+	Namespace  string     `json:"namespace"`
+	Decision   string     `json:"decision"`
+	ReasonCode ReasonCode `json:"reason_code"`
+	DryRun     bool       `json:"dry_run"`
+	User       string     `json:"user"`
+}
+
+// newDecisionRecord creates the record for a decision on the eviction, made at now
+func newDecisionRecord(eviction *policyv1.Eviction, request *admissionv1.AdmissionRequest, dryRun bool, decision Decision, now time.Time) decisionRecord {
+	result := decisionDenied
+	if decision.Response.Allowed {
+		result = decisionAllowed
+	}
+
+	return decisionRecord{
+		Timestamp:  now,
+		Pod:        eviction.Name,
+		Namespace:  eviction.Namespace,
+		Decision:   result,
+		ReasonCode: decision.Reason,
+		DryRun:     dryRun,
+		User:       requestingUser(request),
+	}
+}
+
+// DecisionLogWriter writes eviction decisions as NDJSON, one line per decision. It is kept separate from slog so that the output
+// only ever contains decisions. It is safe for concurrent use.
+type DecisionLogWriter struct {
+	mu       sync.Mutex
+	w        io.Writer
+	jsonCase JSONCase
+}
+
+// NewDecisionLogWriter creates a DecisionLogWriter writing to w. The keys are converted to jsonCase, or left in snake_case if it
+// is empty.
+func NewDecisionLogWriter(w io.Writer, jsonCase JSONCase) *DecisionLogWriter {
+	return &DecisionLogWriter{w: w, jsonCase: jsonCase}
+}
+
+// Write writes the record as a single line. A nil writer, used when DECISION_LOG is off, ignores records.
+func (d *DecisionLogWriter) Write(record decisionRecord) {
+	if d == nil {
+		return
+	}
+
+	line, err := marshalWithCase(record, d.jsonCase)
+	if err != nil {
+		slog.Warn("Failed to encode decision log", "pod", record.Pod, "namespace", record.Namespace, "error", err)
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, err := d.w.Write(append(line, '\n')); err != nil {
+		slog.Warn("Failed to write decision log", "pod", record.Pod, "namespace", record.Namespace, "error", err)
+	}
+}
@@ -0,0 +1,86 @@
+package reschedule
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDecisionLogWriter(t *testing.T) {
+	var output bytes.Buffer
+	writer := NewDecisionLogWriter(&output, "")
+
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	eviction := policyv1.Eviction{ObjectMeta: metav1.ObjectMeta{Name: "pod1", Namespace: "default"}}
+	request := &admissionv1.AdmissionRequest{UserInfo: authenticationv1.UserInfo{Username: "admin"}}
+
+	writer.Write(newDecisionRecord(&eviction, request, false, newDecision(ReasonAnnotationAdded, denyEviction(http.StatusTooManyRequests, metav1.StatusReasonTooManyRequests, RescheduleAnnotationAddedToPodMsg)), now))
+	writer.Write(newDecisionRecord(&eviction, nil, true, newDecision(ReasonLabelMismatch, allowEviction()), now))
+
+	// A nil writer, used when DECISION_LOG is off, ignores records
+	var disabled *DecisionLogWriter
+	disabled.Write(newDecisionRecord(&eviction, nil, false, newDecision(ReasonLabelMismatch, allowEviction()), now))
+
+	expected := []map[string]interface{}{
+		{
+			"timestamp":   "2025-01-01T12:00:00Z",
+			"pod":         "pod1",
+			"namespace":   "default",
+			"decision":    "denied",
+			"reason_code": "ANNOTATION_ADDED",
+			"dry_run":     false,
+			"user":        "admin",
+		},
+		{
+			"timestamp":   "2025-01-01T12:00:00Z",
+			"pod":         "pod1",
+			"namespace":   "default",
+			"decision":    "allowed",
+			"reason_code": "LABEL_MISMATCH",
+			"dry_run":     true,
+			"user":        "",
+		},
+	}
+
+	var lines []map[string]interface{}
+	scanner := bufio.NewScanner(&output)
+	for scanner.Scan() {
+		var line map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("Failed to decode line %q: %v", scanner.Text(), err)
+		}
+		lines = append(lines, line)
+	}
+
+	if len(lines) != len(expected) {
+		t.Fatalf("Expected %d lines, got %d", len(expected), len(lines))
+	}
+
+	for i, line := range lines {
+		for key, value := range expected[i] {
+			if line[key] != value {
+				t.Fatalf("Line %d: expected %s to be %v, got %v", i, key, value, line[key])
+			}
+		}
+
+		// Any extra field would be a change to the schema
+		var keys []string
+		for key := range line {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+
+		if expectedKeys := []string{"decision", "dry_run", "namespace", "pod", "reason_code", "timestamp", "user"}; !slices.Equal(keys, expectedKeys) {
+			t.Fatalf("Line %d: expected fields %v, got %v", i, expectedKeys, keys)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package reschedule
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// firstSeenIdleTimeout is how long a pod can go without an eviction request before it is removed from the registry
+const firstSeenIdleTimeout = 10 * time.Minute
+
+// firstSeenRegistry records when an eviction was first requested for each pod, so that marking the pod for rescheduling can
+// be delayed. It is held in memory, so it is reset when the server restarts unless it is saved with a registryStore. It is safe
+// for concurrent use.
+type firstSeenRegistry struct {
+	mu          sync.Mutex
+	pods        map[types.UID]*firstSeenPod
+	lastCleanup time.Time
+	// changes counts modifications to the registry, so that it is only saved when it has changed
+	changes uint64
+}
+
+type firstSeenPod struct {
+	FirstSeen time.Time `json:"firstSeen"`
+	LastSeen  time.Time `json:"lastSeen"`
+}
+
+func newFirstSeenRegistry() *firstSeenRegistry {
+	return &firstSeenRegistry{pods: map[types.UID]*firstSeenPod{}}
+}
+
+// Observe records an eviction request for the pod at now and returns when an eviction was first requested for it
+func (r *firstSeenRegistry) Observe(uid types.UID, now time.Time) time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cleanup(now)
+
+	pod, exists := r.pods[uid]
+	if !exists {
+		pod = &firstSeenPod{FirstSeen: now}
+		r.pods[uid] = pod
+	}
+
+	pod.LastSeen = now
+	r.changes++
+	return pod.FirstSeen
+}
+
+// Forget removes the pod from the registry once it has been marked for rescheduling
+func (r *firstSeenRegistry) Forget(uid types.UID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.pods[uid]; exists {
+		delete(r.pods, uid)
+		r.changes++
+	}
+}
+
+// Snapshot returns a copy of the pods in the registry, along with the number of changes made to the registry so far
+func (r *firstSeenRegistry) Snapshot() (map[types.UID]firstSeenPod, uint64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	pods := make(map[types.UID]firstSeenPod, len(r.pods))
+	for uid, pod := range r.pods {
+		pods[uid] = *pod
+	}
+
+	return pods, r.changes
+}
+
+// Restore adds previously saved pods to the registry. Pods that are already in the registry keep the earlier first seen time.
+func (r *firstSeenRegistry) Restore(pods map[types.UID]firstSeenPod) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for uid, pod := range pods {
+		if current, exists := r.pods[uid]; exists {
+			if pod.FirstSeen.Before(current.FirstSeen) {
+				current.FirstSeen = pod.FirstSeen
+			}
+
+			continue
+		}
+
+		r.pods[uid] = &pod
+	}
+}
+
+// cleanup removes pods that have had no eviction requests for longer than firstSeenIdleTimeout, such as pods whose node is no
+// longer being drained. To avoid scanning the map on every request, this is done at most once per firstSeenIdleTimeout.
+func (r *firstSeenRegistry) cleanup(now time.Time) {
+	if now.Sub(r.lastCleanup) < firstSeenIdleTimeout {
+		return
+	}
+
+	for uid, pod := range r.pods {
+		if now.Sub(pod.LastSeen) >= firstSeenIdleTimeout {
+			delete(r.pods, uid)
+			r.changes++
+		}
+	}
+
+	r.lastCleanup = now
+}
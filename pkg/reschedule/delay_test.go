@@ -0,0 +1,44 @@
+package reschedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFirstSeenRegistry(t *testing.T) {
+	now := time.Now()
+	registry := newFirstSeenRegistry()
+
+	if firstSeen := registry.Observe("uid1", now); !firstSeen.Equal(now) {
+		t.Fatalf("Expected first seen to be %v, got %v", now, firstSeen)
+	}
+
+	if firstSeen := registry.Observe("uid1", now.Add(time.Minute)); !firstSeen.Equal(now) {
+		t.Fatalf("Expected first seen to be unchanged at %v, got %v", now, firstSeen)
+	}
+
+	registry.Forget("uid1")
+	later := now.Add(2 * time.Minute)
+	if firstSeen := registry.Observe("uid1", later); !firstSeen.Equal(later) {
+		t.Fatalf("Expected first seen to be reset to %v, got %v", later, firstSeen)
+	}
+}
+
+func TestFirstSeenRegistryCleanup(t *testing.T) {
+	now := time.Now()
+	registry := newFirstSeenRegistry()
+
+	registry.Observe("idle", now)
+	now = now.Add(firstSeenIdleTimeout / 2)
+	registry.Observe("active", now)
+	now = now.Add(firstSeenIdleTimeout / 2)
+	registry.Observe("active", now)
+
+	if _, exists := registry.pods["idle"]; exists {
+		t.Fatalf("Expected idle pod to be removed")
+	}
+
+	if _, exists := registry.pods["active"]; !exists {
+		t.Fatalf("Expected active pod to be kept")
+	}
+}
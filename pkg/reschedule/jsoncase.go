@@ -0,0 +1,63 @@
+package reschedule
+
+import (
+	"encoding/json"
+	"strings"
+	"unicode"
+)
+
+// marshalWithCase marshals v as a JSON object with its top level keys converted to jsonCase. If jsonCase is empty, the keys are
+// left as they are tagged. Converted keys are written in sorted order.
+func marshalWithCase(v any, jsonCase JSONCase) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil || jsonCase == "" {
+		return data, err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	converted := make(map[string]json.RawMessage, len(fields))
+	for key, value := range fields {
+		converted[convertCase(key, jsonCase)] = value
+	}
+
+	return json.Marshal(converted)
+}
+
+// convertCase converts a snake_case or camelCase key to jsonCase
+func convertCase(key string, jsonCase JSONCase) string {
+	var builder strings.Builder
+
+	switch jsonCase {
+	case JSONCaseSnake:
+		for i, r := range key {
+			if unicode.IsUpper(r) {
+				if i > 0 {
+					builder.WriteByte('_')
+				}
+				r = unicode.ToLower(r)
+			}
+			builder.WriteRune(r)
+		}
+	case JSONCaseCamel:
+		upper := false
+		for _, r := range key {
+			if r == '_' {
+				upper = true
+				continue
+			}
+			if upper {
+				r = unicode.ToUpper(r)
+				upper = false
+			}
+			builder.WriteRune(r)
+		}
+	default:
+		return key
+	}
+
+	return builder.String()
+}
@@ -0,0 +1,94 @@
+package reschedule
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+	"time"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestMarshalWithCase(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	eviction := policyv1.Eviction{ObjectMeta: metav1.ObjectMeta{Name: "pod1", Namespace: "default"}}
+	request := &admissionv1.AdmissionRequest{UserInfo: authenticationv1.UserInfo{Username: "admin"}}
+	decision := newDecision(ReasonLabelMismatch, allowEviction())
+
+	notification := newDecisionNotification(&eviction, request, true, decision, now)
+	record := newDecisionRecord(&eviction, request, true, decision, now)
+
+	testcases := []struct {
+		testname     string
+		payload      any
+		jsonCase     JSONCase
+		expectedKeys []string
+	}{
+		{
+			testname:     "Notification without a case",
+			payload:      notification,
+			expectedKeys: []string{"allowed", "dryRun", "namespace", "pod", "reasonCode", "time", "user"},
+		},
+		{
+			testname:     "Notification in snake case",
+			payload:      notification,
+			jsonCase:     JSONCaseSnake,
+			expectedKeys: []string{"allowed", "dry_run", "namespace", "pod", "reason_code", "time", "user"},
+		},
+		{
+			testname:     "Notification in camel case",
+			payload:      notification,
+			jsonCase:     JSONCaseCamel,
+			expectedKeys: []string{"allowed", "dryRun", "namespace", "pod", "reasonCode", "time", "user"},
+		},
+		{
+			testname:     "Decision record without a case",
+			payload:      record,
+			expectedKeys: []string{"decision", "dry_run", "namespace", "pod", "reason_code", "timestamp", "user"},
+		},
+		{
+			testname:     "Decision record in snake case",
+			payload:      record,
+			jsonCase:     JSONCaseSnake,
+			expectedKeys: []string{"decision", "dry_run", "namespace", "pod", "reason_code", "timestamp", "user"},
+		},
+		{
+			testname:     "Decision record in camel case",
+			payload:      record,
+			jsonCase:     JSONCaseCamel,
+			expectedKeys: []string{"decision", "dryRun", "namespace", "pod", "reasonCode", "timestamp", "user"},
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testname, func(t *testing.T) {
+			data, err := marshalWithCase(testcase.payload, testcase.jsonCase)
+			if err != nil {
+				t.Fatalf("Failed to marshal payload: %v", err)
+			}
+
+			var fields map[string]interface{}
+			if err := json.Unmarshal(data, &fields); err != nil {
+				t.Fatalf("Failed to decode payload %s: %v", data, err)
+			}
+
+			var keys []string
+			for key := range fields {
+				keys = append(keys, key)
+			}
+			slices.Sort(keys)
+
+			if !slices.Equal(keys, testcase.expectedKeys) {
+				t.Fatalf("Expected keys %v, got %v", testcase.expectedKeys, keys)
+			}
+
+			// Only the keys are renamed
+			if fields["pod"] != "pod1" || fields["user"] != "admin" {
+				t.Fatalf("Expected values to be unchanged, got %s", data)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package reschedule
+
+import (
+	"log/slog"
+	"net/http"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Reasons a tracking annotation is not added for a pod being rescheduled
+const (
+	trackingSkippedDisabled       = "tracking_disabled"
+	trackingSkippedNotRequired    = "not_required"
+	trackingSkippedUnresolved     = "instance_unresolved"
+	trackingSkippedAlreadyPresent = "already_present"
+)
+
+// instanceLabelOverflow is the instance label value that tracking resource instances beyond the instance limit are reported under
+const instanceLabelOverflow = "__other__"
+
+var (
+	// metricsRegistry holds the metrics served on /metrics. A dedicated registry is used so that only the hook's own metrics
+	// are exposed.
+	metricsRegistry = prometheus.NewRegistry()
+
+	trackingAnnotationAddedTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "reschedule_tracking_annotation_added_total",
+		Help: "Number of tracking annotations added to tracking resources for pods rescheduled with the same name.",
+	})
+
+	trackingAnnotationRemovedTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "reschedule_tracking_annotation_removed_total",
+		Help: "Number of tracking annotations removed from tracking resources once the pod has been rescheduled.",
+	})
+
+	trackingAnnotationSkippedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "reschedule_tracking_annotation_skipped_total",
+		Help: "Number of pods marked for rescheduling without adding a tracking annotation, by reason.",
+	}, []string{"reason"})
+
+	waitingPods = newInstanceGauge(prometheus.GaugeOpts{
+		Name: "reschedule_waiting_pods",
+		Help: "Number of pods tracked as waiting to be rescheduled with the same name, by tracking resource instance, as last seen by the hook.",
+	}, DefaultMetricsInstanceLimit)
+)
+
+func init() {
+	metricsRegistry.MustRegister(
+		trackingAnnotationAddedTotal,
+		trackingAnnotationRemovedTotal,
+		trackingAnnotationSkippedTotal,
+		waitingPods.gauge,
+	)
+}
+
+// instanceKey identifies a tracking resource instance in metric labels
+type instanceKey struct {
+	namespace string
+	instance  string
+}
+
+// instanceGauge is a gauge labelled by namespace and tracking resource instance. Pod names are never used as labels. To bound
+// the cardinality of the metric, only the first limit instances are given their own instance label value. The values of any
+// further instances are summed under the instanceLabelOverflow value for their namespace. It is safe for concurrent use.
+type instanceGauge struct {
+	gauge *prometheus.GaugeVec
+
+	mu    sync.Mutex
+	limit int
+	// labelled are the instances reported under their own label value
+	labelled map[instanceKey]struct{}
+	// overflow holds the last value of each instance reported under instanceLabelOverflow
+	overflow map[instanceKey]float64
+}
+
+// newInstanceGauge creates an instanceGauge giving at most limit instances their own label value
+func newInstanceGauge(opts prometheus.GaugeOpts, limit int) *instanceGauge {
+	return &instanceGauge{
+		gauge:    prometheus.NewGaugeVec(opts, []string{"namespace", "instance"}),
+		limit:    limit,
+		labelled: map[instanceKey]struct{}{},
+		overflow: map[instanceKey]float64{},
+	}
+}
+
+// SetLimit sets how many instances are given their own label value. Instances that already have one keep it.
+func (g *instanceGauge) SetLimit(limit int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.limit = limit
+}
+
+// Set sets the value for the tracking resource instance in namespace
+func (g *instanceGauge) Set(namespace, instance string, value float64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	key := instanceKey{namespace: namespace, instance: instance}
+	if _, ok := g.labelled[key]; ok || (len(g.labelled) < g.limit && !g.overflowed(key)) {
+		g.labelled[key] = struct{}{}
+		g.gauge.WithLabelValues(namespace, instance).Set(value)
+		return
+	}
+
+	g.overflow[key] = value
+
+	total := 0.0
+	for other, otherValue := range g.overflow {
+		if other.namespace == namespace {
+			total += otherValue
+		}
+	}
+
+	g.gauge.WithLabelValues(namespace, instanceLabelOverflow).Set(total)
+}
+
+// overflowed checks whether the instance is already reported under instanceLabelOverflow, so that it is not moved to its own
+// label value, which would count it twice
+func (g *instanceGauge) overflowed(key instanceKey) bool {
+	_, ok := g.overflow[key]
+	return ok
+}
+
+// metricsHandler serves the hook's metrics in the Prometheus exposition format
+func metricsHandler() http.Handler {
+	return promhttp.HandlerFor(metricsRegistry, promhttp.HandlerOpts{})
+}
+
+// metricsSummary returns the total of each of the hook's counters and gauges, summed over their labels, for logging
+func metricsSummary() []any {
+	families, err := metricsRegistry.Gather()
+	if err != nil {
+		slog.Warn("Failed to gather metrics", "error", err)
+	}
+
+	attrs := make([]any, 0, len(families))
+	for _, family := range families {
+		total := 0.0
+		for _, metric := range family.GetMetric() {
+			total += metric.GetCounter().GetValue() + metric.GetGauge().GetValue()
+		}
+
+		attrs = append(attrs, slog.Float64(family.GetName(), total))
+	}
+
+	return attrs
+}
@@ -0,0 +1,147 @@
+package reschedule
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestTrackingAnnotationMetrics(t *testing.T) {
+	trackedPod, err := json.Marshal(TrackedPod{Name: "pod1", Namespace: "default", NodeName: "node1", UID: "uid1"})
+	if err != nil {
+		t.Fatalf("Failed to marshal tracked pod: %v", err)
+	}
+
+	testcases := []struct {
+		testname       string
+		mockClient     *mockClient
+		expectedMetric prometheus.Collector
+	}{
+		{
+			testname: "Added",
+			mockClient: &mockClient{
+				shouldTrackRescheduledPods:  true,
+				shouldAddTrackingAnnotation: true,
+			},
+			expectedMetric: trackingAnnotationAddedTotal,
+		},
+		{
+			testname: "Removed",
+			mockClient: &mockClient{
+				shouldTrackRescheduledPods:  true,
+				trackingResourceAnnotations: map[string]string{TrackingResourceAnnotation("pod1", "default"): "true"},
+			},
+			expectedMetric: trackingAnnotationRemovedTotal,
+		},
+		{
+			testname:       "Skipped when tracking is disabled",
+			mockClient:     &mockClient{},
+			expectedMetric: trackingAnnotationSkippedTotal.WithLabelValues(trackingSkippedDisabled),
+		},
+		{
+			testname: "Skipped when tracking is not required",
+			mockClient: &mockClient{
+				shouldTrackRescheduledPods: true,
+			},
+			expectedMetric: trackingAnnotationSkippedTotal.WithLabelValues(trackingSkippedNotRequired),
+		},
+		{
+			testname: "Skipped when the tracking resource cannot be determined",
+			mockClient: &mockClient{
+				shouldTrackRescheduledPods:  true,
+				shouldAddTrackingAnnotation: true,
+				noTrackingInstanceName:      true,
+			},
+			expectedMetric: trackingAnnotationSkippedTotal.WithLabelValues(trackingSkippedUnresolved),
+		},
+		{
+			testname: "Skipped when the tracking resource does not exist",
+			mockClient: &mockClient{
+				shouldTrackRescheduledPods:  true,
+				shouldAddTrackingAnnotation: true,
+				trackingResourceNotFound:    true,
+			},
+			expectedMetric: trackingAnnotationSkippedTotal.WithLabelValues(trackingSkippedUnresolved),
+		},
+		{
+			testname: "Skipped when the tracking annotation is already present",
+			mockClient: &mockClient{
+				shouldTrackRescheduledPods:  true,
+				shouldAddTrackingAnnotation: true,
+				trackingResourceAnnotations: map[string]string{TrackingResourceAnnotation("pod1", "default"): string(trackedPod)},
+			},
+			expectedMetric: trackingAnnotationSkippedTotal.WithLabelValues(trackingSkippedAlreadyPresent),
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testname, func(t *testing.T) {
+			testcase.mockClient.pod = trackedPodStub("pod1", "node1", "uid1")
+			config := NewConfigBuilder().FromEnvironment().Build()
+			testcase.mockClient.config = config
+
+			eviction := policyv1.Eviction{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "pod1",
+					Namespace: "default",
+				},
+			}
+
+			before := testutil.ToFloat64(testcase.expectedMetric)
+			handleEviction(context.Background(), eviction, testcase.mockClient, config, CreateLogger(eviction.Name, eviction.Namespace, false))
+
+			if after := testutil.ToFloat64(testcase.expectedMetric); after != before+1 {
+				t.Fatalf("Expected metric to be incremented from %v, got %v", before, after)
+			}
+		})
+	}
+}
+
+func TestInstanceGaugeCardinalityLimit(t *testing.T) {
+	gauge := newInstanceGauge(prometheus.GaugeOpts{Name: "test_waiting_pods"}, 2)
+
+	gauge.Set("default", "cluster1", 1)
+	gauge.Set("default", "cluster2", 2)
+	gauge.Set("default", "cluster3", 3)
+	gauge.Set("default", "cluster4", 4)
+	gauge.Set("other", "cluster5", 5)
+
+	// Instances that already have their own label value keep it, and updates to overflowed instances replace their previous value
+	gauge.Set("default", "cluster1", 6)
+	gauge.Set("default", "cluster3", 7)
+
+	expected := map[instanceKey]float64{
+		{namespace: "default", instance: "cluster1"}:            6,
+		{namespace: "default", instance: "cluster2"}:            2,
+		{namespace: "default", instance: instanceLabelOverflow}: 11,
+		{namespace: "other", instance: instanceLabelOverflow}:   5,
+	}
+
+	if count := testutil.CollectAndCount(gauge.gauge); count != len(expected) {
+		t.Fatalf("Expected %d series, got %d", len(expected), count)
+	}
+
+	for key, value := range expected {
+		if actual := testutil.ToFloat64(gauge.gauge.WithLabelValues(key.namespace, key.instance)); actual != value {
+			t.Fatalf("Expected %s/%s to be %v, got %v", key.namespace, key.instance, value, actual)
+		}
+	}
+
+	// Raising the limit gives new instances their own label value, but overflowed instances stay under the overflow value
+	gauge.SetLimit(4)
+	gauge.Set("default", "cluster4", 8)
+	gauge.Set("default", "cluster6", 9)
+
+	if actual := testutil.ToFloat64(gauge.gauge.WithLabelValues("default", instanceLabelOverflow)); actual != 15 {
+		t.Fatalf("Expected overflow to be 15, got %v", actual)
+	}
+
+	if actual := testutil.ToFloat64(gauge.gauge.WithLabelValues("default", "cluster6")); actual != 9 {
+		t.Fatalf("Expected cluster6 to be 9, got %v", actual)
+	}
+}
@@ -0,0 +1,126 @@
+package reschedule
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	policyv1 "k8s.io/api/policy/v1"
+)
+
+// notifyQueueSize is the number of decisions that can be waiting to be sent before further decisions are dropped
+const notifyQueueSize = 100
+
+// decisionNotification is the JSON body posted to NOTIFY_URL for each eviction decision
+type decisionNotification struct {
+	Pod        string     `json:"pod"`
+	Namespace  string     `json:"namespace"`
+	User       string     `json:"user,omitempty"`
+	DryRun     bool       `json:"dryRun"`
+	Allowed    bool       `json:"allowed"`
+	ReasonCode ReasonCode `json:"reasonCode"`
+	Code       int32      `json:"code,omitempty"`
+	Message    string     `json:"message,omitempty"`
+	Time       time.Time  `json:"time"`
+}
+
+// newDecisionNotification creates the notification for a decision on the eviction, made at now
+func newDecisionNotification(eviction *policyv1.Eviction, request *admissionv1.AdmissionRequest, dryRun bool, decision Decision, now time.Time) decisionNotification {
+	notification := decisionNotification{
+		Pod:        eviction.Name,
+		Namespace:  eviction.Namespace,
+		User:       requestingUser(request),
+		DryRun:     dryRun,
+		Allowed:    decision.Response.Allowed,
+		ReasonCode: decision.Reason,
+		Time:       now,
+	}
+
+	if result := decision.Response.Result; result != nil {
+		notification.Code = result.Code
+		notification.Message = result.Message
+	}
+
+	return notification
+}
+
+// Notifier posts eviction decisions to an external URL. Decisions are queued and sent by a single worker, so a slow receiver
+// never delays the admission response or starts more goroutines. It is safe for concurrent use.
+type Notifier struct {
+	url      string
+	client   *http.Client
+	queue    chan decisionNotification
+	jsonCase JSONCase
+}
+
+// NewNotifier creates a Notifier posting to url, with each request limited to timeout and at most queueSize decisions waiting
+// to be sent. The payload keys are converted to jsonCase, or left in camelCase if it is empty.
+func NewNotifier(url string, timeout time.Duration, queueSize int, jsonCase JSONCase) *Notifier {
+	return &Notifier{
+		url:      url,
+		client:   &http.Client{Timeout: timeout},
+		queue:    make(chan decisionNotification, queueSize),
+		jsonCase: jsonCase,
+	}
+}
+
+// Notify queues the notification to be sent. If the queue is full the notification is dropped rather than blocking.
+func (n *Notifier) Notify(notification decisionNotification) {
+	if n == nil {
+		return
+	}
+
+	select {
+	case n.queue <- notification:
+	default:
+		slog.Warn("Notification queue full, dropping decision", "pod", notification.Pod, "namespace", notification.Namespace, "reason_code", notification.ReasonCode)
+	}
+}
+
+// Run sends queued notifications until the context is cancelled. Notifications still queued at that point are dropped.
+func (n *Notifier) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case notification := <-n.queue:
+			if err := n.send(ctx, notification); err != nil {
+				slog.Warn("Failed to send decision notification", "url", n.url, "pod", notification.Pod, "namespace", notification.Namespace, "error", err)
+			}
+		}
+	}
+}
+
+// send posts a single notification, treating any non 2xx status as a failure
+func (n *Notifier) send(ctx context.Context, notification decisionNotification) error {
+	body, err := marshalWithCase(notification, n.jsonCase)
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := n.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	// Drain the body so that the connection can be reused
+	_, _ = io.Copy(io.Discard, response.Body)
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %s", response.Status)
+	}
+
+	return nil
+}
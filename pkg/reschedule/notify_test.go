@@ -0,0 +1,107 @@
+package reschedule
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNotifierSendsDecision(t *testing.T) {
+	received := make(chan decisionNotification, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Expected a JSON POST, got %s with Content-Type %q", r.Method, r.Header.Get("Content-Type"))
+		}
+
+		var notification decisionNotification
+		if err := json.NewDecoder(r.Body).Decode(&notification); err != nil {
+			t.Errorf("Failed to decode notification: %v", err)
+		}
+
+		received <- notification
+	}))
+	defer server.Close()
+
+	notifier := NewNotifier(server.URL, time.Second, notifyQueueSize, "")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go notifier.Run(ctx)
+
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	eviction := policyv1.Eviction{ObjectMeta: metav1.ObjectMeta{Name: "pod1", Namespace: "default"}}
+	request := &admissionv1.AdmissionRequest{UserInfo: authenticationv1.UserInfo{Username: "admin"}}
+	decision := newDecision(ReasonAnnotationAdded, denyEviction(http.StatusTooManyRequests, metav1.StatusReasonTooManyRequests, RescheduleAnnotationAddedToPodMsg))
+
+	notifier.Notify(newDecisionNotification(&eviction, request, false, decision, now))
+
+	expected := decisionNotification{
+		Pod:        "pod1",
+		Namespace:  "default",
+		User:       "admin",
+		Allowed:    false,
+		ReasonCode: ReasonAnnotationAdded,
+		Code:       http.StatusTooManyRequests,
+		Message:    RescheduleAnnotationAddedToPodMsg,
+		Time:       now,
+	}
+
+	select {
+	case notification := <-received:
+		if notification != expected {
+			t.Fatalf("Expected notification %+v, got %+v", expected, notification)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timed out waiting for notification")
+	}
+}
+
+func TestNotifierDropsWhenQueueFull(t *testing.T) {
+	// Without a worker running nothing is taken from the queue, so only the first notification fits
+	notifier := NewNotifier("http://localhost", time.Second, 1, "")
+	eviction := policyv1.Eviction{ObjectMeta: metav1.ObjectMeta{Name: "pod1", Namespace: "default"}}
+	decision := newDecision(ReasonLabelMismatch, allowEviction())
+
+	done := make(chan struct{})
+	go func() {
+		notifier.Notify(newDecisionNotification(&eviction, nil, false, decision, time.Now()))
+		notifier.Notify(newDecisionNotification(&eviction, nil, false, decision, time.Now()))
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Notify blocked on a full queue")
+	}
+
+	if queued := len(notifier.queue); queued != 1 {
+		t.Fatalf("Expected 1 queued notification, got %d", queued)
+	}
+
+	// A nil notifier, used when NOTIFY_URL is not set, ignores notifications
+	var disabled *Notifier
+	disabled.Notify(newDecisionNotification(&eviction, nil, false, decision, time.Now()))
+}
+
+func TestNotifierSendFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	notifier := NewNotifier(server.URL, time.Second, notifyQueueSize, "")
+	eviction := policyv1.Eviction{ObjectMeta: metav1.ObjectMeta{Name: "pod1", Namespace: "default"}}
+	notification := newDecisionNotification(&eviction, nil, false, newDecision(ReasonLabelMismatch, allowEviction()), time.Now())
+
+	if err := notifier.send(context.Background(), notification); err == nil {
+		t.Fatalf("Expected an error for a non 2xx response")
+	}
+}
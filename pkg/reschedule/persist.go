@@ -0,0 +1,129 @@
+package reschedule
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+var configMapResource = schema.GroupVersionResource{Group: "", Version: "v1", Resource: "configmaps"}
+
+// registryConfigMapKey is the key in the ConfigMap data that the first seen registry is saved under
+const registryConfigMapKey = "firstSeen"
+
+// registryStore saves the first seen registry to a ConfigMap and loads it when the server starts, so that a restarted server
+// continues delaying pods from when their evictions were first requested. The ConfigMap must already exist. It is safe for
+// concurrent use.
+type registryStore struct {
+	client    Client
+	registry  *firstSeenRegistry
+	namespace string
+	name      string
+
+	mu sync.Mutex
+	// saved is the number of registry changes included in the last successful save
+	saved uint64
+}
+
+// newRegistryStore creates a registryStore for the registry, saving it to the ConfigMap given as <namespace>/<name>
+func newRegistryStore(client Client, registry *firstSeenRegistry, configMap string) (*registryStore, error) {
+	namespace, name, err := splitNamespacedName(configMap)
+	if err != nil {
+		return nil, err
+	}
+
+	return &registryStore{
+		client:    client,
+		registry:  registry,
+		namespace: namespace,
+		name:      name,
+	}, nil
+}
+
+// Load restores the registry from the ConfigMap. An empty ConfigMap leaves the registry unchanged.
+func (s *registryStore) Load() error {
+	configMap, err := s.client.GetResource(configMapResource, s.namespace, s.name)
+	if err != nil {
+		return err
+	}
+
+	value, _, err := unstructured.NestedString(configMap.Object, "data", registryConfigMapKey)
+	if err != nil || value == "" {
+		return err
+	}
+
+	var pods map[types.UID]firstSeenPod
+	if err := json.Unmarshal([]byte(value), &pods); err != nil {
+		return fmt.Errorf("failed to decode registry from ConfigMap %s/%s: %w", s.namespace, s.name, err)
+	}
+
+	s.registry.Restore(pods)
+	return nil
+}
+
+// Sync saves the registry to the ConfigMap if it has changed since it was last saved
+func (s *registryStore) Sync() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pods, changes := s.registry.Snapshot()
+	if changes == s.saved {
+		return nil
+	}
+
+	value, err := json.Marshal(pods)
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(map[string]interface{}{
+		"data": map[string]string{
+			registryConfigMapKey: string(value),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := s.client.PatchResource(configMapResource, s.namespace, s.name, types.MergePatchType, payload); err != nil {
+		return err
+	}
+
+	s.saved = changes
+	return nil
+}
+
+// Run syncs the registry every interval until the context is cancelled, bounding how often the ConfigMap is written
+func (s *registryStore) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Sync(); err != nil {
+				slog.Warn("Failed to save registry", "configMap", s.namespace+"/"+s.name, "error", err)
+			}
+		}
+	}
+}
+
+// splitNamespacedName splits a value of the form <namespace>/<name>
+func splitNamespacedName(value string) (string, string, error) {
+	namespace, name, ok := strings.Cut(value, "/")
+	if !ok || namespace == "" || name == "" {
+		return "", "", fmt.Errorf("invalid name %q, expected <namespace>/<name>", value)
+	}
+
+	return namespace, name, nil
+}
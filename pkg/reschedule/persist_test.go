@@ -0,0 +1,125 @@
+package reschedule
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+)
+
+func registryConfigMapStub(namespace, name string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"name":      name,
+				"namespace": namespace,
+			},
+		},
+	}
+
+	obj.SetKind("ConfigMap")
+	obj.SetAPIVersion("v1")
+	return obj
+}
+
+func TestRegistryStoreRestart(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	client := &ClientImpl{
+		dynamicClient: fake.NewSimpleDynamicClient(runtime.NewScheme(), registryConfigMapStub("default", "reschedule-hook-registry")),
+	}
+
+	registry := newFirstSeenRegistry()
+	store, err := newRegistryStore(client, registry, "default/reschedule-hook-registry")
+	if err != nil {
+		t.Fatalf("Failed to create registry store: %v", err)
+	}
+
+	if err := store.Load(); err != nil {
+		t.Fatalf("Failed to load empty registry: %v", err)
+	}
+
+	registry.Observe("uid1", now)
+	registry.Observe("uid2", now.Add(time.Second))
+	if err := store.Sync(); err != nil {
+		t.Fatalf("Failed to save registry: %v", err)
+	}
+
+	// A restarted server starts with an empty registry and restores it from the ConfigMap
+	restarted := newFirstSeenRegistry()
+	restartedStore, err := newRegistryStore(client, restarted, "default/reschedule-hook-registry")
+	if err != nil {
+		t.Fatalf("Failed to create registry store: %v", err)
+	}
+
+	if err := restartedStore.Load(); err != nil {
+		t.Fatalf("Failed to load registry: %v", err)
+	}
+
+	later := now.Add(time.Minute)
+	if firstSeen := restarted.Observe("uid1", later); !firstSeen.Equal(now) {
+		t.Fatalf("Expected first seen to be restored as %v, got %v", now, firstSeen)
+	}
+
+	if firstSeen := restarted.Observe("uid3", later); !firstSeen.Equal(later) {
+		t.Fatalf("Expected first seen of a new pod to be %v, got %v", later, firstSeen)
+	}
+}
+
+func TestRegistryStoreSyncOnlyOnChange(t *testing.T) {
+	dynamicClient := fake.NewSimpleDynamicClient(runtime.NewScheme(), registryConfigMapStub("default", "reschedule-hook-registry"))
+	registry := newFirstSeenRegistry()
+	store, err := newRegistryStore(&ClientImpl{dynamicClient: dynamicClient}, registry, "default/reschedule-hook-registry")
+	if err != nil {
+		t.Fatalf("Failed to create registry store: %v", err)
+	}
+
+	patches := func() int {
+		count := 0
+		for _, action := range dynamicClient.Actions() {
+			if action.GetVerb() == "patch" {
+				count++
+			}
+		}
+		return count
+	}
+
+	steps := []struct {
+		change          func()
+		expectedPatches int
+	}{
+		{change: func() {}, expectedPatches: 0},
+		{change: func() { registry.Observe("uid1", time.Now()) }, expectedPatches: 1},
+		{change: func() {}, expectedPatches: 1},
+		{change: func() { registry.Forget("uid2") }, expectedPatches: 1},
+		{change: func() { registry.Forget("uid1") }, expectedPatches: 2},
+	}
+
+	for i, step := range steps {
+		step.change()
+		if err := store.Sync(); err != nil {
+			t.Fatalf("Step %d: failed to save registry: %v", i, err)
+		}
+
+		if count := patches(); count != step.expectedPatches {
+			t.Fatalf("Step %d: expected %d patches, got %d", i, step.expectedPatches, count)
+		}
+	}
+}
+
+func TestRegistryStoreMissingConfigMap(t *testing.T) {
+	client := &ClientImpl{dynamicClient: fake.NewSimpleDynamicClient(runtime.NewScheme())}
+	store, err := newRegistryStore(client, newFirstSeenRegistry(), "default/reschedule-hook-registry")
+	if err != nil {
+		t.Fatalf("Failed to create registry store: %v", err)
+	}
+
+	if err := store.Load(); err == nil {
+		t.Fatalf("Expected loading from a missing ConfigMap to fail")
+	}
+
+	if _, err := newRegistryStore(client, newFirstSeenRegistry(), "reschedule-hook-registry"); err == nil {
+		t.Fatalf("Expected a ConfigMap without a namespace to be invalid")
+	}
+}
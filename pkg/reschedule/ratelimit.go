@@ -0,0 +1,80 @@
+package reschedule
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// rateLimiterIdleTimeout is how long a key's limiter can go unused before it is removed
+const rateLimiterIdleTimeout = 10 * time.Minute
+
+// RateLimiter limits the rate at which eviction requests are handled for each key, such as a namespace, so that a single
+// drain cannot starve evictions elsewhere. It is safe for concurrent use. A nil RateLimiter allows all requests.
+type RateLimiter struct {
+	mu          sync.Mutex
+	limit       rate.Limit
+	burst       int
+	limiters    map[string]*keyedLimiter
+	lastCleanup time.Time
+	clock       Clock
+}
+
+type keyedLimiter struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// NewRateLimiter creates a RateLimiter allowing requestsPerSecond for each key with the given burst. If requestsPerSecond is not
+// positive, rate limiting is disabled and nil is returned.
+func NewRateLimiter(requestsPerSecond float64, burst int, clock Clock) *RateLimiter {
+	if requestsPerSecond <= 0 {
+		return nil
+	}
+
+	return &RateLimiter{
+		limit:    rate.Limit(requestsPerSecond),
+		burst:    burst,
+		limiters: map[string]*keyedLimiter{},
+		clock:    clock,
+	}
+}
+
+// Allow reports whether a request for the given key can be handled now
+func (l *RateLimiter) Allow(key string) bool {
+	if l == nil {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+	l.cleanup(now)
+
+	limiter, exists := l.limiters[key]
+	if !exists {
+		limiter = &keyedLimiter{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.limiters[key] = limiter
+	}
+
+	limiter.lastSeen = now
+	return limiter.limiter.AllowN(now, 1)
+}
+
+// cleanup removes limiters that have been idle for longer than rateLimiterIdleTimeout. To avoid scanning the map on every
+// request, this is done at most once per rateLimiterIdleTimeout.
+func (l *RateLimiter) cleanup(now time.Time) {
+	if now.Sub(l.lastCleanup) < rateLimiterIdleTimeout {
+		return
+	}
+
+	for key, limiter := range l.limiters {
+		if now.Sub(limiter.lastSeen) >= rateLimiterIdleTimeout {
+			delete(l.limiters, key)
+		}
+	}
+
+	l.lastCleanup = now
+}
@@ -0,0 +1,91 @@
+package reschedule
+
+import (
+	"testing"
+	"time"
+)
+
+type rateLimitRequest struct {
+	key     string
+	elapsed time.Duration
+}
+
+func TestRateLimiter(t *testing.T) {
+	testcases := []struct {
+		testname string
+		rate     float64
+		burst    int
+		requests []rateLimitRequest
+		expected []bool
+	}{
+		{
+			testname: "Disabled",
+			rate:     0,
+			burst:    1,
+			requests: []rateLimitRequest{
+				{"default", 0}, {"default", 0}, {"default", 0},
+			},
+			expected: []bool{true, true, true},
+		},
+		{
+			testname: "Limited after burst",
+			rate:     1,
+			burst:    2,
+			requests: []rateLimitRequest{
+				{"default", 0}, {"default", 0}, {"default", 0},
+			},
+			expected: []bool{true, true, false},
+		},
+		{
+			testname: "Allowed again at the configured rate",
+			rate:     1,
+			burst:    1,
+			requests: []rateLimitRequest{
+				{"default", 0}, {"default", 500 * time.Millisecond}, {"default", 500 * time.Millisecond},
+			},
+			expected: []bool{true, false, true},
+		},
+		{
+			testname: "Namespaces are limited independently",
+			rate:     1,
+			burst:    1,
+			requests: []rateLimitRequest{
+				{"namespace-1", 0}, {"namespace-1", 0}, {"namespace-2", 0},
+			},
+			expected: []bool{true, false, true},
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testname, func(t *testing.T) {
+			clock := newFakeClock(time.Now())
+			limiter := NewRateLimiter(testcase.rate, testcase.burst, clock)
+
+			for i, request := range testcase.requests {
+				clock.Advance(request.elapsed)
+				if allowed := limiter.Allow(request.key); allowed != testcase.expected[i] {
+					t.Fatalf("Expected request %d for %s to be allowed=%t, got %t", i, request.key, testcase.expected[i], allowed)
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimiterCleanup(t *testing.T) {
+	clock := newFakeClock(time.Now())
+	limiter := NewRateLimiter(1, 1, clock)
+
+	limiter.Allow("idle")
+	clock.Advance(rateLimiterIdleTimeout / 2)
+	limiter.Allow("active")
+	clock.Advance(rateLimiterIdleTimeout / 2)
+	limiter.Allow("active")
+
+	if _, exists := limiter.limiters["idle"]; exists {
+		t.Fatalf("Expected idle limiter to be removed")
+	}
+
+	if _, exists := limiter.limiters["active"]; !exists {
+		t.Fatalf("Expected active limiter to be kept")
+	}
+}
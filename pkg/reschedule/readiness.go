@@ -0,0 +1,92 @@
+package reschedule
+
+import (
+	"errors"
+	"sync"
+)
+
+// readinessResult is the outcome of a single named readiness check, as reported by /readyz?verbose
+type readinessResult struct {
+	Name  string `json:"name"`
+	Ready bool   `json:"ready"`
+	Error string `json:"error,omitempty"`
+}
+
+// readinessReport is the body of /readyz?verbose
+type readinessReport struct {
+	Ready  bool              `json:"ready"`
+	Checks []readinessResult `json:"checks"`
+}
+
+// namedReadinessCheck is a readiness check registered with a readinessRegistry. The check returns an error describing why the
+// server is not ready.
+type namedReadinessCheck struct {
+	name  string
+	check func() error
+}
+
+// readinessRegistry holds the named checks that make up readiness. Checks are evaluated on demand, in the order they were
+// registered, each time readiness is probed. It is safe for concurrent use.
+type readinessRegistry struct {
+	mu     sync.RWMutex
+	checks []namedReadinessCheck
+}
+
+// newReadinessRegistry creates a registry with the shutdown check, along with the checks enabled by the readiness check if one
+// is given
+func newReadinessRegistry(readiness *readinessCheck) *readinessRegistry {
+	registry := &readinessRegistry{}
+	registry.Register("shutdown", func() error {
+		if shuttingDown.Load() {
+			return errors.New("server is shutting down")
+		}
+
+		return nil
+	})
+
+	if readiness != nil {
+		readiness.register(registry)
+	}
+
+	return registry
+}
+
+// Register adds a named check to the registry
+func (r *readinessRegistry) Register(name string, check func() error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.checks = append(r.checks, namedReadinessCheck{name: name, check: check})
+}
+
+// Evaluate runs every check and returns their results. Every check is run, even after one has failed, so that the report shows
+// each reason the server is not ready.
+func (r *readinessRegistry) Evaluate() []readinessResult {
+	r.mu.RLock()
+	checks := r.checks
+	r.mu.RUnlock()
+
+	results := make([]readinessResult, 0, len(checks))
+	for _, c := range checks {
+		result := readinessResult{Name: c.name, Ready: true}
+		if err := c.check(); err != nil {
+			result.Ready = false
+			result.Error = err.Error()
+		}
+
+		results = append(results, result)
+	}
+
+	return results
+}
+
+// allReady reports whether every readiness check passed
+func allReady(results []readinessResult) bool {
+	for _, result := range results {
+		if !result.Ready {
+			return false
+		}
+	}
+
+	return true
+}
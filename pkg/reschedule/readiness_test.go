@@ -0,0 +1,99 @@
+package reschedule
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestReadinessRegistry(t *testing.T) {
+	config := NewConfigBuilder().WithReadyRequireTrackingCRD(true).WithDeepReadiness(true, "default/readiness-canary").Build()
+
+	testcases := []struct {
+		testname        string
+		readiness       *readinessCheck
+		checks          map[string]error
+		expectedResults []readinessResult
+		expectedCode    int
+	}{
+		{
+			testname:        "Only the shutdown check",
+			expectedResults: []readinessResult{{Name: "shutdown", Ready: true}},
+			expectedCode:    http.StatusOK,
+		},
+		{
+			testname:  "Tracking resource served and canary patchable",
+			readiness: &readinessCheck{config: config, client: &mockClient{config: config}},
+			expectedResults: []readinessResult{
+				{Name: "shutdown", Ready: true},
+				{Name: "trackingResource", Ready: true},
+				{Name: "canary", Ready: true},
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			testname:  "Tracking resource not served",
+			readiness: &readinessCheck{config: config, client: &mockClient{config: config, trackingResourceNotServed: true}},
+			expectedResults: []readinessResult{
+				{Name: "shutdown", Ready: true},
+				{Name: "trackingResource", Ready: false, Error: "tracking resource couchbasecluster is not served"},
+				{Name: "canary", Ready: true},
+			},
+			expectedCode: http.StatusServiceUnavailable,
+		},
+		{
+			testname: "Registered check failing",
+			checks: map[string]error{
+				"certificate": errors.New("certificate expired"),
+			},
+			expectedResults: []readinessResult{
+				{Name: "shutdown", Ready: true},
+				{Name: "certificate", Ready: false, Error: "certificate expired"},
+			},
+			expectedCode: http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testname, func(t *testing.T) {
+			registry := newReadinessRegistry(testcase.readiness)
+			for name, err := range testcase.checks {
+				registry.Register(name, func() error { return err })
+			}
+
+			if results := registry.Evaluate(); !reflect.DeepEqual(results, testcase.expectedResults) {
+				t.Fatalf("Expected results %+v, got %+v", testcase.expectedResults, results)
+			}
+
+			// The plain probe only reports the aggregate status code
+			recorder := httptest.NewRecorder()
+			serveReadiness(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil), registry)
+			if recorder.Code != testcase.expectedCode {
+				t.Fatalf("Expected status code %d, got %d", testcase.expectedCode, recorder.Code)
+			}
+
+			if recorder.Body.Len() != 0 {
+				t.Fatalf("Expected no body without verbose, got %q", recorder.Body.String())
+			}
+
+			recorder = httptest.NewRecorder()
+			serveReadiness(recorder, httptest.NewRequest(http.MethodGet, "/readyz?verbose", nil), registry)
+			if recorder.Code != testcase.expectedCode {
+				t.Fatalf("Expected verbose status code %d, got %d", testcase.expectedCode, recorder.Code)
+			}
+
+			var report readinessReport
+			if err := json.Unmarshal(recorder.Body.Bytes(), &report); err != nil {
+				t.Fatalf("Failed to decode readiness report %q: %v", recorder.Body.String(), err)
+			}
+
+			expected := readinessReport{Ready: testcase.expectedCode == http.StatusOK, Checks: testcase.expectedResults}
+			if !reflect.DeepEqual(report, expected) {
+				t.Fatalf("Expected report %+v, got %+v", expected, report)
+			}
+		})
+	}
+}
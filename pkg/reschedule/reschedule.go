@@ -6,100 +6,491 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"hash/fnv"
 	"io"
 	"log/slog"
+	"mime"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	admissionv1 "k8s.io/api/admission/v1"
 	corev1 "k8s.io/api/core/v1"
 	policyv1 "k8s.io/api/policy/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
 )
 
 const (
 	PodWaitingForRescheduleMsg                        = "Pod waiting to be rescheduled"
 	PodNoLongerExistsMsg                              = "Pod no longer exists"
 	PodRescheduledWithSameNameMsg                     = "Pod has been rescheduled with the same name"
+	PodRescheduledToDifferentNodeMsg                  = "Pod has been rescheduled with the same name to a different node"
+	PodWaitingForAnnotationDelayMsg                   = "Pod waiting for the first annotation delay before being marked for rescheduling"
 	RescheduleAnnotationAddedToPodMsg                 = "Reschedule annotation added to pod"
 	FailedToAddRescheduleAnnotationMsg                = "Failed to add reschedule annotation to pod"
 	FailedToGetTrackingResourceMsg                    = "Failed to get rescheduled pods tracking resource"
+	TrackingResourceNotFoundMsg                       = "Rescheduled pods tracking resource not found"
+	NoTrackingInstanceNameMsg                         = "Unable to determine rescheduled pods tracking resource for pod"
+	DryRunWarningMsg                                  = "Pods will not be marked for rescheduling on a dry run"
+	RateLimitExceededMsg                              = "Too many eviction requests for namespace, please retry"
+	InvalidEvictionMsg                                = "Invalid eviction request"
+	APIServerUnavailableMsg                           = "Kubernetes API server unavailable, please retry"
+	PodChangedDuringRescheduleMsg                     = "Pod changed while adding reschedule annotation, please retry"
 	FailedToRemoveRescheduleHookTrackingAnnotationMsg = "Failed to remove tracking annotation from rescheduled pods tracking resource"
 	FailedToGetPodMsg                                 = "Failed to get pod"
+	FailedToGetNodeMsg                                = "Failed to get pod's node"
+	FailedToCountPodsMsg                              = "Failed to count pods in pod's cluster"
+	FailedToListPeerPodsMsg                           = "Failed to list pods in pod's cluster"
+	PodWaitingForHigherPriorityPodsMsg                = "Pod waiting for higher priority pods on the node to be rescheduled"
 	FailedToAddRescheduleHookTrackingAnnotationMsg    = "Failed to add annotation to rescheduled pods tracking resource"
+	TrackingAnnotationSizeLimitMsg                    = "Rescheduled pods tracking resource annotations are too close to the Kubernetes size limit"
+	WebhookShuttingDownMsg                            = "Eviction webhook shutting down, please retry"
+	AuthenticationFailedMsg                           = "Eviction webhook failed to authenticate to the Kubernetes API server"
+	ClusterNotReadyToRescheduleMsg                    = "Cluster not ready to reschedule pods, please retry"
 )
 
-func tlsConfig(config *Config) *tls.Config {
-	cert, err := tls.LoadX509KeyPair(config.certFile, config.keyFile)
-	if err != nil {
-		slog.Error("Unable to load TLS certificate", "error", err)
-		os.Exit(1)
-	}
+// shuttingDown is set once the server has been asked to shut down
+var shuttingDown atomic.Bool
 
+// AuditAnnotationRequestingUser is the audit annotation key used to record the user that requested the eviction
+const AuditAnnotationRequestingUser = "requesting-user"
+
+// apiServerTimeoutMarginPercent is how far below the API server's timeout an eviction request is bounded, leaving time for the
+// response to be written and received
+const apiServerTimeoutMarginPercent = 10
+
+// maxDebugBodySize is the maximum number of bytes of a request body that will be logged at debug level
+const maxDebugBodySize = 4096
+
+func tlsConfig(reloader *certificateReloader) *tls.Config {
 	return &tls.Config{
-		Certificates: []tls.Certificate{cert},
+		GetCertificate: reloader.GetCertificate,
+	}
+}
+
+// reloadCertificateOnSignal reloads the TLS certificate each time a signal is received until the channel is closed
+func reloadCertificateOnSignal(signals <-chan os.Signal, reloader *certificateReloader) {
+	for range signals {
+		if err := reloader.Reload(); err != nil {
+			slog.Error("Failed to reload TLS certificate, keeping the current certificate", "error", err)
+			continue
+		}
+
+		slog.Info("Reloaded TLS certificate", "notAfter", reloader.current().NotAfter)
+	}
+}
+
+// dumpStateOnSignal logs the server's state each time a signal is received until the channel is closed
+func dumpStateOnSignal(signals <-chan os.Signal, config *Config) {
+	for range signals {
+		dumpState(config)
+	}
+}
+
+// dumpState logs the effective config, the number of pods in the first seen registry and the metric totals, for debugging a
+// running server where the admin and metrics endpoints are not exposed
+func dumpState(config *Config) {
+	registryPods := 0
+	if config.firstSeen != nil {
+		pods, _ := config.firstSeen.Snapshot()
+		registryPods = len(pods)
 	}
+
+	slog.Info("State dump",
+		"config", config.String(),
+		"registryPods", registryPods,
+		slog.Group("metrics", metricsSummary()...),
+	)
 }
 
 func Serve() {
 	// Config is loaded from environment variables or default values if not set
 	config := NewConfigBuilder().FromEnvironment().Build()
+	slog.SetLogLoggerLevel(config.logLevel)
+
+	if err := config.Validate(); err != nil {
+		slog.Error("Invalid config", "error", err)
+		os.Exit(1)
+	}
+
+	waitingPods.SetLimit(config.metricsInstanceLimit)
+
+	limiter := NewRateLimiter(config.rateLimit, config.rateLimitBurst, config.clock)
+	breaker := NewCircuitBreaker(config.circuitBreakerThreshold, config.circuitBreakerWindow, config.circuitBreakerCooldown, config.clock)
+
+	// The registry is restored before serving so that delays continue from when evictions were first requested
+	store := loadRegistryStore(config)
+	syncCtx, stopSync := context.WithCancel(context.Background())
+	defer stopSync()
+	if store != nil {
+		go store.Run(syncCtx, config.registrySaveInterval)
+	}
+
+	// Decisions are sent to NOTIFY_URL in the background so that a slow receiver never delays the admission response
+	var notifier *Notifier
+	notifyCtx, stopNotify := context.WithCancel(context.Background())
+	defer stopNotify()
+	if config.notifyURL != "" {
+		notifier = NewNotifier(config.notifyURL, config.notifyTimeout, notifyQueueSize, config.decisionJSONCase)
+		go notifier.Run(notifyCtx)
+	}
+
+	var decisionLog *DecisionLogWriter
+	if config.decisionLog == DecisionLogStdout {
+		decisionLog = NewDecisionLogWriter(os.Stdout, config.decisionJSONCase)
+	}
+
+	// Reporting decisions never delays or fails the admission response
+	telemetry := NewTelemetry(config.telemetryTimeout)
+
+	evictionVersion := EvictionVersionV1
+	if client, err := NewClient(config, false); err == nil {
+		evictionVersion = discoverEvictionVersion(client)
+	} else {
+		slog.Warn("Failed to create Kubernetes client for discovery, assuming Eviction API v1", "error", err)
+	}
 
 	mux := http.NewServeMux()
-	mux.HandleFunc("/", serveDefault)
-	mux.HandleFunc("/readyz", serveReadiness)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		serveDefault(w, r, config)
+	})
+	readiness, err := newReadinessCheck(config)
+	if err != nil {
+		slog.Error("Failed to create Kubernetes client for readiness checks", "error", err)
+		os.Exit(1)
+	}
+	readinessChecks := newReadinessRegistry(readiness)
+	registerHealthHandlers(mux, readinessChecks)
 	mux.HandleFunc("/eviction", func(w http.ResponseWriter, r *http.Request) {
-		serveEviction(w, r, config)
+		serveEviction(w, r, config, limiter, breaker, telemetry, notifier, decisionLog, evictionVersion)
 	})
+	if config.adminEndpoints {
+		mux.HandleFunc("/admin/reset-tracking", func(w http.ResponseWriter, r *http.Request) {
+			client, err := NewClient(config, false)
+			if err != nil {
+				slog.Error("Failed to create Kubernetes client", "error", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
 
-	tlsConfig := tlsConfig(config)
-	server := &http.Server{
-		Addr:         ":8443",
-		TLSConfig:    tlsConfig,
-		Handler:      mux,
-		ReadTimeout:  10 * time.Second,
-		WriteTimeout: 10 * time.Second,
-		IdleTimeout:  30 * time.Second,
+			serveResetTracking(w, r, client)
+		})
+	}
+
+	reloader, err := newCertificateReloader(config.certFile, config.keyFile, config.clock)
+	if err != nil {
+		slog.Error("Unable to load TLS certificate", "error", err)
+		os.Exit(1)
+	}
+
+	// The certificate is reloaded on SIGHUP without restarting the listener
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	go reloadCertificateOnSignal(reload, reloader)
+
+	// The config and a summary of the server's state are logged on SIGUSR1, for debugging without the HTTP endpoints
+	dump := make(chan os.Signal, 1)
+	signal.Notify(dump, syscall.SIGUSR1)
+	go dumpStateOnSignal(dump, config)
+
+	server := newServer(config, reloader, mux)
+
+	// Health probes can be served without TLS on a separate port, for example where network policies only allow probes there
+	var healthServer *http.Server
+	if config.healthAddr != "" {
+		healthMux := http.NewServeMux()
+		registerHealthHandlers(healthMux, readinessChecks)
+		healthServer = newHealthServer(config, healthMux)
+
+		healthListener, err := net.Listen("tcp", healthServer.Addr)
+		if err != nil {
+			slog.Error("Health server failed to start", "error", err)
+			os.Exit(1)
+		}
+
+		go func() {
+			slog.Info("Health server started", "addr", healthServer.Addr)
+			if err := healthServer.Serve(healthListener); !errors.Is(err, http.ErrServerClosed) {
+				slog.Error("Health server failed to start", "error", err)
+			}
+		}()
+	}
+
+	// Listen before serving so that the CA check can connect as soon as it starts
+	listener, err := net.Listen("tcp", server.Addr)
+	if err != nil {
+		slog.Error("Server failed to start", "error", err)
+		os.Exit(1)
 	}
 
 	go func() {
 		slog.Info("Reschedule hook server started")
 		config.Print()
-		if err := server.ListenAndServeTLS("", ""); !errors.Is(err, http.ErrServerClosed) {
+		if err := server.ServeTLS(listener, "", ""); !errors.Is(err, http.ErrServerClosed) {
 			slog.Error("Server failed to start", "error", err)
 		}
 	}()
 
+	checkCtx, stopCheck := context.WithCancel(context.Background())
+	defer stopCheck()
+	if config.caBundleFile != "" {
+		go runCACheck(checkCtx, "localhost"+server.Addr, config.caBundleFile, config.caCheckInterval)
+	}
+	if config.validateCABundle {
+		checkWebhookCABundle(config, reloader)
+	}
+
 	// Gracefully handle server shutdown
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
 
 	<-stop
+	shutdownServers(config, server, healthServer)
+
+	if store != nil {
+		stopSync()
+		if err := store.Sync(); err != nil {
+			slog.Warn("Failed to save registry", "configMap", config.registryConfigMap, "error", err)
+		}
+	}
+
+	closeClients(readiness, store)
+	slog.Info("Server exited")
+}
+
+// shutdownServers shuts down the webhook server and the health server, if there is one, in order. First, for the shutdown
+// delay, both keep serving while /readyz returns 503 and evictions are denied with 429, so that the endpoint is removed from
+// the service and drains retry against another replica rather than seeing connection errors. The webhook server is then shut
+// down, followed by the health server, so that probes are answered until the webhook stops listening.
+func shutdownServers(config *Config, server, healthServer *http.Server) {
+	shuttingDown.Store(true)
+	if config.shutdownDelay > 0 {
+		// Keep answering requests while the server is removed from the service endpoints
+		slog.Info("Shutdown requested, denying evictions until the shutdown delay has passed", "delay", config.shutdownDelay)
+		time.Sleep(config.shutdownDelay)
+	}
+
 	slog.Info("Shutting down reschedule hook server")
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), config.shutdownTimeout)
 	defer cancel()
 
 	if err := server.Shutdown(ctx); err != nil {
 		slog.Error("Server shutdown failed", "error", err)
 	}
 
-	slog.Info("Server exited")
+	if healthServer != nil {
+		if err := healthServer.Shutdown(ctx); err != nil {
+			slog.Error("Health server shutdown failed", "error", err)
+		}
+	}
+}
+
+// closeClients closes the clients that are kept for the lifetime of the server, once they are no longer used. Clients created for
+// each request are left to be garbage collected, as they share their transport with the clients that are kept.
+func closeClients(readiness *readinessCheck, store *registryStore) {
+	if readiness != nil {
+		readiness.client.Close()
+	}
+
+	if store != nil {
+		store.client.Close()
+	}
+}
+
+// newServer creates the HTTP server for the webhook, serving the reloader's certificate with the configured timeouts
+func newServer(config *Config, reloader *certificateReloader, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:         ":8443",
+		TLSConfig:    tlsConfig(reloader),
+		Handler:      handler,
+		ReadTimeout:  config.readTimeout,
+		WriteTimeout: config.writeTimeout,
+		IdleTimeout:  config.idleTimeout,
+	}
 }
 
-func serveReadiness(w http.ResponseWriter, r *http.Request) {
+// newHealthServer creates the plain HTTP server for the health endpoints, with the same timeouts as the webhook server
+func newHealthServer(config *Config, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:         config.healthAddr,
+		Handler:      handler,
+		ReadTimeout:  config.readTimeout,
+		WriteTimeout: config.writeTimeout,
+		IdleTimeout:  config.idleTimeout,
+	}
+}
+
+// registerHealthHandlers registers the liveness, readiness and metrics endpoints, which are served by both the webhook server
+// and the health server
+func registerHealthHandlers(mux *http.ServeMux, readiness *readinessRegistry) {
+	mux.HandleFunc("/healthz", serveHealth)
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		serveReadiness(w, r, readiness)
+	})
+	mux.Handle("/metrics", metricsHandler())
+}
+
+// serveHealth reports the server as live. Unlike readiness, this does not change while shutting down, so the pod is not
+// restarted during the shutdown delay.
+func serveHealth(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
-func serveDefault(w http.ResponseWriter, r *http.Request) {
+// loadRegistryStore creates the store for the first seen registry and restores the registry from it, if a registry ConfigMap
+// is configured. If the registry cannot be restored, the server starts with an empty registry but still saves changes to it.
+func loadRegistryStore(config *Config) *registryStore {
+	if config.firstSeen == nil || config.registryConfigMap == "" {
+		return nil
+	}
+
+	client, err := NewClient(config, false)
+	if err != nil {
+		slog.Warn("Failed to create Kubernetes client for the registry, it will not be saved", "error", err)
+		return nil
+	}
+
+	store, err := newRegistryStore(client, config.firstSeen, config.registryConfigMap)
+	if err != nil {
+		slog.Warn("Invalid registry ConfigMap, the registry will not be saved", "configMap", config.registryConfigMap, "error", err)
+		return nil
+	}
+
+	if err := store.Load(); err != nil {
+		slog.Warn("Failed to restore registry, starting with an empty registry", "configMap", config.registryConfigMap, "error", err)
+	} else {
+		slog.Info("Restored registry", "configMap", config.registryConfigMap)
+	}
+
+	return store
+}
+
+// readinessCheck holds the readiness checks that need a Kubernetes client. The client is created once so that discovery is not
+// repeated for every probe.
+type readinessCheck struct {
+	config *Config
+	client Client
+	// patchable is set once the deep readiness check has passed, after which it is not repeated
+	patchable atomic.Bool
+}
+
+// newReadinessCheck creates the readiness checks enabled by the config, or returns nil if none are enabled
+func newReadinessCheck(config *Config) (*readinessCheck, error) {
+	if !(config.readyRequireTrackingCRD && config.trackRescheduledPods) && !config.deepReadiness {
+		return nil, nil
+	}
+
+	client, err := NewClient(config, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return &readinessCheck{config: config, client: client}, nil
+}
+
+// ready checks whether the tracking resource is served by the API server, as tracked evictions will fail until then, and
+// whether the deep readiness canary can be patched, when these are enabled
+func (c *readinessCheck) ready() bool {
+	return c.trackingResourceServed() == nil && c.canaryPatchable() == nil
+}
+
+// register adds the readiness checks enabled by the config to the registry
+func (c *readinessCheck) register(registry *readinessRegistry) {
+	if c.config.readyRequireTrackingCRD && c.config.trackRescheduledPods {
+		registry.Register("trackingResource", c.trackingResourceServed)
+	}
+
+	if c.config.deepReadiness {
+		registry.Register("canary", c.canaryPatchable)
+	}
+}
+
+// trackingResourceServed checks whether the tracking resource is served by the API server, if this is required
+func (c *readinessCheck) trackingResourceServed() error {
+	if !c.config.readyRequireTrackingCRD || !c.config.trackRescheduledPods {
+		return nil
+	}
+
+	served, err := c.client.IsTrackingResourceServed()
+	if err != nil {
+		slog.Error("Failed to check tracking resource is served", "error", err)
+		return fmt.Errorf("failed to check tracking resource is served: %w", err)
+	}
+
+	if !served {
+		slog.Warn("Tracking resource not served, not ready", "trackingResource", c.config.trackingResource.GetResourceType())
+		return fmt.Errorf("tracking resource %s is not served", c.config.trackingResource.GetResourceType())
+	}
+
+	return nil
+}
+
+// canaryPatchable checks whether the deep readiness canary can be patched, if deep readiness is enabled. Once the check has
+// passed it is not repeated.
+func (c *readinessCheck) canaryPatchable() error {
+	if !c.config.deepReadiness || c.patchable.Load() {
+		return nil
+	}
+
+	namespace, name := c.config.readinessCanaryName()
+	if err := c.client.DryRunPatchTrackingResource(name, namespace); err != nil {
+		slog.Warn("Readiness canary cannot be patched, not ready", "trackingResource", c.config.trackingResource.GetResourceType(), "canary", c.config.readinessCanary, "error", err)
+		return fmt.Errorf("readiness canary %s cannot be patched: %w", c.config.readinessCanary, err)
+	}
+
+	c.patchable.Store(true)
+	return nil
+}
+
+// serveReadiness reports the server as ready once every check in the registry passes. The server is never ready while shutting
+// down. With the verbose query parameter, the result of each check is returned as JSON along with the same status code.
+func serveReadiness(w http.ResponseWriter, r *http.Request, registry *readinessRegistry) {
+	results := registry.Evaluate()
+
+	code := http.StatusOK
+	if !allReady(results) {
+		code = http.StatusServiceUnavailable
+	}
+
+	if !r.URL.Query().Has("verbose") {
+		w.WriteHeader(code)
+		return
+	}
+
+	body, err := json.Marshal(readinessReport{Ready: code == http.StatusOK, Checks: results})
+	if err != nil {
+		slog.Error("Failed to encode readiness report", "error", err)
+		w.WriteHeader(code)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_, _ = w.Write(body)
+}
+
+func serveDefault(w http.ResponseWriter, r *http.Request, config *Config) {
+	// Load balancer health checks may probe the root path
+	if config.rootOK && r.URL.Path == "/" && r.Method == http.MethodGet {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
 	slog.Error("Unexpected request", "path", r.URL.String())
 	w.WriteHeader(http.StatusNotFound)
 }
 
-func serveEviction(w http.ResponseWriter, r *http.Request, config *Config) {
+func serveEviction(w http.ResponseWriter, r *http.Request, config *Config, limiter *RateLimiter, breaker *CircuitBreaker, telemetry *Telemetry, notifier *Notifier, decisionLog *DecisionLogWriter, evictionVersion string) {
 	var body []byte
 	if r.Body != nil {
 		if data, err := io.ReadAll(r.Body); err == nil {
@@ -113,7 +504,7 @@ func serveEviction(w http.ResponseWriter, r *http.Request, config *Config) {
 
 	// Ensure the content is JSON before decoding it
 	contentType := r.Header.Get("Content-Type")
-	if contentType != "application/json" {
+	if !isJSONContentType(contentType) {
 		slog.Error("Unsupported Content-Type", "content-type", contentType)
 		w.WriteHeader(http.StatusUnsupportedMediaType)
 		return
@@ -127,37 +518,135 @@ func serveEviction(w http.ResponseWriter, r *http.Request, config *Config) {
 		return
 	}
 
+	logAdmissionReview(r.Context(), slog.Default(), body, &reviewRequest)
+
+	// A review without a request has nothing to answer, as the response must echo the request UID
+	if reviewRequest.Request == nil {
+		slog.Error("Admission review has no request")
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	// Evictions are always sent as a CREATE of the eviction subresource. Any other operation is allowed without being handled,
+	// so that a misconfigured webhook never blocks unrelated requests.
+	if operation := reviewRequest.Request.Operation; operation != admissionv1.Create {
+		slog.Warn("Unsupported admission operation, request allowed", "operation", operation, "reason_code", ReasonUnsupportedOperation)
+		response := allowEviction()
+		finaliseResponse(response, reviewRequest.Request, false)
+		writeAdmissionReview(w, response, isPrettyRequested(r))
+		return
+	}
+
+	// Only evictions are handled, so that a webhook registered for other pod requests, such as plain creates, never marks or
+	// tracks pods
+	if request := reviewRequest.Request; !isEvictionRequest(request) {
+		slog.Warn("Admission request is not for the eviction subresource, request allowed", "resource", request.Resource.Resource, "subresource", request.SubResource, "kind", request.Kind.Kind, "reason_code", ReasonUnsupportedSubresource)
+		response := allowEviction()
+		finaliseResponse(response, reviewRequest.Request, false)
+		writeAdmissionReview(w, response, isPrettyRequested(r))
+		return
+	}
+
 	// Decode the review body into an eviction request
-	var eviction policyv1.Eviction
-	if err := json.Unmarshal(reviewRequest.Request.Object.Raw, &eviction); err != nil {
+	eviction, err := decodeEviction(reviewRequest.Request.Object.Raw, evictionVersion)
+	if err != nil {
 		slog.Error("Failed to decode eviction request", "error", err)
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
 
+	defaultEvictionNamespace(&eviction, reviewRequest.Request)
+
 	dryRun := isDryRun(&eviction)
+	logger := evictionLogger(&eviction, reviewRequest.Request, dryRun)
 
-	// Initialise the Kubernetes client
-	client, err := NewClient(config, dryRun)
-	if err != nil {
-		slog.Error("Failed to create Kubernetes client", "error", err)
-		w.WriteHeader(http.StatusInternalServerError)
-		return
+	var decision Decision
+	switch {
+	case shuttingDown.Load():
+		// The drain command is told to retry so that the eviction is handled by a surviving replica. This is answered before
+		// creating a client, so that evictions are still denied with a retriable status if the API server cannot be reached
+		logger.Info("Webhook shutting down, eviction will be retried", "reason_code", ReasonShuttingDown)
+		decision = newDecision(ReasonShuttingDown, denyEviction(http.StatusTooManyRequests, metav1.StatusReasonTooManyRequests, WebhookShuttingDownMsg))
+	case !limiter.Allow(eviction.Namespace):
+		// The drain command will retry evictions denied with StatusReasonTooManyRequests
+		logger.Info("Rate limit exceeded for namespace", "reason_code", ReasonRateLimited)
+		decision = newDecision(ReasonRateLimited, denyEviction(http.StatusTooManyRequests, metav1.StatusReasonTooManyRequests, RateLimitExceededMsg))
+	case !breaker.Allow():
+		// Fail fast rather than waiting on an API server that is known to be unavailable
+		logger.Warn("Circuit breaker open, API server unavailable", "reason_code", ReasonAPIServerUnavailable)
+		decision = newDecision(ReasonAPIServerUnavailable, denyEviction(http.StatusTooManyRequests, metav1.StatusReasonTooManyRequests, APIServerUnavailableMsg))
+	default:
+		// Initialise the Kubernetes client
+		client, err := NewClient(config, dryRun)
+		if err != nil {
+			slog.Error("Failed to create Kubernetes client", "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		// Handle the eviction request, stopping before the API server gives up waiting for the response
+		ctx := r.Context()
+		if timeout := requestTimeout(r, config.requestTimeout); timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+
+		decision = decideEviction(ctx, eviction, breaker.Wrap(client), config, logger)
+		logDecision(decision, logger)
 	}
 
-	logger := CreateLogger(eviction.Name, eviction.Namespace, dryRun)
+	response := decision.Response
+	finaliseResponse(response, reviewRequest.Request, dryRun)
+	now := config.clock.Now()
+	telemetry.Emit("notify", func() {
+		notifier.Notify(newDecisionNotification(&eviction, reviewRequest.Request, dryRun, decision, now))
+	})
+	telemetry.Emit("decisionLog", func() {
+		decisionLog.Write(newDecisionRecord(&eviction, reviewRequest.Request, dryRun, decision, now))
+	})
+	writeAdmissionReview(w, response, isPrettyRequested(r))
+}
 
-	// Handle the eviction request
-	response := handleEviction(eviction, client, logger)
+// requestTimeout returns how long the eviction request should be handled for, or 0 if it is not bounded. The API server sends
+// the webhook's timeoutSeconds in the timeout query parameter, and the request is bounded below it by
+// apiServerTimeoutMarginPercent so that the response is written before the API server gives up. If configured is positive, it
+// bounds the request further.
+func requestTimeout(r *http.Request, configured time.Duration) time.Duration {
+	timeout := configured
 
-	if dryRun && response.Result != nil {
-		response.Result.Message = fmt.Sprintf("%s (server dry run)", response.Result.Message)
-		response.Warnings = append(response.Warnings, "Pods will not be marked for rescheduling on a dry run")
+	if val := r.URL.Query().Get("timeout"); val != "" {
+		apiServerTimeout, err := time.ParseDuration(val)
+		if err != nil || apiServerTimeout <= 0 {
+			slog.Warn("Invalid timeout in admission request, ignoring", "timeout", val)
+			return timeout
+		}
+
+		apiServerTimeout -= apiServerTimeout * apiServerTimeoutMarginPercent / 100
+		if timeout <= 0 || apiServerTimeout < timeout {
+			timeout = apiServerTimeout
+		}
 	}
 
-	// Set the UID of the response to the UID of the request
-	response.UID = reviewRequest.Request.UID
+	return timeout
+}
+
+// isEvictionRequest checks whether the admission request is for the eviction subresource of a pod, or is for an Eviction
+// object. RequestSubResource is checked too, in case the request was converted from the subresource it was originally made to.
+func isEvictionRequest(request *admissionv1.AdmissionRequest) bool {
+	return request.SubResource == EvictionSubresource || request.RequestSubResource == EvictionSubresource ||
+		(request.Kind.Group == EvictionGroup && request.Kind.Kind == EvictionKind)
+}
 
+// isPrettyRequested checks whether the request asked for indented JSON with ?pretty=true, for reading responses when calling the
+// webhook manually. The API server never sets this, so its responses are always compact.
+func isPrettyRequested(r *http.Request) bool {
+	pretty, _ := strconv.ParseBool(r.URL.Query().Get("pretty"))
+	return pretty
+}
+
+// writeAdmissionReview writes an admission review containing the response, indenting the JSON if pretty is set
+func writeAdmissionReview(w http.ResponseWriter, response *admissionv1.AdmissionResponse, pretty bool) {
 	// Create the admission review response
 	review := admissionv1.AdmissionReview{
 		TypeMeta: metav1.TypeMeta{
@@ -168,7 +657,13 @@ func serveEviction(w http.ResponseWriter, r *http.Request, config *Config) {
 	}
 
 	// Marshal to JSON and write the response
-	resp, err := json.Marshal(review)
+	var resp []byte
+	var err error
+	if pretty {
+		resp, err = json.MarshalIndent(review, "", "  ")
+	} else {
+		resp, err = json.Marshal(review)
+	}
 	if err != nil {
 		slog.Error("Failed to encode admission review response", "error", err)
 	}
@@ -179,93 +674,1100 @@ func serveEviction(w http.ResponseWriter, r *http.Request, config *Config) {
 	}
 }
 
-func handleEviction(eviction policyv1.Eviction, client Client, logger *slog.Logger) *admissionv1.AdmissionResponse {
-	logger.Info("Handling eviction request")
+// isJSONContentType checks whether a Content-Type header is application/json, ignoring parameters such as the charset
+func isJSONContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && mediaType == "application/json"
+}
 
-	pod, err := client.GetPod(eviction.Name, eviction.Namespace)
-	// If the pod doesn't exist, we can assume that it has already been evicted
-	if err != nil {
-		if k8serrors.IsNotFound(err) {
-			logger.Info("Pod no longer exists")
-			return denyEviction(http.StatusNotFound, metav1.StatusReasonNotFound, PodNoLongerExistsMsg)
+// evictionLogger creates the logger for an eviction request, including the user that requested the eviction
+func evictionLogger(eviction *policyv1.Eviction, request *admissionv1.AdmissionRequest, dryRun bool) *slog.Logger {
+	return CreateLogger(eviction.Name, eviction.Namespace, dryRun).With("user", requestingUser(request))
+}
+
+// requestingUser returns the username of the identity that requested the eviction, for example the user running the drain command
+func requestingUser(request *admissionv1.AdmissionRequest) string {
+	if request == nil {
+		return ""
+	}
+
+	return request.UserInfo.Username
+}
+
+// finaliseResponse sets the fields of the admission response that depend on the admission request rather than the eviction decision
+func finaliseResponse(response *admissionv1.AdmissionResponse, request *admissionv1.AdmissionRequest, dryRun bool) {
+	if dryRun && response.Result != nil {
+		response.Result.Message = fmt.Sprintf("%s (server dry run)", response.Result.Message)
+		response.Warnings = append(response.Warnings, DryRunWarningMsg)
+	}
+
+	if user := requestingUser(request); user != "" {
+		if response.AuditAnnotations == nil {
+			response.AuditAnnotations = map[string]string{}
 		}
+		response.AuditAnnotations[AuditAnnotationRequestingUser] = user
+	}
+
+	// Set the UID of the response to the UID of the request
+	response.UID = request.UID
+}
 
-		logger.Error("Failed to get pod", "error", err)
-		return denyEviction(http.StatusInternalServerError, metav1.StatusReasonInternalError, FailedToGetPodMsg)
+// logAdmissionReview logs the raw request body, capped at maxDebugBodySize, and the key fields of the decoded admission review.
+// This is only done when the logger is enabled at debug level.
+func logAdmissionReview(ctx context.Context, logger *slog.Logger, body []byte, review *admissionv1.AdmissionReview) {
+	if !logger.Enabled(ctx, slog.LevelDebug) {
+		return
 	}
 
-	// If the pod does not have the correct label, we can allow the eviction immediately
-	if pod.Labels[client.GetConfig().podLabelSelectorKey] != client.GetConfig().podLabelSelectorValue {
-		logger.Info(fmt.Sprintf("Pod does not have the %s=%s label, eviction allowed", client.GetConfig().podLabelSelectorKey, client.GetConfig().podLabelSelectorValue))
-		return allowEviction()
+	size := len(body)
+	truncated := size > maxDebugBodySize
+	if truncated {
+		body = body[:maxDebugBodySize]
 	}
 
-	// If the pod has already been marked for rescheduling, we can exit here but deny the eviction to keep the drain command
-	// in a loop until the pod no longer exists
-	if reschedule, exists := pod.GetAnnotations()[client.GetConfig().rescheduleAnnotationKey]; exists && reschedule == client.GetConfig().rescheduleAnnotationValue {
-		logger.Info("Pod waiting to be rescheduled")
-		return denyEviction(http.StatusTooManyRequests, metav1.StatusReasonTooManyRequests, PodWaitingForRescheduleMsg)
+	logger.Debug("Received admission review request body", "body", string(body), "size", size, "truncated", truncated)
+
+	if review.Request == nil {
+		logger.Debug("Admission review has no request")
+		return
 	}
 
-	// If the pod does not have the reschedule annotation, it's possible it has already been rescheduled with the same name.
-	// When the TrackRescheduledPods config value has been enabled, we will use an annotation on another resource to track which pods have already been rescheduled
-	// If the pod is missing the reschedule annotation, but is present in this tracking list, we can assume it has already been rescheduled with the same name
-	if client.ShouldTrackRescheduledPods() {
-		response := trackRescheduledPods(client, pod, logger)
-		if response != nil {
-			return response
+	dryRun := review.Request.DryRun != nil && *review.Request.DryRun
+	logger.Debug("Decoded admission review",
+		"uid", review.Request.UID,
+		"dryRun", dryRun,
+		"resource", review.Request.Resource.String(),
+		"subResource", review.Request.SubResource)
+}
+
+// recordRescheduleAttempt increments the attempts annotation on the pod when enabled. Failures are logged but do not affect
+// the eviction response, as the counter is only used for debugging.
+func recordRescheduleAttempt(client Client, config *Config, pod *corev1.Pod, logger *slog.Logger) {
+	if !config.trackAttempts {
+		return
+	}
+
+	if err := client.RecordRescheduleAttempt(pod); err != nil {
+		logger.Warn("Failed to record reschedule attempt", "error", err)
+	}
+}
+
+// cleanupPodAnnotations removes stale reschedule and reschedule.hook annotations from a pod whose eviction is being allowed,
+// when enabled. Failures are logged but do not affect the eviction response.
+func cleanupPodAnnotations(client Client, config *Config, pod *corev1.Pod, logger *slog.Logger) {
+	if config.cleanupPodAnnotations {
+		removeHookAnnotations(client, config, pod, logger)
+	}
+}
+
+// removeHookAnnotations removes the reschedule and reschedule.hook annotations from a pod. The force tracking, approval and
+// reschedule done annotations are left in place as they are set by users and the operator rather than the hook, and the eviction may still be
+// retried if it is denied by a PodDisruptionBudget. Failures are logged but do not affect the eviction response.
+func removeHookAnnotations(client Client, config *Config, pod *corev1.Pod, logger *slog.Logger) {
+	rescheduleAnnotations := config.rescheduleAnnotationSet()
+
+	var stale []string
+	for key := range pod.GetAnnotations() {
+		if key == config.forceTrackingAnnotation || key == config.approvalAnnotation || key == config.rescheduleDoneAnnotation {
+			continue
+		}
+
+		if _, ok := rescheduleAnnotations[key]; ok || strings.HasPrefix(key, RescheduledPodsTrackingKeyPrefix) {
+			stale = append(stale, key)
 		}
 	}
 
-	// At this point, we can assume the pod has not already been rescheduled and should therefore be marked for rescheduling
-	logger.Info("Adding reschedule annotation to pod")
-	err = client.ReschedulePod(pod)
-	if err != nil {
-		logger.Error("Failed to add reschedule annotation to pod", "error", err)
-		return denyEviction(http.StatusInternalServerError, metav1.StatusReasonInternalError, FailedToAddRescheduleAnnotationMsg)
+	if len(stale) == 0 {
+		return
 	}
 
-	// By denying the eviction with StatusReasonTooManyRequests, the drain command will continue attempting to evict
-	// the pod every 5 seconds until it has been rescheduled correctly
-	return denyEviction(http.StatusTooManyRequests, metav1.StatusReasonTooManyRequests, RescheduleAnnotationAddedToPodMsg)
+	slices.Sort(stale)
+	logger.Info("Removing stale annotations from pod", "annotations", stale)
+	if err := client.RemovePodAnnotations(pod, stale...); err != nil {
+		logger.Warn("Failed to remove stale annotations from pod", "error", err)
+	}
 }
 
-// trackRescheduledPods handles situations where a pod may have been rescheduled with the same name. This method will
-// check for the existence of a tracking annotation on the tracking resource.
-// If a tracking annotation already exists for the pod, it must have already been rescheduled with the same name.
-// We can therefore remove the tracking annotation and return a 404.
-// If the tracking resource does not have a tracking annotation for the pod and the pod will be rescheduled with the same name,
-// we will add a tracking annotation before marking the pod for rescheduling.
-func trackRescheduledPods(client Client, pod *corev1.Pod, logger *slog.Logger) *admissionv1.AdmissionResponse {
-	trackingResourceInstance, err := client.GetTrackingResourceInstance(client.GetConfig().trackingResource.GetInstanceName(pod), pod.Namespace)
-	if err != nil {
-		logger.Error("Failed to get tracking resource", "error", err)
-		return denyEviction(http.StatusInternalServerError, metav1.StatusReasonInternalError, FailedToGetTrackingResourceMsg)
+// completeReschedule removes the hook's annotations from a pod whose replacement the operator has completed, along with the pod's
+// tracking annotation on its tracking resource. Tracking resources are resolved from owner references, so the full pod is
+// fetched when tracking is enabled. Failures are logged but do not affect the eviction response.
+func completeReschedule(client Client, config *Config, eviction policyv1.Eviction, meta *corev1.Pod, logger *slog.Logger) {
+	removeHookAnnotations(client, config, meta, logger)
+	if firstSeen := config.firstSeen; firstSeen != nil {
+		firstSeen.Forget(meta.UID)
 	}
 
-	if val, exists := trackingResourceInstance.GetAnnotations()[TrackingResourceAnnotation(pod.Name, pod.Namespace)]; exists && val == "true" {
-		logger.Info("Pod has been rescheduled with the same name")
+	if !client.ShouldTrackRescheduledPods() {
+		return
+	}
 
-		err = client.RemoveRescheduleHookTrackingAnnotation(pod.Name, pod.Namespace, trackingResourceInstance.GetName())
-		if err != nil {
-			logger.Error("Failed to remove tracking annotation", "error", err)
-			return denyEviction(http.StatusInternalServerError, metav1.StatusReasonInternalError, FailedToRemoveRescheduleHookTrackingAnnotationMsg)
-		}
+	pod, err := client.GetPod(eviction.Name, eviction.Namespace)
+	if err != nil {
+		logger.Warn("Failed to get pod to remove its tracking annotation", "error", err)
+		return
+	}
 
-		return denyEviction(http.StatusNotFound, metav1.StatusReasonNotFound, PodRescheduledWithSameNameMsg)
+	trackingResourceInstance, err := client.ResolveTrackingInstance(pod)
+	if err != nil {
+		logger.Warn("Failed to get tracking resource to remove the pod's tracking annotation", "error", err)
+		return
 	}
 
-	// If we want to track the rescheduled pods (this may be conditional on the tracking resource type), we can add an annotation to the tracking resource
-	if client.ShouldAddTrackingAnnotation(trackingResourceInstance) {
-		logger.Info("Pod will be rescheduled with the same name, adding annotation to tracking resource", "trackingResource", trackingResourceInstance.GetName())
-		err = client.AddRescheduleHookTrackingAnnotation(pod.Name, pod.Namespace, trackingResourceInstance.GetName())
-		if err != nil {
-			logger.Error("Failed to add tracking annotation", "error", err)
-			return denyEviction(http.StatusInternalServerError, metav1.StatusReasonInternalError, FailedToAddRescheduleHookTrackingAnnotationMsg)
-		}
+	if _, exists := trackingResourceInstance.GetAnnotations()[trackingAnnotationKey(pod, config)]; !exists {
+		return
 	}
 
-	return nil
+	if err := client.RemoveRescheduleHookTrackingAnnotation(pod, trackingResourceInstance.GetName()); err != nil {
+		logger.Warn("Failed to remove tracking annotation", "error", err)
+		return
+	}
+
+	trackingAnnotationRemovedTotal.Inc()
+}
+
+// belongsToTrackingInstance checks whether the pod belongs to an existing instance of a namespaced tracking resource. Namespace
+// tracking resources are not used, as every pod belongs to its namespace.
+func belongsToTrackingInstance(client Client, config *Config, pod *corev1.Pod, logger *slog.Logger) bool {
+	if !config.trackingResource.IsNamespaced() {
+		return false
+	}
+
+	if _, err := client.ResolveTrackingInstance(pod); err != nil {
+		logger.Debug("Pod does not belong to a tracking resource instance", "error", err)
+		return false
+	}
+
+	return true
+}
+
+// hasNodeDrainAnnotation checks whether a node has REQUIRE_NODE_DRAIN_ANNOTATION, with NODE_DRAIN_ANNOTATION_VALUE if it is set.
+// As with isNodeDraining, a node that no longer exists is treated as annotated, and a pod that has not been scheduled to a node
+// is not.
+func hasNodeDrainAnnotation(client Client, config *Config, nodeName string) (bool, error) {
+	if nodeName == "" {
+		return false, nil
+	}
+
+	node, err := client.GetNode(nodeName)
+	if k8serrors.IsNotFound(err) {
+		return true, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	value, ok := node.Annotations[config.nodeDrainAnnotation]
+	return ok && (config.nodeDrainAnnotationValue == "" || value == config.nodeDrainAnnotationValue), nil
+}
+
+// isNodeDraining checks whether a node is being drained or is unhealthy, meaning it is cordoned with the
+// node.kubernetes.io/unschedulable taint or is not Ready. A node that no longer exists is treated as draining, and a pod that
+// has not been scheduled to a node is not.
+func isNodeDraining(client Client, nodeName string) (bool, error) {
+	if nodeName == "" {
+		return false, nil
+	}
+
+	taints, ready, err := client.GetNodeConditions(nodeName)
+	if k8serrors.IsNotFound(err) {
+		return true, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	if ready != corev1.ConditionTrue {
+		return true, nil
+	}
+
+	for _, taint := range taints {
+		if taint.Key == corev1.TaintNodeUnschedulable {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// trackingInstancePeers returns the selected pods that belong to the same tracking resource instance as the pod, including the
+// pod itself
+func trackingInstancePeers(client Client, config *Config, pod *corev1.Pod) ([]corev1.Pod, error) {
+	instance, err := client.TrackingInstanceName(pod)
+	if err != nil {
+		return nil, err
+	}
+
+	pods, err := client.ListPodsByTrackingInstance(instance, pod.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	peers := make([]corev1.Pod, 0, len(pods))
+	for _, peer := range pods {
+		if config.isSelected(peer.Labels) {
+			peers = append(peers, peer)
+		}
+	}
+
+	return peers, nil
+}
+
+// waitingForRescheduleMessage returns the message denying the eviction of a pod waiting to be rescheduled. When pending peers are
+// reported, the number of other pods in the same tracking resource instance that are still marked for rescheduling is appended,
+// so that drain tooling can gauge progress. If the peers cannot be listed, the message is returned without the count.
+func waitingForRescheduleMessage(client Client, config *Config, pod *corev1.Pod, logger *slog.Logger) string {
+	if !config.reportPendingPeers || config.trackingResource.GetInstanceName(pod) == "" {
+		return PodWaitingForRescheduleMsg
+	}
+
+	peers, err := trackingInstancePeers(client, config, pod)
+	if err != nil {
+		logger.Warn("Failed to list pods in cluster, pending peers not reported", "error", err)
+		return PodWaitingForRescheduleMsg
+	}
+
+	pending := 0
+	for _, peer := range peers {
+		if peer.Name != pod.Name && isMarkedForReschedule(&peer, config) {
+			pending++
+		}
+	}
+
+	return fmt.Sprintf("%s (%d peers pending)", PodWaitingForRescheduleMsg, pending)
+}
+
+// podPriority returns the rescheduling priority of the pod from the priority annotation. Pods without the annotation, or with
+// a value that is not an integer, have a priority of 0.
+func podPriority(pod *corev1.Pod, annotation string) int {
+	priority, err := strconv.Atoi(pod.Annotations[annotation])
+	if err != nil {
+		return 0
+	}
+
+	return priority
+}
+
+// higherPriorityPeer returns the name of a pod in the same tracking resource instance and on the same node as the pod that has
+// a higher priority, or an empty string if there is none. Only peers on the same node are considered, as they are being
+// drained too, and peers that have finished running are ignored, as they will never be rescheduled.
+func higherPriorityPeer(client Client, config *Config, pod *corev1.Pod) (string, error) {
+	if pod.Spec.NodeName == "" {
+		return "", nil
+	}
+
+	peers, err := trackingInstancePeers(client, config, pod)
+	if err != nil {
+		return "", err
+	}
+
+	priority := podPriority(pod, config.priorityAnnotation)
+	for _, peer := range peers {
+		if peer.Name == pod.Name || peer.Spec.NodeName != pod.Spec.NodeName || peer.Status.Phase == corev1.PodSucceeded || peer.Status.Phase == corev1.PodFailed {
+			continue
+		}
+
+		if podPriority(&peer, config.priorityAnnotation) > priority {
+			return peer.Name, nil
+		}
+	}
+
+	return "", nil
+}
+
+// hasPersistentVolumeClaim checks whether the pod has a volume backed by a PersistentVolumeClaim
+func hasPersistentVolumeClaim(pod *corev1.Pod) bool {
+	for _, volume := range pod.Spec.Volumes {
+		if volume.PersistentVolumeClaim != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isInRollout checks whether the pod is within the rollout percentage. The pod's namespace and name are hashed into one of 100
+// buckets, so that the same pod is always in or out of the rollout, including after it is recreated with the same name.
+func isInRollout(namespace, name string, percent int) bool {
+	if percent >= 100 {
+		return true
+	}
+
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(namespace + "/" + name))
+	return int(hash.Sum32()%100) < percent
+}
+
+// isProtectedOrdinal checks whether the ordinal of the pod is within the protected range. If a range is configured, pods
+// without an ordinal are not protected.
+func isProtectedOrdinal(name string, protected *ordinalRange) bool {
+	if protected == nil {
+		return true
+	}
+
+	ordinal, ok := podOrdinal(name)
+	return ok && protected.Contains(ordinal)
+}
+
+// podOrdinal parses the ordinal from the suffix of a pod name after the last '-', e.g. 2 for cluster-0002
+func podOrdinal(name string) (int, bool) {
+	i := strings.LastIndex(name, "-")
+	if i < 0 || i == len(name)-1 {
+		return 0, false
+	}
+
+	ordinal, err := strconv.Atoi(name[i+1:])
+	if err != nil || ordinal < 0 {
+		return 0, false
+	}
+
+	return ordinal, true
+}
+
+// isMarkedForReschedule checks whether the pod has all of the configured reschedule annotations, or labels if
+// RESCHEDULE_MARKER_TYPE is label
+func isMarkedForReschedule(pod *corev1.Pod, config *Config) bool {
+	markers := pod.GetAnnotations()
+	if config.rescheduleMarkerType == RescheduleMarkerLabel {
+		markers = pod.GetLabels()
+	}
+
+	for key, value := range config.rescheduleAnnotationSet() {
+		if current, exists := markers[key]; !exists || current != value {
+			return false
+		}
+	}
+
+	return true
+}
+
+// IsPodReady checks whether the pod is ready using its PodReady condition, which is only true once every container, including
+// any sidecars, is ready. If the condition has not been reported yet, the pod is ready only if every container status is ready.
+func IsPodReady(pod *corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+
+	if len(pod.Status.ContainerStatuses) == 0 {
+		return false
+	}
+
+	for _, status := range pod.Status.ContainerStatuses {
+		if !status.Ready {
+			return false
+		}
+	}
+
+	return true
+}
+
+// discoverEvictionVersion returns the preferred Eviction API version served by the API server. If discovery fails, v1 is assumed.
+func discoverEvictionVersion(client Client) string {
+	versions, err := client.GetEvictionSubresourceSupport()
+	if err != nil {
+		slog.Warn("Failed to discover served Eviction API versions, assuming v1", "error", err)
+		return EvictionVersionV1
+	}
+
+	slog.Info("Discovered served Eviction API versions", "versions", versions, "using", versions[0])
+	return versions[0]
+}
+
+// decodeEviction decodes an eviction of the given API version. Evictions are handled as policy/v1 internally, so v1beta1
+// evictions are converted.
+func decodeEviction(raw []byte, version string) (policyv1.Eviction, error) {
+	if version != EvictionVersionV1beta1 {
+		var eviction policyv1.Eviction
+		err := json.Unmarshal(raw, &eviction)
+		return eviction, err
+	}
+
+	var eviction policyv1beta1.Eviction
+	if err := json.Unmarshal(raw, &eviction); err != nil {
+		return policyv1.Eviction{}, err
+	}
+
+	return policyv1.Eviction{
+		TypeMeta:      eviction.TypeMeta,
+		ObjectMeta:    eviction.ObjectMeta,
+		DeleteOptions: eviction.DeleteOptions,
+	}, nil
+}
+
+// defaultEvictionNamespace sets the namespace of an eviction that does not have one to the namespace of the admission request.
+// Clients relying on the namespace of their context can send evictions without a namespace, but the request is always made to
+// the eviction subresource of a pod in a namespace. If neither is set, the eviction is left without one and fails validation.
+func defaultEvictionNamespace(eviction *policyv1.Eviction, request *admissionv1.AdmissionRequest) {
+	if eviction.Namespace == "" {
+		eviction.Namespace = request.Namespace
+	}
+}
+
+// validateEviction checks the eviction identifies the pod to be evicted
+func validateEviction(eviction *policyv1.Eviction) error {
+	var missing []string
+	if eviction.Name == "" {
+		missing = append(missing, "name")
+	}
+
+	if eviction.Namespace == "" {
+		missing = append(missing, "namespace")
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("missing %s", strings.Join(missing, " and "))
+	}
+
+	return nil
+}
+
+func handleEviction(ctx context.Context, eviction policyv1.Eviction, client Client, config *Config, logger *slog.Logger) *admissionv1.AdmissionResponse {
+	decision := decideEviction(ctx, eviction, client, config, logger)
+	logDecision(decision, logger)
+	return decision.Response
+}
+
+// logDecision logs the outcome of an eviction request with its reason code
+func logDecision(decision Decision, logger *slog.Logger) {
+	logger.Info("Eviction request handled", "reason_code", decision.Reason, "allowed", decision.Response.Allowed)
+}
+
+// decideEviction decides whether an eviction request should be allowed, returning the response with the reason code for
+// the path taken. The decision is made using config rather than the client's config, which the client only uses for its own
+// requests. The context is that of the admission request, and bounds how long the decision and its API requests can wait for.
+func decideEviction(ctx context.Context, eviction policyv1.Eviction, client Client, config *Config, logger *slog.Logger) Decision {
+	logger.Info("Handling eviction request")
+	client = client.WithContext(ctx)
+
+	// Reject malformed evictions before making any API calls
+	if err := validateEviction(&eviction); err != nil {
+		logger.Error("Invalid eviction request", "error", err)
+		return newDecision(ReasonInvalidEviction, denyEviction(http.StatusBadRequest, metav1.StatusReasonBadRequest, fmt.Sprintf("%s: %v", InvalidEvictionMsg, err)))
+	}
+
+	labels, annotations, uid, phase, deletionTimestamp, err := client.GetPodMeta(eviction.Name, eviction.Namespace)
+	if err != nil {
+		return denyPodLookup(err, logger)
+	}
+
+	// Most evictions can be decided from the pod's metadata alone, so the full pod is only fetched when it is needed
+	meta := podFromMeta(eviction.Name, eviction.Namespace, labels, annotations, uid, phase, deletionTimestamp)
+
+	// If the operator has approved the eviction, it has already handled replacing the pod, so the eviction is allowed
+	// immediately and the hook's own annotations are removed
+	if hasTrueAnnotation(meta.Annotations, config.approvalAnnotation) {
+		logger.Info(fmt.Sprintf("Pod has the %s annotation, eviction allowed", config.approvalAnnotation))
+		removeHookAnnotations(client, config, meta, logger)
+		if firstSeen := config.firstSeen; firstSeen != nil {
+			firstSeen.Forget(meta.UID)
+		}
+
+		return newDecision(ReasonApproved, allowEviction())
+	}
+
+	// The operator marks a pod as done once its replacement is complete, so the eviction is allowed and everything the hook
+	// recorded for the pod is removed
+	if _, done := meta.Annotations[config.rescheduleDoneAnnotation]; done && config.rescheduleDoneAnnotation != "" {
+		logger.Info(fmt.Sprintf("Pod has the %s annotation, eviction allowed", config.rescheduleDoneAnnotation))
+		completeReschedule(client, config, eviction, meta, logger)
+		return newDecision(ReasonRescheduleDone, allowEviction())
+	}
+
+	// The operator is what reschedules pods, so blocking the eviction of its own pod could stop it from being upgraded
+	if config.isOperatorPod(meta.Labels) {
+		logger.Info(fmt.Sprintf("Pod matches the operator pod selector %s, eviction allowed", config.operatorPodSelector))
+		cleanupPodAnnotations(client, config, meta, logger)
+		return newDecision(ReasonOperatorPod, allowEviction())
+	}
+
+	// If the pod is owned by an ignored kind, we can allow the eviction immediately. Owner references are not part of the
+	// pod metadata, so the full pod is fetched when owner kinds are ignored.
+	var pod *corev1.Pod
+	if len(config.ignoreOwnerKinds) > 0 {
+		if pod, err = client.GetPod(eviction.Name, eviction.Namespace); err != nil {
+			return denyPodLookup(err, logger)
+		}
+
+		if kind, ignored := ignoredOwnerKind(pod, config.ignoreOwnerKinds); ignored {
+			logger.Info(fmt.Sprintf("Pod is owned by a %s, eviction allowed", kind))
+			cleanupPodAnnotations(client, config, pod, logger)
+			return newDecision(ReasonIgnoredOwnerKind, allowEviction())
+		}
+	}
+
+	// If the pod does not have the correct label, we can allow the eviction immediately. When pod selection is left to the
+	// webhook's objectSelector, every pod we receive is treated as matching. A Pending pod may not have been labelled yet, so when
+	// enabled it is still treated as matching if it belongs to a tracking resource instance.
+	selected := config.isSelected(meta.Labels)
+	if !selected && config.protectPendingInstance && meta.Status.Phase == corev1.PodPending {
+		if pod == nil {
+			if pod, err = client.GetPod(eviction.Name, eviction.Namespace); err != nil {
+				return denyPodLookup(err, logger)
+			}
+		}
+
+		if selected = belongsToTrackingInstance(client, config, pod, logger); selected {
+			logger.Info(fmt.Sprintf("Pending pod does not have the %s=%s label but belongs to a tracking resource instance, treating it as selected", config.podLabelSelectorKey, config.podLabelSelectorValue))
+		}
+	}
+
+	if !selected {
+		logger.Info(fmt.Sprintf("Pod does not have the %s=%s label, eviction allowed", config.podLabelSelectorKey, config.podLabelSelectorValue))
+		cleanupPodAnnotations(client, config, meta, logger)
+		return newDecision(ReasonLabelMismatch, allowEviction())
+	}
+
+	// Pods matching the exclude selector are not rescheduled, even if they have the pod label
+	if config.isExcluded(meta.Labels) {
+		logger.Info(fmt.Sprintf("Pod matches the exclude selector %s, eviction allowed", config.excludeSelector))
+		cleanupPodAnnotations(client, config, meta, logger)
+		return newDecision(ReasonExcluded, allowEviction())
+	}
+
+	// If only pods with certain ordinals are protected, pods outside the range can be evicted immediately
+	if !isProtectedOrdinal(meta.Name, config.protectOrdinals) {
+		logger.Info(fmt.Sprintf("Pod ordinal is not within the protected range %s, eviction allowed", config.protectOrdinals))
+		cleanupPodAnnotations(client, config, meta, logger)
+		return newDecision(ReasonOrdinalNotProtected, allowEviction())
+	}
+
+	// Pods controlled by a kind that recreates them elsewhere, such as a ReplicaSet, do not need rescheduling the stateful way.
+	// Owner references are not part of the pod metadata, so the full pod is fetched when stateful owner kinds are set.
+	if len(config.statefulOwnerKinds) > 0 {
+		if pod == nil {
+			if pod, err = client.GetPod(eviction.Name, eviction.Namespace); err != nil {
+				return denyPodLookup(err, logger)
+			}
+		}
+
+		if kind, stateful := statefulOwnerKind(pod, config.statefulOwnerKinds); !stateful {
+			logger.Info(fmt.Sprintf("Pod is controlled by a %s, which is not a stateful owner kind, eviction allowed", kind))
+			cleanupPodAnnotations(client, config, meta, logger)
+			return newDecision(ReasonStatelessOwner, allowEviction())
+		}
+	}
+
+	// Volumes are not part of the pod metadata, so the full pod is fetched when only stateful pods are protected
+	if config.protectOnlyStateful {
+		if pod == nil {
+			if pod, err = client.GetPod(eviction.Name, eviction.Namespace); err != nil {
+				return denyPodLookup(err, logger)
+			}
+		}
+
+		if !hasPersistentVolumeClaim(pod) {
+			logger.Info("Pod has no PersistentVolumeClaim volumes, eviction allowed")
+			cleanupPodAnnotations(client, config, meta, logger)
+			return newDecision(ReasonStateless, allowEviction())
+		}
+	}
+
+	// Evictions that are not part of draining an unhealthy or cordoned node, such as those made by a descheduler, do not need
+	// the pod to be rescheduled
+	if config.onlyDrainingNodes {
+		if pod == nil {
+			if pod, err = client.GetPod(eviction.Name, eviction.Namespace); err != nil {
+				return denyPodLookup(err, logger)
+			}
+		}
+
+		draining, err := isNodeDraining(client, pod.Spec.NodeName)
+		if err != nil {
+			logger.Error("Failed to get node conditions", "node", pod.Spec.NodeName, "error", err)
+			return newDecision(ReasonNodeLookupError, denyEviction(http.StatusInternalServerError, metav1.StatusReasonInternalError, FailedToGetNodeMsg))
+		}
+
+		if !draining {
+			logger.Info("Pod's node is not being drained, eviction allowed", "node", pod.Spec.NodeName)
+			cleanupPodAnnotations(client, config, meta, logger)
+			return newDecision(ReasonNodeNotDraining, allowEviction())
+		}
+	}
+
+	// Drain tools that annotate the node when a drain starts allow the hook to only engage during coordinated drains
+	if config.nodeDrainAnnotation != "" {
+		if pod == nil {
+			if pod, err = client.GetPod(eviction.Name, eviction.Namespace); err != nil {
+				return denyPodLookup(err, logger)
+			}
+		}
+
+		annotated, err := hasNodeDrainAnnotation(client, config, pod.Spec.NodeName)
+		if err != nil {
+			logger.Error("Failed to get node", "node", pod.Spec.NodeName, "error", err)
+			return newDecision(ReasonNodeLookupError, denyEviction(http.StatusInternalServerError, metav1.StatusReasonInternalError, FailedToGetNodeMsg))
+		}
+
+		if !annotated {
+			logger.Info("Pod's node does not have the drain annotation, eviction allowed", "node", pod.Spec.NodeName, "annotation", config.nodeDrainAnnotation)
+			cleanupPodAnnotations(client, config, meta, logger)
+			return newDecision(ReasonNodeNotAnnotated, allowEviction())
+		}
+	}
+
+	// Marking the only pods of a small cluster for rescheduling can leave the drain waiting forever, for example in a single node
+	// development cluster where the pod has nowhere else to go
+	if minClusterSize := config.minClusterSize; minClusterSize > 0 {
+		// The pod's owner references are needed to derive its tracking resource instance from its controller
+		if pod == nil && config.instanceNameFrom == InstanceNameFromOwner {
+			if pod, err = client.GetPod(eviction.Name, eviction.Namespace); err != nil {
+				return denyPodLookup(err, logger)
+			}
+		}
+
+		peersOf := meta
+		if pod != nil {
+			peersOf = pod
+		}
+
+		peers, err := trackingInstancePeers(client, config, peersOf)
+		if err != nil {
+			logger.Error("Failed to count pods in cluster", "error", err)
+			return newDecision(ReasonPodCountError, denyEviction(http.StatusInternalServerError, metav1.StatusReasonInternalError, FailedToCountPodsMsg))
+		}
+
+		if len(peers) <= minClusterSize {
+			logger.Info(fmt.Sprintf("Cluster has %d pods, no more than the minimum of %d, eviction allowed", len(peers), minClusterSize))
+			cleanupPodAnnotations(client, config, meta, logger)
+			return newDecision(ReasonClusterTooSmall, allowEviction())
+		}
+	}
+
+	// While the webhook is being rolled out, only a fraction of pods are rescheduled so that the impact can be observed
+	if !isInRollout(meta.Namespace, meta.Name, config.rolloutPercent) {
+		logger.Info(fmt.Sprintf("Pod is outside of the %d%% rollout, eviction allowed", config.rolloutPercent))
+		cleanupPodAnnotations(client, config, meta, logger)
+		return newDecision(ReasonOutsideRollout, allowEviction())
+	}
+
+	// Outside of the active windows the reschedule behaviour is suspended, so the eviction can be allowed immediately
+	if !config.activeWindows.Active(config.clock.Now()) {
+		return allowOutsideActiveWindows(client, config, meta, pod, logger)
+	}
+
+	// If the pod has already been marked for rescheduling, we can exit here but deny the eviction to keep the drain command
+	// in a loop until the pod no longer exists
+	if isMarkedForReschedule(meta, config) {
+		if !config.blockEviction {
+			logger.Info("Pod already marked for rescheduling, eviction allowed")
+			cleanupPodAnnotations(client, config, meta, logger)
+			return newDecision(ReasonAlreadyMarked, allowEviction())
+		}
+
+		logger.Info("Pod waiting to be rescheduled")
+		recordRescheduleAttempt(client, config, meta, logger)
+		return newDecision(ReasonAlreadyMarked, denyEviction(http.StatusTooManyRequests, metav1.StatusReasonTooManyRequests, waitingForRescheduleMessage(client, config, meta, logger)))
+	}
+
+	// Tracking and rescheduling need the pod's spec and resource version
+	if pod == nil {
+		if pod, err = client.GetPod(eviction.Name, eviction.Namespace); err != nil {
+			return denyPodLookup(err, logger)
+		}
+	}
+
+	// Pods are rescheduled in priority order, so the pod is not marked while a higher priority peer on its node is still there
+	if config.priorityAnnotation != "" {
+		peer, err := higherPriorityPeer(client, config, pod)
+		if err != nil {
+			logger.Error("Failed to list pods in cluster", "error", err)
+			return newDecision(ReasonPeerLookupError, denyEviction(http.StatusInternalServerError, metav1.StatusReasonInternalError, FailedToListPeerPodsMsg))
+		}
+
+		if peer != "" {
+			logger.Info("Waiting for higher priority pod to be rescheduled", "peer", peer)
+			return newDecision(ReasonWaitingForPriority, denyEviction(http.StatusTooManyRequests, metav1.StatusReasonTooManyRequests, PodWaitingForHigherPriorityPodsMsg))
+		}
+	}
+
+	// Give the operator a chance to respond to the eviction before the pod is marked for rescheduling or tracked
+	if remaining := firstAnnotationDelayRemaining(config, pod); remaining > 0 {
+		logger.Info("Delaying reschedule annotation", "remaining", remaining)
+		return newDecision(ReasonAnnotationDelayed, denyEviction(http.StatusTooManyRequests, metav1.StatusReasonTooManyRequests, PodWaitingForAnnotationDelayMsg))
+	}
+
+	// If the pod does not have the reschedule annotation, it's possible it has already been rescheduled with the same name.
+	// When the TrackRescheduledPods config value has been enabled, we will use an annotation on another resource to track which pods have already been rescheduled
+	// If the pod is missing the reschedule annotation, but is present in this tracking list, we can assume it has already been rescheduled with the same name.
+	// Tracking is not needed when evictions are not blocked, as the drain command will not retry the eviction.
+	var trackingResourceInstance *unstructured.Unstructured
+	if client.ShouldTrackRescheduledPods() && config.blockEviction {
+		var decision *Decision
+		if trackingResourceInstance, decision = trackRescheduledPods(client, config, pod, logger); decision != nil {
+			return *decision
+		}
+	} else {
+		trackingAnnotationSkippedTotal.WithLabelValues(trackingSkippedDisabled).Inc()
+	}
+
+	// At this point, we can assume the pod has not already been rescheduled and should therefore be marked for rescheduling
+	logger.Info("Adding reschedule annotation to pod")
+	err = client.ReschedulePod(pod)
+	if k8serrors.IsConflict(err) {
+		return handleRescheduleConflict(client, pod, logger)
+	}
+
+	// The pod can be deleted between being fetched and being patched, in which case it has already been evicted or rescheduled
+	if k8serrors.IsNotFound(err) {
+		return denyPodLookup(err, logger)
+	}
+
+	if errors.Is(err, ErrUnauthorized) {
+		return denyUnauthorized(err, logger)
+	}
+
+	if err != nil {
+		logger.Error("Failed to add reschedule annotation to pod", "error", err)
+		return newDecision(ReasonAnnotationError, denyEviction(http.StatusInternalServerError, metav1.StatusReasonInternalError, FailedToAddRescheduleAnnotationMsg))
+	}
+
+	if trackingResourceInstance != nil {
+		if decision := addTrackingAnnotation(client, config, pod, trackingResourceInstance, logger); decision != nil {
+			return *decision
+		}
+	}
+
+	if firstSeen := config.firstSeen; firstSeen != nil {
+		firstSeen.Forget(pod.UID)
+	}
+
+	// When evictions are not blocked, the operator is trusted to handle replacing the pod once it has been evicted
+	if !config.blockEviction {
+		logger.Info("Reschedule annotation added to pod, eviction allowed")
+		return newDecision(ReasonAnnotationAdded, allowEviction())
+	}
+
+	// By denying the eviction with StatusReasonTooManyRequests, the drain command will continue attempting to evict
+	// the pod every 5 seconds until it has been rescheduled correctly
+	recordRescheduleAttempt(client, config, pod, logger)
+	waitAfterReschedule(ctx, config.postRescheduleWait, logger)
+	return newDecision(ReasonAnnotationAdded, denyEviction(http.StatusTooManyRequests, metav1.StatusReasonTooManyRequests, RescheduleAnnotationAddedToPodMsg))
+}
+
+// waitAfterReschedule waits for up to wait after a pod has been marked for rescheduling, giving the operator a head start so
+// that the drain command's next retry is more likely to find progress. The wait ends early once ctx is done, so it never runs
+// past the deadline of the admission request.
+func waitAfterReschedule(ctx context.Context, wait time.Duration, logger *slog.Logger) {
+	if wait <= 0 {
+		return
+	}
+
+	logger.Debug("Waiting before returning to give the operator a head start", "wait", wait)
+	waitCtx, cancel := context.WithTimeout(ctx, wait)
+	defer cancel()
+	<-waitCtx.Done()
+}
+
+// firstAnnotationDelayRemaining records the eviction request and returns how much longer marking the pod for rescheduling
+// should be delayed since an eviction was first requested for it. If no delay is configured, this is always zero.
+func firstAnnotationDelayRemaining(config *Config, pod *corev1.Pod) time.Duration {
+	if config.firstSeen == nil {
+		return 0
+	}
+
+	now := config.clock.Now()
+	return config.firstAnnotationDelay - now.Sub(config.firstSeen.Observe(pod.UID, now))
+}
+
+// allowOutsideActiveWindows allows an eviction outside of the active windows. When enabled, the pod is still marked for
+// rescheduling first. Failing to mark the pod is logged but does not affect the eviction response.
+func allowOutsideActiveWindows(client Client, config *Config, meta, pod *corev1.Pod, logger *slog.Logger) Decision {
+	if config.annotateOutsideWindows && !isMarkedForReschedule(meta, config) {
+		var err error
+		if pod == nil {
+			pod, err = client.GetPod(meta.Name, meta.Namespace)
+		}
+
+		if err == nil {
+			err = client.ReschedulePod(pod)
+		}
+
+		if err != nil {
+			logger.Warn("Failed to add reschedule annotation to pod outside of the active windows", "error", err)
+		} else {
+			logger.Info("Reschedule annotation added to pod outside of the active windows")
+		}
+	}
+
+	logger.Info("Outside of the active windows, eviction allowed")
+	return newDecision(ReasonOutsideActiveWindows, allowEviction())
+}
+
+// denyPodLookup denies an eviction when the pod could not be fetched. If the pod doesn't exist, we can assume that it has
+// already been evicted.
+func denyPodLookup(err error, logger *slog.Logger) Decision {
+	if k8serrors.IsNotFound(err) {
+		logger.Info("Pod no longer exists")
+		return newDecision(ReasonPodNotFound, denyEviction(http.StatusNotFound, metav1.StatusReasonNotFound, PodNoLongerExistsMsg))
+	}
+
+	if errors.Is(err, ErrUnauthorized) {
+		return denyUnauthorized(err, logger)
+	}
+
+	logger.Error("Failed to get pod", "error", err)
+	return newDecision(ReasonPodLookupError, denyEviction(http.StatusInternalServerError, metav1.StatusReasonInternalError, FailedToGetPodMsg))
+}
+
+// denyUnauthorized denies an eviction when the API server rejected the webhook's service account token, even after re-reading
+// it, so that the cause is clear from the drain output rather than a generic failure
+func denyUnauthorized(err error, logger *slog.Logger) Decision {
+	logger.Error("Failed to authenticate to the Kubernetes API server", "error", err)
+	return newDecision(ReasonAuthenticationFailed, denyEviction(http.StatusInternalServerError, metav1.StatusReasonInternalError, AuthenticationFailedMsg))
+}
+
+// podFromMeta builds a pod holding only the metadata returned by GetPodMeta, for the checks and annotation patches that do not
+// need the full pod
+func podFromMeta(name, namespace string, labels, annotations map[string]string, uid types.UID, phase corev1.PodPhase, deletionTimestamp *metav1.Time) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         namespace,
+			UID:               uid,
+			Labels:            labels,
+			Annotations:       annotations,
+			DeletionTimestamp: deletionTimestamp,
+		},
+		Status: corev1.PodStatus{Phase: phase},
+	}
+}
+
+// handleRescheduleConflict is called when the pod has changed between being fetched and the reschedule annotation being added.
+// If the pod has since been recreated with the same name, it has already been rescheduled. Otherwise the pod has only been
+// updated, so the eviction is denied with TooManyRequests for the drain command to retry.
+func handleRescheduleConflict(client Client, pod *corev1.Pod, logger *slog.Logger) Decision {
+	_, _, currentUID, _, _, err := client.GetPodMeta(pod.Name, pod.Namespace)
+	if err != nil {
+		return denyPodLookup(err, logger)
+	}
+
+	if currentUID != pod.UID {
+		logger.Info("Pod has been rescheduled with the same name")
+		return newDecision(ReasonSameNameRescheduled, denyEviction(http.StatusNotFound, metav1.StatusReasonNotFound, PodRescheduledWithSameNameMsg))
+	}
+
+	logger.Info("Pod changed while adding reschedule annotation, eviction will be retried")
+	return newDecision(ReasonPodChanged, denyEviction(http.StatusTooManyRequests, metav1.StatusReasonTooManyRequests, PodChangedDuringRescheduleMsg))
+}
+
+// checkTrackingInstanceReady denies the eviction if the field of the tracking resource instance at TRACKING_RESOURCE_STATUS_PATH
+// has one of the TRACKING_RESOURCE_NOT_READY_STATUSES, such as a cluster that is still rebalancing, so that the pod is not marked
+// for rescheduling until the instance can accept it. A field that is not set, or is not a string, number or bool, does not
+// block the eviction.
+func checkTrackingInstanceReady(config *Config, trackingResourceInstance *unstructured.Unstructured, logger *slog.Logger) *Decision {
+	if config.trackingStatusPath == "" {
+		return nil
+	}
+
+	value, found, err := unstructured.NestedFieldNoCopy(trackingResourceInstance.Object, strings.Split(config.trackingStatusPath, ".")...)
+	if err != nil || !found {
+		return nil
+	}
+
+	var status string
+	switch v := value.(type) {
+	case string, bool, int64, float64:
+		status = fmt.Sprint(v)
+	default:
+		return nil
+	}
+
+	if !slices.Contains(config.trackingNotReadyStatuses, status) {
+		return nil
+	}
+
+	logger.Info("Tracking resource is not ready to reschedule pods, eviction denied", "trackingResource", trackingResourceInstance.GetName(), "path", config.trackingStatusPath, "status", status)
+	message := fmt.Sprintf("%s (%s is %s)", ClusterNotReadyToRescheduleMsg, config.trackingStatusPath, status)
+	return trackingDecision(ReasonInstanceNotReady, denyEviction(http.StatusTooManyRequests, metav1.StatusReasonTooManyRequests, message))
+}
+
+// trackRescheduledPods handles situations where a pod may have been rescheduled with the same name. This method will
+// check for the existence of a tracking annotation on the tracking resource.
+// If a tracking annotation already exists for the pod, it must have already been rescheduled with the same name.
+// We can therefore remove the tracking annotation and return a 404.
+// If the tracking resource does not have a tracking annotation for the pod and the pod will be rescheduled with the same name,
+// the tracking resource instance is returned so that a tracking annotation can be added once the pod has been marked for
+// rescheduling. The annotation is only added after the mark, so that a tracked pod is never one that was left unmarked.
+func trackRescheduledPods(client Client, config *Config, pod *corev1.Pod, logger *slog.Logger) (*unstructured.Unstructured, *Decision) {
+	trackingResourceInstance, err := client.ResolveTrackingInstance(pod)
+	if errors.Is(err, ErrNoTrackingInstanceName) || errors.Is(err, ErrTrackingResourceNotFound) {
+		trackingAnnotationSkippedTotal.WithLabelValues(trackingSkippedUnresolved).Inc()
+	}
+
+	if errors.Is(err, ErrNoTrackingInstanceName) {
+		logger.Error("Unable to determine tracking resource", "error", err)
+		return nil, trackingDecision(ReasonTrackingError, denyEviction(http.StatusInternalServerError, metav1.StatusReasonInternalError, NoTrackingInstanceNameMsg))
+	}
+
+	// The pod may refer to a tracking resource instance that no longer exists, such as a stale cluster label. Unless the failure
+	// policy is to ignore this, the eviction is denied rather than rescheduling a pod that cannot be tracked.
+	if errors.Is(err, ErrTrackingResourceNotFound) && config.trackingFailurePolicy == TrackingFailurePolicyIgnore {
+		logger.Warn("Tracking resource not found, pod will be rescheduled without tracking", "error", err)
+		return nil, nil
+	}
+
+	if errors.Is(err, ErrTrackingResourceNotFound) {
+		logger.Error("Tracking resource not found", "error", err)
+		return nil, trackingDecision(ReasonTrackingError, denyEviction(http.StatusInternalServerError, metav1.StatusReasonInternalError, TrackingResourceNotFoundMsg))
+	}
+
+	if err != nil {
+		logger.Error("Failed to get tracking resource", "error", err)
+		return nil, trackingDecision(ReasonTrackingError, denyEviction(http.StatusInternalServerError, metav1.StatusReasonInternalError, FailedToGetTrackingResourceMsg))
+	}
+
+	// Rescheduling the pods of an instance that is being deleted would only hold up its teardown
+	if config.allowTerminatingInstance && trackingResourceInstance.GetDeletionTimestamp() != nil {
+		logger.Info("Tracking resource is being deleted, eviction allowed", "trackingResource", trackingResourceInstance.GetName())
+		cleanupPodAnnotations(client, config, pod, logger)
+		return nil, trackingDecision(ReasonInstanceTerminating, allowEviction())
+	}
+
+	// A tracking resource that has never been annotated has no annotations map, in which case no pods are being tracked
+	annotations := trackingResourceInstance.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+
+	// A tracking annotation left by a drain that never completed would make a new pod with the same name look like it has
+	// already been rescheduled, so once it is too old it is removed and the pod is tracked again
+	key := trackingAnnotationKey(pod, config)
+	if val, exists := annotations[key]; exists && isTrackingAnnotationExpired(val, config.clock.Now(), config.trackingAnnotationMaxAge) {
+		logger.Info("Tracking annotation is older than the maximum age, removing it", "maxAge", config.trackingAnnotationMaxAge)
+		if err := client.RemoveRescheduleHookTrackingAnnotation(pod, trackingResourceInstance.GetName()); err != nil {
+			logger.Error("Failed to remove stale tracking annotation", "error", err)
+			return nil, trackingDecision(ReasonTrackingError, denyEviction(http.StatusInternalServerError, metav1.StatusReasonInternalError, FailedToRemoveRescheduleHookTrackingAnnotationMsg))
+		}
+
+		trackingAnnotationRemovedTotal.Inc()
+		delete(annotations, key)
+	}
+
+	waiting := countTrackingAnnotations(annotations, config.trackingControlAnnotations()...)
+	waitingPods.Set(pod.Namespace, trackingResourceInstance.GetName(), float64(waiting))
+
+	if val, exists := annotations[key]; exists {
+		rescheduled, recognised := IsTrackedPodRescheduled(val, pod)
+		if recognised && rescheduled {
+			// A concurrent eviction of the same pod may have marked and tracked it since the pod was fetched, in which case
+			// the pod is still the original one waiting to be rescheduled
+			labels, annotations, uid, phase, deletionTimestamp, err := client.GetPodMeta(pod.Name, pod.Namespace)
+			if err != nil {
+				decision := denyPodLookup(err, logger)
+				return nil, &decision
+			}
+
+			if current := podFromMeta(pod.Name, pod.Namespace, labels, annotations, uid, phase, deletionTimestamp); isMarkedForReschedule(current, config) {
+				logger.Info("Pod waiting to be rescheduled")
+				return nil, trackingDecision(ReasonAlreadyMarked, denyEviction(http.StatusTooManyRequests, metav1.StatusReasonTooManyRequests, PodWaitingForRescheduleMsg))
+			}
+
+			logger.Info("Pod has been rescheduled with the same name")
+
+			err = client.RemoveRescheduleHookTrackingAnnotation(pod, trackingResourceInstance.GetName())
+			if err != nil {
+				logger.Error("Failed to remove tracking annotation", "error", err)
+				return nil, trackingDecision(ReasonTrackingError, denyEviction(http.StatusInternalServerError, metav1.StatusReasonInternalError, FailedToRemoveRescheduleHookTrackingAnnotationMsg))
+			}
+
+			trackingAnnotationRemovedTotal.Inc()
+
+			// Drain tooling can tell from the message whether the pod also moved node, and from the number of pods still
+			// tracked how far the drain has progressed
+			message := PodRescheduledWithSameNameMsg
+			if isTrackedPodOnDifferentNode(val, pod) {
+				message = PodRescheduledToDifferentNodeMsg
+			}
+
+			remaining := waiting - 1
+			waitingPods.Set(pod.Namespace, trackingResourceInstance.GetName(), float64(remaining))
+			return nil, trackingDecision(ReasonSameNameRescheduled, denyEviction(http.StatusNotFound, metav1.StatusReasonNotFound, fmt.Sprintf("%s (%d tracked pods remaining)", message, remaining)))
+		}
+
+		// The pod is on the same node with the same UID, so it is still the original pod and should be marked for rescheduling again
+		if recognised {
+			if decision := checkTrackingInstanceReady(config, trackingResourceInstance, logger); decision != nil {
+				return nil, decision
+			}
+
+			logger.Info("Pod is tracked but has not been rescheduled", "node", pod.Spec.NodeName)
+			trackingAnnotationSkippedTotal.WithLabelValues(trackingSkippedAlreadyPresent).Inc()
+			return nil, nil
+		}
+	}
+
+	// A pod that has already been rescheduled is reported above whatever the status of its instance, but a pod is only marked
+	// for rescheduling once its instance can accept it
+	if decision := checkTrackingInstanceReady(config, trackingResourceInstance, logger); decision != nil {
+		return nil, decision
+	}
+
+	// If we want to track the rescheduled pods (this may be conditional on the tracking resource type), an annotation is added to
+	// the tracking resource once the pod has been marked
+	if client.ShouldAddTrackingAnnotation(pod, trackingResourceInstance) {
+		return trackingResourceInstance, nil
+	}
+
+	trackingAnnotationSkippedTotal.WithLabelValues(trackingSkippedNotRequired).Inc()
+	return nil, nil
+}
+
+// addTrackingAnnotation adds the tracking annotation for a pod that has been marked for rescheduling to the tracking resource
+// instance. If it cannot be added, the eviction is denied. The pod is left marked, as the operator may already be rescheduling it.
+func addTrackingAnnotation(client Client, config *Config, pod *corev1.Pod, trackingResourceInstance *unstructured.Unstructured, logger *slog.Logger) *Decision {
+	logger.Info("Pod will be rescheduled with the same name, adding annotation to tracking resource", "trackingResource", trackingResourceInstance.GetName())
+	err := client.AddRescheduleHookTrackingAnnotation(pod, trackingResourceInstance)
+	if errors.Is(err, ErrAnnotationSizeLimit) {
+		logger.Error("Tracking resource annotations are too large to add tracking annotation", "error", err)
+		return trackingDecision(ReasonTrackingError, denyEviction(http.StatusInternalServerError, metav1.StatusReasonInternalError, TrackingAnnotationSizeLimitMsg))
+	}
+
+	if err != nil {
+		logger.Error("Failed to add tracking annotation", "error", err)
+		return trackingDecision(ReasonTrackingError, denyEviction(http.StatusInternalServerError, metav1.StatusReasonInternalError, FailedToAddRescheduleHookTrackingAnnotationMsg))
+	}
+
+	// A stale annotation for the pod may have been removed since the instance was fetched, in which case it is replaced
+	annotations := trackingResourceInstance.GetAnnotations()
+	delete(annotations, trackingAnnotationKey(pod, config))
+	trackingAnnotationAddedTotal.Inc()
+	waitingPods.Set(pod.Namespace, trackingResourceInstance.GetName(), float64(countTrackingAnnotations(annotations, config.trackingControlAnnotations()...)+1))
+	return nil
+}
+
+// trackingDecision returns a decision ending the eviction request while tracking rescheduled pods
+func trackingDecision(reason ReasonCode, response *admissionv1.AdmissionResponse) *Decision {
+	decision := newDecision(reason, response)
+	return &decision
+}
+
+// ignoredOwnerKind returns the kind of the first owner of the pod that is in the list of ignored owner kinds
+func ignoredOwnerKind(pod *corev1.Pod, ignoreOwnerKinds []string) (string, bool) {
+	for _, owner := range pod.OwnerReferences {
+		for _, kind := range ignoreOwnerKinds {
+			if strings.EqualFold(owner.Kind, kind) {
+				return owner.Kind, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// statefulOwnerKind returns the kind of the pod's controller and whether it is one of the stateful owner kinds. Pods without a
+// controller are treated as stateful, as nothing will recreate them.
+func statefulOwnerKind(pod *corev1.Pod, statefulOwnerKinds []string) (string, bool) {
+	controller := metav1.GetControllerOf(pod)
+	if controller == nil {
+		return "", true
+	}
+
+	for _, kind := range statefulOwnerKinds {
+		if strings.EqualFold(controller.Kind, kind) {
+			return controller.Kind, true
+		}
+	}
+
+	return controller.Kind, false
 }
 
 func denyEviction(code int32, reason metav1.StatusReason, message string) *admissionv1.AdmissionResponse {
@@ -1,17 +1,39 @@
 package reschedule
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net"
 	"net/http"
+	"net/http/httptest"
+	"os"
 	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
 	"testing"
+	"time"
 
+	"github.com/couchbaselabs/eviction-reschedule-hook/pkg/reschedule/tracking"
 	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
 	corev1 "k8s.io/api/core/v1"
 	policyv1 "k8s.io/api/policy/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
 )
 
 type mockClient struct {
@@ -20,31 +42,164 @@ type mockClient struct {
 	trackingResourceAnnotations map[string]string
 	shouldTrackRescheduledPods  bool
 	shouldAddTrackingAnnotation bool
+	trackingResourceNotFound    bool
+	noTrackingInstanceName      bool
+	trackingResourceNotServed   bool
+	// rescheduleConflict causes ReschedulePod to fail with a Conflict error, replacing the pod with recreatedPod if it is set
+	rescheduleConflict bool
+	recreatedPod       *corev1.Pod
+	// rescheduleNotFound causes ReschedulePod to fail with a NotFound error, as if the pod was deleted after it was fetched
+	rescheduleNotFound bool
+	// node is returned by GetNode and GetNodeConditions for any node name
+	node *corev1.Node
+	// clusterPods are the pods listed by ListPodsByTrackingInstance, and listPodsFailure causes it to fail
+	clusterPods     []*corev1.Pod
+	listPodsFailure bool
+	// getPodCalls counts the number of calls to GetPod and GetPodMeta
+	getPodCalls int
+	// webhookCABundle is returned by GetWebhookCABundle, which fails if it is not set
+	webhookCABundle []byte
+	// trackingResourceDeletionTimestamp is set on the tracking resource instance, as if it is being deleted
+	trackingResourceDeletionTimestamp *metav1.Time
+	// trackingResourceStatus is set as the status of the tracking resource instance
+	trackingResourceStatus map[string]interface{}
 }
 
 func (m *mockClient) GetPod(name, namespace string) (*corev1.Pod, error) {
+	m.getPodCalls++
 	if m.pod == nil {
 		return nil, k8serrors.NewNotFound(schema.GroupResource{Group: "", Resource: "pods"}, name)
 	}
 	return m.pod, nil
 }
 
+func (m *mockClient) GetPodMeta(name, namespace string) (map[string]string, map[string]string, types.UID, corev1.PodPhase, *metav1.Time, error) {
+	pod, err := m.GetPod(name, namespace)
+	if err != nil {
+		return nil, nil, "", "", nil, err
+	}
+	return pod.Labels, pod.Annotations, pod.UID, pod.Status.Phase, pod.DeletionTimestamp, nil
+}
+
+func (m *mockClient) GetNode(nodeName string) (*corev1.Node, error) {
+	if m.node == nil {
+		return nil, k8serrors.NewNotFound(schema.GroupResource{Group: "", Resource: "nodes"}, nodeName)
+	}
+	return m.node, nil
+}
+
+func (m *mockClient) GetNodeConditions(nodeName string) ([]corev1.Taint, corev1.ConditionStatus, error) {
+	if m.node == nil {
+		return nil, "", k8serrors.NewNotFound(schema.GroupResource{Group: "", Resource: "nodes"}, nodeName)
+	}
+
+	ready := corev1.ConditionUnknown
+	for _, condition := range m.node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			ready = condition.Status
+		}
+	}
+	return m.node.Spec.Taints, ready, nil
+}
+
+func (m *mockClient) ListPodsByTrackingInstance(instance, namespace string) ([]corev1.Pod, error) {
+	if m.listPodsFailure {
+		return nil, fmt.Errorf("failed to list pods")
+	}
+
+	selector := labels.SelectorFromSet(m.config.trackingResource.GetInstanceLabels(instance))
+	var pods []corev1.Pod
+	for _, pod := range m.clusterPods {
+		if pod.Namespace == namespace && selector.Matches(labels.Set(pod.Labels)) {
+			pods = append(pods, *pod)
+		}
+	}
+	return pods, nil
+}
+
 func (m *mockClient) ReschedulePod(pod *corev1.Pod) error {
+	if m.rescheduleNotFound {
+		return k8serrors.NewNotFound(schema.GroupResource{Group: "", Resource: "pods"}, pod.Name)
+	}
+
+	if m.rescheduleConflict {
+		if m.recreatedPod != nil {
+			m.pod = m.recreatedPod
+		}
+		return k8serrors.NewConflict(schema.GroupResource{Group: "", Resource: "pods"}, pod.Name, fmt.Errorf("the object has been modified"))
+	}
+
+	labels, annotations := rescheduleMarkers(pod, m.config)
+	if len(labels) > 0 && pod.Labels == nil {
+		pod.Labels = make(map[string]string)
+	}
+
+	if len(annotations) > 0 && pod.Annotations == nil {
+		pod.Annotations = make(map[string]string)
+	}
+
+	maps.Copy(pod.Labels, labels)
+	maps.Copy(pod.Annotations, annotations)
+	m.pod = pod
+	return nil
+}
+
+func (m *mockClient) RecordRescheduleAttempt(pod *corev1.Pod) error {
+	attempts, _ := strconv.Atoi(pod.Annotations[DefaultAttemptsAnnotation])
 	if pod.Annotations == nil {
 		pod.Annotations = make(map[string]string)
 	}
 
-	pod.Annotations[m.config.rescheduleAnnotationKey] = m.config.rescheduleAnnotationValue
+	pod.Annotations[DefaultAttemptsAnnotation] = strconv.Itoa(attempts + 1)
+	m.pod = pod
+	return nil
+}
+
+func (m *mockClient) RemovePodAnnotations(pod *corev1.Pod, annotations ...string) error {
+	for _, annotation := range annotations {
+		delete(pod.Annotations, annotation)
+	}
+
 	m.pod = pod
 	return nil
 }
 
 func (m *mockClient) GetTrackingResourceInstance(name, namespace string) (*unstructured.Unstructured, error) {
-	return &unstructured.Unstructured{Object: map[string]interface{}{
-		"metadata": map[string]interface{}{
-			"annotations": stringMapToInterfaceMap(m.trackingResourceAnnotations),
-		},
-	}}, nil
+	if m.trackingResourceNotFound {
+		return nil, fmt.Errorf("%w: %s", ErrTrackingResourceNotFound, name)
+	}
+
+	// Resources that have never been annotated have no annotations map
+	metadata := map[string]interface{}{}
+	if m.trackingResourceAnnotations != nil {
+		metadata["annotations"] = stringMapToInterfaceMap(m.trackingResourceAnnotations)
+	}
+
+	if m.trackingResourceDeletionTimestamp != nil {
+		metadata["deletionTimestamp"] = m.trackingResourceDeletionTimestamp.UTC().Format(time.RFC3339)
+	}
+
+	object := map[string]interface{}{
+		"metadata": metadata,
+	}
+
+	if m.trackingResourceStatus != nil {
+		object["status"] = m.trackingResourceStatus
+	}
+
+	return &unstructured.Unstructured{Object: object}, nil
+}
+
+func (m *mockClient) ResolveTrackingInstance(pod *corev1.Pod) (*unstructured.Unstructured, error) {
+	if m.noTrackingInstanceName {
+		return nil, fmt.Errorf("%w: %s", ErrNoTrackingInstanceName, pod.Name)
+	}
+
+	return m.GetTrackingResourceInstance(pod.Labels["couchbase_cluster"], pod.Namespace)
+}
+
+func (m *mockClient) TrackingInstanceName(pod *corev1.Pod) (string, error) {
+	return m.config.trackingResource.GetInstanceName(pod), nil
 }
 
 func stringMapToInterfaceMap(in map[string]string) map[string]interface{} {
@@ -55,39 +210,310 @@ func stringMapToInterfaceMap(in map[string]string) map[string]interface{} {
 	return out
 }
 
+func (m *mockClient) GetEvictionSubresourceSupport() ([]string, error) {
+	return []string{EvictionVersionV1}, nil
+}
+
+func (m *mockClient) DryRunPatchTrackingResource(name, namespace string) error {
+	_, err := m.GetTrackingResourceInstance(name, namespace)
+	return err
+}
+
+func (m *mockClient) GetWebhookCABundle(configName string) ([]byte, error) {
+	if len(m.webhookCABundle) == 0 {
+		return nil, k8serrors.NewNotFound(schema.GroupResource{Group: "admissionregistration.k8s.io", Resource: "validatingwebhookconfigurations"}, configName)
+	}
+	return m.webhookCABundle, nil
+}
+
+func (m *mockClient) IsTrackingResourceServed() (bool, error) {
+	return !m.trackingResourceNotServed, nil
+}
+
+func (m *mockClient) GetResource(gvr schema.GroupVersionResource, namespace, name string) (*unstructured.Unstructured, error) {
+	if gvr != podResource {
+		return m.GetTrackingResourceInstance(name, namespace)
+	}
+
+	pod, err := m.GetPod(name, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	object, err := runtime.DefaultUnstructuredConverter.ToUnstructured(pod)
+	if err != nil {
+		return nil, err
+	}
+
+	return &unstructured.Unstructured{Object: object}, nil
+}
+
+func (m *mockClient) PatchResource(gvr schema.GroupVersionResource, namespace, name string, patchType types.PatchType, payload []byte) error {
+	return nil
+}
+
 func (m *mockClient) GetConfig() *Config {
 	return m.config
 }
 
-func (m *mockClient) AddRescheduleHookTrackingAnnotation(podName, podNamespace, trackingResourceName string) error {
+func (m *mockClient) WithContext(ctx context.Context) Client {
+	return m
+}
+
+func (m *mockClient) Close() {}
+
+func (m *mockClient) AddRescheduleHookTrackingAnnotation(pod *corev1.Pod, trackingResourceInstance *unstructured.Unstructured) error {
 	if m.trackingResourceAnnotations == nil {
 		m.trackingResourceAnnotations = make(map[string]string)
 	}
-	m.trackingResourceAnnotations[TrackingResourceAnnotation(podName, podNamespace)] = "true"
+	m.trackingResourceAnnotations[trackingAnnotationKey(pod, m.config)] = trackingAnnotationValue(pod, m.config)
+	return nil
+}
+
+func (m *mockClient) RemoveRescheduleHookTrackingAnnotation(pod *corev1.Pod, trackingResourceName string) error {
+	delete(m.trackingResourceAnnotations, trackingAnnotationKey(pod, m.config))
 	return nil
 }
 
-func (m *mockClient) RemoveRescheduleHookTrackingAnnotation(podName, podNamespace, trackingResourceName string) error {
-	delete(m.trackingResourceAnnotations, TrackingResourceAnnotation(podName, podNamespace))
+func (m *mockClient) BatchRemoveTrackingAnnotations(trackingResourceName, namespace string, podKeys []string) error {
+	annotations, err := podKeyTrackingAnnotations(podKeys)
+	if err != nil {
+		return err
+	}
+
+	for _, annotation := range annotations {
+		delete(m.trackingResourceAnnotations, annotation)
+	}
 	return nil
 }
 
+func (m *mockClient) ClearTrackingAnnotations(trackingResourceName, namespace string) ([]string, error) {
+	if m.trackingResourceNotFound {
+		return nil, fmt.Errorf("%w: %s", ErrTrackingResourceNotFound, trackingResourceName)
+	}
+
+	var removed []string
+	for key := range m.trackingResourceAnnotations {
+		if strings.HasPrefix(key, RescheduledPodsTrackingKeyPrefix) && !slices.Contains(m.config.trackingControlAnnotations(), key) {
+			removed = append(removed, key)
+			delete(m.trackingResourceAnnotations, key)
+		}
+	}
+
+	slices.Sort(removed)
+	return removed, nil
+}
+
 func (m *mockClient) ShouldTrackRescheduledPods() bool {
 	return m.shouldTrackRescheduledPods
 }
 
-func (m *mockClient) ShouldAddTrackingAnnotation(trackingResourceInstance *unstructured.Unstructured) bool {
+func (m *mockClient) ShouldAddTrackingAnnotation(pod *corev1.Pod, trackingResourceInstance *unstructured.Unstructured) bool {
 	return m.shouldAddTrackingAnnotation
 }
 
+// recordedCall is a call made to a recordingClient, with the method name and its arguments. Pods are recorded by their
+// <namespace>/<name> key so that calls can be compared after the pod has been modified.
+type recordedCall struct {
+	method string
+	args   []any
+}
+
+// mutatingMethods are the Client methods that write to the API server
+var mutatingMethods = []string{
+	"ReschedulePod",
+	"RecordRescheduleAttempt",
+	"RemovePodAnnotations",
+	"AddRescheduleHookTrackingAnnotation",
+	"RemoveRescheduleHookTrackingAnnotation",
+	"ClearTrackingAnnotations",
+	"BatchRemoveTrackingAnnotations",
+	"PatchResource",
+}
+
+// recordingClient is a Client that records every call made to it before delegating to another Client, so that tests can
+// assert which calls were made rather than reconstructing them from the resulting state
+type recordingClient struct {
+	client Client
+	calls  []recordedCall
+}
+
+func newRecordingClient(client Client) *recordingClient {
+	return &recordingClient{client: client}
+}
+
+func (r *recordingClient) record(method string, args ...any) {
+	r.calls = append(r.calls, recordedCall{method: method, args: args})
+}
+
+// callsTo returns the recorded calls to the method
+func (r *recordingClient) callsTo(method string) []recordedCall {
+	var calls []recordedCall
+	for _, call := range r.calls {
+		if call.method == method {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// mutations returns the names of the recorded methods that write to the API server, in the order they were called
+func (r *recordingClient) mutations() []string {
+	var methods []string
+	for _, call := range r.calls {
+		if slices.Contains(mutatingMethods, call.method) {
+			methods = append(methods, call.method)
+		}
+	}
+	return methods
+}
+
+func podKey(pod *corev1.Pod) string {
+	return pod.Namespace + "/" + pod.Name
+}
+
+func (r *recordingClient) GetPod(name, namespace string) (*corev1.Pod, error) {
+	r.record("GetPod", name, namespace)
+	return r.client.GetPod(name, namespace)
+}
+
+func (r *recordingClient) GetPodMeta(name, namespace string) (map[string]string, map[string]string, types.UID, corev1.PodPhase, *metav1.Time, error) {
+	r.record("GetPodMeta", name, namespace)
+	return r.client.GetPodMeta(name, namespace)
+}
+
+func (r *recordingClient) GetNode(nodeName string) (*corev1.Node, error) {
+	r.record("GetNode", nodeName)
+	return r.client.GetNode(nodeName)
+}
+
+func (r *recordingClient) GetNodeConditions(nodeName string) ([]corev1.Taint, corev1.ConditionStatus, error) {
+	r.record("GetNodeConditions", nodeName)
+	return r.client.GetNodeConditions(nodeName)
+}
+
+func (r *recordingClient) ListPodsByTrackingInstance(instance, namespace string) ([]corev1.Pod, error) {
+	r.record("ListPodsByTrackingInstance", instance, namespace)
+	return r.client.ListPodsByTrackingInstance(instance, namespace)
+}
+
+func (r *recordingClient) ReschedulePod(pod *corev1.Pod) error {
+	r.record("ReschedulePod", podKey(pod))
+	return r.client.ReschedulePod(pod)
+}
+
+func (r *recordingClient) RecordRescheduleAttempt(pod *corev1.Pod) error {
+	r.record("RecordRescheduleAttempt", podKey(pod))
+	return r.client.RecordRescheduleAttempt(pod)
+}
+
+func (r *recordingClient) RemovePodAnnotations(pod *corev1.Pod, annotations ...string) error {
+	r.record("RemovePodAnnotations", podKey(pod), annotations)
+	return r.client.RemovePodAnnotations(pod, annotations...)
+}
+
+func (r *recordingClient) GetTrackingResourceInstance(name, namespace string) (*unstructured.Unstructured, error) {
+	r.record("GetTrackingResourceInstance", name, namespace)
+	return r.client.GetTrackingResourceInstance(name, namespace)
+}
+
+func (r *recordingClient) TrackingInstanceName(pod *corev1.Pod) (string, error) {
+	r.record("TrackingInstanceName", podKey(pod))
+	return r.client.TrackingInstanceName(pod)
+}
+
+func (r *recordingClient) ResolveTrackingInstance(pod *corev1.Pod) (*unstructured.Unstructured, error) {
+	r.record("ResolveTrackingInstance", podKey(pod))
+	return r.client.ResolveTrackingInstance(pod)
+}
+
+func (r *recordingClient) AddRescheduleHookTrackingAnnotation(pod *corev1.Pod, trackingResourceInstance *unstructured.Unstructured) error {
+	r.record("AddRescheduleHookTrackingAnnotation", podKey(pod), trackingResourceInstance.GetName())
+	return r.client.AddRescheduleHookTrackingAnnotation(pod, trackingResourceInstance)
+}
+
+func (r *recordingClient) RemoveRescheduleHookTrackingAnnotation(pod *corev1.Pod, resourceInstanceName string) error {
+	r.record("RemoveRescheduleHookTrackingAnnotation", podKey(pod), resourceInstanceName)
+	return r.client.RemoveRescheduleHookTrackingAnnotation(pod, resourceInstanceName)
+}
+
+func (r *recordingClient) ClearTrackingAnnotations(resourceInstanceName, namespace string) ([]string, error) {
+	r.record("ClearTrackingAnnotations", resourceInstanceName, namespace)
+	return r.client.ClearTrackingAnnotations(resourceInstanceName, namespace)
+}
+
+func (r *recordingClient) BatchRemoveTrackingAnnotations(resourceInstanceName, namespace string, podKeys []string) error {
+	r.record("BatchRemoveTrackingAnnotations", resourceInstanceName, namespace, podKeys)
+	return r.client.BatchRemoveTrackingAnnotations(resourceInstanceName, namespace, podKeys)
+}
+
+func (r *recordingClient) ShouldTrackRescheduledPods() bool {
+	r.record("ShouldTrackRescheduledPods")
+	return r.client.ShouldTrackRescheduledPods()
+}
+
+func (r *recordingClient) ShouldAddTrackingAnnotation(pod *corev1.Pod, trackingResourceInstance *unstructured.Unstructured) bool {
+	r.record("ShouldAddTrackingAnnotation", podKey(pod), trackingResourceInstance)
+	return r.client.ShouldAddTrackingAnnotation(pod, trackingResourceInstance)
+}
+
+func (r *recordingClient) GetEvictionSubresourceSupport() ([]string, error) {
+	r.record("GetEvictionSubresourceSupport")
+	return r.client.GetEvictionSubresourceSupport()
+}
+
+func (r *recordingClient) IsTrackingResourceServed() (bool, error) {
+	r.record("IsTrackingResourceServed")
+	return r.client.IsTrackingResourceServed()
+}
+
+func (r *recordingClient) DryRunPatchTrackingResource(name, namespace string) error {
+	r.record("DryRunPatchTrackingResource", name, namespace)
+	return r.client.DryRunPatchTrackingResource(name, namespace)
+}
+
+func (r *recordingClient) GetWebhookCABundle(configName string) ([]byte, error) {
+	r.record("GetWebhookCABundle", configName)
+	return r.client.GetWebhookCABundle(configName)
+}
+
+func (r *recordingClient) GetResource(gvr schema.GroupVersionResource, namespace, name string) (*unstructured.Unstructured, error) {
+	r.record("GetResource", gvr, namespace, name)
+	return r.client.GetResource(gvr, namespace, name)
+}
+
+func (r *recordingClient) PatchResource(gvr schema.GroupVersionResource, namespace, name string, patchType types.PatchType, payload []byte) error {
+	r.record("PatchResource", gvr, namespace, name, patchType, string(payload))
+	return r.client.PatchResource(gvr, namespace, name, patchType, payload)
+}
+
+func (r *recordingClient) GetConfig() *Config {
+	r.record("GetConfig")
+	return r.client.GetConfig()
+}
+
+// WithContext is not recorded, as every eviction is decided with the context of its request
+func (r *recordingClient) WithContext(ctx context.Context) Client {
+	r.client = r.client.WithContext(ctx)
+	return r
+}
+
+func (r *recordingClient) Close() {
+	r.record("Close")
+	r.client.Close()
+}
+
 func TestHandleEviction(t *testing.T) {
 	testcases := []struct {
 		testname                            string
 		evictedPodName                      string
+		config                              *Config
 		mockClient                          *mockClient
 		expectedResult                      *admissionv1.AdmissionResponse
+		expectedReasonCode                  ReasonCode
 		expectedPod                         *corev1.Pod
 		expectedTrackingResourceAnnotations map[string]string
+		expectedMutations                   []string
 	}{
 		{
 			testname:       "Ignore non-existent/rescheduled pod",
@@ -95,7 +521,8 @@ func TestHandleEviction(t *testing.T) {
 			mockClient: &mockClient{
 				pod: nil,
 			},
-			expectedResult: denyEviction(http.StatusNotFound, metav1.StatusReasonNotFound, PodNoLongerExistsMsg),
+			expectedResult:     denyEviction(http.StatusNotFound, metav1.StatusReasonNotFound, PodNoLongerExistsMsg),
+			expectedReasonCode: ReasonPodNotFound,
 		},
 		{
 			testname:       "Ignore non-couchbase pods",
@@ -111,114 +538,148 @@ func TestHandleEviction(t *testing.T) {
 					},
 				},
 			},
-			expectedResult: allowEviction(),
+			expectedResult:     allowEviction(),
+			expectedReasonCode: ReasonLabelMismatch,
 		},
 		{
-			testname:       "Deny eviction with TooManyRequests if pod has reschedule annotation",
-			evictedPodName: "rescheduled-pod",
+			testname:       "Deny eviction with TooManyRequests, add reschedule annotation to unlabelled Pending pod of a tracking resource instance",
+			evictedPodName: "pending-pod",
+			config:         NewConfigBuilder().WithProtectPendingInstancePods(true).Build(),
 			mockClient: &mockClient{
 				pod: &corev1.Pod{
 					ObjectMeta: metav1.ObjectMeta{
-						Name:      "rescheduled-pod",
+						Name:      "pending-pod",
 						Namespace: "default",
 						Labels: map[string]string{
-							"app": "couchbase",
-						},
-						Annotations: map[string]string{
-							"cao.couchbase.com/reschedule": "true",
+							"couchbase_cluster": "cluster1",
 						},
 					},
+					Status: corev1.PodStatus{Phase: corev1.PodPending},
+				},
+			},
+			expectedPod: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "pending-pod",
+					Namespace: "default",
+					Labels: map[string]string{
+						"couchbase_cluster": "cluster1",
+					},
+					Annotations: map[string]string{
+						"cao.couchbase.com/reschedule": "true",
+					},
 				},
+				Status: corev1.PodStatus{Phase: corev1.PodPending},
 			},
-			expectedResult: denyEviction(http.StatusTooManyRequests, metav1.StatusReasonTooManyRequests, PodWaitingForRescheduleMsg),
+			expectedResult:     denyEviction(http.StatusTooManyRequests, metav1.StatusReasonTooManyRequests, RescheduleAnnotationAddedToPodMsg),
+			expectedReasonCode: ReasonAnnotationAdded,
+			expectedMutations:  []string{"ReschedulePod"},
 		},
 		{
-			testname:       "Deny eviction with TooManyRequests, track reschedule and add reschedule annotation to pod",
-			evictedPodName: "pod2",
+			testname:       "Allow eviction of unlabelled Pending pod without a tracking resource instance name",
+			evictedPodName: "pending-pod",
+			config:         NewConfigBuilder().WithProtectPendingInstancePods(true).Build(),
 			mockClient: &mockClient{
 				pod: &corev1.Pod{
 					ObjectMeta: metav1.ObjectMeta{
-						Name:      "pod2",
+						Name:      "pending-pod",
 						Namespace: "default",
 						Labels: map[string]string{
-							"app": "couchbase",
+							"couchbase_cluster": "cluster1",
 						},
 					},
+					Status: corev1.PodStatus{Phase: corev1.PodPending},
 				},
-				shouldTrackRescheduledPods:  true,
-				shouldAddTrackingAnnotation: true,
+				noTrackingInstanceName: true,
 			},
-			expectedPod: &corev1.Pod{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:      "pod2",
-					Namespace: "default",
-					Labels: map[string]string{
-						"app": "couchbase",
-					},
-					Annotations: map[string]string{
-						"cao.couchbase.com/reschedule": "true",
+			expectedResult:     allowEviction(),
+			expectedReasonCode: ReasonLabelMismatch,
+		},
+		{
+			testname:       "Allow eviction of unlabelled Pending pod whose tracking resource instance does not exist",
+			evictedPodName: "pending-pod",
+			config:         NewConfigBuilder().WithProtectPendingInstancePods(true).Build(),
+			mockClient: &mockClient{
+				pod: &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "pending-pod",
+						Namespace: "default",
+						Labels: map[string]string{
+							"couchbase_cluster": "cluster1",
+						},
 					},
+					Status: corev1.PodStatus{Phase: corev1.PodPending},
 				},
+				trackingResourceNotFound: true,
 			},
-			expectedTrackingResourceAnnotations: map[string]string{
-				TrackingResourceAnnotation("pod2", "default"): "true",
-			},
-			expectedResult: denyEviction(http.StatusTooManyRequests, metav1.StatusReasonTooManyRequests, RescheduleAnnotationAddedToPodMsg),
+			expectedResult:     allowEviction(),
+			expectedReasonCode: ReasonLabelMismatch,
 		},
 		{
-			testname:       "Allow eviction if pod is tracked but missing reschedule annotation",
-			evictedPodName: "pod2",
+			testname:       "Allow eviction of unlabelled Running pod of a tracking resource instance",
+			evictedPodName: "running-pod",
+			config:         NewConfigBuilder().WithProtectPendingInstancePods(true).Build(),
 			mockClient: &mockClient{
 				pod: &corev1.Pod{
 					ObjectMeta: metav1.ObjectMeta{
-						Name:      "pod2",
+						Name:      "running-pod",
 						Namespace: "default",
 						Labels: map[string]string{
-							"app": "couchbase",
+							"couchbase_cluster": "cluster1",
 						},
 					},
+					Status: corev1.PodStatus{Phase: corev1.PodRunning},
 				},
-				trackingResourceAnnotations: map[string]string{
-					TrackingResourceAnnotation("pod2", "default"): "true",
+			},
+			expectedResult:     allowEviction(),
+			expectedReasonCode: ReasonLabelMismatch,
+		},
+		{
+			testname:       "Allow eviction of unlabelled Pending pod when Pending pods are not protected",
+			evictedPodName: "pending-pod",
+			mockClient: &mockClient{
+				pod: &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "pending-pod",
+						Namespace: "default",
+						Labels: map[string]string{
+							"couchbase_cluster": "cluster1",
+						},
+					},
+					Status: corev1.PodStatus{Phase: corev1.PodPending},
 				},
-				shouldTrackRescheduledPods:  true,
-				shouldAddTrackingAnnotation: true,
 			},
-			expectedTrackingResourceAnnotations: map[string]string{},
-			expectedResult:                      denyEviction(http.StatusNotFound, metav1.StatusReasonNotFound, PodRescheduledWithSameNameMsg),
+			expectedResult:     allowEviction(),
+			expectedReasonCode: ReasonLabelMismatch,
 		},
 		{
-			testname:       "Deny eviction with TooManyRequests if different pod is tracked, but this pod is missing reschedule annotation",
-			evictedPodName: "pod2",
+			testname:       "Allow eviction for pods owned by an ignored kind",
+			evictedPodName: "job-pod",
+			config:         NewConfigBuilder().WithIgnoreOwnerKinds("Job", "DaemonSet").Build(),
 			mockClient: &mockClient{
 				pod: &corev1.Pod{
 					ObjectMeta: metav1.ObjectMeta{
-						Name:      "pod2",
+						Name:      "job-pod",
 						Namespace: "default",
 						Labels: map[string]string{
 							"app": "couchbase",
 						},
+						OwnerReferences: []metav1.OwnerReference{
+							{APIVersion: "batch/v1", Kind: "Job", Name: "job"},
+						},
 					},
 				},
-				trackingResourceAnnotations: map[string]string{
-					TrackingResourceAnnotation("pod1", "default"): "true",
-				},
-				shouldTrackRescheduledPods:  true,
-				shouldAddTrackingAnnotation: true,
-			},
-			expectedTrackingResourceAnnotations: map[string]string{
-				TrackingResourceAnnotation("pod1", "default"): "true",
-				TrackingResourceAnnotation("pod2", "default"): "true",
 			},
-			expectedResult: denyEviction(http.StatusTooManyRequests, metav1.StatusReasonTooManyRequests, RescheduleAnnotationAddedToPodMsg),
+			expectedResult:     allowEviction(),
+			expectedReasonCode: ReasonIgnoredOwnerKind,
 		},
 		{
-			testname:       "Deny eviction with TooManyRequests, add reschedule annotation to pod",
-			evictedPodName: "pod2",
+			testname:       "Deny eviction with TooManyRequests for pods without an ignored owner kind",
+			evictedPodName: "bare-pod",
+			config:         NewConfigBuilder().WithIgnoreOwnerKinds("Job", "DaemonSet").Build(),
 			mockClient: &mockClient{
 				pod: &corev1.Pod{
 					ObjectMeta: metav1.ObjectMeta{
-						Name:      "pod2",
+						Name:      "bare-pod",
 						Namespace: "default",
 						Labels: map[string]string{
 							"app": "couchbase",
@@ -228,7 +689,7 @@ func TestHandleEviction(t *testing.T) {
 			},
 			expectedPod: &corev1.Pod{
 				ObjectMeta: metav1.ObjectMeta{
-					Name:      "pod2",
+					Name:      "bare-pod",
 					Namespace: "default",
 					Labels: map[string]string{
 						"app": "couchbase",
@@ -238,40 +699,3380 @@ func TestHandleEviction(t *testing.T) {
 					},
 				},
 			},
-			expectedResult: denyEviction(http.StatusTooManyRequests, metav1.StatusReasonTooManyRequests, RescheduleAnnotationAddedToPodMsg),
+			expectedResult:     denyEviction(http.StatusTooManyRequests, metav1.StatusReasonTooManyRequests, RescheduleAnnotationAddedToPodMsg),
+			expectedReasonCode: ReasonAnnotationAdded,
+			expectedMutations:  []string{"ReschedulePod"},
 		},
-	}
-
-	for _, testcase := range testcases {
-		t.Run(testcase.testname, func(t *testing.T) {
-			testcase.mockClient.config = NewConfigBuilder().FromEnvironment().Build()
-			eviction := policyv1.Eviction{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:      testcase.evictedPodName,
-					Namespace: "default",
-				},
-			}
-
-			result := handleEviction(eviction, testcase.mockClient, CreateLogger(eviction.Name, eviction.Namespace, false))
-
-			if !reflect.DeepEqual(result, testcase.expectedResult) {
-				t.Errorf("Expected response to be %v, got %v", testcase.expectedResult, result)
-			}
-
-			if testcase.expectedPod != nil {
-				pod, err := testcase.mockClient.GetPod(testcase.evictedPodName, "default")
-				if err != nil {
-					t.Errorf("Failed to get pod: %v", err)
+		{
+			testname:       "Deny eviction with TooManyRequests if pod has reschedule annotation",
+			evictedPodName: "rescheduled-pod",
+			mockClient: &mockClient{
+				pod: &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "rescheduled-pod",
+						Namespace: "default",
+						Labels: map[string]string{
+							"app": "couchbase",
+						},
+						Annotations: map[string]string{
+							"cao.couchbase.com/reschedule": "true",
+						},
+					},
+				},
+			},
+			expectedResult:     denyEviction(http.StatusTooManyRequests, metav1.StatusReasonTooManyRequests, PodWaitingForRescheduleMsg),
+			expectedReasonCode: ReasonAlreadyMarked,
+		},
+		{
+			testname:       "Deny eviction with TooManyRequests, track reschedule and add reschedule annotation to pod",
+			evictedPodName: "pod2",
+			mockClient: &mockClient{
+				pod: &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "pod2",
+						Namespace: "default",
+						Labels: map[string]string{
+							"app": "couchbase",
+						},
+					},
+				},
+				shouldTrackRescheduledPods:  true,
+				shouldAddTrackingAnnotation: true,
+			},
+			expectedPod: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "pod2",
+					Namespace: "default",
+					Labels: map[string]string{
+						"app": "couchbase",
+					},
+					Annotations: map[string]string{
+						"cao.couchbase.com/reschedule": "true",
+					},
+				},
+			},
+			expectedTrackingResourceAnnotations: map[string]string{
+				TrackingResourceAnnotation("pod2", "default"): "true",
+			},
+			expectedResult:     denyEviction(http.StatusTooManyRequests, metav1.StatusReasonTooManyRequests, RescheduleAnnotationAddedToPodMsg),
+			expectedReasonCode: ReasonAnnotationAdded,
+			expectedMutations:  []string{"ReschedulePod", "AddRescheduleHookTrackingAnnotation"},
+		},
+		{
+			testname:       "Deny eviction with TooManyRequests and track reschedule when tracking resource has no annotations",
+			evictedPodName: "pod1",
+			mockClient: &mockClient{
+				pod: &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "pod1",
+						Namespace: "default",
+						Labels: map[string]string{
+							"app": "couchbase",
+						},
+					},
+				},
+				trackingResourceAnnotations: nil,
+				shouldTrackRescheduledPods:  true,
+				shouldAddTrackingAnnotation: true,
+			},
+			expectedTrackingResourceAnnotations: map[string]string{
+				TrackingResourceAnnotation("pod1", "default"): "true",
+			},
+			expectedResult:     denyEviction(http.StatusTooManyRequests, metav1.StatusReasonTooManyRequests, RescheduleAnnotationAddedToPodMsg),
+			expectedReasonCode: ReasonAnnotationAdded,
+			expectedMutations:  []string{"ReschedulePod", "AddRescheduleHookTrackingAnnotation"},
+		},
+		{
+			testname:       "Allow eviction for pod of a tracking resource that is being deleted",
+			evictedPodName: "pod1",
+			config:         NewConfigBuilder().WithAllowTerminatingInstance(true).Build(),
+			mockClient: &mockClient{
+				pod: &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "pod1",
+						Namespace: "default",
+						Labels: map[string]string{
+							"app": "couchbase",
+						},
+					},
+				},
+				trackingResourceAnnotations:       map[string]string{},
+				trackingResourceDeletionTimestamp: &metav1.Time{Time: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)},
+				shouldTrackRescheduledPods:        true,
+				shouldAddTrackingAnnotation:       true,
+			},
+			expectedTrackingResourceAnnotations: map[string]string{},
+			expectedResult:                      allowEviction(),
+			expectedReasonCode:                  ReasonInstanceTerminating,
+		},
+		{
+			testname:       "Deny eviction with TooManyRequests for pod of a tracking resource being deleted when not allowed",
+			evictedPodName: "pod1",
+			mockClient: &mockClient{
+				pod: &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "pod1",
+						Namespace: "default",
+						Labels: map[string]string{
+							"app": "couchbase",
+						},
+					},
+				},
+				trackingResourceAnnotations:       map[string]string{},
+				trackingResourceDeletionTimestamp: &metav1.Time{Time: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)},
+				shouldTrackRescheduledPods:        true,
+				shouldAddTrackingAnnotation:       true,
+			},
+			expectedTrackingResourceAnnotations: map[string]string{
+				TrackingResourceAnnotation("pod1", "default"): "true",
+			},
+			expectedResult:     denyEviction(http.StatusTooManyRequests, metav1.StatusReasonTooManyRequests, RescheduleAnnotationAddedToPodMsg),
+			expectedReasonCode: ReasonAnnotationAdded,
+			expectedMutations:  []string{"ReschedulePod", "AddRescheduleHookTrackingAnnotation"},
+		},
+		{
+			testname:       "Deny eviction with TooManyRequests without marking pod of a tracking resource that is not ready",
+			evictedPodName: "pod1",
+			config:         NewConfigBuilder().WithTrackingResourceNotReadyStatuses("status.phase", "Rebalancing", "Failed").Build(),
+			mockClient: &mockClient{
+				pod: &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "pod1",
+						Namespace: "default",
+						Labels: map[string]string{
+							"app": "couchbase",
+						},
+					},
+				},
+				trackingResourceAnnotations: map[string]string{},
+				trackingResourceStatus:      map[string]interface{}{"phase": "Rebalancing"},
+				shouldTrackRescheduledPods:  true,
+				shouldAddTrackingAnnotation: true,
+			},
+			expectedTrackingResourceAnnotations: map[string]string{},
+			expectedResult:                      denyEviction(http.StatusTooManyRequests, metav1.StatusReasonTooManyRequests, ClusterNotReadyToRescheduleMsg+" (status.phase is Rebalancing)"),
+			expectedReasonCode:                  ReasonInstanceNotReady,
+		},
+		{
+			testname:       "Deny eviction with TooManyRequests, add reschedule annotation to pod of a tracking resource that is ready",
+			evictedPodName: "pod1",
+			config:         NewConfigBuilder().WithTrackingResourceNotReadyStatuses("status.phase", "Rebalancing", "Failed").Build(),
+			mockClient: &mockClient{
+				pod: &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "pod1",
+						Namespace: "default",
+						Labels: map[string]string{
+							"app": "couchbase",
+						},
+					},
+				},
+				trackingResourceAnnotations: map[string]string{},
+				trackingResourceStatus:      map[string]interface{}{"phase": "Running"},
+				shouldTrackRescheduledPods:  true,
+				shouldAddTrackingAnnotation: true,
+			},
+			expectedTrackingResourceAnnotations: map[string]string{
+				TrackingResourceAnnotation("pod1", "default"): "true",
+			},
+			expectedResult:     denyEviction(http.StatusTooManyRequests, metav1.StatusReasonTooManyRequests, RescheduleAnnotationAddedToPodMsg),
+			expectedReasonCode: ReasonAnnotationAdded,
+			expectedMutations:  []string{"ReschedulePod", "AddRescheduleHookTrackingAnnotation"},
+		},
+		{
+			testname:       "Deny eviction with TooManyRequests, add reschedule annotation to pod of a tracking resource without the status field",
+			evictedPodName: "pod1",
+			config:         NewConfigBuilder().WithTrackingResourceNotReadyStatuses("status.phase", "Rebalancing", "Failed").Build(),
+			mockClient: &mockClient{
+				pod: &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "pod1",
+						Namespace: "default",
+						Labels: map[string]string{
+							"app": "couchbase",
+						},
+					},
+				},
+				trackingResourceAnnotations: map[string]string{},
+				shouldTrackRescheduledPods:  true,
+				shouldAddTrackingAnnotation: true,
+			},
+			expectedTrackingResourceAnnotations: map[string]string{
+				TrackingResourceAnnotation("pod1", "default"): "true",
+			},
+			expectedResult:     denyEviction(http.StatusTooManyRequests, metav1.StatusReasonTooManyRequests, RescheduleAnnotationAddedToPodMsg),
+			expectedReasonCode: ReasonAnnotationAdded,
+			expectedMutations:  []string{"ReschedulePod", "AddRescheduleHookTrackingAnnotation"},
+		},
+		{
+			testname:       "Deny eviction with NotFound for pod rescheduled with the same name while its tracking resource is not ready",
+			evictedPodName: "pod1",
+			config:         NewConfigBuilder().WithTrackingResourceNotReadyStatuses("status.phase", "Rebalancing", "Failed").Build(),
+			mockClient: &mockClient{
+				pod: &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "pod1",
+						Namespace: "default",
+						Labels: map[string]string{
+							"app": "couchbase",
+						},
+					},
+				},
+				trackingResourceAnnotations: map[string]string{
+					TrackingResourceAnnotation("pod1", "default"): "true",
+				},
+				trackingResourceStatus:      map[string]interface{}{"phase": "Rebalancing"},
+				shouldTrackRescheduledPods:  true,
+				shouldAddTrackingAnnotation: true,
+			},
+			expectedTrackingResourceAnnotations: map[string]string{},
+			expectedResult:                      denyEviction(http.StatusNotFound, metav1.StatusReasonNotFound, PodRescheduledWithSameNameMsg+" (0 tracked pods remaining)"),
+			expectedReasonCode:                  ReasonSameNameRescheduled,
+			expectedMutations:                   []string{"RemoveRescheduleHookTrackingAnnotation"},
+		},
+		{
+			testname:       "Allow eviction if pod is tracked but missing reschedule annotation",
+			evictedPodName: "pod2",
+			mockClient: &mockClient{
+				pod: &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "pod2",
+						Namespace: "default",
+						Labels: map[string]string{
+							"app": "couchbase",
+						},
+					},
+				},
+				trackingResourceAnnotations: map[string]string{
+					TrackingResourceAnnotation("pod2", "default"): "true",
+				},
+				shouldTrackRescheduledPods:  true,
+				shouldAddTrackingAnnotation: true,
+			},
+			expectedTrackingResourceAnnotations: map[string]string{},
+			expectedResult:                      denyEviction(http.StatusNotFound, metav1.StatusReasonNotFound, PodRescheduledWithSameNameMsg+" (0 tracked pods remaining)"),
+			expectedReasonCode:                  ReasonSameNameRescheduled,
+			expectedMutations:                   []string{"RemoveRescheduleHookTrackingAnnotation"},
+		},
+		{
+			testname:       "Deny eviction with NotFound and report remaining tracked pods",
+			evictedPodName: "pod2",
+			mockClient: &mockClient{
+				pod: &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "pod2",
+						Namespace: "default",
+						Labels: map[string]string{
+							"app": "couchbase",
+						},
+					},
+				},
+				trackingResourceAnnotations: map[string]string{
+					TrackingResourceAnnotation("pod1", "default"): "true",
+					TrackingResourceAnnotation("pod2", "default"): "true",
+					TrackingResourceAnnotation("pod3", "default"): "true",
+					DefaultForceTrackingAnnotation:                "true",
+				},
+				shouldTrackRescheduledPods:  true,
+				shouldAddTrackingAnnotation: true,
+			},
+			expectedTrackingResourceAnnotations: map[string]string{
+				TrackingResourceAnnotation("pod1", "default"): "true",
+				TrackingResourceAnnotation("pod3", "default"): "true",
+				DefaultForceTrackingAnnotation:                "true",
+			},
+			expectedResult:     denyEviction(http.StatusNotFound, metav1.StatusReasonNotFound, PodRescheduledWithSameNameMsg+" (2 tracked pods remaining)"),
+			expectedReasonCode: ReasonSameNameRescheduled,
+			expectedMutations:  []string{"RemoveRescheduleHookTrackingAnnotation"},
+		},
+		{
+			testname:       "Deny eviction with TooManyRequests if different pod is tracked, but this pod is missing reschedule annotation",
+			evictedPodName: "pod2",
+			mockClient: &mockClient{
+				pod: &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "pod2",
+						Namespace: "default",
+						Labels: map[string]string{
+							"app": "couchbase",
+						},
+					},
+				},
+				trackingResourceAnnotations: map[string]string{
+					TrackingResourceAnnotation("pod1", "default"): "true",
+				},
+				shouldTrackRescheduledPods:  true,
+				shouldAddTrackingAnnotation: true,
+			},
+			expectedTrackingResourceAnnotations: map[string]string{
+				TrackingResourceAnnotation("pod1", "default"): "true",
+				TrackingResourceAnnotation("pod2", "default"): "true",
+			},
+			expectedResult:     denyEviction(http.StatusTooManyRequests, metav1.StatusReasonTooManyRequests, RescheduleAnnotationAddedToPodMsg),
+			expectedReasonCode: ReasonAnnotationAdded,
+			expectedMutations:  []string{"ReschedulePod", "AddRescheduleHookTrackingAnnotation"},
+		},
+		{
+			testname:       "Deny eviction with TooManyRequests and record pod node in tracking annotation",
+			evictedPodName: "pod2",
+			config:         NewConfigBuilder().WithTrackPodNode(true).Build(),
+			mockClient: &mockClient{
+				pod:                         trackedPodStub("pod2", "node-1", "uid-1"),
+				shouldTrackRescheduledPods:  true,
+				shouldAddTrackingAnnotation: true,
+			},
+			expectedTrackingResourceAnnotations: map[string]string{
+				TrackingResourceAnnotation("pod2", "default"): `{"nodeName":"node-1","uid":"uid-1"}`,
+			},
+			expectedResult:     denyEviction(http.StatusTooManyRequests, metav1.StatusReasonTooManyRequests, RescheduleAnnotationAddedToPodMsg),
+			expectedReasonCode: ReasonAnnotationAdded,
+			expectedMutations:  []string{"ReschedulePod", "AddRescheduleHookTrackingAnnotation"},
+		},
+		{
+			testname:       "Deny eviction with TooManyRequests if tracked pod is on the same node with the same UID",
+			evictedPodName: "pod2",
+			config:         NewConfigBuilder().WithTrackPodNode(true).Build(),
+			mockClient: &mockClient{
+				pod: trackedPodStub("pod2", "node-1", "uid-1"),
+				trackingResourceAnnotations: map[string]string{
+					TrackingResourceAnnotation("pod2", "default"): `{"nodeName":"node-1","uid":"uid-1"}`,
+				},
+				shouldTrackRescheduledPods:  true,
+				shouldAddTrackingAnnotation: true,
+			},
+			expectedTrackingResourceAnnotations: map[string]string{
+				TrackingResourceAnnotation("pod2", "default"): `{"nodeName":"node-1","uid":"uid-1"}`,
+			},
+			expectedResult:     denyEviction(http.StatusTooManyRequests, metav1.StatusReasonTooManyRequests, RescheduleAnnotationAddedToPodMsg),
+			expectedReasonCode: ReasonAnnotationAdded,
+			expectedMutations:  []string{"ReschedulePod"},
+		},
+		{
+			testname:       "Deny eviction with NotFound if tracked pod is on a different node",
+			evictedPodName: "pod2",
+			config:         NewConfigBuilder().WithTrackPodNode(true).Build(),
+			mockClient: &mockClient{
+				pod: trackedPodStub("pod2", "node-2", "uid-2"),
+				trackingResourceAnnotations: map[string]string{
+					TrackingResourceAnnotation("pod2", "default"): `{"nodeName":"node-1","uid":"uid-1"}`,
+				},
+				shouldTrackRescheduledPods:  true,
+				shouldAddTrackingAnnotation: true,
+			},
+			expectedTrackingResourceAnnotations: map[string]string{},
+			expectedResult:                      denyEviction(http.StatusNotFound, metav1.StatusReasonNotFound, PodRescheduledToDifferentNodeMsg+" (0 tracked pods remaining)"),
+			expectedReasonCode:                  ReasonSameNameRescheduled,
+			expectedMutations:                   []string{"RemoveRescheduleHookTrackingAnnotation"},
+		},
+		{
+			testname:       "Deny eviction with NotFound if tracked pod is on the same node with a different UID",
+			evictedPodName: "pod2",
+			config:         NewConfigBuilder().WithTrackPodNode(true).Build(),
+			mockClient: &mockClient{
+				pod: trackedPodStub("pod2", "node-1", "uid-2"),
+				trackingResourceAnnotations: map[string]string{
+					TrackingResourceAnnotation("pod2", "default"): `{"nodeName":"node-1","uid":"uid-1"}`,
+				},
+				shouldTrackRescheduledPods:  true,
+				shouldAddTrackingAnnotation: true,
+			},
+			expectedTrackingResourceAnnotations: map[string]string{},
+			expectedResult:                      denyEviction(http.StatusNotFound, metav1.StatusReasonNotFound, PodRescheduledWithSameNameMsg+" (0 tracked pods remaining)"),
+			expectedReasonCode:                  ReasonSameNameRescheduled,
+			expectedMutations:                   []string{"RemoveRescheduleHookTrackingAnnotation"},
+		},
+		{
+			testname:       "Deny eviction with InternalError if tracking resource cannot be determined",
+			evictedPodName: "pod2",
+			mockClient: &mockClient{
+				pod: &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "pod2",
+						Namespace: "default",
+						Labels: map[string]string{
+							"app": "couchbase",
+						},
+					},
+				},
+				shouldTrackRescheduledPods:  true,
+				shouldAddTrackingAnnotation: true,
+				noTrackingInstanceName:      true,
+			},
+			expectedResult:     denyEviction(http.StatusInternalServerError, metav1.StatusReasonInternalError, NoTrackingInstanceNameMsg),
+			expectedReasonCode: ReasonTrackingError,
+		},
+		{
+			testname:       "Deny eviction with InternalError if tracking resource does not exist",
+			evictedPodName: "pod2",
+			mockClient: &mockClient{
+				pod: &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "pod2",
+						Namespace: "default",
+						Labels: map[string]string{
+							"app": "couchbase",
+						},
+					},
+				},
+				shouldTrackRescheduledPods:  true,
+				shouldAddTrackingAnnotation: true,
+				trackingResourceNotFound:    true,
+			},
+			expectedResult:     denyEviction(http.StatusInternalServerError, metav1.StatusReasonInternalError, TrackingResourceNotFoundMsg),
+			expectedReasonCode: ReasonTrackingError,
+		},
+		{
+			testname:       "Deny eviction with TooManyRequests and add reschedule annotation if the tracking resource does not exist and failures are ignored",
+			evictedPodName: "pod2",
+			config:         NewConfigBuilder().FromEnvironment().WithTrackingFailurePolicy(TrackingFailurePolicyIgnore).Build(),
+			mockClient: &mockClient{
+				pod: &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "pod2",
+						Namespace: "default",
+						Labels: map[string]string{
+							"app":               "couchbase",
+							"couchbase_cluster": "deleted-cluster",
+						},
+					},
+				},
+				shouldTrackRescheduledPods:  true,
+				shouldAddTrackingAnnotation: true,
+				trackingResourceNotFound:    true,
+			},
+			expectedPod: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "pod2",
+					Namespace: "default",
+					Labels: map[string]string{
+						"app":               "couchbase",
+						"couchbase_cluster": "deleted-cluster",
+					},
+					Annotations: map[string]string{
+						DefaultRescheduleAnnotationKey: DefaultRescheduleAnnotationValue,
+					},
+				},
+			},
+			expectedResult:     denyEviction(http.StatusTooManyRequests, metav1.StatusReasonTooManyRequests, RescheduleAnnotationAddedToPodMsg),
+			expectedReasonCode: ReasonAnnotationAdded,
+			expectedMutations:  []string{"ReschedulePod"},
+		},
+		{
+			testname:       "Deny eviction with InternalError if tracking resource cannot be determined and failures are ignored",
+			evictedPodName: "pod2",
+			config:         NewConfigBuilder().FromEnvironment().WithTrackingFailurePolicy(TrackingFailurePolicyIgnore).Build(),
+			mockClient: &mockClient{
+				pod: &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "pod2",
+						Namespace: "default",
+						Labels: map[string]string{
+							"app": "couchbase",
+						},
+					},
+				},
+				shouldTrackRescheduledPods:  true,
+				shouldAddTrackingAnnotation: true,
+				noTrackingInstanceName:      true,
+			},
+			expectedResult:     denyEviction(http.StatusInternalServerError, metav1.StatusReasonInternalError, NoTrackingInstanceNameMsg),
+			expectedReasonCode: ReasonTrackingError,
+		},
+		{
+			testname:       "Deny eviction with TooManyRequests if pod changes while adding reschedule annotation",
+			evictedPodName: "pod2",
+			mockClient: &mockClient{
+				pod:                trackedPodStub("pod2", "node-1", "uid-1"),
+				rescheduleConflict: true,
+			},
+			expectedResult:     denyEviction(http.StatusTooManyRequests, metav1.StatusReasonTooManyRequests, PodChangedDuringRescheduleMsg),
+			expectedReasonCode: ReasonPodChanged,
+			expectedMutations:  []string{"ReschedulePod"},
+		},
+		{
+			testname:       "Deny eviction with NotFound if pod is recreated while adding reschedule annotation",
+			evictedPodName: "pod2",
+			mockClient: &mockClient{
+				pod:                trackedPodStub("pod2", "node-1", "uid-1"),
+				rescheduleConflict: true,
+				recreatedPod:       trackedPodStub("pod2", "node-2", "uid-2"),
+			},
+			expectedResult:     denyEviction(http.StatusNotFound, metav1.StatusReasonNotFound, PodRescheduledWithSameNameMsg),
+			expectedReasonCode: ReasonSameNameRescheduled,
+			expectedMutations:  []string{"ReschedulePod"},
+		},
+		{
+			testname:       "Allow eviction and add reschedule annotation to pod when evictions are not blocked",
+			evictedPodName: "pod2",
+			config:         NewConfigBuilder().WithBlockEviction(false).Build(),
+			mockClient: &mockClient{
+				pod: &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "pod2",
+						Namespace: "default",
+						Labels: map[string]string{
+							"app": "couchbase",
+						},
+					},
+				},
+				shouldTrackRescheduledPods:  true,
+				shouldAddTrackingAnnotation: true,
+			},
+			expectedPod: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "pod2",
+					Namespace: "default",
+					Labels: map[string]string{
+						"app": "couchbase",
+					},
+					Annotations: map[string]string{
+						"cao.couchbase.com/reschedule": "true",
+					},
+				},
+			},
+			expectedResult:     allowEviction(),
+			expectedReasonCode: ReasonAnnotationAdded,
+			expectedMutations:  []string{"ReschedulePod"},
+		},
+		{
+			testname:       "Allow eviction if pod has reschedule annotation when evictions are not blocked",
+			evictedPodName: "rescheduled-pod",
+			config:         NewConfigBuilder().WithBlockEviction(false).Build(),
+			mockClient: &mockClient{
+				pod: &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "rescheduled-pod",
+						Namespace: "default",
+						Labels: map[string]string{
+							"app": "couchbase",
+						},
+						Annotations: map[string]string{
+							"cao.couchbase.com/reschedule": "true",
+						},
+					},
+				},
+			},
+			expectedResult:     allowEviction(),
+			expectedReasonCode: ReasonAlreadyMarked,
+		},
+		{
+			testname:       "Allow eviction and remove stale annotations from rescheduled pod when cleanup is enabled",
+			evictedPodName: "rescheduled-pod",
+			config:         NewConfigBuilder().WithBlockEviction(false).WithCleanupPodAnnotations(true).Build(),
+			mockClient: &mockClient{
+				pod: &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "rescheduled-pod",
+						Namespace: "default",
+						Labels: map[string]string{
+							"app": "couchbase",
+						},
+						Annotations: map[string]string{
+							"cao.couchbase.com/reschedule": "true",
+							DefaultAttemptsAnnotation:      "3",
+							DefaultForceTrackingAnnotation: "true",
+						},
+					},
+				},
+			},
+			expectedPod: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "rescheduled-pod",
+					Namespace: "default",
+					Labels: map[string]string{
+						"app": "couchbase",
+					},
+					Annotations: map[string]string{
+						DefaultForceTrackingAnnotation: "true",
+					},
+				},
+			},
+			expectedResult:     allowEviction(),
+			expectedReasonCode: ReasonAlreadyMarked,
+			expectedMutations:  []string{"RemovePodAnnotations"},
+		},
+		{
+			testname:       "Deny eviction with NotFound if pod is deleted before the reschedule annotation is added",
+			evictedPodName: "pod1",
+			mockClient: &mockClient{
+				pod:                trackedPodStub("pod1", "node1", "uid1"),
+				rescheduleNotFound: true,
+			},
+			expectedResult:     denyEviction(http.StatusNotFound, metav1.StatusReasonNotFound, PodNoLongerExistsMsg),
+			expectedReasonCode: ReasonPodNotFound,
+			expectedMutations:  []string{"ReschedulePod"},
+		},
+		{
+			testname:       "Allow eviction if pod ordinal is outside the protected range",
+			evictedPodName: "cluster-0003",
+			config:         NewConfigBuilder().WithProtectOrdinalRange(0, 2).Build(),
+			mockClient: &mockClient{
+				pod: trackedPodStub("cluster-0003", "node1", "uid1"),
+			},
+			expectedResult:     allowEviction(),
+			expectedReasonCode: ReasonOrdinalNotProtected,
+		},
+		{
+			testname:       "Allow eviction if pod has no ordinal and a protected range is set",
+			evictedPodName: "cluster",
+			config:         NewConfigBuilder().WithProtectOrdinalRange(0, 2).Build(),
+			mockClient: &mockClient{
+				pod: trackedPodStub("cluster", "node1", "uid1"),
+			},
+			expectedResult:     allowEviction(),
+			expectedReasonCode: ReasonOrdinalNotProtected,
+		},
+		{
+			testname:       "Deny eviction with TooManyRequests if pod ordinal is within the protected range",
+			evictedPodName: "cluster-0002",
+			config:         NewConfigBuilder().WithProtectOrdinalRange(0, 2).Build(),
+			mockClient: &mockClient{
+				pod: trackedPodStub("cluster-0002", "node1", "uid1"),
+			},
+			expectedResult:     denyEviction(http.StatusTooManyRequests, metav1.StatusReasonTooManyRequests, RescheduleAnnotationAddedToPodMsg),
+			expectedReasonCode: ReasonAnnotationAdded,
+			expectedMutations:  []string{"ReschedulePod"},
+		},
+		{
+			testname:       "Deny eviction with TooManyRequests if pod has the pod label and does not match the exclude selector",
+			evictedPodName: "data-pod",
+			config:         NewConfigBuilder().WithExcludeSelector("role=backup").Build(),
+			mockClient: &mockClient{
+				pod: &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "data-pod",
+						Namespace: "default",
+						Labels: map[string]string{
+							"app":  "couchbase",
+							"role": "data",
+						},
+					},
+				},
+			},
+			expectedResult:     denyEviction(http.StatusTooManyRequests, metav1.StatusReasonTooManyRequests, RescheduleAnnotationAddedToPodMsg),
+			expectedReasonCode: ReasonAnnotationAdded,
+			expectedMutations:  []string{"ReschedulePod"},
+		},
+		{
+			testname:       "Allow eviction if pod has the pod label and matches the exclude selector",
+			evictedPodName: "backup-pod",
+			config:         NewConfigBuilder().WithExcludeSelector("role in (backup,restore)").Build(),
+			mockClient: &mockClient{
+				pod: &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "backup-pod",
+						Namespace: "default",
+						Labels: map[string]string{
+							"app":  "couchbase",
+							"role": "backup",
+						},
+					},
+				},
+			},
+			expectedResult:     allowEviction(),
+			expectedReasonCode: ReasonExcluded,
+		},
+		{
+			testname:       "Allow eviction if pod has the pod label and matches the operator pod selector",
+			evictedPodName: "couchbase-operator",
+			config:         NewConfigBuilder().WithOperatorPodSelector("name=couchbase-operator").Build(),
+			mockClient: &mockClient{
+				pod: &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "couchbase-operator",
+						Namespace: "default",
+						Labels: map[string]string{
+							"app":  "couchbase",
+							"name": "couchbase-operator",
+						},
+					},
+				},
+			},
+			expectedResult:     allowEviction(),
+			expectedReasonCode: ReasonOperatorPod,
+		},
+		{
+			testname:       "Allow eviction if pod matches the operator pod selector and is already marked for rescheduling",
+			evictedPodName: "couchbase-operator",
+			config:         NewConfigBuilder().WithOperatorPodSelector("name=couchbase-operator").Build(),
+			mockClient: &mockClient{
+				pod: &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "couchbase-operator",
+						Namespace: "default",
+						Labels: map[string]string{
+							"app":  "couchbase",
+							"name": "couchbase-operator",
+						},
+						Annotations: map[string]string{
+							DefaultRescheduleAnnotationKey: "true",
+						},
+					},
+				},
+			},
+			expectedResult:     allowEviction(),
+			expectedReasonCode: ReasonOperatorPod,
+		},
+		{
+			testname:       "Deny eviction with TooManyRequests if pod has the pod label and does not match the operator pod selector",
+			evictedPodName: "data-pod",
+			config:         NewConfigBuilder().WithOperatorPodSelector("name=couchbase-operator").Build(),
+			mockClient: &mockClient{
+				pod: &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "data-pod",
+						Namespace: "default",
+						Labels: map[string]string{
+							"app": "couchbase",
+						},
+					},
+				},
+			},
+			expectedResult:     denyEviction(http.StatusTooManyRequests, metav1.StatusReasonTooManyRequests, RescheduleAnnotationAddedToPodMsg),
+			expectedReasonCode: ReasonAnnotationAdded,
+			expectedMutations:  []string{"ReschedulePod"},
+		},
+		{
+			testname:       "Allow eviction if pod matches the exclude selector when trusting the webhook selector",
+			evictedPodName: "backup-pod",
+			config:         NewConfigBuilder().WithTrustWebhookSelector(true).WithExcludeSelector("role=backup").Build(),
+			mockClient: &mockClient{
+				pod: &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "backup-pod",
+						Namespace: "default",
+						Labels: map[string]string{
+							"app":  "couchbase",
+							"role": "backup",
+						},
+					},
+				},
+			},
+			expectedResult:     allowEviction(),
+			expectedReasonCode: ReasonExcluded,
+		},
+		{
+			testname:       "Allow eviction if the pod's node is Ready and not cordoned and only draining nodes are protected",
+			evictedPodName: "pod1",
+			config:         NewConfigBuilder().WithOnlyDrainingNodes(true).Build(),
+			mockClient: &mockClient{
+				pod:  trackedPodStub("pod1", "node1", "uid1"),
+				node: nodeStub("node1", corev1.ConditionTrue),
+			},
+			expectedResult:     allowEviction(),
+			expectedReasonCode: ReasonNodeNotDraining,
+		},
+		{
+			testname:       "Deny eviction with TooManyRequests if the pod's node is cordoned and only draining nodes are protected",
+			evictedPodName: "pod1",
+			config:         NewConfigBuilder().WithOnlyDrainingNodes(true).Build(),
+			mockClient: &mockClient{
+				pod:  trackedPodStub("pod1", "node1", "uid1"),
+				node: nodeStub("node1", corev1.ConditionTrue, unschedulableTaint),
+			},
+			expectedResult:     denyEviction(http.StatusTooManyRequests, metav1.StatusReasonTooManyRequests, RescheduleAnnotationAddedToPodMsg),
+			expectedReasonCode: ReasonAnnotationAdded,
+			expectedMutations:  []string{"ReschedulePod"},
+		},
+		{
+			testname:       "Deny eviction with TooManyRequests if the pod's node is NotReady and only draining nodes are protected",
+			evictedPodName: "pod1",
+			config:         NewConfigBuilder().WithOnlyDrainingNodes(true).Build(),
+			mockClient: &mockClient{
+				pod:  trackedPodStub("pod1", "node1", "uid1"),
+				node: nodeStub("node1", corev1.ConditionFalse),
+			},
+			expectedResult:     denyEviction(http.StatusTooManyRequests, metav1.StatusReasonTooManyRequests, RescheduleAnnotationAddedToPodMsg),
+			expectedReasonCode: ReasonAnnotationAdded,
+			expectedMutations:  []string{"ReschedulePod"},
+		},
+		{
+			testname:       "Deny eviction with TooManyRequests if the pod's node no longer exists and only draining nodes are protected",
+			evictedPodName: "pod1",
+			config:         NewConfigBuilder().WithOnlyDrainingNodes(true).Build(),
+			mockClient: &mockClient{
+				pod: trackedPodStub("pod1", "node1", "uid1"),
+			},
+			expectedResult:     denyEviction(http.StatusTooManyRequests, metav1.StatusReasonTooManyRequests, RescheduleAnnotationAddedToPodMsg),
+			expectedReasonCode: ReasonAnnotationAdded,
+			expectedMutations:  []string{"ReschedulePod"},
+		},
+		{
+			testname:       "Deny eviction with TooManyRequests if the pod's node has the drain annotation",
+			evictedPodName: "pod1",
+			config:         NewConfigBuilder().WithRequiredNodeDrainAnnotation("node.kubernetes.io/drain", "").Build(),
+			mockClient: &mockClient{
+				pod:  trackedPodStub("pod1", "node1", "uid1"),
+				node: annotatedNodeStub("node1", map[string]string{"node.kubernetes.io/drain": "started"}),
+			},
+			expectedResult:     denyEviction(http.StatusTooManyRequests, metav1.StatusReasonTooManyRequests, RescheduleAnnotationAddedToPodMsg),
+			expectedReasonCode: ReasonAnnotationAdded,
+			expectedMutations:  []string{"ReschedulePod"},
+		},
+		{
+			testname:       "Allow eviction if the pod's node does not have the drain annotation",
+			evictedPodName: "pod1",
+			config:         NewConfigBuilder().WithRequiredNodeDrainAnnotation("node.kubernetes.io/drain", "").Build(),
+			mockClient: &mockClient{
+				pod:  trackedPodStub("pod1", "node1", "uid1"),
+				node: nodeStub("node1", corev1.ConditionTrue),
+			},
+			expectedResult:     allowEviction(),
+			expectedReasonCode: ReasonNodeNotAnnotated,
+		},
+		{
+			testname:       "Deny eviction with TooManyRequests if the pod's node has the drain annotation with the required value",
+			evictedPodName: "pod1",
+			config:         NewConfigBuilder().WithRequiredNodeDrainAnnotation("node.kubernetes.io/drain", "true").Build(),
+			mockClient: &mockClient{
+				pod:  trackedPodStub("pod1", "node1", "uid1"),
+				node: annotatedNodeStub("node1", map[string]string{"node.kubernetes.io/drain": "true"}),
+			},
+			expectedResult:     denyEviction(http.StatusTooManyRequests, metav1.StatusReasonTooManyRequests, RescheduleAnnotationAddedToPodMsg),
+			expectedReasonCode: ReasonAnnotationAdded,
+			expectedMutations:  []string{"ReschedulePod"},
+		},
+		{
+			testname:       "Allow eviction if the pod's node has the drain annotation with a different value",
+			evictedPodName: "pod1",
+			config:         NewConfigBuilder().WithRequiredNodeDrainAnnotation("node.kubernetes.io/drain", "true").Build(),
+			mockClient: &mockClient{
+				pod:  trackedPodStub("pod1", "node1", "uid1"),
+				node: annotatedNodeStub("node1", map[string]string{"node.kubernetes.io/drain": "false"}),
+			},
+			expectedResult:     allowEviction(),
+			expectedReasonCode: ReasonNodeNotAnnotated,
+		},
+		{
+			testname:       "Deny eviction with TooManyRequests if the pod's node no longer exists and the drain annotation is required",
+			evictedPodName: "pod1",
+			config:         NewConfigBuilder().WithRequiredNodeDrainAnnotation("node.kubernetes.io/drain", "").Build(),
+			mockClient: &mockClient{
+				pod: trackedPodStub("pod1", "node1", "uid1"),
+			},
+			expectedResult:     denyEviction(http.StatusTooManyRequests, metav1.StatusReasonTooManyRequests, RescheduleAnnotationAddedToPodMsg),
+			expectedReasonCode: ReasonAnnotationAdded,
+			expectedMutations:  []string{"ReschedulePod"},
+		},
+		{
+			testname:       "Allow eviction if the pod is not scheduled to a node and the drain annotation is required",
+			evictedPodName: "pod1",
+			config:         NewConfigBuilder().WithRequiredNodeDrainAnnotation("node.kubernetes.io/drain", "").Build(),
+			mockClient: &mockClient{
+				pod: trackedPodStub("pod1", "", "uid1"),
+			},
+			expectedResult:     allowEviction(),
+			expectedReasonCode: ReasonNodeNotAnnotated,
+		},
+		{
+			testname:       "Allow eviction if no pods are in the rollout",
+			evictedPodName: "pod1",
+			config:         NewConfigBuilder().WithRolloutPercent(0).Build(),
+			mockClient: &mockClient{
+				pod: trackedPodStub("pod1", "node1", "uid1"),
+			},
+			expectedResult:     allowEviction(),
+			expectedReasonCode: ReasonOutsideRollout,
+		},
+		{
+			testname:       "Deny eviction with TooManyRequests if all pods are in the rollout",
+			evictedPodName: "pod1",
+			config:         NewConfigBuilder().WithRolloutPercent(100).Build(),
+			mockClient: &mockClient{
+				pod: trackedPodStub("pod1", "node1", "uid1"),
+			},
+			expectedResult:     denyEviction(http.StatusTooManyRequests, metav1.StatusReasonTooManyRequests, RescheduleAnnotationAddedToPodMsg),
+			expectedReasonCode: ReasonAnnotationAdded,
+			expectedMutations:  []string{"ReschedulePod"},
+		},
+		{
+			testname:       "Allow eviction if the pod's cluster has no more than the minimum number of pods",
+			evictedPodName: "pod1",
+			config:         NewConfigBuilder().WithMinClusterSize(2).Build(),
+			mockClient: &mockClient{
+				pod:         clusterPodStub("pod1", "cluster1"),
+				clusterPods: []*corev1.Pod{clusterPodStub("pod1", "cluster1"), clusterPodStub("pod2", "cluster1"), clusterPodStub("pod3", "cluster2")},
+			},
+			expectedResult:     allowEviction(),
+			expectedReasonCode: ReasonClusterTooSmall,
+		},
+		{
+			testname:       "Deny eviction with TooManyRequests if the pod's cluster has more than the minimum number of pods",
+			evictedPodName: "pod1",
+			config:         NewConfigBuilder().WithMinClusterSize(1).Build(),
+			mockClient: &mockClient{
+				pod:         clusterPodStub("pod1", "cluster1"),
+				clusterPods: []*corev1.Pod{clusterPodStub("pod1", "cluster1"), clusterPodStub("pod2", "cluster1"), clusterPodStub("pod3", "cluster2")},
+			},
+			expectedResult:     denyEviction(http.StatusTooManyRequests, metav1.StatusReasonTooManyRequests, RescheduleAnnotationAddedToPodMsg),
+			expectedReasonCode: ReasonAnnotationAdded,
+			expectedMutations:  []string{"ReschedulePod"},
+		},
+		{
+			testname:       "Deny eviction with InternalError if the pods in the pod's cluster cannot be counted",
+			evictedPodName: "pod1",
+			config:         NewConfigBuilder().WithMinClusterSize(1).Build(),
+			mockClient: &mockClient{
+				pod:             clusterPodStub("pod1", "cluster1"),
+				listPodsFailure: true,
+			},
+			expectedResult:     denyEviction(http.StatusInternalServerError, metav1.StatusReasonInternalError, FailedToCountPodsMsg),
+			expectedReasonCode: ReasonPodCountError,
+		},
+		{
+			testname:       "Allow eviction if pod has no PersistentVolumeClaim volumes and only stateful pods are protected",
+			evictedPodName: "stateless-pod",
+			config:         NewConfigBuilder().WithProtectOnlyStateful(true).Build(),
+			mockClient: &mockClient{
+				pod: trackedPodStub("stateless-pod", "node1", "uid1"),
+			},
+			expectedResult:     allowEviction(),
+			expectedReasonCode: ReasonStateless,
+		},
+		{
+			testname:       "Deny eviction with TooManyRequests if pod has a PersistentVolumeClaim volume and only stateful pods are protected",
+			evictedPodName: "stateful-pod",
+			config:         NewConfigBuilder().WithProtectOnlyStateful(true).Build(),
+			mockClient: &mockClient{
+				pod: statefulPodStub("stateful-pod", "node1", "uid1"),
+			},
+			expectedResult:     denyEviction(http.StatusTooManyRequests, metav1.StatusReasonTooManyRequests, RescheduleAnnotationAddedToPodMsg),
+			expectedReasonCode: ReasonAnnotationAdded,
+			expectedMutations:  []string{"ReschedulePod"},
+		},
+		{
+			testname:       "Allow eviction if pod is controlled by a ReplicaSet and StatefulSets are the stateful owner kind",
+			evictedPodName: "replicaset-pod",
+			config:         NewConfigBuilder().WithStatefulOwnerKinds("StatefulSet").Build(),
+			mockClient: &mockClient{
+				pod: controlledPodStub("replicaset-pod", "apps/v1", "ReplicaSet"),
+			},
+			expectedResult:     allowEviction(),
+			expectedReasonCode: ReasonStatelessOwner,
+		},
+		{
+			testname:       "Deny eviction with TooManyRequests if pod is controlled by a StatefulSet and StatefulSets are the stateful owner kind",
+			evictedPodName: "statefulset-pod",
+			config:         NewConfigBuilder().WithStatefulOwnerKinds("StatefulSet").Build(),
+			mockClient: &mockClient{
+				pod: controlledPodStub("statefulset-pod", "apps/v1", "StatefulSet"),
+			},
+			expectedResult:     denyEviction(http.StatusTooManyRequests, metav1.StatusReasonTooManyRequests, RescheduleAnnotationAddedToPodMsg),
+			expectedReasonCode: ReasonAnnotationAdded,
+			expectedMutations:  []string{"ReschedulePod"},
+		},
+		{
+			testname:       "Deny eviction with TooManyRequests if pod has no controller and stateful owner kinds are set",
+			evictedPodName: "bare-pod",
+			config:         NewConfigBuilder().WithStatefulOwnerKinds("StatefulSet").Build(),
+			mockClient: &mockClient{
+				pod: trackedPodStub("bare-pod", "node1", "uid1"),
+			},
+			expectedResult:     denyEviction(http.StatusTooManyRequests, metav1.StatusReasonTooManyRequests, RescheduleAnnotationAddedToPodMsg),
+			expectedReasonCode: ReasonAnnotationAdded,
+			expectedMutations:  []string{"ReschedulePod"},
+		},
+		{
+			testname:       "Deny eviction with TooManyRequests, add reschedule annotation to unlabelled pod when trusting the webhook selector",
+			evictedPodName: "unlabelled-pod",
+			config:         NewConfigBuilder().WithTrustWebhookSelector(true).Build(),
+			mockClient: &mockClient{
+				pod: &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "unlabelled-pod",
+						Namespace: "default",
+					},
+				},
+			},
+			expectedPod: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "unlabelled-pod",
+					Namespace: "default",
+					Annotations: map[string]string{
+						"cao.couchbase.com/reschedule": "true",
+					},
+				},
+			},
+			expectedResult:     denyEviction(http.StatusTooManyRequests, metav1.StatusReasonTooManyRequests, RescheduleAnnotationAddedToPodMsg),
+			expectedReasonCode: ReasonAnnotationAdded,
+			expectedMutations:  []string{"ReschedulePod"},
+		},
+		{
+			testname:       "Allow eviction and remove stale reschedule annotation from unlabelled pod when cleanup is enabled",
+			evictedPodName: "unlabelled-pod",
+			config:         NewConfigBuilder().WithCleanupPodAnnotations(true).Build(),
+			mockClient: &mockClient{
+				pod: &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "unlabelled-pod",
+						Namespace: "default",
+						Annotations: map[string]string{
+							"cao.couchbase.com/reschedule": "true",
+							"other":                        "value",
+						},
+					},
+				},
+			},
+			expectedPod: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "unlabelled-pod",
+					Namespace: "default",
+					Annotations: map[string]string{
+						"other": "value",
+					},
+				},
+			},
+			expectedResult:     allowEviction(),
+			expectedReasonCode: ReasonLabelMismatch,
+			expectedMutations:  []string{"RemovePodAnnotations"},
+		},
+		{
+			testname:       "Deny eviction with TooManyRequests, add multiple reschedule annotations to pod",
+			evictedPodName: "pod2",
+			config:         multipleRescheduleAnnotationsConfig(),
+			mockClient: &mockClient{
+				pod: &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "pod2",
+						Namespace: "default",
+						Labels: map[string]string{
+							"app": "couchbase",
+						},
+						Annotations: map[string]string{
+							"cao.couchbase.com/reschedule": "true",
+						},
+					},
+				},
+			},
+			expectedPod: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "pod2",
+					Namespace: "default",
+					Labels: map[string]string{
+						"app": "couchbase",
+					},
+					Annotations: map[string]string{
+						"cao.couchbase.com/reschedule":        "true",
+						"cao.couchbase.com/reschedule-reason": "eviction",
+					},
+				},
+			},
+			expectedResult:     denyEviction(http.StatusTooManyRequests, metav1.StatusReasonTooManyRequests, RescheduleAnnotationAddedToPodMsg),
+			expectedReasonCode: ReasonAnnotationAdded,
+			expectedMutations:  []string{"ReschedulePod"},
+		},
+		{
+			testname:       "Deny eviction with TooManyRequests if pod has all reschedule annotations",
+			evictedPodName: "rescheduled-pod",
+			config:         multipleRescheduleAnnotationsConfig(),
+			mockClient: &mockClient{
+				pod: &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "rescheduled-pod",
+						Namespace: "default",
+						Labels: map[string]string{
+							"app": "couchbase",
+						},
+						Annotations: map[string]string{
+							"cao.couchbase.com/reschedule":        "true",
+							"cao.couchbase.com/reschedule-reason": "eviction",
+						},
+					},
+				},
+			},
+			expectedResult:     denyEviction(http.StatusTooManyRequests, metav1.StatusReasonTooManyRequests, PodWaitingForRescheduleMsg),
+			expectedReasonCode: ReasonAlreadyMarked,
+		},
+		{
+			testname:       "Allow eviction of pod approved by the operator and remove hook annotations",
+			evictedPodName: "approved-pod",
+			mockClient: &mockClient{
+				pod: &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "approved-pod",
+						Namespace: "default",
+						Labels: map[string]string{
+							"app": "couchbase",
+						},
+						Annotations: map[string]string{
+							"cao.couchbase.com/reschedule": "true",
+							DefaultAttemptsAnnotation:      "3",
+							DefaultApprovalAnnotation:      "true",
+						},
+					},
+				},
+			},
+			expectedPod: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "approved-pod",
+					Namespace: "default",
+					Labels: map[string]string{
+						"app": "couchbase",
+					},
+					Annotations: map[string]string{
+						DefaultApprovalAnnotation: "true",
+					},
+				},
+			},
+			expectedResult:     allowEviction(),
+			expectedReasonCode: ReasonApproved,
+			expectedMutations:  []string{"RemovePodAnnotations"},
+		},
+		{
+			testname:       "Allow eviction of pod approved with a configured annotation key",
+			evictedPodName: "approved-pod",
+			config:         NewConfigBuilder().WithApprovalAnnotation("cao.couchbase.com/approved").Build(),
+			mockClient: &mockClient{
+				pod: &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "approved-pod",
+						Namespace: "default",
+						Labels: map[string]string{
+							"app": "couchbase",
+						},
+						Annotations: map[string]string{
+							"cao.couchbase.com/approved": "true",
+						},
+					},
+				},
+			},
+			expectedResult:     allowEviction(),
+			expectedReasonCode: ReasonApproved,
+		},
+		{
+			testname:       "Allow eviction of pod marked as done by the operator and remove hook and tracking annotations",
+			evictedPodName: "done-pod",
+			config:         NewConfigBuilder().WithRescheduleDoneAnnotation("cao.couchbase.com/reschedule-done").Build(),
+			mockClient: &mockClient{
+				pod: &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "done-pod",
+						Namespace: "default",
+						Labels: map[string]string{
+							"app": "couchbase",
+						},
+						Annotations: map[string]string{
+							"cao.couchbase.com/reschedule":      "true",
+							"cao.couchbase.com/reschedule-done": "",
+						},
+					},
+				},
+				trackingResourceAnnotations: map[string]string{
+					TrackingResourceAnnotation("done-pod", "default"): "true",
+					TrackingResourceAnnotation("pod2", "default"):     "true",
+				},
+				shouldTrackRescheduledPods: true,
+			},
+			expectedPod: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "done-pod",
+					Namespace: "default",
+					Labels: map[string]string{
+						"app": "couchbase",
+					},
+					Annotations: map[string]string{
+						"cao.couchbase.com/reschedule-done": "",
+					},
+				},
+			},
+			expectedTrackingResourceAnnotations: map[string]string{
+				TrackingResourceAnnotation("pod2", "default"): "true",
+			},
+			expectedResult:     allowEviction(),
+			expectedReasonCode: ReasonRescheduleDone,
+			expectedMutations:  []string{"RemovePodAnnotations", "RemoveRescheduleHookTrackingAnnotation"},
+		},
+		{
+			testname:       "Deny eviction with TooManyRequests, add reschedule annotation to pod not marked as done",
+			evictedPodName: "pending-pod",
+			config:         NewConfigBuilder().WithRescheduleDoneAnnotation("cao.couchbase.com/reschedule-done").Build(),
+			mockClient: &mockClient{
+				pod: &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "pending-pod",
+						Namespace: "default",
+						Labels: map[string]string{
+							"app": "couchbase",
+						},
+					},
+				},
+			},
+			expectedPod: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "pending-pod",
+					Namespace: "default",
+					Labels: map[string]string{
+						"app": "couchbase",
+					},
+					Annotations: map[string]string{
+						"cao.couchbase.com/reschedule": "true",
+					},
+				},
+			},
+			expectedResult:     denyEviction(http.StatusTooManyRequests, metav1.StatusReasonTooManyRequests, RescheduleAnnotationAddedToPodMsg),
+			expectedReasonCode: ReasonAnnotationAdded,
+			expectedMutations:  []string{"ReschedulePod"},
+		},
+		{
+			testname:       "Deny eviction with TooManyRequests for pod with a done annotation when it is not configured",
+			evictedPodName: "done-pod",
+			mockClient: &mockClient{
+				pod: &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "done-pod",
+						Namespace: "default",
+						Labels: map[string]string{
+							"app": "couchbase",
+						},
+						Annotations: map[string]string{
+							"cao.couchbase.com/reschedule":      "true",
+							"cao.couchbase.com/reschedule-done": "",
+						},
+					},
+				},
+			},
+			expectedResult:     denyEviction(http.StatusTooManyRequests, metav1.StatusReasonTooManyRequests, PodWaitingForRescheduleMsg),
+			expectedReasonCode: ReasonAlreadyMarked,
+		},
+		{
+			testname:       "Deny eviction with TooManyRequests, add reschedule annotation to pod not approved by the operator",
+			evictedPodName: "unapproved-pod",
+			mockClient: &mockClient{
+				pod: &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "unapproved-pod",
+						Namespace: "default",
+						Labels: map[string]string{
+							"app": "couchbase",
+						},
+						Annotations: map[string]string{
+							DefaultApprovalAnnotation: "false",
+						},
+					},
+				},
+			},
+			expectedPod: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "unapproved-pod",
+					Namespace: "default",
+					Labels: map[string]string{
+						"app": "couchbase",
+					},
+					Annotations: map[string]string{
+						"cao.couchbase.com/reschedule": "true",
+						DefaultApprovalAnnotation:      "false",
+					},
+				},
+			},
+			expectedResult:     denyEviction(http.StatusTooManyRequests, metav1.StatusReasonTooManyRequests, RescheduleAnnotationAddedToPodMsg),
+			expectedReasonCode: ReasonAnnotationAdded,
+			expectedMutations:  []string{"ReschedulePod"},
+		},
+		{
+			testname:       "Deny eviction with TooManyRequests, add reschedule annotation to pod",
+			evictedPodName: "pod2",
+			mockClient: &mockClient{
+				pod: &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "pod2",
+						Namespace: "default",
+						Labels: map[string]string{
+							"app": "couchbase",
+						},
+					},
+				},
+			},
+			expectedPod: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "pod2",
+					Namespace: "default",
+					Labels: map[string]string{
+						"app": "couchbase",
+					},
+					Annotations: map[string]string{
+						"cao.couchbase.com/reschedule": "true",
+					},
+				},
+			},
+			expectedResult:     denyEviction(http.StatusTooManyRequests, metav1.StatusReasonTooManyRequests, RescheduleAnnotationAddedToPodMsg),
+			expectedReasonCode: ReasonAnnotationAdded,
+			expectedMutations:  []string{"ReschedulePod"},
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testname, func(t *testing.T) {
+			config := testcase.config
+			if config == nil {
+				config = NewConfigBuilder().FromEnvironment().Build()
+			}
+			testcase.mockClient.config = config
+
+			eviction := policyv1.Eviction{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      testcase.evictedPodName,
+					Namespace: "default",
+				},
+			}
+
+			client := newRecordingClient(testcase.mockClient)
+			decision := decideEviction(context.Background(), eviction, client, config, CreateLogger(eviction.Name, eviction.Namespace, false))
+
+			if !reflect.DeepEqual(decision.Response, testcase.expectedResult) {
+				t.Errorf("Expected response to be %v, got %v", testcase.expectedResult, decision.Response)
+			}
+
+			if decision.Reason != testcase.expectedReasonCode {
+				t.Errorf("Expected reason code to be %s, got %s", testcase.expectedReasonCode, decision.Reason)
+			}
+
+			if testcase.expectedPod != nil {
+				pod, err := testcase.mockClient.GetPod(testcase.evictedPodName, "default")
+				if err != nil {
+					t.Errorf("Failed to get pod: %v", err)
+				}
+
+				if !reflect.DeepEqual(pod, testcase.expectedPod) {
+					t.Errorf("Expected pod to be %v, got %v", testcase.expectedPod, pod)
+				}
+			}
+
+			if !reflect.DeepEqual(testcase.mockClient.trackingResourceAnnotations, testcase.expectedTrackingResourceAnnotations) {
+				t.Errorf("Expected tracking resource annotations to be %v, got %v", testcase.expectedTrackingResourceAnnotations, testcase.mockClient.trackingResourceAnnotations)
+			}
+
+			// Every API write is asserted, so that paths which should not modify anything, and accidental double patches,
+			// are caught
+			if mutations := client.mutations(); !slices.Equal(mutations, testcase.expectedMutations) {
+				t.Errorf("Expected API writes %v, got %v", testcase.expectedMutations, mutations)
+			}
+
+			for _, call := range client.callsTo("ReschedulePod") {
+				if call.args[0] != "default/"+testcase.evictedPodName {
+					t.Errorf("Expected ReschedulePod to be called for the evicted pod, got %v", call.args)
+				}
+			}
+		})
+	}
+}
+
+func TestHandleEvictionTrackAttempts(t *testing.T) {
+	client := &mockClient{
+		pod:    trackedPodStub("pod1", "node1", "uid1"),
+		config: NewConfigBuilder().FromEnvironment().WithTrackAttempts(true).Build(),
+	}
+
+	eviction := policyv1.Eviction{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "pod1",
+			Namespace: "default",
+		},
+	}
+
+	expectedResults := []*admissionv1.AdmissionResponse{
+		denyEviction(http.StatusTooManyRequests, metav1.StatusReasonTooManyRequests, RescheduleAnnotationAddedToPodMsg),
+		denyEviction(http.StatusTooManyRequests, metav1.StatusReasonTooManyRequests, PodWaitingForRescheduleMsg),
+		denyEviction(http.StatusTooManyRequests, metav1.StatusReasonTooManyRequests, PodWaitingForRescheduleMsg),
+	}
+
+	for i, expectedResult := range expectedResults {
+		result := handleEviction(context.Background(), eviction, client, client.config, CreateLogger(eviction.Name, eviction.Namespace, false))
+		if !reflect.DeepEqual(result, expectedResult) {
+			t.Fatalf("Attempt %d: expected response to be %v, got %v", i+1, expectedResult, result)
+		}
+
+		if attempts := client.pod.Annotations[DefaultAttemptsAnnotation]; attempts != strconv.Itoa(i+1) {
+			t.Fatalf("Attempt %d: expected attempts annotation to be %d, got %q", i+1, i+1, attempts)
+		}
+	}
+}
+
+func TestHandleEvictionLabelMarker(t *testing.T) {
+	client := &mockClient{
+		pod:    trackedPodStub("pod1", "node1", "uid1"),
+		config: NewConfigBuilder().FromEnvironment().WithRescheduleMarker(RescheduleMarkerLabel).Build(),
+	}
+
+	eviction := policyv1.Eviction{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "pod1",
+			Namespace: "default",
+		},
+	}
+
+	expectedResults := []*admissionv1.AdmissionResponse{
+		denyEviction(http.StatusTooManyRequests, metav1.StatusReasonTooManyRequests, RescheduleAnnotationAddedToPodMsg),
+		denyEviction(http.StatusTooManyRequests, metav1.StatusReasonTooManyRequests, PodWaitingForRescheduleMsg),
+	}
+
+	for i, expectedResult := range expectedResults {
+		result := handleEviction(context.Background(), eviction, client, client.config, CreateLogger(eviction.Name, eviction.Namespace, false))
+		if !reflect.DeepEqual(result, expectedResult) {
+			t.Fatalf("Attempt %d: expected response to be %v, got %v", i+1, expectedResult, result)
+		}
+
+		if value := client.pod.Labels[DefaultRescheduleAnnotationKey]; value != DefaultRescheduleAnnotationValue {
+			t.Fatalf("Attempt %d: expected reschedule label to be %q, got %q", i+1, DefaultRescheduleAnnotationValue, value)
+		}
+
+		if _, exists := client.pod.Annotations[DefaultRescheduleAnnotationKey]; exists {
+			t.Fatalf("Attempt %d: expected no reschedule annotation, got %v", i+1, client.pod.Annotations)
+		}
+	}
+}
+
+func TestHandleEvictionActiveWindows(t *testing.T) {
+	windows := "Mon-Fri 22:00-06:00"
+	inWindow := time.Date(2025, 6, 4, 23, 0, 0, 0, time.UTC)
+	outOfWindow := time.Date(2025, 6, 4, 12, 0, 0, 0, time.UTC)
+
+	testcases := []struct {
+		testname           string
+		now                time.Time
+		annotate           bool
+		expectedResult     *admissionv1.AdmissionResponse
+		expectedAnnotation bool
+	}{
+		{
+			testname:           "In window",
+			now:                inWindow,
+			expectedResult:     denyEviction(http.StatusTooManyRequests, metav1.StatusReasonTooManyRequests, RescheduleAnnotationAddedToPodMsg),
+			expectedAnnotation: true,
+		},
+		{
+			testname:       "Out of window",
+			now:            outOfWindow,
+			expectedResult: allowEviction(),
+		},
+		{
+			testname:           "Out of window with annotation",
+			now:                outOfWindow,
+			annotate:           true,
+			expectedResult:     allowEviction(),
+			expectedAnnotation: true,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testname, func(t *testing.T) {
+			client := &mockClient{
+				pod: trackedPodStub("pod1", "node1", "uid1"),
+				config: NewConfigBuilder().FromEnvironment().
+					WithActiveWindows(windows, time.UTC).
+					WithAnnotateOutsideActiveWindows(testcase.annotate).
+					WithClock(newFakeClock(testcase.now)).
+					Build(),
+			}
+
+			eviction := policyv1.Eviction{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "pod1",
+					Namespace: "default",
+				},
+			}
+
+			result := handleEviction(context.Background(), eviction, client, client.config, CreateLogger(eviction.Name, eviction.Namespace, false))
+			if !reflect.DeepEqual(result, testcase.expectedResult) {
+				t.Fatalf("Expected response to be %v, got %v", testcase.expectedResult, result)
+			}
+
+			if marked := isMarkedForReschedule(client.pod, client.config); marked != testcase.expectedAnnotation {
+				t.Fatalf("Expected pod marked for reschedule=%t, got %t", testcase.expectedAnnotation, marked)
+			}
+		})
+	}
+}
+
+func TestHandleEvictionClock(t *testing.T) {
+	clock := newFakeClock(time.Date(2025, 6, 4, 21, 59, 0, 0, time.UTC))
+	client := &mockClient{
+		pod:    trackedPodStub("pod1", "node1", "uid1"),
+		config: NewConfigBuilder().FromEnvironment().WithActiveWindows("22:00-06:00", time.UTC).WithClock(clock).Build(),
+	}
+
+	eviction := policyv1.Eviction{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "pod1",
+			Namespace: "default",
+		},
+	}
+
+	if result := handleEviction(context.Background(), eviction, client, client.config, CreateLogger(eviction.Name, eviction.Namespace, false)); !reflect.DeepEqual(result, allowEviction()) {
+		t.Fatalf("Expected eviction to be allowed before the active window, got %v", result)
+	}
+
+	clock.Advance(time.Minute)
+
+	expected := denyEviction(http.StatusTooManyRequests, metav1.StatusReasonTooManyRequests, RescheduleAnnotationAddedToPodMsg)
+	if result := handleEviction(context.Background(), eviction, client, client.config, CreateLogger(eviction.Name, eviction.Namespace, false)); !reflect.DeepEqual(result, expected) {
+		t.Fatalf("Expected response to be %v once the active window starts, got %v", expected, result)
+	}
+}
+
+func TestWaitAfterReschedule(t *testing.T) {
+	testcases := []struct {
+		testname   string
+		wait       time.Duration
+		deadline   time.Duration
+		minElapsed time.Duration
+		maxElapsed time.Duration
+	}{
+		{
+			testname:   "Disabled",
+			maxElapsed: 50 * time.Millisecond,
+		},
+		{
+			testname:   "Full wait",
+			wait:       100 * time.Millisecond,
+			minElapsed: 100 * time.Millisecond,
+			maxElapsed: time.Second,
+		},
+		{
+			testname:   "Ends at deadline",
+			wait:       10 * time.Second,
+			deadline:   100 * time.Millisecond,
+			minElapsed: 100 * time.Millisecond,
+			maxElapsed: time.Second,
+		},
+		{
+			testname:   "Deadline after wait",
+			wait:       100 * time.Millisecond,
+			deadline:   10 * time.Second,
+			minElapsed: 100 * time.Millisecond,
+			maxElapsed: time.Second,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testname, func(t *testing.T) {
+			ctx := context.Background()
+			if testcase.deadline > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, testcase.deadline)
+				defer cancel()
+			}
+
+			start := time.Now()
+			waitAfterReschedule(ctx, testcase.wait, slog.Default())
+			elapsed := time.Since(start)
+
+			if elapsed < testcase.minElapsed || elapsed > testcase.maxElapsed {
+				t.Fatalf("Expected wait of between %s and %s, got %s", testcase.minElapsed, testcase.maxElapsed, elapsed)
+			}
+		})
+	}
+}
+
+func TestRequestTimeout(t *testing.T) {
+	testcases := []struct {
+		testname        string
+		url             string
+		configured      time.Duration
+		expectedTimeout time.Duration
+	}{
+		{
+			testname: "No timeout",
+			url:      "/eviction",
+		},
+		{
+			testname:        "Configured timeout only",
+			url:             "/eviction",
+			configured:      5 * time.Second,
+			expectedTimeout: 5 * time.Second,
+		},
+		{
+			testname:        "API server timeout is bounded below by the margin",
+			url:             "/eviction?timeout=10s",
+			expectedTimeout: 9 * time.Second,
+		},
+		{
+			testname:        "Configured timeout shorter than the API server timeout",
+			url:             "/eviction?timeout=10s",
+			configured:      5 * time.Second,
+			expectedTimeout: 5 * time.Second,
+		},
+		{
+			testname:        "Configured timeout longer than the API server timeout",
+			url:             "/eviction?timeout=10s",
+			configured:      30 * time.Second,
+			expectedTimeout: 9 * time.Second,
+		},
+		{
+			testname:        "Invalid API server timeout",
+			url:             "/eviction?timeout=ten",
+			configured:      5 * time.Second,
+			expectedTimeout: 5 * time.Second,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testname, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodPost, testcase.url, nil)
+
+			if timeout := requestTimeout(request, testcase.configured); timeout != testcase.expectedTimeout {
+				t.Fatalf("Expected timeout %s, got %s", testcase.expectedTimeout, timeout)
+			}
+		})
+	}
+}
+
+func TestHandleEvictionPostRescheduleWait(t *testing.T) {
+	client := &mockClient{
+		pod:    trackedPodStub("pod1", "node1", "uid1"),
+		config: NewConfigBuilder().FromEnvironment().WithPostRescheduleWait(10 * time.Second).Build(),
+	}
+
+	eviction := policyv1.Eviction{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "pod1",
+			Namespace: "default",
+		},
+	}
+
+	// The admission request being cancelled ends the wait, and the pod is still marked for rescheduling
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	result := handleEviction(ctx, eviction, client, client.config, CreateLogger(eviction.Name, eviction.Namespace, false))
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("Expected wait to end at the request deadline, took %s", elapsed)
+	}
+
+	expected := denyEviction(http.StatusTooManyRequests, metav1.StatusReasonTooManyRequests, RescheduleAnnotationAddedToPodMsg)
+	if !reflect.DeepEqual(result, expected) {
+		t.Fatalf("Expected response to be %v, got %v", expected, result)
+	}
+
+	if !isMarkedForReschedule(client.pod, client.config) {
+		t.Fatalf("Expected pod to be marked for rescheduling")
+	}
+
+	// Only marking the pod waits, so a retry of the eviction is answered immediately
+	start = time.Now()
+	handleEviction(context.Background(), eviction, client, client.config, CreateLogger(eviction.Name, eviction.Namespace, false))
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("Expected a pod already marked for rescheduling not to wait, took %s", elapsed)
+	}
+}
+
+func TestHandleEvictionSharedNamespace(t *testing.T) {
+	appPod := func(app string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "db-0",
+				Namespace: "default",
+				Labels: map[string]string{
+					"app":                    "couchbase",
+					"app.kubernetes.io/name": app,
+				},
+			},
+		}
+	}
+
+	testcases := []struct {
+		testname           string
+		config             *Config
+		expectedResult     *admissionv1.AdmissionResponse
+		expectedReasonCode ReasonCode
+	}{
+		{
+			testname:           "Unscoped tracking mistakes another app's pod with the same name for the rescheduled pod",
+			config:             NewConfigBuilder().WithTrackingResource(tracking.ResourceTypeNamespace).Build(),
+			expectedResult:     denyEviction(http.StatusNotFound, metav1.StatusReasonNotFound, PodRescheduledWithSameNameMsg+" (0 tracked pods remaining)"),
+			expectedReasonCode: ReasonSameNameRescheduled,
+		},
+		{
+			testname:           "Scoped tracking keeps each app's pods separate",
+			config:             NewConfigBuilder().WithTrackingResource(tracking.ResourceTypeNamespace).WithNamespaceTracking("", "app.kubernetes.io/name").Build(),
+			expectedResult:     denyEviction(http.StatusTooManyRequests, metav1.StatusReasonTooManyRequests, RescheduleAnnotationAddedToPodMsg),
+			expectedReasonCode: ReasonAnnotationAdded,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testname, func(t *testing.T) {
+			// The orders app's db-0 has been tracked in the namespace, and the payments app's db-0 is now being evicted
+			ordersKey := trackingAnnotationKey(appPod("orders"), testcase.config)
+			client := &mockClient{
+				config:                      testcase.config,
+				pod:                         appPod("payments"),
+				trackingResourceAnnotations: map[string]string{ordersKey: "true"},
+				shouldTrackRescheduledPods:  true,
+				shouldAddTrackingAnnotation: true,
+			}
+
+			eviction := policyv1.Eviction{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "db-0",
+					Namespace: "default",
+				},
+			}
+
+			decision := decideEviction(context.Background(), eviction, client, testcase.config, CreateLogger(eviction.Name, eviction.Namespace, false))
+			if !reflect.DeepEqual(decision.Response, testcase.expectedResult) {
+				t.Fatalf("Expected response to be %v, got %v", testcase.expectedResult, decision.Response)
+			}
+
+			if decision.Reason != testcase.expectedReasonCode {
+				t.Fatalf("Expected reason code to be %s, got %s", testcase.expectedReasonCode, decision.Reason)
+			}
+
+			if testcase.expectedReasonCode == ReasonAnnotationAdded {
+				paymentsKey := ScopedTrackingResourceAnnotation("db-0", "default", "payments")
+				if _, tracked := client.trackingResourceAnnotations[ordersKey]; !tracked {
+					t.Fatalf("Expected the orders pod to still be tracked, got %v", client.trackingResourceAnnotations)
+				}
+
+				if _, tracked := client.trackingResourceAnnotations[paymentsKey]; !tracked {
+					t.Fatalf("Expected the payments pod to be tracked under %s, got %v", paymentsKey, client.trackingResourceAnnotations)
+				}
+			}
+		})
+	}
+}
+
+// handleEvictions handles a batch of evictions with the client's config, like a drain evicting several pods, returning the
+// responses for each pod in the order they were handled. If concurrent is set, every eviction is handled at once, as a drain
+// does, so that running the tests with -race surfaces data races in state shared between evictions.
+func handleEvictions(evictions []policyv1.Eviction, client Client, concurrent bool) map[string][]*admissionv1.AdmissionResponse {
+	var mu sync.Mutex
+	responses := map[string][]*admissionv1.AdmissionResponse{}
+	handle := func(eviction policyv1.Eviction) {
+		response := handleEviction(context.Background(), eviction, client, client.GetConfig(), CreateLogger(eviction.Name, eviction.Namespace, false))
+
+		mu.Lock()
+		defer mu.Unlock()
+		key := eviction.Namespace + "/" + eviction.Name
+		responses[key] = append(responses[key], response)
+	}
+
+	var wg sync.WaitGroup
+	for _, eviction := range evictions {
+		if !concurrent {
+			handle(eviction)
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			handle(eviction)
+		}()
+	}
+
+	wg.Wait()
+	return responses
+}
+
+// newBatchClient returns a client backed by a fake dynamic client holding the tracking resource instance and the given pods
+func newBatchClient(t *testing.T, trackingResourceInstance *unstructured.Unstructured, pods ...*corev1.Pod) (*ClientImpl, *fake.FakeDynamicClient) {
+	objects := []runtime.Object{trackingResourceInstance}
+	for _, pod := range pods {
+		pod.TypeMeta = metav1.TypeMeta{Kind: "Pod", APIVersion: "v1"}
+		object, err := runtime.DefaultUnstructuredConverter.ToUnstructured(pod)
+		if err != nil {
+			t.Fatalf("Failed to convert pod to unstructured: %v", err)
+		}
+		objects = append(objects, &unstructured.Unstructured{Object: object})
+	}
+
+	dynamicClient := fake.NewSimpleDynamicClient(runtime.NewScheme(), objects...)
+	return &ClientImpl{dynamicClient: dynamicClient, config: NewConfigBuilder().Build()}, dynamicClient
+}
+
+func TestHandleEvictionsBatch(t *testing.T) {
+	testcases := []struct {
+		testname   string
+		concurrent bool
+		// evictionsPerPod is how many times each pod is evicted in the batch, as a drain retrying an eviction does
+		evictionsPerPod int
+	}{
+		{
+			testname:        "Sequential",
+			evictionsPerPod: 1,
+		},
+		{
+			testname:        "Concurrent",
+			concurrent:      true,
+			evictionsPerPod: 1,
+		},
+		{
+			testname:        "Concurrent with repeated evictions of each pod",
+			concurrent:      true,
+			evictionsPerPod: 3,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testname, func(t *testing.T) {
+			var pods []*corev1.Pod
+			var evictions []policyv1.Eviction
+			for i := range 10 {
+				pod := clusterPodStub(fmt.Sprintf("cluster1-%04d", i), "cluster1")
+				pods = append(pods, pod)
+				for range testcase.evictionsPerPod {
+					evictions = append(evictions, policyv1.Eviction{ObjectMeta: metav1.ObjectMeta{Name: pod.Name, Namespace: pod.Namespace}})
+				}
+			}
+
+			client, dynamicClient := newBatchClient(t, couchbaseClusterStub("cluster1", "default", true, nil), pods...)
+			responses := handleEvictions(evictions, client, testcase.concurrent)
+
+			for _, pod := range pods {
+				podResponses := responses[podKey(pod)]
+				if len(podResponses) != testcase.evictionsPerPod {
+					t.Fatalf("Expected %d responses for pod %s, got %d", testcase.evictionsPerPod, pod.Name, len(podResponses))
+				}
+
+				// A pod is only tracked once it has been marked, so an eviction that finds it tracked also finds it marked rather
+				// than reporting it as rescheduled with the same name
+				for _, response := range podResponses {
+					if response.Allowed || response.Result.Code != http.StatusTooManyRequests ||
+						(response.Result.Message != RescheduleAnnotationAddedToPodMsg && response.Result.Message != PodWaitingForRescheduleMsg) {
+						t.Fatalf("Expected the eviction of pod %s to mark it or wait for it to be rescheduled, got %v", pod.Name, response)
+					}
+				}
+
+				// The fake client does not check resource versions, so concurrent evictions of a pod can each be the one to mark it
+				if !slices.ContainsFunc(podResponses, func(response *admissionv1.AdmissionResponse) bool {
+					return response.Result.Message == RescheduleAnnotationAddedToPodMsg
+				}) {
+					t.Fatalf("Expected an eviction of pod %s to mark it for rescheduling, got %v", pod.Name, podResponses)
+				}
+
+				current, err := client.GetPod(pod.Name, pod.Namespace)
+				if err != nil {
+					t.Fatalf("Failed to get pod %s: %v", pod.Name, err)
+				}
+
+				if current.Annotations[DefaultRescheduleAnnotationKey] != DefaultRescheduleAnnotationValue {
+					t.Fatalf("Expected pod %s to have the reschedule annotation, got %v", pod.Name, current.Annotations)
+				}
+			}
+
+			cluster, err := dynamicClient.Resource(client.config.trackingResource.GetGroupVersionResource()).Namespace("default").Get(context.Background(), "cluster1", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("Failed to get tracking resource instance: %v", err)
+			}
+
+			annotations := cluster.GetAnnotations()
+			if count := countTrackingAnnotations(annotations); count != len(pods) {
+				t.Fatalf("Expected %d tracking annotations, got %d: %v", len(pods), count, annotations)
+			}
+
+			for _, pod := range pods {
+				if _, tracked := annotations[TrackingResourceAnnotation(pod.Name, pod.Namespace)]; !tracked {
+					t.Fatalf("Expected pod %s to be tracked, got %v", pod.Name, annotations)
+				}
+			}
+		})
+	}
+}
+
+func TestHandleEvictionRescheduleFailure(t *testing.T) {
+	testcases := []struct {
+		testname        string
+		err             error
+		expectedMessage string
+	}{
+		{
+			testname:        "Conflict",
+			err:             k8serrors.NewConflict(schema.GroupResource{Resource: "pods"}, "cluster1-0000", fmt.Errorf("the object has been modified")),
+			expectedMessage: PodChangedDuringRescheduleMsg,
+		},
+		{
+			testname:        "Internal error",
+			err:             k8serrors.NewInternalError(fmt.Errorf("etcd unavailable")),
+			expectedMessage: FailedToAddRescheduleAnnotationMsg,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testname, func(t *testing.T) {
+			pod := clusterPodStub("cluster1-0000", "cluster1")
+			client, dynamicClient := newBatchClient(t, couchbaseClusterStub("cluster1", "default", true, nil), pod)
+
+			// Only the first patch of the pod fails, as when a kubelet status update changes its resource version
+			failed := false
+			dynamicClient.PrependReactor("patch", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				if failed {
+					return false, nil, nil
 				}
+				failed = true
+				return true, nil, testcase.err
+			})
+
+			eviction := policyv1.Eviction{ObjectMeta: metav1.ObjectMeta{Name: pod.Name, Namespace: pod.Namespace}}
+			result := handleEviction(context.Background(), eviction, client, client.config, CreateLogger(eviction.Name, eviction.Namespace, false))
+			if result.Allowed || result.Result.Message != testcase.expectedMessage {
+				t.Fatalf("Expected eviction to be denied with %q, got %v", testcase.expectedMessage, result)
+			}
+
+			cluster, err := client.GetTrackingResourceInstance("cluster1", "default")
+			if err != nil {
+				t.Fatalf("Failed to get tracking resource instance: %v", err)
+			}
+
+			if _, tracked := cluster.GetAnnotations()[TrackingResourceAnnotation(pod.Name, pod.Namespace)]; tracked {
+				t.Fatalf("Expected pod not to be tracked when it could not be marked, got %v", cluster.GetAnnotations())
+			}
+
+			// The retry marks the pod rather than finding it tracked and reporting it as rescheduled with the same name
+			expected := denyEviction(http.StatusTooManyRequests, metav1.StatusReasonTooManyRequests, RescheduleAnnotationAddedToPodMsg)
+			if result := handleEviction(context.Background(), eviction, client, client.config, CreateLogger(eviction.Name, eviction.Namespace, false)); !reflect.DeepEqual(result, expected) {
+				t.Fatalf("Expected retried eviction to be %v, got %v", expected, result)
+			}
+
+			current, err := client.GetPod(pod.Name, pod.Namespace)
+			if err != nil {
+				t.Fatalf("Failed to get pod: %v", err)
+			}
+
+			if current.Annotations[DefaultRescheduleAnnotationKey] != DefaultRescheduleAnnotationValue {
+				t.Fatalf("Expected pod to have the reschedule annotation, got %v", current.Annotations)
+			}
+
+			cluster, err = client.GetTrackingResourceInstance("cluster1", "default")
+			if err != nil {
+				t.Fatalf("Failed to get tracking resource instance: %v", err)
+			}
+
+			if _, tracked := cluster.GetAnnotations()[TrackingResourceAnnotation(pod.Name, pod.Namespace)]; !tracked {
+				t.Fatalf("Expected pod to be tracked once it was marked, got %v", cluster.GetAnnotations())
+			}
+		})
+	}
+}
+
+func TestHandleEvictionDisableTrackingAnnotation(t *testing.T) {
+	testcases := []struct {
+		testname      string
+		annotations   map[string]interface{}
+		expectTracked bool
+	}{
+		{
+			testname:      "Disable tracking annotation unset",
+			expectTracked: true,
+		},
+		{
+			testname:    "Disable tracking annotation set",
+			annotations: map[string]interface{}{DefaultDisableTrackingAnnotation: "true"},
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testname, func(t *testing.T) {
+			pod := clusterPodStub("cluster1-0000", "cluster1")
+			client, dynamicClient := newBatchClient(t, couchbaseClusterStub("cluster1", "default", true, testcase.annotations), pod)
+
+			eviction := policyv1.Eviction{ObjectMeta: metav1.ObjectMeta{Name: pod.Name, Namespace: pod.Namespace}}
+			expected := denyEviction(http.StatusTooManyRequests, metav1.StatusReasonTooManyRequests, RescheduleAnnotationAddedToPodMsg)
+			if result := handleEviction(context.Background(), eviction, client, client.config, CreateLogger(eviction.Name, eviction.Namespace, false)); !reflect.DeepEqual(result, expected) {
+				t.Fatalf("Expected response to be %v, got %v", expected, result)
+			}
+
+			// The pod is marked for rescheduling whether or not it is tracked
+			current, err := client.GetPod(pod.Name, pod.Namespace)
+			if err != nil {
+				t.Fatalf("Failed to get pod: %v", err)
+			}
+
+			if current.Annotations[DefaultRescheduleAnnotationKey] != DefaultRescheduleAnnotationValue {
+				t.Fatalf("Expected pod to have the reschedule annotation, got %v", current.Annotations)
+			}
+
+			cluster, err := dynamicClient.Resource(client.config.trackingResource.GetGroupVersionResource()).Namespace("default").Get(context.Background(), "cluster1", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("Failed to get tracking resource instance: %v", err)
+			}
+
+			if _, tracked := cluster.GetAnnotations()[TrackingResourceAnnotation(pod.Name, pod.Namespace)]; tracked != testcase.expectTracked {
+				t.Fatalf("Expected pod tracked to be %t, got annotations %v", testcase.expectTracked, cluster.GetAnnotations())
+			}
+		})
+	}
+}
+
+func TestHandleEvictionPendingPeers(t *testing.T) {
+	marked := func(name, clusterName string) *corev1.Pod {
+		pod := clusterPodStub(name, clusterName)
+		pod.Annotations = map[string]string{DefaultRescheduleAnnotationKey: "true"}
+		return pod
+	}
+
+	testcases := []struct {
+		testname        string
+		config          *Config
+		listPodsFailure bool
+		expectedMessage string
+	}{
+		{
+			testname:        "Pending peers not reported",
+			config:          NewConfigBuilder().Build(),
+			expectedMessage: PodWaitingForRescheduleMsg,
+		},
+		{
+			testname:        "Pending peers reported",
+			config:          NewConfigBuilder().WithReportPendingPeers(true).Build(),
+			expectedMessage: PodWaitingForRescheduleMsg + " (2 peers pending)",
+		},
+		{
+			testname:        "Peers cannot be listed",
+			config:          NewConfigBuilder().WithReportPendingPeers(true).Build(),
+			listPodsFailure: true,
+			expectedMessage: PodWaitingForRescheduleMsg,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testname, func(t *testing.T) {
+			// Only marked pods in the same cluster are pending, and the evicted pod is not counted as its own peer
+			client := &mockClient{
+				config: testcase.config,
+				pod:    marked("pod1", "cluster1"),
+				clusterPods: []*corev1.Pod{
+					marked("pod1", "cluster1"),
+					marked("pod2", "cluster1"),
+					marked("pod3", "cluster1"),
+					clusterPodStub("pod4", "cluster1"),
+					marked("pod5", "cluster2"),
+				},
+				listPodsFailure: testcase.listPodsFailure,
+			}
+
+			eviction := policyv1.Eviction{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "pod1",
+					Namespace: "default",
+				},
+			}
+
+			result := handleEviction(context.Background(), eviction, client, testcase.config, CreateLogger(eviction.Name, eviction.Namespace, false))
+
+			expected := denyEviction(http.StatusTooManyRequests, metav1.StatusReasonTooManyRequests, testcase.expectedMessage)
+			if !reflect.DeepEqual(result, expected) {
+				t.Fatalf("Expected response to be %v, got %v", expected, result)
+			}
+		})
+	}
+}
+
+func TestHandleEvictionFirstAnnotationDelay(t *testing.T) {
+	clock := newFakeClock(time.Now())
+	client := &mockClient{
+		pod:    trackedPodStub("pod1", "node1", "uid1"),
+		config: NewConfigBuilder().FromEnvironment().WithFirstAnnotationDelay(30 * time.Second).WithClock(clock).Build(),
+	}
+
+	eviction := policyv1.Eviction{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "pod1",
+			Namespace: "default",
+		},
+	}
+
+	delayed := denyEviction(http.StatusTooManyRequests, metav1.StatusReasonTooManyRequests, PodWaitingForAnnotationDelayMsg)
+	annotated := denyEviction(http.StatusTooManyRequests, metav1.StatusReasonTooManyRequests, RescheduleAnnotationAddedToPodMsg)
+	waiting := denyEviction(http.StatusTooManyRequests, metav1.StatusReasonTooManyRequests, PodWaitingForRescheduleMsg)
+
+	steps := []struct {
+		elapsed        time.Duration
+		expectedResult *admissionv1.AdmissionResponse
+		expectedMarked bool
+	}{
+		{elapsed: 0, expectedResult: delayed},
+		{elapsed: 29 * time.Second, expectedResult: delayed},
+		{elapsed: time.Second, expectedResult: annotated, expectedMarked: true},
+		{elapsed: 5 * time.Second, expectedResult: waiting, expectedMarked: true},
+	}
+
+	for i, step := range steps {
+		clock.Advance(step.elapsed)
+
+		result := handleEviction(context.Background(), eviction, client, client.config, CreateLogger(eviction.Name, eviction.Namespace, false))
+		if !reflect.DeepEqual(result, step.expectedResult) {
+			t.Fatalf("Step %d: expected response to be %v, got %v", i, step.expectedResult, result)
+		}
+
+		if marked := isMarkedForReschedule(client.pod, client.config); marked != step.expectedMarked {
+			t.Fatalf("Step %d: expected pod marked for reschedule=%t, got %t", i, step.expectedMarked, marked)
+		}
+	}
+
+	// A pod recreated with the same name is delayed from its own first eviction
+	client.pod = trackedPodStub("pod1", "node2", "uid2")
+	if result := handleEviction(context.Background(), eviction, client, client.config, CreateLogger(eviction.Name, eviction.Namespace, false)); !reflect.DeepEqual(result, delayed) {
+		t.Fatalf("Expected recreated pod to be delayed, got %v", result)
+	}
+}
+
+func TestHandleEvictionPriority(t *testing.T) {
+	withPriority := func(pod *corev1.Pod, priority string) *corev1.Pod {
+		pod.Annotations = map[string]string{"example.com/priority": priority}
+		return pod
+	}
+
+	// Replicas have a higher priority than primaries, so they are rescheduled first
+	replica1 := withPriority(clusterPodStub("replica1", "cluster1"), "10")
+	replica2 := withPriority(clusterPodStub("replica2", "cluster1"), "10")
+	replica2.Spec.NodeName = "node2"
+	primary := withPriority(clusterPodStub("primary", "cluster1"), "0")
+	otherCluster := withPriority(clusterPodStub("other", "cluster2"), "10")
+
+	client := &mockClient{
+		config:      NewConfigBuilder().WithPriorityAnnotation("example.com/priority").Build(),
+		clusterPods: []*corev1.Pod{replica1, replica2, primary, otherCluster},
+	}
+
+	steps := []struct {
+		testname           string
+		pod                *corev1.Pod
+		change             func()
+		expectedReasonCode ReasonCode
+	}{
+		{
+			testname:           "Primary waits for the replica on its node",
+			pod:                primary,
+			expectedReasonCode: ReasonWaitingForPriority,
+		},
+		{
+			testname:           "Replica is marked as no peer on its node has a higher priority",
+			pod:                replica1,
+			expectedReasonCode: ReasonAnnotationAdded,
+		},
+		{
+			testname:           "Primary still waits while the marked replica is on its node",
+			pod:                primary,
+			expectedReasonCode: ReasonWaitingForPriority,
+		},
+		{
+			testname:           "Primary is marked once the replica has been rescheduled to another node",
+			pod:                primary,
+			change:             func() { replica1.Spec.NodeName = "node3" },
+			expectedReasonCode: ReasonAnnotationAdded,
+		},
+	}
+
+	for _, step := range steps {
+		if step.change != nil {
+			step.change()
+		}
+
+		client.pod = step.pod
+		eviction := policyv1.Eviction{ObjectMeta: metav1.ObjectMeta{Name: step.pod.Name, Namespace: step.pod.Namespace}}
+		decision := decideEviction(context.Background(), eviction, client, client.config, CreateLogger(eviction.Name, eviction.Namespace, false))
+		if decision.Reason != step.expectedReasonCode {
+			t.Fatalf("%s: expected reason code %s, got %s", step.testname, step.expectedReasonCode, decision.Reason)
+		}
+
+		// A pod waiting for a higher priority peer is not marked
+		expectedMarked := step.expectedReasonCode == ReasonAnnotationAdded
+		if marked := isMarkedForReschedule(step.pod, client.config); marked != expectedMarked {
+			t.Fatalf("%s: expected pod marked for reschedule=%t, got %t", step.testname, expectedMarked, marked)
+		}
+	}
+}
+
+func TestHigherPriorityPeer(t *testing.T) {
+	pod := clusterPodStub("primary", "cluster1")
+	replica := clusterPodStub("replica", "cluster1")
+	replica.Annotations = map[string]string{"example.com/priority": "1"}
+
+	testcases := []struct {
+		testname     string
+		change       func(peer *corev1.Pod)
+		expectedPeer string
+	}{
+		{
+			testname:     "Running peer with a higher priority",
+			change:       func(peer *corev1.Pod) {},
+			expectedPeer: "replica",
+		},
+		{
+			testname: "Peer with an equal priority",
+			change:   func(peer *corev1.Pod) { peer.Annotations["example.com/priority"] = "0" },
+		},
+		{
+			testname: "Peer with a priority that is not an integer",
+			change:   func(peer *corev1.Pod) { peer.Annotations["example.com/priority"] = "high" },
+		},
+		{
+			testname: "Peer on another node",
+			change:   func(peer *corev1.Pod) { peer.Spec.NodeName = "node2" },
+		},
+		{
+			testname: "Peer that has succeeded",
+			change:   func(peer *corev1.Pod) { peer.Status.Phase = corev1.PodSucceeded },
+		},
+		{
+			testname: "Peer in another cluster",
+			change:   func(peer *corev1.Pod) { peer.Labels["couchbase_cluster"] = "cluster2" },
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testname, func(t *testing.T) {
+			peer := replica.DeepCopy()
+			testcase.change(peer)
+			client := &mockClient{
+				config:      NewConfigBuilder().WithPriorityAnnotation("example.com/priority").Build(),
+				clusterPods: []*corev1.Pod{pod, peer},
+			}
+
+			name, err := higherPriorityPeer(client, client.config, pod)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if name != testcase.expectedPeer {
+				t.Fatalf("Expected higher priority peer %q, got %q", testcase.expectedPeer, name)
+			}
+		})
+	}
+}
+
+func TestTrackRescheduledPodsMaxAge(t *testing.T) {
+	now := time.Date(2025, 6, 4, 12, 0, 0, 0, time.UTC)
+	trackedAt := func(age time.Duration) string {
+		return `{"trackedAt":"` + now.Add(-age).Format(time.RFC3339) + `"}`
+	}
+
+	testcases := []struct {
+		testname                            string
+		trackingAnnotation                  string
+		expectedResult                      *admissionv1.AdmissionResponse
+		expectedReasonCode                  ReasonCode
+		expectedTrackingResourceAnnotations map[string]string
+	}{
+		{
+			testname:                            "Fresh tracking annotation",
+			trackingAnnotation:                  trackedAt(30 * time.Minute),
+			expectedResult:                      denyEviction(http.StatusNotFound, metav1.StatusReasonNotFound, PodRescheduledWithSameNameMsg+" (0 tracked pods remaining)"),
+			expectedReasonCode:                  ReasonSameNameRescheduled,
+			expectedTrackingResourceAnnotations: map[string]string{},
+		},
+		{
+			testname:           "Expired tracking annotation",
+			trackingAnnotation: trackedAt(2 * time.Hour),
+			expectedResult:     denyEviction(http.StatusTooManyRequests, metav1.StatusReasonTooManyRequests, RescheduleAnnotationAddedToPodMsg),
+			expectedReasonCode: ReasonAnnotationAdded,
+			expectedTrackingResourceAnnotations: map[string]string{
+				TrackingResourceAnnotation("pod1", "default"): trackedAt(0),
+			},
+		},
+		{
+			testname:                            "Tracking annotation without a timestamp",
+			trackingAnnotation:                  "true",
+			expectedResult:                      denyEviction(http.StatusNotFound, metav1.StatusReasonNotFound, PodRescheduledWithSameNameMsg+" (0 tracked pods remaining)"),
+			expectedReasonCode:                  ReasonSameNameRescheduled,
+			expectedTrackingResourceAnnotations: map[string]string{},
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testname, func(t *testing.T) {
+			client := &mockClient{
+				pod:    trackedPodStub("pod1", "node1", "uid1"),
+				config: NewConfigBuilder().WithTrackingAnnotationMaxAge(time.Hour).WithClock(newFakeClock(now)).Build(),
+				trackingResourceAnnotations: map[string]string{
+					TrackingResourceAnnotation("pod1", "default"): testcase.trackingAnnotation,
+				},
+				shouldTrackRescheduledPods:  true,
+				shouldAddTrackingAnnotation: true,
+			}
+
+			eviction := policyv1.Eviction{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "pod1",
+					Namespace: "default",
+				},
+			}
+
+			decision := decideEviction(context.Background(), eviction, client, client.config, CreateLogger(eviction.Name, eviction.Namespace, false))
+			if !reflect.DeepEqual(decision.Response, testcase.expectedResult) || decision.Reason != testcase.expectedReasonCode {
+				t.Fatalf("Expected response %v with reason code %s, got %v with %s", testcase.expectedResult, testcase.expectedReasonCode, decision.Response, decision.Reason)
+			}
+
+			if !reflect.DeepEqual(client.trackingResourceAnnotations, testcase.expectedTrackingResourceAnnotations) {
+				t.Fatalf("Expected tracking resource annotations to be %v, got %v", testcase.expectedTrackingResourceAnnotations, client.trackingResourceAnnotations)
+			}
+		})
+	}
+}
+
+func TestServeEvictionContentType(t *testing.T) {
+	review := admissionv1.AdmissionReview{
+		Request: &admissionv1.AdmissionRequest{
+			UID:         "test-uid",
+			Operation:   admissionv1.Create,
+			SubResource: "eviction",
+			Object: runtime.RawExtension{
+				Raw: []byte(`{"apiVersion":"policy/v1","kind":"Eviction","metadata":{"name":"pod1","namespace":"default"}}`),
+			},
+		},
+	}
+
+	body, err := json.Marshal(review)
+	if err != nil {
+		t.Fatalf("Failed to marshal admission review: %v", err)
+	}
+
+	testcases := []struct {
+		contentType  string
+		expectedCode int
+	}{
+		{contentType: "application/json", expectedCode: http.StatusOK},
+		{contentType: "application/json; charset=utf-8", expectedCode: http.StatusOK},
+		{contentType: "Application/JSON", expectedCode: http.StatusOK},
+		{contentType: "text/plain", expectedCode: http.StatusUnsupportedMediaType},
+		{contentType: "", expectedCode: http.StatusUnsupportedMediaType},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.contentType, func(t *testing.T) {
+			// A limiter without any burst denies every request, so the eviction is answered without a Kubernetes client
+			limiter := NewRateLimiter(1, 0, RealClock)
+
+			recorder := httptest.NewRecorder()
+			request := httptest.NewRequest(http.MethodPost, "/eviction", bytes.NewReader(body))
+			request.Header.Set("Content-Type", testcase.contentType)
+
+			serveEviction(recorder, request, NewConfigBuilder().Build(), limiter, nil, nil, nil, nil, EvictionVersionV1)
+
+			if recorder.Code != testcase.expectedCode {
+				t.Fatalf("Expected status code %d, got %d", testcase.expectedCode, recorder.Code)
+			}
+		})
+	}
+}
+
+func TestServeEvictionPretty(t *testing.T) {
+	review := admissionv1.AdmissionReview{
+		Request: &admissionv1.AdmissionRequest{
+			UID:         "test-uid",
+			Operation:   admissionv1.Create,
+			SubResource: "eviction",
+			Object: runtime.RawExtension{
+				Raw: []byte(`{"apiVersion":"policy/v1","kind":"Eviction","metadata":{"name":"pod1","namespace":"default"}}`),
+			},
+		},
+	}
+
+	body, err := json.Marshal(review)
+	if err != nil {
+		t.Fatalf("Failed to marshal admission review: %v", err)
+	}
+
+	testcases := []struct {
+		target         string
+		expectIndented bool
+	}{
+		{target: "/eviction"},
+		{target: "/eviction?pretty=false"},
+		{target: "/eviction?pretty=true", expectIndented: true},
+		{target: "/eviction?pretty=1", expectIndented: true},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.target, func(t *testing.T) {
+			// A limiter without any burst denies every request, so the eviction is answered without a Kubernetes client
+			limiter := NewRateLimiter(1, 0, RealClock)
+
+			recorder := httptest.NewRecorder()
+			request := httptest.NewRequest(http.MethodPost, testcase.target, bytes.NewReader(body))
+			request.Header.Set("Content-Type", "application/json")
+
+			serveEviction(recorder, request, NewConfigBuilder().Build(), limiter, nil, nil, nil, nil, EvictionVersionV1)
+
+			if indented := strings.Contains(recorder.Body.String(), "\n  "); indented != testcase.expectIndented {
+				t.Fatalf("Expected indented response=%t, got %s", testcase.expectIndented, recorder.Body.String())
+			}
+
+			var response admissionv1.AdmissionReview
+			if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil || response.Response == nil || response.Response.UID != "test-uid" {
+				t.Fatalf("Expected a valid admission review response, got %s", recorder.Body.String())
+			}
+		})
+	}
+}
+
+func TestServeEvictionUnsupportedOperation(t *testing.T) {
+	review := admissionv1.AdmissionReview{
+		Request: &admissionv1.AdmissionRequest{
+			UID:       "test-uid",
+			Operation: admissionv1.Update,
+			Object: runtime.RawExtension{
+				Raw: []byte(`{"apiVersion":"policy/v1","kind":"Eviction","metadata":{"name":"pod1","namespace":"default"}}`),
+			},
+		},
+	}
+
+	body, err := json.Marshal(review)
+	if err != nil {
+		t.Fatalf("Failed to marshal admission review: %v", err)
+	}
+
+	// A limiter without any burst denies every request that is handled, so an allowed response shows the request was not
+	// handled and the pod was never fetched
+	limiter := NewRateLimiter(1, 0, RealClock)
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodPost, "/eviction", bytes.NewReader(body))
+	request.Header.Set("Content-Type", "application/json")
+
+	serveEviction(recorder, request, NewConfigBuilder().Build(), limiter, nil, nil, nil, nil, EvictionVersionV1)
+
+	var response admissionv1.AdmissionReview
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode admission review response: %v", err)
+	}
+
+	if response.Response == nil || !response.Response.Allowed || response.Response.UID != "test-uid" {
+		t.Fatalf("Expected UPDATE operation to be allowed with the request UID, got %v", response.Response)
+	}
+}
+
+func TestServeEvictionMissingRequest(t *testing.T) {
+	body := []byte(`{"apiVersion":"admission.k8s.io/v1","kind":"AdmissionReview"}`)
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodPost, "/eviction", bytes.NewReader(body))
+	request.Header.Set("Content-Type", "application/json")
+
+	serveEviction(recorder, request, NewConfigBuilder().Build(), NewRateLimiter(1, 0, RealClock), nil, nil, nil, nil, EvictionVersionV1)
+
+	if recorder.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status code %d, got %d", http.StatusBadRequest, recorder.Code)
+	}
+}
+
+func TestServeEvictionUnsupportedSubresource(t *testing.T) {
+	testcases := []struct {
+		testname        string
+		request         admissionv1.AdmissionRequest
+		expectedAllowed bool
+	}{
+		{
+			testname: "Pod create",
+			request: admissionv1.AdmissionRequest{
+				Kind:     metav1.GroupVersionKind{Version: "v1", Kind: "Pod"},
+				Resource: metav1.GroupVersionResource{Version: "v1", Resource: "pods"},
+				Object:   runtime.RawExtension{Raw: []byte(`{"apiVersion":"v1","kind":"Pod","metadata":{"name":"pod1","namespace":"default"}}`)},
+			},
+			expectedAllowed: true,
+		},
+		{
+			testname: "Other pod subresource",
+			request: admissionv1.AdmissionRequest{
+				Kind:        metav1.GroupVersionKind{Version: "v1", Kind: "Binding"},
+				Resource:    metav1.GroupVersionResource{Version: "v1", Resource: "pods"},
+				SubResource: "binding",
+				Object:      runtime.RawExtension{Raw: []byte(`{"apiVersion":"v1","kind":"Binding","metadata":{"name":"pod1","namespace":"default"}}`)},
+			},
+			expectedAllowed: true,
+		},
+		{
+			testname: "Eviction subresource",
+			request: admissionv1.AdmissionRequest{
+				Kind:        metav1.GroupVersionKind{Group: "policy", Version: "v1", Kind: "Eviction"},
+				Resource:    metav1.GroupVersionResource{Version: "v1", Resource: "pods"},
+				SubResource: "eviction",
+				Object:      runtime.RawExtension{Raw: []byte(`{"apiVersion":"policy/v1","kind":"Eviction","metadata":{"name":"pod1","namespace":"default"}}`)},
+			},
+		},
+		{
+			testname: "Eviction converted from the eviction subresource",
+			request: admissionv1.AdmissionRequest{
+				RequestSubResource: "eviction",
+				Object:             runtime.RawExtension{Raw: []byte(`{"apiVersion":"policy/v1","kind":"Eviction","metadata":{"name":"pod1","namespace":"default"}}`)},
+			},
+		},
+		{
+			testname: "Eviction kind",
+			request: admissionv1.AdmissionRequest{
+				Kind:   metav1.GroupVersionKind{Group: "policy", Version: "v1", Kind: "Eviction"},
+				Object: runtime.RawExtension{Raw: []byte(`{"apiVersion":"policy/v1","kind":"Eviction","metadata":{"name":"pod1","namespace":"default"}}`)},
+			},
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testname, func(t *testing.T) {
+			request := testcase.request
+			request.UID = "test-uid"
+			request.Operation = admissionv1.Create
+			body, err := json.Marshal(admissionv1.AdmissionReview{Request: &request})
+			if err != nil {
+				t.Fatalf("Failed to marshal admission review: %v", err)
+			}
+
+			// A limiter without any burst denies every request that is handled, so an allowed response shows the request was not
+			// handled and the pod was never fetched or annotated
+			limiter := NewRateLimiter(1, 0, RealClock)
+
+			recorder := httptest.NewRecorder()
+			httpRequest := httptest.NewRequest(http.MethodPost, "/eviction", bytes.NewReader(body))
+			httpRequest.Header.Set("Content-Type", "application/json")
+
+			serveEviction(recorder, httpRequest, NewConfigBuilder().Build(), limiter, nil, nil, nil, nil, EvictionVersionV1)
+
+			var response admissionv1.AdmissionReview
+			if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil || response.Response == nil {
+				t.Fatalf("Expected a valid admission review response, got %s", recorder.Body.String())
+			}
+
+			if response.Response.Allowed != testcase.expectedAllowed || response.Response.UID != "test-uid" {
+				t.Fatalf("Expected allowed=%t with the request UID, got %v", testcase.expectedAllowed, response.Response)
+			}
+		})
+	}
+}
+
+func TestServeEvictionShuttingDown(t *testing.T) {
+	review := admissionv1.AdmissionReview{
+		Request: &admissionv1.AdmissionRequest{
+			UID:         "test-uid",
+			Operation:   admissionv1.Create,
+			SubResource: "eviction",
+			Object: runtime.RawExtension{
+				Raw: []byte(`{"apiVersion":"policy/v1","kind":"Eviction","metadata":{"name":"pod1","namespace":"default"}}`),
+			},
+		},
+	}
+
+	body, err := json.Marshal(review)
+	if err != nil {
+		t.Fatalf("Failed to marshal admission review: %v", err)
+	}
+
+	// A limiter without any burst denies every request that gets past the shutdown check, so the eviction is always answered
+	// without a Kubernetes client
+	limiter := NewRateLimiter(1, 0, RealClock)
+	serve := func() *admissionv1.AdmissionResponse {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodPost, "/eviction", bytes.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+
+		serveEviction(recorder, request, NewConfigBuilder().Build(), limiter, nil, nil, nil, nil, EvictionVersionV1)
+
+		var response admissionv1.AdmissionReview
+		if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to decode admission review response: %v", err)
+		}
+		return response.Response
+	}
+
+	shuttingDown.Store(true)
+	defer shuttingDown.Store(false)
+
+	if response := serve(); response == nil || response.Allowed || response.Result.Message != WebhookShuttingDownMsg || response.Result.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected eviction to be denied with %q while shutting down, got %v", WebhookShuttingDownMsg, response)
+	}
+
+	recorder := httptest.NewRecorder()
+	serveReadiness(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil), newReadinessRegistry(nil))
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected readiness status code %d while shutting down, got %d", http.StatusServiceUnavailable, recorder.Code)
+	}
+
+	shuttingDown.Store(false)
+
+	if response := serve(); response == nil || response.Result.Message != RateLimitExceededMsg {
+		t.Fatalf("Expected eviction to be handled once no longer shutting down, got %v", response)
+	}
+}
+
+func TestShutdownServers(t *testing.T) {
+	config := NewConfigBuilder().WithShutdownDelay(500 * time.Millisecond).Build()
+	mux := http.NewServeMux()
+	registerHealthHandlers(mux, newReadinessRegistry(nil))
+	mux.HandleFunc("/eviction", func(w http.ResponseWriter, r *http.Request) {
+		serveEviction(w, r, config, nil, nil, nil, nil, nil, EvictionVersionV1)
+	})
+	server := &http.Server{Addr: "127.0.0.1:0", Handler: mux}
+
+	listener, err := net.Listen("tcp", server.Addr)
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	url := "http://" + listener.Addr().String()
+
+	go func() {
+		_ = server.Serve(listener)
+	}()
+
+	t.Cleanup(func() {
+		shuttingDown.Store(false)
+	})
+
+	done := make(chan struct{})
+	go func() {
+		shutdownServers(config, server, nil)
+		close(done)
+	}()
+
+	// The pre-shutdown state is entered straight away, and lasts for the shutdown delay
+	for !shuttingDown.Load() {
+		time.Sleep(time.Millisecond)
+	}
+
+	response, err := http.Get(url + "/readyz")
+	if err != nil {
+		t.Fatalf("Expected readiness to be served during the shutdown delay: %v", err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("Expected readiness status code %d during the shutdown delay, got %d", http.StatusServiceUnavailable, response.StatusCode)
+	}
+
+	// No Kubernetes client is available, so a retriable denial shows the eviction was answered without one
+	body, err := json.Marshal(admissionv1.AdmissionReview{Request: &admissionv1.AdmissionRequest{
+		UID:         "test-uid",
+		Operation:   admissionv1.Create,
+		SubResource: "eviction",
+		Object:      runtime.RawExtension{Raw: []byte(`{"apiVersion":"policy/v1","kind":"Eviction","metadata":{"name":"pod1","namespace":"default"}}`)},
+	}})
+	if err != nil {
+		t.Fatalf("Failed to marshal admission review: %v", err)
+	}
+
+	response, err = http.Post(url+"/eviction", "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("Expected evictions to be served during the shutdown delay: %v", err)
+	}
+
+	var review admissionv1.AdmissionReview
+	err = json.NewDecoder(response.Body).Decode(&review)
+	response.Body.Close()
+	if err != nil {
+		t.Fatalf("Failed to decode admission review response: %v", err)
+	}
+
+	expected := denyEviction(http.StatusTooManyRequests, metav1.StatusReasonTooManyRequests, WebhookShuttingDownMsg)
+	if review.Response == nil || review.Response.Allowed || review.Response.Result.Code != expected.Result.Code || review.Response.Result.Message != expected.Result.Message {
+		t.Fatalf("Expected eviction to be denied with %v during the shutdown delay, got %v", expected.Result, review.Response)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timed out waiting for shutdown")
+	}
+
+	if _, err := http.Get(url + "/readyz"); err == nil {
+		t.Fatalf("Expected the listener to be closed after shutdown")
+	}
+}
+
+func TestDecideEvictionReasonCode(t *testing.T) {
+	clock := newFakeClock(time.Date(2025, 6, 4, 12, 0, 0, 0, time.UTC))
+
+	testcases := []struct {
+		testname           string
+		evictedPodName     string
+		config             *Config
+		expectedReasonCode ReasonCode
+	}{
+		{
+			testname:           "Invalid eviction",
+			expectedReasonCode: ReasonInvalidEviction,
+		},
+		{
+			testname:           "Outside of the active windows",
+			evictedPodName:     "pod1",
+			config:             NewConfigBuilder().FromEnvironment().WithActiveWindows("Mon-Fri 22:00-06:00", time.UTC).WithClock(clock).Build(),
+			expectedReasonCode: ReasonOutsideActiveWindows,
+		},
+		{
+			testname:           "First annotation delayed",
+			evictedPodName:     "pod1",
+			config:             NewConfigBuilder().FromEnvironment().WithFirstAnnotationDelay(time.Minute).WithClock(clock).Build(),
+			expectedReasonCode: ReasonAnnotationDelayed,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testname, func(t *testing.T) {
+			client := &mockClient{
+				pod:    trackedPodStub("pod1", "node1", "uid1"),
+				config: testcase.config,
+			}
+			if client.config == nil {
+				client.config = NewConfigBuilder().FromEnvironment().Build()
+			}
+
+			eviction := policyv1.Eviction{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      testcase.evictedPodName,
+					Namespace: "default",
+				},
+			}
+
+			decision := decideEviction(context.Background(), eviction, client, client.config, CreateLogger(eviction.Name, eviction.Namespace, false))
+			if decision.Reason != testcase.expectedReasonCode {
+				t.Fatalf("Expected reason code to be %s, got %s", testcase.expectedReasonCode, decision.Reason)
+			}
+		})
+	}
+}
+
+func TestHandleEvictionLogsReasonCode(t *testing.T) {
+	client := &mockClient{
+		pod:    trackedPodStub("pod1", "node1", "uid1"),
+		config: NewConfigBuilder().FromEnvironment().Build(),
+	}
+
+	eviction := policyv1.Eviction{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "pod1",
+			Namespace: "default",
+		},
+	}
+
+	var buf bytes.Buffer
+	handleEviction(context.Background(), eviction, client, client.config, slog.New(slog.NewJSONHandler(&buf, nil)))
+
+	var entry struct {
+		Msg        string     `json:"msg"`
+		ReasonCode ReasonCode `json:"reason_code"`
+		Allowed    bool       `json:"allowed"`
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if err := json.Unmarshal([]byte(lines[len(lines)-1]), &entry); err != nil {
+		t.Fatalf("Failed to decode log entry %q: %v", buf.String(), err)
+	}
+
+	if entry.Msg != "Eviction request handled" || entry.ReasonCode != ReasonAnnotationAdded || entry.Allowed {
+		t.Fatalf("Expected the decision to be logged with reason code %s, got %q", ReasonAnnotationAdded, buf.String())
+	}
+}
+
+func TestIsInRollout(t *testing.T) {
+	names := make([]string, 1000)
+	for i := range names {
+		names[i] = fmt.Sprintf("cluster-%04d", i)
+	}
+
+	for _, percent := range []int{0, 1, 25, 50, 99, 100} {
+		in := 0
+		for _, name := range names {
+			inRollout := isInRollout("default", name, percent)
+			if inRollout != isInRollout("default", name, percent) {
+				t.Fatalf("Expected pod %s to be consistently bucketed at %d%%", name, percent)
+			}
+
+			// Raising the percentage only adds pods to the rollout
+			if inRollout && !isInRollout("default", name, percent+1) {
+				t.Fatalf("Expected pod %s in the %d%% rollout to be in the %d%% rollout", name, percent, percent+1)
+			}
+
+			if inRollout {
+				in++
+			}
+		}
+
+		switch {
+		case percent == 0 && in != 0:
+			t.Fatalf("Expected no pods in the 0%% rollout, got %d", in)
+		case percent == 100 && in != len(names):
+			t.Fatalf("Expected all pods in the 100%% rollout, got %d", in)
+		case in < (percent-10)*len(names)/100 || in > (percent+10)*len(names)/100:
+			t.Fatalf("Expected about %d%% of pods in the rollout, got %d of %d", percent, in, len(names))
+		}
+	}
+}
 
-				if !reflect.DeepEqual(pod, testcase.expectedPod) {
-					t.Errorf("Expected pod to be %v, got %v", testcase.expectedPod, pod)
-				}
+func TestPodOrdinal(t *testing.T) {
+	testcases := []struct {
+		name            string
+		expectedOrdinal int
+		expectedOk      bool
+	}{
+		{name: "cluster-0000", expectedOrdinal: 0, expectedOk: true},
+		{name: "cluster-0012", expectedOrdinal: 12, expectedOk: true},
+		{name: "my-cluster-3", expectedOrdinal: 3, expectedOk: true},
+		{name: "cluster"},
+		{name: "cluster-"},
+		{name: "cluster-abc"},
+		{name: "cluster-1a"},
+		{name: ""},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.name, func(t *testing.T) {
+			ordinal, ok := podOrdinal(testcase.name)
+			if ordinal != testcase.expectedOrdinal || ok != testcase.expectedOk {
+				t.Errorf("Expected ordinal %d and ok=%t, got %d and ok=%t", testcase.expectedOrdinal, testcase.expectedOk, ordinal, ok)
 			}
+		})
+	}
+}
 
-			if !reflect.DeepEqual(testcase.mockClient.trackingResourceAnnotations, testcase.expectedTrackingResourceAnnotations) {
-				t.Errorf("Expected tracking resource annotations to be %v, got %v", testcase.expectedTrackingResourceAnnotations, testcase.mockClient.trackingResourceAnnotations)
+func TestIsPodReady(t *testing.T) {
+	readyCondition := func(status corev1.ConditionStatus) []corev1.PodCondition {
+		return []corev1.PodCondition{
+			{Type: corev1.PodScheduled, Status: corev1.ConditionTrue},
+			{Type: corev1.PodReady, Status: status},
+		}
+	}
+
+	containerStatuses := func(ready ...bool) []corev1.ContainerStatus {
+		statuses := make([]corev1.ContainerStatus, 0, len(ready))
+		for i, r := range ready {
+			statuses = append(statuses, corev1.ContainerStatus{Name: fmt.Sprintf("container-%d", i), Ready: r})
+		}
+		return statuses
+	}
+
+	testcases := []struct {
+		testname string
+		status   corev1.PodStatus
+		expected bool
+	}{
+		{
+			testname: "No conditions or container statuses",
+			expected: false,
+		},
+		{
+			testname: "Ready condition with all containers ready",
+			status:   corev1.PodStatus{Conditions: readyCondition(corev1.ConditionTrue), ContainerStatuses: containerStatuses(true, true)},
+			expected: true,
+		},
+		{
+			testname: "Not ready condition with the first container ready",
+			status:   corev1.PodStatus{Conditions: readyCondition(corev1.ConditionFalse), ContainerStatuses: containerStatuses(true, false)},
+			expected: false,
+		},
+		{
+			testname: "Not ready condition with all containers ready, e.g. a failing readiness gate",
+			status:   corev1.PodStatus{Conditions: readyCondition(corev1.ConditionFalse), ContainerStatuses: containerStatuses(true, true)},
+			expected: false,
+		},
+		{
+			testname: "No ready condition with all containers ready",
+			status:   corev1.PodStatus{ContainerStatuses: containerStatuses(true, true, true)},
+			expected: true,
+		},
+		{
+			testname: "No ready condition with a sidecar not ready",
+			status:   corev1.PodStatus{ContainerStatuses: containerStatuses(true, false)},
+			expected: false,
+		},
+		{
+			testname: "No ready condition with the first container not ready",
+			status:   corev1.PodStatus{ContainerStatuses: containerStatuses(false, true)},
+			expected: false,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testname, func(t *testing.T) {
+			if ready := IsPodReady(&corev1.Pod{Status: testcase.status}); ready != testcase.expected {
+				t.Errorf("Expected pod ready to be %t, got %t", testcase.expected, ready)
+			}
+		})
+	}
+}
+
+func TestDecodeEviction(t *testing.T) {
+	testcases := []struct {
+		testname string
+		version  string
+		raw      string
+	}{
+		{
+			testname: "v1",
+			version:  EvictionVersionV1,
+			raw:      `{"apiVersion":"policy/v1","kind":"Eviction","metadata":{"name":"pod1","namespace":"default"},"deleteOptions":{"dryRun":["All"]}}`,
+		},
+		{
+			testname: "v1beta1",
+			version:  EvictionVersionV1beta1,
+			raw:      `{"apiVersion":"policy/v1beta1","kind":"Eviction","metadata":{"name":"pod1","namespace":"default"},"deleteOptions":{"dryRun":["All"]}}`,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testname, func(t *testing.T) {
+			eviction, err := decodeEviction([]byte(testcase.raw), testcase.version)
+			if err != nil {
+				t.Fatalf("Failed to decode eviction: %v", err)
+			}
+
+			if eviction.Name != "pod1" || eviction.Namespace != "default" {
+				t.Errorf("Expected eviction for default/pod1, got %s/%s", eviction.Namespace, eviction.Name)
+			}
+
+			if !isDryRun(&eviction) {
+				t.Errorf("Expected eviction delete options to be decoded")
+			}
+		})
+	}
+}
+
+func TestHandleEvictionInvalid(t *testing.T) {
+	testcases := []struct {
+		testname        string
+		name            string
+		namespace       string
+		expectedMessage string
+	}{
+		{
+			testname:        "Missing name",
+			namespace:       "default",
+			expectedMessage: InvalidEvictionMsg + ": missing name",
+		},
+		{
+			testname:        "Missing namespace",
+			name:            "pod1",
+			expectedMessage: InvalidEvictionMsg + ": missing namespace",
+		},
+		{
+			testname:        "Missing name and namespace",
+			expectedMessage: InvalidEvictionMsg + ": missing name and namespace",
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testname, func(t *testing.T) {
+			client := &mockClient{
+				pod:    trackedPodStub("pod1", "node1", "uid1"),
+				config: NewConfigBuilder().FromEnvironment().Build(),
+			}
+
+			eviction := policyv1.Eviction{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      testcase.name,
+					Namespace: testcase.namespace,
+				},
+			}
+
+			result := handleEviction(context.Background(), eviction, client, client.config, CreateLogger(eviction.Name, eviction.Namespace, false))
+
+			expected := denyEviction(http.StatusBadRequest, metav1.StatusReasonBadRequest, testcase.expectedMessage)
+			if !reflect.DeepEqual(result, expected) {
+				t.Errorf("Expected response to be %v, got %v", expected, result)
+			}
+
+			if client.getPodCalls != 0 {
+				t.Errorf("Expected no calls to GetPod, got %d", client.getPodCalls)
+			}
+		})
+	}
+}
+
+func TestDefaultEvictionNamespace(t *testing.T) {
+	testcases := []struct {
+		testname          string
+		evictionNamespace string
+		requestNamespace  string
+		expectedNamespace string
+	}{
+		{
+			testname:          "Eviction with namespace",
+			evictionNamespace: "default",
+			requestNamespace:  "other",
+			expectedNamespace: "default",
+		},
+		{
+			testname:          "Eviction without namespace",
+			requestNamespace:  "default",
+			expectedNamespace: "default",
+		},
+		{
+			testname: "Neither with namespace",
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testname, func(t *testing.T) {
+			eviction := policyv1.Eviction{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "pod1",
+					Namespace: testcase.evictionNamespace,
+				},
+			}
+
+			defaultEvictionNamespace(&eviction, &admissionv1.AdmissionRequest{Namespace: testcase.requestNamespace})
+			if eviction.Namespace != testcase.expectedNamespace {
+				t.Fatalf("Expected namespace %q, got %q", testcase.expectedNamespace, eviction.Namespace)
+			}
+
+			client := &mockClient{
+				pod:    trackedPodStub("pod1", "node1", "uid1"),
+				config: NewConfigBuilder().FromEnvironment().Build(),
+			}
+
+			// An eviction that still has no namespace is denied without looking up the pod
+			expected := denyEviction(http.StatusTooManyRequests, metav1.StatusReasonTooManyRequests, RescheduleAnnotationAddedToPodMsg)
+			if testcase.expectedNamespace == "" {
+				expected = denyEviction(http.StatusBadRequest, metav1.StatusReasonBadRequest, InvalidEvictionMsg+": missing namespace")
+			}
+
+			result := handleEviction(context.Background(), eviction, client, client.config, CreateLogger(eviction.Name, eviction.Namespace, false))
+			if !reflect.DeepEqual(result, expected) {
+				t.Fatalf("Expected response to be %v, got %v", expected, result)
+			}
+		})
+	}
+}
+
+func multipleRescheduleAnnotationsConfig() *Config {
+	return NewConfigBuilder().WithRescheduleAnnotations(map[string]string{
+		"cao.couchbase.com/reschedule":        "true",
+		"cao.couchbase.com/reschedule-reason": "eviction",
+	}).Build()
+}
+
+func trackedPodStub(name, nodeName string, uid types.UID) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			UID:       uid,
+			Labels: map[string]string{
+				"app": "couchbase",
+			},
+		},
+		Spec: corev1.PodSpec{
+			NodeName: nodeName,
+		},
+	}
+}
+
+func clusterPodStub(name, clusterName string) *corev1.Pod {
+	pod := trackedPodStub(name, "node1", types.UID(name))
+	pod.Labels["couchbase_cluster"] = clusterName
+	return pod
+}
+
+func nodeStub(name string, ready corev1.ConditionStatus, taints ...corev1.Taint) *corev1.Node {
+	return &corev1.Node{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Node",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Spec: corev1.NodeSpec{
+			Taints: taints,
+		},
+		Status: corev1.NodeStatus{
+			Conditions: []corev1.NodeCondition{
+				{Type: corev1.NodeReady, Status: ready},
+			},
+		},
+	}
+}
+
+// annotatedNodeStub returns a Ready node with the given annotations
+func annotatedNodeStub(name string, annotations map[string]string) *corev1.Node {
+	node := nodeStub(name, corev1.ConditionTrue)
+	node.Annotations = annotations
+	return node
+}
+
+var unschedulableTaint = corev1.Taint{Key: corev1.TaintNodeUnschedulable, Effect: corev1.TaintEffectNoSchedule}
+
+// controlledPodStub returns a tracked pod whose controller is of the given kind
+func controlledPodStub(name, apiVersion, kind string) *corev1.Pod {
+	pod := trackedPodStub(name, "node1", "uid1")
+	controller := true
+	pod.OwnerReferences = []metav1.OwnerReference{
+		{APIVersion: apiVersion, Kind: kind, Name: "owner", Controller: &controller},
+	}
+
+	return pod
+}
+
+func statefulPodStub(name, nodeName string, uid types.UID) *corev1.Pod {
+	pod := trackedPodStub(name, nodeName, uid)
+	pod.Spec.Volumes = []corev1.Volume{
+		{
+			Name: "data",
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "data-" + name},
+			},
+		},
+	}
+
+	return pod
+}
+
+func TestLogAdmissionReview(t *testing.T) {
+	review := &admissionv1.AdmissionReview{
+		Request: &admissionv1.AdmissionRequest{
+			UID:         "test-uid",
+			SubResource: "eviction",
+			Resource:    metav1.GroupVersionResource{Version: "v1", Resource: "pods"},
+		},
+	}
+
+	testcases := []struct {
+		testname        string
+		level           slog.Level
+		body            []byte
+		expectLogged    bool
+		expectTruncated bool
+	}{
+		{
+			testname:     "Not logged at info level",
+			level:        slog.LevelInfo,
+			body:         []byte(`{"kind":"AdmissionReview"}`),
+			expectLogged: false,
+		},
+		{
+			testname:     "Logged at debug level",
+			level:        slog.LevelDebug,
+			body:         []byte(`{"kind":"AdmissionReview"}`),
+			expectLogged: true,
+		},
+		{
+			testname:        "Body truncated at debug level",
+			level:           slog.LevelDebug,
+			body:            []byte(strings.Repeat("a", maxDebugBodySize+1)),
+			expectLogged:    true,
+			expectTruncated: true,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testname, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: testcase.level}))
+
+			logAdmissionReview(context.Background(), logger, testcase.body, review)
+
+			output := buf.String()
+			if logged := strings.Contains(output, "uid=test-uid"); logged != testcase.expectLogged {
+				t.Fatalf("Expected logged to be %t, got output %q", testcase.expectLogged, output)
+			}
+
+			if truncated := strings.Contains(output, "truncated=true"); truncated != testcase.expectTruncated {
+				t.Fatalf("Expected truncated to be %t, got output %q", testcase.expectTruncated, output)
+			}
+
+			if strings.Contains(output, strings.Repeat("a", maxDebugBodySize+1)) {
+				t.Fatalf("Expected body to be capped at %d bytes", maxDebugBodySize)
+			}
+		})
+	}
+}
+
+func TestNewServer(t *testing.T) {
+	config := NewConfigBuilder().WithHTTPTimeouts(5*time.Second, time.Minute, 2*time.Minute).Build()
+	server := newServer(config, &certificateReloader{}, http.NewServeMux())
+
+	if server.ReadTimeout != 5*time.Second || server.WriteTimeout != time.Minute || server.IdleTimeout != 2*time.Minute {
+		t.Fatalf("Expected server timeouts to be 5s/1m0s/2m0s, got %s/%s/%s", server.ReadTimeout, server.WriteTimeout, server.IdleTimeout)
+	}
+
+	if server.Addr != ":8443" || server.TLSConfig == nil || server.TLSConfig.GetCertificate == nil {
+		t.Fatalf("Expected server to listen on :8443 with the reloader's certificate, got %s", server.Addr)
+	}
+}
+
+func TestHealthServer(t *testing.T) {
+	config := NewConfigBuilder().WithHealthAddr("127.0.0.1:0").Build()
+	mux := http.NewServeMux()
+	registerHealthHandlers(mux, newReadinessRegistry(nil))
+	server := newHealthServer(config, mux)
+
+	listener, err := net.Listen("tcp", server.Addr)
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	defer server.Close()
+
+	testcases := []struct {
+		path         string
+		expectedCode int
+	}{
+		{path: "/healthz", expectedCode: http.StatusOK},
+		{path: "/readyz", expectedCode: http.StatusOK},
+		{path: "/metrics", expectedCode: http.StatusOK},
+		{path: "/eviction", expectedCode: http.StatusNotFound},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.path, func(t *testing.T) {
+			response, err := http.Get("http://" + listener.Addr().String() + testcase.path)
+			if err != nil {
+				t.Fatalf("Failed to get %s: %v", testcase.path, err)
+			}
+			response.Body.Close()
+
+			if response.StatusCode != testcase.expectedCode {
+				t.Fatalf("Expected status code %d, got %d", testcase.expectedCode, response.StatusCode)
+			}
+		})
+	}
+}
+
+func TestDumpState(t *testing.T) {
+	var buf bytes.Buffer
+	defaultLogger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, nil)))
+	t.Cleanup(func() {
+		slog.SetDefault(defaultLogger)
+	})
+
+	config := NewConfigBuilder().WithFirstAnnotationDelay(time.Minute).Build()
+	config.firstSeen.Observe("uid1", time.Now())
+
+	signals := make(chan os.Signal, 1)
+	signals <- syscall.SIGUSR1
+	close(signals)
+	dumpStateOnSignal(signals, config)
+
+	output := buf.String()
+	expectedFields := []string{
+		"msg=\"State dump\"",
+		"podLabelSelectorKey=" + DefaultPodLabelSelectorKey,
+		"firstAnnotationDelay=1m0s",
+		"registryPods=1",
+		"metrics.reschedule_tracking_annotation_added_total=",
+	}
+	for _, field := range expectedFields {
+		if !strings.Contains(output, field) {
+			t.Errorf("Expected state dump to contain %q, got %q", field, output)
+		}
+	}
+}
+
+func TestServeDefault(t *testing.T) {
+	testcases := []struct {
+		testname     string
+		rootOK       bool
+		method       string
+		path         string
+		expectedCode int
+	}{
+		{
+			testname:     "GET root when disabled",
+			rootOK:       false,
+			method:       http.MethodGet,
+			path:         "/",
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			testname:     "GET root when enabled",
+			rootOK:       true,
+			method:       http.MethodGet,
+			path:         "/",
+			expectedCode: http.StatusOK,
+		},
+		{
+			testname:     "POST root when enabled",
+			rootOK:       true,
+			method:       http.MethodPost,
+			path:         "/",
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			testname:     "GET unknown path when enabled",
+			rootOK:       true,
+			method:       http.MethodGet,
+			path:         "/unknown",
+			expectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testname, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			request := httptest.NewRequest(testcase.method, testcase.path, nil)
+
+			serveDefault(recorder, request, NewConfigBuilder().WithRootOK(testcase.rootOK).Build())
+
+			if recorder.Code != testcase.expectedCode {
+				t.Fatalf("Expected status code %d, got %d", testcase.expectedCode, recorder.Code)
+			}
+		})
+	}
+}
+
+func TestServeReadiness(t *testing.T) {
+	config := NewConfigBuilder().WithReadyRequireTrackingCRD(true).Build()
+
+	testcases := []struct {
+		testname     string
+		readiness    *readinessCheck
+		expectedCode int
+	}{
+		{
+			testname:     "No tracking resource check",
+			expectedCode: http.StatusOK,
+		},
+		{
+			testname:     "Tracking resource served",
+			readiness:    &readinessCheck{config: config, client: &mockClient{config: config}},
+			expectedCode: http.StatusOK,
+		},
+		{
+			testname:     "Tracking resource not served",
+			readiness:    &readinessCheck{config: config, client: &mockClient{config: config, trackingResourceNotServed: true}},
+			expectedCode: http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testname, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			request := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+
+			serveReadiness(recorder, request, newReadinessRegistry(testcase.readiness))
+
+			if recorder.Code != testcase.expectedCode {
+				t.Fatalf("Expected status code %d, got %d", testcase.expectedCode, recorder.Code)
 			}
 		})
 	}
 }
+
+func TestRequestingUser(t *testing.T) {
+	request := &admissionv1.AdmissionRequest{
+		UID: "test-uid",
+		UserInfo: authenticationv1.UserInfo{
+			Username: "system:admin",
+			Groups:   []string{"system:masters"},
+		},
+	}
+
+	eviction := &policyv1.Eviction{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-pod",
+			Namespace: "default",
+		},
+	}
+
+	var buf bytes.Buffer
+	defaultLogger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, nil)))
+	t.Cleanup(func() {
+		slog.SetDefault(defaultLogger)
+	})
+
+	evictionLogger(eviction, request, false).Info("Handling eviction request")
+	if !strings.Contains(buf.String(), "user=system:admin") {
+		t.Fatalf("Expected log to contain the requesting user, got %q", buf.String())
+	}
+
+	response := denyEviction(http.StatusTooManyRequests, metav1.StatusReasonTooManyRequests, RescheduleAnnotationAddedToPodMsg)
+	finaliseResponse(response, request, false)
+
+	if response.UID != request.UID {
+		t.Fatalf("Expected response UID to be %s, got %s", request.UID, response.UID)
+	}
+
+	expectedAuditAnnotations := map[string]string{AuditAnnotationRequestingUser: "system:admin"}
+	if !reflect.DeepEqual(response.AuditAnnotations, expectedAuditAnnotations) {
+		t.Fatalf("Expected audit annotations to be %v, got %v", expectedAuditAnnotations, response.AuditAnnotations)
+	}
+}
@@ -0,0 +1,66 @@
+package reschedule
+
+import (
+	"log/slog"
+	"time"
+)
+
+// telemetryMaxInFlight is the number of telemetry calls that can be running at once, including calls that have outlived the
+// telemetry timeout, before further calls are dropped
+const telemetryMaxInFlight = 100
+
+// Telemetry runs the calls that report a decision, such as notifications and the decision log, so that a failing or slow
+// telemetry backend never delays or changes an admission response. Each call runs in the background and is waited on for at most
+// the timeout. A call that panics is recovered and logged.
+type Telemetry struct {
+	timeout  time.Duration
+	inFlight chan struct{}
+}
+
+// NewTelemetry creates a Telemetry that waits at most timeout for each call
+func NewTelemetry(timeout time.Duration) *Telemetry {
+	return &Telemetry{timeout: timeout, inFlight: make(chan struct{}, telemetryMaxInFlight)}
+}
+
+// Emit runs the named telemetry call. A nil Telemetry runs the call directly, still recovering a panic. If too many calls are
+// already running, the call is dropped.
+func (t *Telemetry) Emit(name string, call func()) {
+	if t == nil {
+		runTelemetry(name, call)
+		return
+	}
+
+	select {
+	case t.inFlight <- struct{}{}:
+	default:
+		slog.Warn("Too many telemetry calls in flight, dropping call", "telemetry", name)
+		return
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer func() { <-t.inFlight }()
+		defer close(done)
+		runTelemetry(name, call)
+	}()
+
+	timer := time.NewTimer(t.timeout)
+	defer timer.Stop()
+
+	select {
+	case <-done:
+	case <-timer.C:
+		slog.Warn("Telemetry call timed out, continuing without waiting for it", "telemetry", name, "timeout", t.timeout)
+	}
+}
+
+// runTelemetry runs a telemetry call, logging rather than propagating a panic
+func runTelemetry(name string, call func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			slog.Error("Telemetry call panicked", "telemetry", name, "panic", r)
+		}
+	}()
+
+	call()
+}
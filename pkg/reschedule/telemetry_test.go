@@ -0,0 +1,166 @@
+package reschedule
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// blockingWriter blocks every write until it is released, like a stdout that nothing is reading from
+type blockingWriter struct {
+	release chan struct{}
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	<-w.release
+	return len(p), nil
+}
+
+// failingWriter fails every write
+type failingWriter struct{}
+
+func (w failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func TestTelemetryEmit(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	testcases := []struct {
+		testname  string
+		telemetry *Telemetry
+		call      func()
+	}{
+		{
+			testname:  "Call completes",
+			telemetry: NewTelemetry(time.Second),
+			call:      func() {},
+		},
+		{
+			testname:  "Call panics",
+			telemetry: NewTelemetry(time.Second),
+			call:      func() { panic("backend unavailable") },
+		},
+		{
+			testname:  "Call blocks past the timeout",
+			telemetry: NewTelemetry(50 * time.Millisecond),
+			call:      func() { <-release },
+		},
+		{
+			testname: "Call panics without a telemetry",
+			call:     func() { panic("backend unavailable") },
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testname, func(t *testing.T) {
+			start := time.Now()
+			testcase.telemetry.Emit("test", testcase.call)
+
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Fatalf("Expected Emit to return promptly, took %s", elapsed)
+			}
+		})
+	}
+}
+
+func TestTelemetryDropsWhenTooManyInFlight(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	telemetry := NewTelemetry(time.Millisecond)
+	for range telemetryMaxInFlight {
+		telemetry.Emit("test", func() { <-release })
+	}
+
+	// Every slot is held by a blocked call, so this call is dropped rather than run
+	ran := make(chan struct{}, 1)
+	telemetry.Emit("test", func() { ran <- struct{}{} })
+
+	select {
+	case <-ran:
+		t.Fatalf("Expected the call to be dropped while too many calls are in flight")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestServeEvictionFailingTelemetry(t *testing.T) {
+	review := admissionv1.AdmissionReview{
+		Request: &admissionv1.AdmissionRequest{
+			UID:         "test-uid",
+			Operation:   admissionv1.Create,
+			SubResource: "eviction",
+			Object: runtime.RawExtension{
+				Raw: []byte(`{"apiVersion":"policy/v1","kind":"Eviction","metadata":{"name":"pod1","namespace":"default"}}`),
+			},
+		},
+	}
+
+	body, err := json.Marshal(review)
+	if err != nil {
+		t.Fatalf("Failed to marshal admission review: %v", err)
+	}
+
+	// The notification receiver is down, failing every request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	release := make(chan struct{})
+	defer close(release)
+
+	testcases := []struct {
+		testname    string
+		decisionLog *DecisionLogWriter
+	}{
+		{
+			testname:    "Decision log write fails",
+			decisionLog: NewDecisionLogWriter(failingWriter{}, ""),
+		},
+		{
+			testname:    "Decision log write blocks",
+			decisionLog: NewDecisionLogWriter(&blockingWriter{release: release}, ""),
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testname, func(t *testing.T) {
+			notifier := NewNotifier(server.URL, time.Second, notifyQueueSize, "")
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go notifier.Run(ctx)
+
+			// A limiter without any burst denies every request, so the eviction is answered without a Kubernetes client
+			limiter := NewRateLimiter(1, 0, RealClock)
+
+			recorder := httptest.NewRecorder()
+			request := httptest.NewRequest(http.MethodPost, "/eviction", bytes.NewReader(body))
+			request.Header.Set("Content-Type", "application/json")
+
+			start := time.Now()
+			serveEviction(recorder, request, NewConfigBuilder().Build(), limiter, nil, NewTelemetry(50*time.Millisecond), notifier, testcase.decisionLog, EvictionVersionV1)
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Fatalf("Expected the eviction to be answered promptly, took %s", elapsed)
+			}
+
+			var response admissionv1.AdmissionReview
+			if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to decode admission review response: %v", err)
+			}
+
+			if response.Response == nil || response.Response.Allowed || response.Response.Result.Message != RateLimitExceededMsg {
+				t.Fatalf("Expected the eviction to be denied as rate limited, got %v", response.Response)
+			}
+		})
+	}
+}
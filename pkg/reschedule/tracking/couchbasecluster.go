@@ -7,18 +7,45 @@ import (
 	"k8s.io/client-go/dynamic"
 )
 
+// DefaultCouchbaseAPIVersion is the version of the couchbase.com API used for CouchbaseClusters when none is configured
+const DefaultCouchbaseAPIVersion = "v2"
+
+// couchbaseClusterLabel is the label the Couchbase operator sets on pods to the name of their CouchbaseCluster
+const couchbaseClusterLabel = "couchbase_cluster"
+
 // CouchbaseClusterTrackingResource is a TrackingResource implementation for tracking rescheduled pods using annotations on the CouchbaseCluster resource
 type CouchbaseClusterTrackingResource struct {
 	GroupVersionResource schema.GroupVersionResource
 	InstanceName         string
+	// Predicate replaces the InPlaceUpgrade check in ShouldTrack if set
+	Predicate *FieldPredicate
+}
+
+// NewCouchbaseClusterTrackingResource creates a CouchbaseClusterTrackingResource using the given version of the couchbase.com API
+func NewCouchbaseClusterTrackingResource(version string) *CouchbaseClusterTrackingResource {
+	if version == "" {
+		version = DefaultCouchbaseAPIVersion
+	}
+
+	return &CouchbaseClusterTrackingResource{
+		GroupVersionResource: schema.GroupVersionResource{
+			Group:    "couchbase.com",
+			Version:  version,
+			Resource: "couchbaseclusters",
+		},
+	}
 }
 
 func (t *CouchbaseClusterTrackingResource) GetResourceType() string {
 	return ResourceTypeCouchbaseCluster
 }
 
-// ShouldTrack checks if the resource instance is an InPlaceUpgrade cluster
+// ShouldTrack checks if the resource instance is an InPlaceUpgrade cluster, or matches the predicate if one is set
 func (t *CouchbaseClusterTrackingResource) ShouldTrack(resourceInstance *unstructured.Unstructured) bool {
+	if t.Predicate != nil {
+		return t.Predicate.Matches(resourceInstance)
+	}
+
 	upgradeStrategy, found, err := unstructured.NestedString(resourceInstance.Object, "spec", "upgradeProcess")
 	if err != nil || !found {
 		return false
@@ -28,13 +55,31 @@ func (t *CouchbaseClusterTrackingResource) ShouldTrack(resourceInstance *unstruc
 }
 
 func (t *CouchbaseClusterTrackingResource) GetInstanceName(pod *corev1.Pod) string {
-	return pod.Labels["couchbase_cluster"]
+	return pod.Labels[couchbaseClusterLabel]
+}
+
+func (t *CouchbaseClusterTrackingResource) GetInstanceLabels(instanceName string) map[string]string {
+	return map[string]string{couchbaseClusterLabel: instanceName}
+}
+
+// GetAnnotationScope returns no scope, as a CouchbaseCluster is only shared by the pods of that cluster
+func (t *CouchbaseClusterTrackingResource) GetAnnotationScope(pod *corev1.Pod) string {
+	return ""
+}
+
+// GetGroupVersionResource returns the configured GroupVersionResource, defaulting to the DefaultCouchbaseAPIVersion if none is set
+func (t *CouchbaseClusterTrackingResource) GetGroupVersionResource() schema.GroupVersionResource {
+	if t.GroupVersionResource.Empty() {
+		return NewCouchbaseClusterTrackingResource(DefaultCouchbaseAPIVersion).GroupVersionResource
+	}
+
+	return t.GroupVersionResource
 }
 
 func (t *CouchbaseClusterTrackingResource) GetResourceInterface(client dynamic.Interface, namespace string) dynamic.ResourceInterface {
-	return client.Resource(schema.GroupVersionResource{
-		Group:    "couchbase.com",
-		Version:  "v2",
-		Resource: "couchbaseclusters",
-	}).Namespace(namespace)
+	return client.Resource(t.GetGroupVersionResource()).Namespace(namespace)
+}
+
+func (t *CouchbaseClusterTrackingResource) IsNamespaced() bool {
+	return true
 }
@@ -0,0 +1,60 @@
+package tracking
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestCouchbaseClusterGroupVersionResource(t *testing.T) {
+	testcases := []struct {
+		testname         string
+		trackingResource *CouchbaseClusterTrackingResource
+		expectedVersion  string
+	}{
+		{
+			testname:         "Default version",
+			trackingResource: NewCouchbaseClusterTrackingResource(""),
+			expectedVersion:  DefaultCouchbaseAPIVersion,
+		},
+		{
+			testname:         "Zero value",
+			trackingResource: &CouchbaseClusterTrackingResource{},
+			expectedVersion:  DefaultCouchbaseAPIVersion,
+		},
+		{
+			testname:         "Non-default version",
+			trackingResource: NewCouchbaseClusterTrackingResource("v3"),
+			expectedVersion:  "v3",
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testname, func(t *testing.T) {
+			expected := schema.GroupVersionResource{Group: "couchbase.com", Version: testcase.expectedVersion, Resource: "couchbaseclusters"}
+			if gvr := testcase.trackingResource.GetGroupVersionResource(); gvr != expected {
+				t.Fatalf("Expected GroupVersionResource to be %v, got %v", expected, gvr)
+			}
+
+			client := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+				expected: "CouchbaseClusterList",
+			})
+
+			var requested schema.GroupVersionResource
+			client.PrependReactor("get", "couchbaseclusters", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				requested = action.GetResource()
+				return false, nil, nil
+			})
+
+			_, _ = testcase.trackingResource.GetResourceInterface(client, "default").Get(context.TODO(), "test-cluster", metav1.GetOptions{})
+			if requested != expected {
+				t.Fatalf("Expected resource interface to use %v, got %v", expected, requested)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package tracking
 
 import (
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic"
@@ -11,6 +12,14 @@ import (
 type NamespaceTrackingResource struct {
 	GroupVersionResource schema.GroupVersionResource
 	InstanceName         string
+	// Predicate limits tracking to namespaces that match it if set
+	Predicate *FieldPredicate
+	// TrackAnnotation limits tracking to namespaces where it is set to true, if set. Unlike a predicate, the annotation key can
+	// contain dots.
+	TrackAnnotation string
+	// ScopeLabel is the pod label whose value scopes tracking annotations, if set. Pods without the label are scoped by the name
+	// of their controller.
+	ScopeLabel string
 }
 
 func (t *NamespaceTrackingResource) GetResourceType() string {
@@ -21,13 +30,49 @@ func (t *NamespaceTrackingResource) GetInstanceName(pod *corev1.Pod) string {
 	return pod.Namespace
 }
 
+func (t *NamespaceTrackingResource) GetInstanceLabels(instanceName string) map[string]string {
+	return nil
+}
+
+// GetAnnotationScope scopes tracking annotations by the pod's app when a scope label is set, as a namespace can be shared by
+// unrelated apps whose pods could otherwise be mistaken for each other
+func (t *NamespaceTrackingResource) GetAnnotationScope(pod *corev1.Pod) string {
+	if t.ScopeLabel == "" {
+		return ""
+	}
+
+	if app := pod.Labels[t.ScopeLabel]; app != "" {
+		return app
+	}
+
+	if owner := metav1.GetControllerOf(pod); owner != nil {
+		return owner.Name
+	}
+
+	return ""
+}
+
+// ShouldTrack always tracks pods in namespaces, unless a predicate is set that the namespace does not match or a track annotation
+// is set that the namespace does not have
 func (t *NamespaceTrackingResource) ShouldTrack(resourceInstance *unstructured.Unstructured) bool {
-	return true
+	if t.TrackAnnotation != "" && resourceInstance.GetAnnotations()[t.TrackAnnotation] != "true" {
+		return false
+	}
+
+	return t.Predicate == nil || t.Predicate.Matches(resourceInstance)
 }
 
-func (t *NamespaceTrackingResource) GetResourceInterface(client dynamic.Interface, namespace string) dynamic.ResourceInterface {
-	return client.Resource(schema.GroupVersionResource{
+func (t *NamespaceTrackingResource) GetGroupVersionResource() schema.GroupVersionResource {
+	return schema.GroupVersionResource{
 		Version:  "v1",
 		Resource: "namespaces",
-	})
+	}
+}
+
+func (t *NamespaceTrackingResource) GetResourceInterface(client dynamic.Interface, namespace string) dynamic.ResourceInterface {
+	return client.Resource(t.GetGroupVersionResource())
+}
+
+func (t *NamespaceTrackingResource) IsNamespaced() bool {
+	return false
 }
@@ -0,0 +1,110 @@
+package tracking
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestNamespaceShouldTrackAnnotation(t *testing.T) {
+	namespaceWithAnnotation := func(value string) *unstructured.Unstructured {
+		namespace := &unstructured.Unstructured{Object: map[string]interface{}{}}
+		if value != "" {
+			namespace.SetAnnotations(map[string]string{"reschedule.hook/track": value})
+		}
+		return namespace
+	}
+
+	testcases := []struct {
+		testname        string
+		trackAnnotation string
+		value           string
+		expected        bool
+	}{
+		{
+			testname: "No track annotation configured",
+			expected: true,
+		},
+		{
+			testname:        "Namespace opted in",
+			trackAnnotation: "reschedule.hook/track",
+			value:           "true",
+			expected:        true,
+		},
+		{
+			testname:        "Namespace opted out",
+			trackAnnotation: "reschedule.hook/track",
+			value:           "false",
+			expected:        false,
+		},
+		{
+			testname:        "Namespace without the annotation",
+			trackAnnotation: "reschedule.hook/track",
+			expected:        false,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testname, func(t *testing.T) {
+			trackingResource := &NamespaceTrackingResource{TrackAnnotation: testcase.trackAnnotation}
+			if tracked := trackingResource.ShouldTrack(namespaceWithAnnotation(testcase.value)); tracked != testcase.expected {
+				t.Errorf("Expected ShouldTrack to be %t, got %t", testcase.expected, tracked)
+			}
+		})
+	}
+}
+
+func TestNamespaceAnnotationScope(t *testing.T) {
+	controller := true
+	ownedPod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name:            "db-0",
+		OwnerReferences: []metav1.OwnerReference{{Kind: "StatefulSet", Name: "db", Controller: &controller}},
+	}}
+	labelledPod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name:            "db-0",
+		Labels:          map[string]string{"app.kubernetes.io/name": "orders"},
+		OwnerReferences: ownedPod.OwnerReferences,
+	}}
+
+	testcases := []struct {
+		testname   string
+		scopeLabel string
+		pod        *corev1.Pod
+		expected   string
+	}{
+		{
+			testname: "Not scoped",
+			pod:      labelledPod,
+			expected: "",
+		},
+		{
+			testname:   "Scoped by label",
+			scopeLabel: "app.kubernetes.io/name",
+			pod:        labelledPod,
+			expected:   "orders",
+		},
+		{
+			testname:   "Scoped by controller without the label",
+			scopeLabel: "app.kubernetes.io/name",
+			pod:        ownedPod,
+			expected:   "db",
+		},
+		{
+			testname:   "No label or controller",
+			scopeLabel: "app.kubernetes.io/name",
+			pod:        &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "db-0"}},
+			expected:   "",
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testname, func(t *testing.T) {
+			trackingResource := &NamespaceTrackingResource{ScopeLabel: testcase.scopeLabel}
+			if scope := trackingResource.GetAnnotationScope(testcase.pod); scope != testcase.expected {
+				t.Errorf("Expected scope %q, got %q", testcase.expected, scope)
+			}
+		})
+	}
+}
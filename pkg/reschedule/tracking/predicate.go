@@ -0,0 +1,52 @@
+package tracking
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// FieldPredicate matches tracking resource instances where the field at Path has the expected Value. It allows the ShouldTrack
+// conditional to be adapted to different resource shapes without code changes.
+type FieldPredicate struct {
+	Path  []string
+	Value string
+}
+
+// ParseFieldPredicate parses a predicate of the form "<dot separated field path>=<value>", e.g. spec.upgradeProcess=InPlaceUpgrade
+func ParseFieldPredicate(expression string) (*FieldPredicate, error) {
+	path, value, found := strings.Cut(expression, "=")
+	path = strings.TrimSpace(path)
+	if !found || path == "" {
+		return nil, fmt.Errorf("invalid predicate %q: expected <field path>=<value>", expression)
+	}
+
+	fields := strings.Split(path, ".")
+	for _, field := range fields {
+		if field == "" {
+			return nil, fmt.Errorf("invalid predicate %q: empty field in path %q", expression, path)
+		}
+	}
+
+	return &FieldPredicate{Path: fields, Value: strings.TrimSpace(value)}, nil
+}
+
+// Matches checks whether the field at the predicate's path exists and has the expected value. Non-string values such as
+// booleans and numbers are compared using their string representation.
+func (p *FieldPredicate) Matches(resourceInstance *unstructured.Unstructured) bool {
+	value, found, err := unstructured.NestedFieldNoCopy(resourceInstance.Object, p.Path...)
+	if err != nil || !found || value == nil {
+		return false
+	}
+
+	return fmt.Sprint(value) == p.Value
+}
+
+func (p *FieldPredicate) String() string {
+	if p == nil {
+		return ""
+	}
+
+	return strings.Join(p.Path, ".") + "=" + p.Value
+}
@@ -0,0 +1,113 @@
+package tracking
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestParseFieldPredicate(t *testing.T) {
+	testcases := []struct {
+		expression  string
+		expected    string
+		expectError bool
+	}{
+		{expression: "spec.upgradeProcess=InPlaceUpgrade", expected: "spec.upgradeProcess=InPlaceUpgrade"},
+		{expression: " spec.paused = true ", expected: "spec.paused=true"},
+		{expression: "metadata.labels.tier=", expected: "metadata.labels.tier="},
+		{expression: "spec.upgradeProcess", expectError: true},
+		{expression: "=InPlaceUpgrade", expectError: true},
+		{expression: "spec..upgradeProcess=InPlaceUpgrade", expectError: true},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.expression, func(t *testing.T) {
+			predicate, err := ParseFieldPredicate(testcase.expression)
+			if testcase.expectError {
+				if err == nil {
+					t.Fatalf("Expected parsing %q to fail, got %s", testcase.expression, predicate)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Failed to parse %q: %v", testcase.expression, err)
+			}
+
+			if predicate.String() != testcase.expected {
+				t.Fatalf("Expected predicate %s, got %s", testcase.expected, predicate)
+			}
+		})
+	}
+}
+
+func TestFieldPredicateMatches(t *testing.T) {
+	resourceInstance := &unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name": "test-cluster",
+			"labels": map[string]interface{}{
+				"tier": "gold",
+			},
+		},
+		"spec": map[string]interface{}{
+			"upgradeProcess": "InPlaceUpgrade",
+			"paused":         true,
+			"servers":        []interface{}{map[string]interface{}{"size": int64(3)}},
+		},
+	}}
+
+	testcases := []struct {
+		expression string
+		expected   bool
+	}{
+		{expression: "spec.upgradeProcess=InPlaceUpgrade", expected: true},
+		{expression: "spec.upgradeProcess=SwapRebalance", expected: false},
+		{expression: "spec.paused=true", expected: true},
+		{expression: "metadata.labels.tier=gold", expected: true},
+		{expression: "metadata.labels.zone=east", expected: false},
+		{expression: "spec.upgradeProcess.value=InPlaceUpgrade", expected: false},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.expression, func(t *testing.T) {
+			predicate, err := ParseFieldPredicate(testcase.expression)
+			if err != nil {
+				t.Fatalf("Failed to parse %q: %v", testcase.expression, err)
+			}
+
+			if matches := predicate.Matches(resourceInstance); matches != testcase.expected {
+				t.Fatalf("Expected predicate to match=%t, got %t", testcase.expected, matches)
+			}
+		})
+	}
+}
+
+func TestShouldTrackPredicate(t *testing.T) {
+	predicate, err := ParseFieldPredicate("spec.reschedule=enabled")
+	if err != nil {
+		t.Fatalf("Failed to parse predicate: %v", err)
+	}
+
+	matching := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{"reschedule": "enabled", "upgradeProcess": "SwapRebalance"},
+	}}
+	notMatching := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{"upgradeProcess": "InPlaceUpgrade"},
+	}}
+
+	couchbaseCluster := NewCouchbaseClusterTrackingResource("")
+	couchbaseCluster.Predicate = predicate
+	namespace := &NamespaceTrackingResource{Predicate: predicate}
+
+	for _, trackingResource := range []TrackingResource{couchbaseCluster, namespace} {
+		t.Run(trackingResource.GetResourceType(), func(t *testing.T) {
+			if !trackingResource.ShouldTrack(matching) {
+				t.Errorf("Expected resource matching the predicate to be tracked")
+			}
+
+			if trackingResource.ShouldTrack(notMatching) {
+				t.Errorf("Expected resource not matching the predicate to not be tracked")
+			}
+		})
+	}
+}
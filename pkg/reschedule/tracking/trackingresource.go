@@ -5,6 +5,7 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic"
 )
 
@@ -16,12 +17,24 @@ type TrackingResource interface {
 	// GetInstanceName returns the name of the instance of the tracking resource that the pod belongs to. During eviction
 	// requests, we only have access to the pod
 	GetInstanceName(pod *corev1.Pod) string
+	// GetInstanceLabels returns the labels identifying the pods that belong to the instance of the tracking resource with the
+	// given name. Pods are only listed within the instance's namespace, so no labels are needed when the instance is the
+	// namespace itself.
+	GetInstanceLabels(instanceName string) map[string]string
+	// GetAnnotationScope returns the scope included in the key of the pod's tracking annotation, so that pods of different apps
+	// sharing an instance are tracked separately. It is empty if tracking annotations are not scoped.
+	GetAnnotationScope(pod *corev1.Pod) string
 	// ShouldTrack can be used to check a conditional on the tracking resource. For example, we only want to track rescheduled pods on
 	// CouchbaseClusters that have InPlaceUpgrade enabled as this determines whether pods will be recreated with the same name
 	ShouldTrack(resourceInstance *unstructured.Unstructured) bool
+	// GetGroupVersionResource returns the GroupVersionResource used to get the tracking resource with the dynamic client
+	GetGroupVersionResource() schema.GroupVersionResource
 	// GetResourceInterface returns the resource interface for the tracking resource. This is used to get the tracking resource using
 	// the dynamic client. It is needed as some tracking resources may not be namespaces.
 	GetResourceInterface(client dynamic.Interface, namespace string) dynamic.ResourceInterface
+	// IsNamespaced returns whether instances of the tracking resource are namespaced. Cluster scoped tracking resources, such as
+	// Namespaces, are fetched without the pod's namespace.
+	IsNamespaced() bool
 }
 
 // ResourceType constants for tracking resources
@@ -33,13 +46,13 @@ const (
 // trackingResourceRegistry holds all registered tracking resource types
 var trackingResourceRegistry = map[string]TrackingResource{
 	ResourceTypeNamespace:        &NamespaceTrackingResource{},
-	ResourceTypeCouchbaseCluster: &CouchbaseClusterTrackingResource{},
+	ResourceTypeCouchbaseCluster: NewCouchbaseClusterTrackingResource(DefaultCouchbaseAPIVersion),
 }
 
 // Init registers each of the possible tracking resources
 func init() {
 	trackingResourceRegistry[ResourceTypeNamespace] = &NamespaceTrackingResource{}
-	trackingResourceRegistry[ResourceTypeCouchbaseCluster] = &CouchbaseClusterTrackingResource{}
+	trackingResourceRegistry[ResourceTypeCouchbaseCluster] = NewCouchbaseClusterTrackingResource(DefaultCouchbaseAPIVersion)
 }
 
 // GetTrackingResource returns the TrackingResource implementation for the given resource type. If the resource type is not found, it will return the default
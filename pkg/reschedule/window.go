@@ -0,0 +1,177 @@
+package reschedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	// The image is built from scratch, so time zone data is embedded for ACTIVE_WINDOWS_TIMEZONE
+	_ "time/tzdata"
+)
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// activeWindow is a daily time range in minutes since midnight, limited to certain days of the week. If the end is not after
+// the start, the window runs past midnight into the following day.
+type activeWindow struct {
+	days  [7]bool
+	start int
+	end   int
+}
+
+// activeWindows is a set of windows during which the reschedule behaviour is active, evaluated in a time zone
+type activeWindows struct {
+	spec     string
+	windows  []activeWindow
+	location *time.Location
+}
+
+// parseActiveWindows parses a comma-separated list of windows of the form "[day[-day]] HH:MM-HH:MM", e.g.
+// "Mon-Fri 22:00-06:00,Sat-Sun 00:00-24:00". Windows without days apply every day.
+func parseActiveWindows(spec string, location *time.Location) (*activeWindows, error) {
+	if location == nil {
+		location = time.UTC
+	}
+
+	windows := &activeWindows{spec: spec, location: location}
+	for _, item := range splitList(spec) {
+		window, err := parseActiveWindow(item)
+		if err != nil {
+			return nil, err
+		}
+
+		windows.windows = append(windows.windows, window)
+	}
+
+	if len(windows.windows) == 0 {
+		return nil, fmt.Errorf("invalid active windows %q: no windows", spec)
+	}
+
+	return windows, nil
+}
+
+func parseActiveWindow(value string) (activeWindow, error) {
+	var window activeWindow
+
+	fields := strings.Fields(value)
+	switch len(fields) {
+	case 1:
+		for day := range window.days {
+			window.days[day] = true
+		}
+	case 2:
+		first, last, isRange := strings.Cut(fields[0], "-")
+		if !isRange {
+			last = first
+		}
+
+		firstDay, ok := weekdays[strings.ToLower(first)]
+		if !ok {
+			return window, fmt.Errorf("invalid active window %q: unknown day %q", value, first)
+		}
+
+		lastDay, ok := weekdays[strings.ToLower(last)]
+		if !ok {
+			return window, fmt.Errorf("invalid active window %q: unknown day %q", value, last)
+		}
+
+		for day := firstDay; ; day = (day + 1) % 7 {
+			window.days[day] = true
+			if day == lastDay {
+				break
+			}
+		}
+	default:
+		return window, fmt.Errorf("invalid active window %q: expected [day[-day]] HH:MM-HH:MM", value)
+	}
+
+	start, end, isRange := strings.Cut(fields[len(fields)-1], "-")
+	if !isRange {
+		return window, fmt.Errorf("invalid active window %q: expected a time range HH:MM-HH:MM", value)
+	}
+
+	var err error
+	if window.start, err = parseClockTime(start); err != nil {
+		return window, fmt.Errorf("invalid active window %q: %w", value, err)
+	}
+
+	if window.end, err = parseClockTime(end); err != nil {
+		return window, fmt.Errorf("invalid active window %q: %w", value, err)
+	}
+
+	return window, nil
+}
+
+// parseClockTime parses a time of the form HH:MM into minutes since midnight. 24:00 is accepted as the end of the day.
+func parseClockTime(value string) (int, error) {
+	hours, minutes, ok := strings.Cut(value, ":")
+	if !ok {
+		return 0, fmt.Errorf("invalid time %q: expected HH:MM", value)
+	}
+
+	h, err := strconv.Atoi(hours)
+	if err != nil || h < 0 || h > 24 {
+		return 0, fmt.Errorf("invalid time %q: hours must be between 0 and 24", value)
+	}
+
+	m, err := strconv.Atoi(minutes)
+	if err != nil || m < 0 || m > 59 || (h == 24 && m != 0) {
+		return 0, fmt.Errorf("invalid time %q: minutes must be between 0 and 59", value)
+	}
+
+	return h*60 + m, nil
+}
+
+func (w *activeWindows) String() string {
+	if w == nil {
+		return ""
+	}
+
+	return w.spec
+}
+
+// Timezone returns the name of the time zone the windows are evaluated in
+func (w *activeWindows) Timezone() string {
+	if w == nil {
+		return ""
+	}
+
+	return w.location.String()
+}
+
+// Active checks whether t is within any of the windows. Nil windows are always active.
+func (w *activeWindows) Active(t time.Time) bool {
+	if w == nil {
+		return true
+	}
+
+	t = t.In(w.location)
+	day := t.Weekday()
+	previousDay := (day + 6) % 7
+	minute := t.Hour()*60 + t.Minute()
+
+	for _, window := range w.windows {
+		if window.start < window.end {
+			if window.days[day] && minute >= window.start && minute < window.end {
+				return true
+			}
+
+			continue
+		}
+
+		// The window runs past midnight, so it either started today or is continuing from yesterday
+		if (window.days[day] && minute >= window.start) || (window.days[previousDay] && minute < window.end) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,123 @@
+package reschedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseActiveWindows(t *testing.T) {
+	testcases := []struct {
+		spec        string
+		expectError bool
+	}{
+		{spec: "22:00-06:00"},
+		{spec: "Mon-Fri 22:00-06:00"},
+		{spec: "Sat 00:00-24:00"},
+		{spec: "Fri-Mon 20:00-23:00, sun 09:00-10:30"},
+		{spec: "", expectError: true},
+		{spec: "22:00", expectError: true},
+		{spec: "Someday 22:00-06:00", expectError: true},
+		{spec: "Mon-Fri 25:00-06:00", expectError: true},
+		{spec: "Mon-Fri 22:00-06:60", expectError: true},
+		{spec: "Mon-Fri 24:30-06:00", expectError: true},
+		{spec: "Mon Fri 22:00-06:00", expectError: true},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.spec, func(t *testing.T) {
+			_, err := parseActiveWindows(testcase.spec, time.UTC)
+			if testcase.expectError && err == nil {
+				t.Fatalf("Expected parsing %q to fail", testcase.spec)
+			}
+
+			if !testcase.expectError && err != nil {
+				t.Fatalf("Expected parsing %q to succeed, got %v", testcase.spec, err)
+			}
+		})
+	}
+}
+
+func TestActiveWindows(t *testing.T) {
+	london, err := time.LoadLocation("Europe/London")
+	if err != nil {
+		t.Fatalf("Failed to load time zone: %v", err)
+	}
+
+	testcases := []struct {
+		testname string
+		spec     string
+		location *time.Location
+		time     time.Time
+		expected bool
+	}{
+		{
+			testname: "Within a daily window",
+			spec:     "09:00-17:00",
+			time:     time.Date(2025, 6, 4, 12, 0, 0, 0, time.UTC),
+			expected: true,
+		},
+		{
+			testname: "End of a window is exclusive",
+			spec:     "09:00-17:00",
+			time:     time.Date(2025, 6, 4, 17, 0, 0, 0, time.UTC),
+			expected: false,
+		},
+		{
+			testname: "Within a window past midnight on the start day",
+			spec:     "Fri 22:00-06:00",
+			time:     time.Date(2025, 6, 6, 23, 0, 0, 0, time.UTC),
+			expected: true,
+		},
+		{
+			testname: "Within a window past midnight on the following day",
+			spec:     "Fri 22:00-06:00",
+			time:     time.Date(2025, 6, 7, 5, 59, 0, 0, time.UTC),
+			expected: true,
+		},
+		{
+			testname: "Outside a window past midnight on the day before the start day",
+			spec:     "Fri 22:00-06:00",
+			time:     time.Date(2025, 6, 6, 5, 0, 0, 0, time.UTC),
+			expected: false,
+		},
+		{
+			testname: "Day range wrapping the end of the week",
+			spec:     "Sat-Sun 00:00-24:00",
+			time:     time.Date(2025, 6, 8, 23, 59, 0, 0, time.UTC),
+			expected: true,
+		},
+		{
+			testname: "Outside every window",
+			spec:     "Sat-Sun 00:00-24:00, Wed 01:00-02:00",
+			time:     time.Date(2025, 6, 4, 12, 0, 0, 0, time.UTC),
+			expected: false,
+		},
+		{
+			testname: "Evaluated in the configured time zone",
+			spec:     "09:00-17:00",
+			location: london,
+			time:     time.Date(2025, 6, 4, 16, 30, 0, 0, time.UTC),
+			expected: false,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testname, func(t *testing.T) {
+			windows, err := parseActiveWindows(testcase.spec, testcase.location)
+			if err != nil {
+				t.Fatalf("Failed to parse active windows: %v", err)
+			}
+
+			if active := windows.Active(testcase.time); active != testcase.expected {
+				t.Fatalf("Expected active=%t at %v, got %t", testcase.expected, testcase.time, active)
+			}
+		})
+	}
+}
+
+func TestActiveWindowsNil(t *testing.T) {
+	var windows *activeWindows
+	if !windows.Active(time.Now()) {
+		t.Fatalf("Expected nil active windows to always be active")
+	}
+}
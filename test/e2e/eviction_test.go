@@ -8,6 +8,7 @@ import (
 	"github.com/couchbaselabs/eviction-reschedule-hook/pkg/reschedule/tracking"
 	"github.com/couchbaselabs/eviction-reschedule-hook/test/framework"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestEvictMultipleCouchbasePodsAddsAnnotationNoTracking(t *testing.T) {
@@ -184,3 +185,22 @@ func TestEvicPodsWithDryRunDoesNotMutateResources(t *testing.T) {
 		reschedule.TrackingResourceAnnotation(cbPod2.Name, cbPod2.Namespace): "true",
 	})
 }
+
+func TestPostEvictionDryRunReturnsWarning(t *testing.T) {
+	cluster := framework.SetupTestCluster(t, nil)
+
+	cleanup := cluster.MustCreateCouchbaseCluster(t, "couchbase-cluster", false)
+	defer cleanup()
+
+	cbPod := cluster.MustCreateCouchbasePod(t, "couchbase-1", "couchbase-cluster")
+
+	response := cluster.PostEviction(t, cbPod.Name, cbPod.Namespace, &metav1.DeleteOptions{DryRun: []string{metav1.DryRunAll}})
+
+	framework.ValidateEvictionResponseDenied(t, response, http.StatusTooManyRequests, reschedule.RescheduleAnnotationAddedToPodMsg)
+	framework.ValidateEvictionWarnings(t, response, []string{reschedule.DryRunWarningMsg})
+	framework.ValidateAuditAnnotations(t, response, map[string]string{
+		reschedule.AuditAnnotationRequestingUser: framework.PostEvictionUsername,
+	})
+
+	cluster.ValidatePodDoesNotHaveAnnotation(t, cbPod.Name, reschedule.DefaultRescheduleAnnotationKey, reschedule.DefaultRescheduleAnnotationValue)
+}
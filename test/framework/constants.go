@@ -13,6 +13,9 @@ const (
 	rescheduleHookImage = "couchbase/eviction-reschedule-hook:latest"
 )
 
+// PostEvictionUsername is the requesting user set on admission reviews sent using PostEviction
+const PostEvictionUsername = "reschedule-hook-e2e"
+
 var CouchbaseClusterGVR = schema.GroupVersionResource{
 	Group:    "couchbase.com",
 	Version:  "v2",
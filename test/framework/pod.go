@@ -2,14 +2,21 @@ package framework
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"sync"
 	"testing"
+	"time"
 
+	reschedule "github.com/couchbaselabs/eviction-reschedule-hook/pkg/reschedule"
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
 	corev1 "k8s.io/api/core/v1"
 	policyv1 "k8s.io/api/policy/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
 )
 
@@ -132,11 +139,78 @@ func createPodAndWait(t *testing.T, client *kubernetes.Clientset, name, namespac
 			return nil, err
 		}
 
-		if pod.Status.Phase != corev1.PodRunning || !pod.Status.ContainerStatuses[0].Ready {
-			return nil, fmt.Errorf("pod %s is not running or not ready, current phase: %s, ready: %t", name, pod.Status.Phase, pod.Status.ContainerStatuses[0].Ready)
+		if ready := reschedule.IsPodReady(pod); pod.Status.Phase != corev1.PodRunning || !ready {
+			return nil, fmt.Errorf("pod %s is not running or not ready, current phase: %s, ready: %t", name, pod.Status.Phase, ready)
 		}
 
 		return pod, nil
 	}
 	return retryFetch(t, name, fetchPod).(*corev1.Pod)
 }
+
+// PostEviction sends an admission review for the eviction of the given pod directly to the reschedule hook server, using the
+// API server's service proxy, and returns the decoded admission response. Unlike EvictPod, this exposes the full response,
+// including any warnings and audit annotations.
+func (tc *TestCluster) PostEviction(t *testing.T, name, namespace string, deleteOptions *metav1.DeleteOptions) *admissionv1.AdmissionResponse {
+	eviction := &policyv1.Eviction{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "policy/v1",
+			Kind:       "Eviction",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		DeleteOptions: deleteOptions,
+	}
+
+	evictionRaw, err := json.Marshal(eviction)
+	if err != nil {
+		t.Fatalf("Failed to encode eviction: %v", err)
+	}
+
+	dryRun := deleteOptions != nil && len(deleteOptions.DryRun) > 0
+	review := admissionv1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "admission.k8s.io/v1",
+			Kind:       "AdmissionReview",
+		},
+		Request: &admissionv1.AdmissionRequest{
+			UID:         types.UID(fmt.Sprintf("%s-%d", name, time.Now().UnixNano())),
+			Kind:        metav1.GroupVersionKind{Group: "policy", Version: "v1", Kind: "Eviction"},
+			Resource:    metav1.GroupVersionResource{Version: "v1", Resource: "pods"},
+			SubResource: "eviction",
+			Name:        name,
+			Namespace:   namespace,
+			Operation:   admissionv1.Create,
+			Object:      runtime.RawExtension{Raw: evictionRaw},
+			DryRun:      &dryRun,
+			UserInfo:    authenticationv1.UserInfo{Username: PostEvictionUsername},
+		},
+	}
+
+	body, err := json.Marshal(review)
+	if err != nil {
+		t.Fatalf("Failed to encode admission review: %v", err)
+	}
+
+	resp, err := tc.client.CoreV1().RESTClient().Post().
+		AbsPath("/api/v1/namespaces", defaultNamespace, "services", "https:"+svcName+":443", "proxy", "eviction").
+		SetHeader("Content-Type", "application/json").
+		Body(body).
+		DoRaw(context.TODO())
+	if err != nil {
+		t.Fatalf("Failed to post eviction for pod %s: %v", name, err)
+	}
+
+	var reviewResponse admissionv1.AdmissionReview
+	if err := json.Unmarshal(resp, &reviewResponse); err != nil {
+		t.Fatalf("Failed to decode admission review response: %v", err)
+	}
+
+	if reviewResponse.Response == nil {
+		t.Fatalf("Expected admission review response for pod %s, got %s", name, string(resp))
+	}
+
+	return reviewResponse.Response
+}
@@ -2,9 +2,11 @@ package framework
 
 import (
 	"context"
+	"slices"
 	"strings"
 	"testing"
 
+	admissionv1 "k8s.io/api/admission/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -28,6 +30,41 @@ func ValidateEvictionAllowed(t *testing.T, responses map[string]error, podName s
 	}
 }
 
+// ValidateEvictionResponseDenied asserts the admission response denies the eviction with the given code and message
+func ValidateEvictionResponseDenied(t *testing.T, response *admissionv1.AdmissionResponse, expectedCode int32, expectedMessage string) {
+	if response.Allowed || response.Result == nil {
+		t.Fatalf("Expected eviction to be denied, got %v", response)
+	}
+	if response.Result.Code != expectedCode {
+		t.Fatalf("Expected code %d, got %d, with message %s", expectedCode, response.Result.Code, response.Result.Message)
+	}
+	if !strings.Contains(response.Result.Message, expectedMessage) {
+		t.Fatalf("Expected message %s, got %s", expectedMessage, response.Result.Message)
+	}
+}
+
+// ValidateEvictionWarnings asserts the admission response contains each of the expected warnings
+func ValidateEvictionWarnings(t *testing.T, response *admissionv1.AdmissionResponse, expectedWarnings []string) {
+	for _, expectedWarning := range expectedWarnings {
+		if !slices.Contains(response.Warnings, expectedWarning) {
+			t.Fatalf("Expected warning %q, got %v", expectedWarning, response.Warnings)
+		}
+	}
+}
+
+// ValidateAuditAnnotations asserts the admission response has each of the expected audit annotations
+func ValidateAuditAnnotations(t *testing.T, response *admissionv1.AdmissionResponse, expectedAnnotations map[string]string) {
+	for key, expectedValue := range expectedAnnotations {
+		actualValue, exists := response.AuditAnnotations[key]
+		if !exists {
+			t.Fatalf("Expected audit annotation %s to exist, got %v", key, response.AuditAnnotations)
+		}
+		if actualValue != expectedValue {
+			t.Fatalf("Expected audit annotation %s to have value %s, got %s", key, expectedValue, actualValue)
+		}
+	}
+}
+
 // ValidatePodHasRescheduleAnnotation asserts the pod has the reschedule annotation.
 func (tc *TestCluster) ValidatePodHasAnnotation(t *testing.T, podName string, annotationKey, annotationValue string) {
 	tc.validatePodAnnotation(t, podName, annotationKey, annotationValue, true)